@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// auditObjectType is the composite key object type of the audit log, keyed by
+// transaction timestamp, transaction ID and MSISDN so it lists in write order
+const auditObjectType = "audit"
+
+// auditTimestampLayout is a fixed width layout, so audit keys sort by time
+const auditTimestampLayout = "2006-01-02T15:04:05.000000000Z"
+
+// AuditEntry records a write to an asset. Status and Balance are those the
+// asset was written with and are empty when Deleted is set. An asset written
+// several times in one transaction has a single entry for its last write.
+type AuditEntry struct {
+	Timestamp time.Time `json:"Timestamp"`
+	TxID      string    `json:"TxID"`
+	Function  string    `json:"Function"`
+	MSISDN    string    `json:"MSISDN"`
+	Deleted   bool      `json:"Deleted"`
+	Status    string    `json:"Status,omitempty"`
+	Balance   int64     `json:"Balance,omitempty"`
+}
+
+// AuditLogPage is one page of the audit log, oldest first
+type AuditLogPage struct {
+	Entries             []*AuditEntry `json:"Entries"`
+	FetchedRecordsCount int32         `json:"FetchedRecordsCount"`
+	Bookmark            string        `json:"Bookmark"`
+}
+
+// auditContext is the transaction context of every contract function. Its
+// stub appends an audit entry for each asset write, so no write path can
+// bypass the audit log.
+type auditContext struct {
+	contractapi.TransactionContext
+}
+
+// SetStub wraps the peer stub with an auditStub
+func (c *auditContext) SetStub(stub shim.ChaincodeStubInterface) {
+	c.TransactionContext.SetStub(&auditStub{ChaincodeStubInterface: stub})
+}
+
+// auditStub appends an audit entry after each write of an asset key
+type auditStub struct {
+	shim.ChaincodeStubInterface
+}
+
+// PutState writes the value and audits it
+func (s *auditStub) PutState(key string, value []byte) error {
+	if err := s.ChaincodeStubInterface.PutState(key, value); err != nil {
+		return err
+	}
+	return s.audit(key, value)
+}
+
+// DelState deletes the key and audits the deletion
+func (s *auditStub) DelState(key string) error {
+	if err := s.ChaincodeStubInterface.DelState(key); err != nil {
+		return err
+	}
+	return s.audit(key, nil)
+}
+
+// audit appends the entry for a write of key, skipping composite keys, which
+// start with a null byte, and archived or snapshot records. A nil value marks
+// a deletion.
+func (s *auditStub) audit(key string, value []byte) error {
+	if strings.HasPrefix(key, "\x00") || !isAssetKey(key) {
+		return nil
+	}
+
+	txTimestamp, err := s.GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("error getting transaction timestamp: %v", err)
+	}
+	timestamp, err := ptypes.Timestamp(txTimestamp)
+	if err != nil {
+		return fmt.Errorf("error converting timestamp: %v", err)
+	}
+
+	function, _ := s.GetFunctionAndParameters()
+	entry := AuditEntry{
+		Timestamp: timestamp,
+		TxID:      s.GetTxID(),
+		Function:  function,
+		MSISDN:    key,
+		Deleted:   value == nil,
+	}
+	if value != nil {
+		var asset Asset
+		if err := unmarshalAsset(value, &asset); err == nil {
+			entry.Status = asset.Status
+			entry.Balance = asset.Balance
+		}
+	}
+
+	auditKey, err := s.CreateCompositeKey(auditObjectType, []string{timestamp.UTC().Format(auditTimestampLayout), entry.TxID, key})
+	if err != nil {
+		return fmt.Errorf("error creating audit key: %v", err)
+	}
+
+	entryJSON, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("error marshalling audit entry: %v", err)
+	}
+
+	// Written through the peer stub so the entry is not audited itself
+	if err := s.ChaincodeStubInterface.PutState(auditKey, entryJSON); err != nil {
+		return fmt.Errorf("failed to put audit entry to world state: %v", err)
+	}
+	return nil
+}
+
+// GetAuditLog returns one page of the audit log of all asset writes, oldest
+// first. Pass the returned Bookmark to fetch the next page; an empty bookmark
+// starts at the beginning.
+func (s *SmartContract) GetAuditLog(ctx contractapi.TransactionContextInterface, pageSize int, bookmark string) (*AuditLogPage, error) {
+	if pageSize <= 0 {
+		return nil, fmt.Errorf("page size must be positive, got %d", pageSize)
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(auditObjectType, []string{}, int32(pageSize), bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("error reading audit log: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	page := &AuditLogPage{Entries: []*AuditEntry{}}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("error iterating through audit log: %v", err)
+		}
+
+		var entry AuditEntry
+		if err := json.Unmarshal(queryResponse.Value, &entry); err != nil {
+			return nil, fmt.Errorf("error unmarshalling audit entry %s: %v", queryResponse.Key, err)
+		}
+		page.Entries = append(page.Entries, &entry)
+	}
+
+	page.FetchedRecordsCount = metadata.FetchedRecordsCount
+	page.Bookmark = metadata.Bookmark
+
+	return page, nil
+}
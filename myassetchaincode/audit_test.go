@@ -0,0 +1,85 @@
+package main
+
+import "testing"
+
+func TestGetAuditLog(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 500)
+	createTestAsset(t, stub, "D001", "9822222222", 100)
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.UpdateAsset(ctx, "9811111111", "700", "Suspended", "CREDIT", "", "", "", "")
+	})
+	if err != nil {
+		t.Fatalf("UpdateAsset returned error: %v", err)
+	}
+	var deleteTxID string
+	err = stub.transact(func(ctx *auditContext) error {
+		deleteTxID = ctx.GetStub().GetTxID()
+		return s.DeleteAsset(ctx, "9822222222", "")
+	})
+	if err != nil {
+		t.Fatalf("DeleteAsset returned error: %v", err)
+	}
+
+	auditLog := func(pageSize int, bookmark string) *AuditLogPage {
+		t.Helper()
+		var page *AuditLogPage
+		err := stub.transact(func(ctx *auditContext) error {
+			var err error
+			page, err = s.GetAuditLog(ctx, pageSize, bookmark)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("GetAuditLog returned error: %v", err)
+		}
+		return page
+	}
+
+	var entries []*AuditEntry
+	first := auditLog(3, "")
+	if first.FetchedRecordsCount != 3 || first.Bookmark == "" {
+		t.Fatalf("first page has %d entries and bookmark %q, want 3 and a bookmark", first.FetchedRecordsCount, first.Bookmark)
+	}
+	entries = append(entries, first.Entries...)
+	second := auditLog(3, first.Bookmark)
+	if second.Bookmark != "" {
+		t.Errorf("last page has bookmark %q, want none", second.Bookmark)
+	}
+	entries = append(entries, second.Entries...)
+
+	// Index and quota records written alongside the assets are not audited
+	want := []AuditEntry{
+		{MSISDN: "9811111111", Status: "Active", Balance: 500},
+		{MSISDN: "9822222222", Status: "Active", Balance: 100},
+		{MSISDN: "9811111111", Status: "Suspended", Balance: 700},
+		{MSISDN: "9822222222", Deleted: true},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("audit log has %d entries, want %d", len(entries), len(want))
+	}
+	for i, entry := range entries {
+		if entry.MSISDN != want[i].MSISDN || entry.Status != want[i].Status || entry.Balance != want[i].Balance || entry.Deleted != want[i].Deleted {
+			t.Errorf("entry %d is %+v, want %+v", i, entry, want[i])
+		}
+		if i > 0 && !entry.Timestamp.After(entries[i-1].Timestamp) {
+			t.Errorf("entry %d at %v is not after entry %d at %v", i, entry.Timestamp, i-1, entries[i-1].Timestamp)
+		}
+	}
+	if entries[3].TxID != deleteTxID {
+		t.Errorf("deletion was audited with TxID %s, want %s", entries[3].TxID, deleteTxID)
+	}
+
+	// Reading the log does not append to it
+	if page := auditLog(10, ""); len(page.Entries) != len(want) {
+		t.Errorf("audit log has %d entries after being read, want %d", len(page.Entries), len(want))
+	}
+
+	err = stub.transact(func(ctx *auditContext) error {
+		_, err := s.GetAuditLog(ctx, 0, "")
+		return err
+	})
+	if err == nil {
+		t.Error("GetAuditLog with page size 0 succeeded")
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrBalanceOverflow is returned when balance arithmetic would not fit in an
+// int64, instead of letting the result wrap around
+var ErrBalanceOverflow = errors.New("balance arithmetic overflows")
+
+// addBalance returns a + b, or ErrBalanceOverflow when the sum does not fit
+func addBalance(a, b int64) (int64, error) {
+	sum := a + b
+	if (b > 0 && sum < a) || (b < 0 && sum > a) {
+		return 0, fmt.Errorf("%w: %d + %d", ErrBalanceOverflow, a, b)
+	}
+	return sum, nil
+}
+
+// subBalance returns a - b, or ErrBalanceOverflow when the difference does not fit
+func subBalance(a, b int64) (int64, error) {
+	diff := a - b
+	if (b > 0 && diff > a) || (b < 0 && diff < a) {
+		return 0, fmt.Errorf("%w: %d - %d", ErrBalanceOverflow, a, b)
+	}
+	return diff, nil
+}
+
+// mulBalance returns a * b, or ErrBalanceOverflow when the product does not fit
+func mulBalance(a, b int64) (int64, error) {
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+	product := a * b
+	if product/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, fmt.Errorf("%w: %d * %d", ErrBalanceOverflow, a, b)
+	}
+	return product, nil
+}
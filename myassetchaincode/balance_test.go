@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBalanceArithmetic(t *testing.T) {
+	tests := []struct {
+		name     string
+		op       func(a, b int64) (int64, error)
+		a, b     int64
+		want     int64
+		overflow bool
+	}{
+		{"add", addBalance, 1500, -500, 1000, false},
+		{"add to max", addBalance, math.MaxInt64 - 1, 1, math.MaxInt64, false},
+		{"add past max", addBalance, math.MaxInt64, 1, 0, true},
+		{"add past min", addBalance, math.MinInt64, -1, 0, true},
+		{"sub", subBalance, 1000, 1500, -500, false},
+		{"sub to min", subBalance, math.MinInt64 + 1, 1, math.MinInt64, false},
+		{"sub past min", subBalance, math.MinInt64, 1, 0, true},
+		{"sub negative past max", subBalance, math.MaxInt64, -1, 0, true},
+		{"mul", mulBalance, 1500, 3, 4500, false},
+		{"mul by zero", mulBalance, math.MaxInt64, 0, 0, false},
+		{"mul past max", mulBalance, math.MaxInt64/2 + 1, 2, 0, true},
+		{"mul min by -1", mulBalance, math.MinInt64, -1, 0, true},
+		{"mul -1 by min", mulBalance, -1, math.MinInt64, 0, true},
+	}
+	for _, tt := range tests {
+		got, err := tt.op(tt.a, tt.b)
+		if tt.overflow {
+			if !errors.Is(err, ErrBalanceOverflow) {
+				t.Errorf("%s(%d, %d) = %d, %v, want ErrBalanceOverflow", tt.name, tt.a, tt.b, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s(%d, %d) = %d, %v, want %d", tt.name, tt.a, tt.b, got, err, tt.want)
+		}
+	}
+}
+
+func TestBalanceOverflowIsCaught(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", math.MaxInt64-5)
+	createTestAsset(t, stub, "D001", "9822222222", 100)
+
+	for name, write := range map[string]func(ctx *auditContext) error{
+		"AdjustBalance": func(ctx *auditContext) error {
+			return s.AdjustBalance(ctx, "9811111111", 10, "", "", "", "", "")
+		},
+		"UpdateAsset": func(ctx *auditContext) error {
+			return s.UpdateAsset(ctx, "9811111111", "-10", "Active", "", "", "", "", "")
+		},
+		"TransferWithFee": func(ctx *auditContext) error {
+			return s.TransferWithFee(ctx, "9822222222", "9811111111", "", 10, 0)
+		},
+		"TransferWithFee fee": func(ctx *auditContext) error {
+			return s.TransferWithFee(ctx, "9822222222", "9811111111", "9811111111", math.MaxInt64, 1)
+		},
+	} {
+		if err := stub.transact(write); !errors.Is(err, ErrBalanceOverflow) {
+			t.Errorf("%s near the int64 boundary returned %v, want ErrBalanceOverflow", name, err)
+		}
+	}
+	if asset := readTestAsset(t, stub, "9811111111"); asset.Balance != math.MaxInt64-5 {
+		t.Errorf("balance is %d after the rejected writes, want %d", asset.Balance, int64(math.MaxInt64-5))
+	}
+
+	var check *TransferCheck
+	err := stub.transact(func(ctx *auditContext) error {
+		var err error
+		check, err = s.CanTransfer(ctx, "9822222222", "9811111111", 10)
+		return err
+	})
+	if err != nil || check.Possible || !strings.Contains(check.Reason, ErrBalanceOverflow.Error()) {
+		t.Errorf("CanTransfer into a full asset returned %+v, %v, want an overflow refusal", check, err)
+	}
+
+	// Sums over several assets overflow too
+	createTestAsset(t, stub, "D001", "9833333333", math.MaxInt64-5)
+	for name, read := range map[string]func(ctx *auditContext) error{
+		"GetTotalBalance": func(ctx *auditContext) error {
+			_, err := s.GetTotalBalance(ctx)
+			return err
+		},
+		"GetAverageBalance": func(ctx *auditContext) error {
+			_, err := s.GetAverageBalance(ctx, "")
+			return err
+		},
+		"GetLedgerStats": func(ctx *auditContext) error {
+			_, err := s.GetLedgerStats(ctx)
+			return err
+		},
+		"GetBalanceByDealer": func(ctx *auditContext) error {
+			_, err := s.GetBalanceByDealer(ctx)
+			return err
+		},
+		"GetDealers": func(ctx *auditContext) error {
+			_, err := s.GetDealers(ctx)
+			return err
+		},
+	} {
+		if err := stub.transact(read); !errors.Is(err, ErrBalanceOverflow) {
+			t.Errorf("%s over balances summing past the int64 boundary returned %v, want ErrBalanceOverflow", name, err)
+		}
+	}
+
+	if _, err := parseBalance(strconv.FormatUint(math.MaxInt64+1, 10)); err == nil {
+		t.Error("parseBalance of a value past the int64 boundary succeeded")
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Operation types accepted by ExecuteBatch
+const (
+	batchOpCreate   = "create"
+	batchOpUpdate   = "update"
+	batchOpTransfer = "transfer"
+)
+
+// batchMPINTransientPrefix prefixes the transient field carrying the MPIN of a
+// batch create, named "MPIN:<msisdn>" with the MSISDN exactly as in the operation
+const batchMPINTransientPrefix = mpinTransientKey + ":"
+
+// BatchOperation is one step of ExecuteBatch. Op selects which of the other
+// fields are used: create and update use the asset fields, transfer moves
+// Amount from the From asset to the To asset, both of which must be Active.
+type BatchOperation struct {
+	Op        string `json:"Op"`
+	DealerID  string `json:"DealerID,omitempty"`
+	MSISDN    string `json:"MSISDN,omitempty"`
+	Balance   int64  `json:"Balance,omitempty"`
+	Status    string `json:"Status,omitempty"`
+	TransType string `json:"TransType,omitempty"`
+	Remarks   string `json:"Remarks,omitempty"`
+	Label     string `json:"Label,omitempty"`
+	From      string `json:"From,omitempty"`
+	To        string `json:"To,omitempty"`
+	Amount    int64  `json:"Amount,omitempty"`
+
+	// ExternalRef and Category are recorded by update and on both sides of a transfer
+	ExternalRef string `json:"ExternalRef,omitempty"`
+	Category    string `json:"Category,omitempty"`
+
+	// ParentMSISDN makes a create fail unless that asset exists, and links the new asset to it
+	ParentMSISDN string `json:"ParentMSISDN,omitempty"`
+}
+
+// batchErrorPrefix starts the message of a failed batch and is followed by
+// the JSON encoded list of BatchItemErrors
+const batchErrorPrefix = "batch failed: "
+
+// BatchItemError identifies a failed batch operation by its 1-based Index
+type BatchItemError struct {
+	Index   int    `json:"Index"`
+	MSISDN  string `json:"MSISDN"`
+	Message string `json:"Message"`
+}
+
+// BatchError lists the operations that made a batch fail. Its message carries
+// the list as JSON so clients can recover it from the error text.
+type BatchError struct {
+	Items []BatchItemError
+}
+
+// Error returns batchErrorPrefix followed by the items as JSON
+func (e *BatchError) Error() string {
+	itemsJSON, err := json.Marshal(e.Items)
+	if err != nil {
+		return batchErrorPrefix + err.Error()
+	}
+	return batchErrorPrefix + string(itemsJSON)
+}
+
+// ExecuteBatch applies a JSON array of operations in order within this
+// transaction. If any operation fails the whole batch fails and none of its
+// writes are committed. Every operation is validated before any is applied,
+// so a *BatchError lists all invalid operations at once. The MPIN of each
+// created asset is read from the "MPIN:<msisdn>" transient field. A single
+// AssetsChanged event names every asset the batch wrote.
+func (s *SmartContract) ExecuteBatch(ctx contractapi.TransactionContextInterface, opsJSON string) error {
+	var ops []BatchOperation
+	if err := json.Unmarshal([]byte(opsJSON), &ops); err != nil {
+		return fmt.Errorf("error parsing batch operations: %v", err)
+	}
+	if len(ops) == 0 {
+		return fmt.Errorf("batch must contain at least one operation")
+	}
+
+	var invalid []BatchItemError
+	for i, op := range ops {
+		if err := validateBatchOperation(ctx, op); err != nil {
+			invalid = append(invalid, BatchItemError{Index: i + 1, MSISDN: op.subject(), Message: err.Error()})
+		}
+	}
+	if len(invalid) > 0 {
+		return &BatchError{Items: invalid}
+	}
+
+	// Later operations must see the writes of earlier ones
+	batchCtx := newBatchContext(ctx)
+
+	var changed []string
+	seen := make(map[string]bool)
+	for i, op := range ops {
+		if err := s.applyBatchOperation(batchCtx, op); err != nil {
+			message := fmt.Sprintf("%s failed: %v", op.Op, err)
+			return &BatchError{Items: []BatchItemError{{Index: i + 1, MSISDN: op.subject(), Message: message}}}
+		}
+		for _, msisdn := range op.affected() {
+			if !seen[msisdn] {
+				seen[msisdn] = true
+				changed = append(changed, msisdn)
+			}
+		}
+	}
+
+	// Replaces the event of the last operation
+	return setAssetsChangedEvent(ctx, changed)
+}
+
+// affected returns the normalized MSISDNs of the assets an operation writes
+func (op BatchOperation) affected() []string {
+	if op.Op == batchOpTransfer {
+		return []string{normalizeMSISDN(op.From), normalizeMSISDN(op.To)}
+	}
+	return []string{normalizeMSISDN(op.MSISDN)}
+}
+
+// subject returns the MSISDN an operation acts on, the source for a transfer
+func (op BatchOperation) subject() string {
+	if op.Op == batchOpTransfer {
+		return op.From
+	}
+	return op.MSISDN
+}
+
+// validateBatchOperation checks the fields of an operation without reading
+// the world state
+func validateBatchOperation(ctx contractapi.TransactionContextInterface, op BatchOperation) error {
+	switch op.Op {
+	case batchOpCreate:
+		if op.MSISDN == "" {
+			return fmt.Errorf("MSISDN is required")
+		}
+		if _, err := getTransientMPIN(ctx, batchMPINTransientPrefix+op.MSISDN); err != nil {
+			return err
+		}
+		_, err := validateStatus(op.Status)
+		return err
+	case batchOpUpdate:
+		if op.MSISDN == "" {
+			return fmt.Errorf("MSISDN is required")
+		}
+		_, err := validateStatus(op.Status)
+		return err
+	case batchOpTransfer:
+		if op.From == "" || op.To == "" {
+			return fmt.Errorf("From and To are required")
+		}
+		if op.Amount <= 0 {
+			return fmt.Errorf("transfer amount must be positive, got %d", op.Amount)
+		}
+		if normalizeMSISDN(op.From) == normalizeMSISDN(op.To) {
+			return fmt.Errorf("cannot transfer from asset %s to itself", op.From)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown operation %q: must be one of create, update, transfer", op.Op)
+	}
+}
+
+// applyBatchOperation applies a single validated batch operation
+func (s *SmartContract) applyBatchOperation(ctx contractapi.TransactionContextInterface, op BatchOperation) error {
+	switch op.Op {
+	case batchOpCreate:
+		mpin, err := getTransientMPIN(ctx, batchMPINTransientPrefix+op.MSISDN)
+		if err != nil {
+			return err
+		}
+		return s.createAsset(ctx, mpin, op.DealerID, op.MSISDN, op.Balance, op.Status, op.Label, op.ParentMSISDN)
+	case batchOpUpdate:
+		return s.UpdateAsset(ctx, op.MSISDN, strconv.FormatInt(op.Balance, 10), op.Status, op.TransType, op.Remarks, "", op.ExternalRef, op.Category)
+	case batchOpTransfer:
+		if err := s.AdjustBalance(ctx, op.From, -op.Amount, transTypeTransferOut, fmt.Sprintf("transfer to %s", op.To), "Active", op.ExternalRef, op.Category); err != nil {
+			return err
+		}
+		return s.AdjustBalance(ctx, op.To, op.Amount, transTypeTransferIn, fmt.Sprintf("transfer from %s", op.From), "Active", op.ExternalRef, op.Category)
+	default:
+		return fmt.Errorf("unknown operation %q: must be one of create, update, transfer", op.Op)
+	}
+}
+
+// batchContext is a transaction context whose stub reads back the writes made
+// earlier in the same transaction, which the peer stub does not. Range and
+// history queries still only see committed state.
+type batchContext struct {
+	contractapi.TransactionContextInterface
+	stub *batchStub
+}
+
+// newBatchContext wraps ctx with a stub that remembers its own writes
+func newBatchContext(ctx contractapi.TransactionContextInterface) *batchContext {
+	return &batchContext{
+		TransactionContextInterface: ctx,
+		stub:                        &batchStub{ChaincodeStubInterface: ctx.GetStub(), writes: make(map[string][]byte)},
+	}
+}
+
+// GetStub returns the write-tracking stub
+func (c *batchContext) GetStub() shim.ChaincodeStubInterface {
+	return c.stub
+}
+
+// batchStub records the writes passed through to the peer stub. A nil value
+// marks a deleted key.
+type batchStub struct {
+	shim.ChaincodeStubInterface
+	writes map[string][]byte
+}
+
+// GetState returns the value written earlier in the transaction, if any
+func (s *batchStub) GetState(key string) ([]byte, error) {
+	if value, ok := s.writes[key]; ok {
+		return value, nil
+	}
+	return s.ChaincodeStubInterface.GetState(key)
+}
+
+// PutState writes the value and remembers it
+func (s *batchStub) PutState(key string, value []byte) error {
+	if err := s.ChaincodeStubInterface.PutState(key, value); err != nil {
+		return err
+	}
+	s.writes[key] = value
+	return nil
+}
+
+// DelState deletes the key and remembers the deletion
+func (s *batchStub) DelState(key string) error {
+	if err := s.ChaincodeStubInterface.DelState(key); err != nil {
+		return err
+	}
+	s.writes[key] = nil
+	return nil
+}
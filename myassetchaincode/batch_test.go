@@ -0,0 +1,168 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExecuteBatchAppliesAllOperations(t *testing.T) {
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 500)
+	createTestAsset(t, stub, "D001", "9822222222", 100)
+	stub.TransientMap["MPIN:9833333333"] = []byte("4321")
+
+	ops := `[
+		{"Op": "create", "DealerID": "D002", "MSISDN": "9833333333", "Balance": 50},
+		{"Op": "update", "MSISDN": "9822222222", "Balance": 150, "TransType": "CREDIT"},
+		{"Op": "transfer", "From": "9811111111", "To": "9833333333", "Amount": 200}
+	]`
+	err := stub.transact(func(ctx *auditContext) error {
+		return new(SmartContract).ExecuteBatch(ctx, ops)
+	})
+	if err != nil {
+		t.Fatalf("ExecuteBatch returned error: %v", err)
+	}
+
+	for msisdn, want := range map[string]int64{"9811111111": 300, "9822222222": 150, "9833333333": 250} {
+		if asset := readTestAsset(t, stub, msisdn); asset.Balance != want {
+			t.Errorf("balance of %s is %d, want %d", msisdn, asset.Balance, want)
+		}
+	}
+	if asset := readTestAsset(t, stub, "9833333333"); asset.MPIN != "4321" || asset.DealerID != "D002" {
+		t.Errorf("created asset has MPIN %q and dealer %s, want the transient MPIN and D002", asset.MPIN, asset.DealerID)
+	}
+	if got := changedMSISDNs(t, stub.lastEvent(t)); strings.Join(got, ",") != "9811111111,9822222222,9833333333" {
+		t.Errorf("batch event names %v, want every asset the batch wrote", got)
+	}
+}
+
+func TestExecuteBatchRollsBackOnFailure(t *testing.T) {
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 500)
+	createTestAsset(t, stub, "D001", "9822222222", 100)
+	stub.TransientMap["MPIN:9833333333"] = []byte("4321")
+	events := len(stub.events)
+
+	// The transfer overdraws 9822222222 after the first two operations succeeded
+	ops := `[
+		{"Op": "create", "DealerID": "D002", "MSISDN": "9833333333", "Balance": 50},
+		{"Op": "update", "MSISDN": "9811111111", "Balance": 400, "TransType": "DEBIT"},
+		{"Op": "transfer", "From": "9822222222", "To": "9811111111", "Amount": 1000}
+	]`
+	err := stub.transact(func(ctx *auditContext) error {
+		return new(SmartContract).ExecuteBatch(ctx, ops)
+	})
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("ExecuteBatch returned %v, want a *BatchError", err)
+	}
+	if len(batchErr.Items) != 1 || batchErr.Items[0].Index != 3 || batchErr.Items[0].MSISDN != "9822222222" {
+		t.Errorf("batch error items are %+v, want the third operation", batchErr.Items)
+	}
+
+	if _, ok := stub.State["9833333333"]; ok {
+		t.Error("asset created by the failed batch was committed")
+	}
+	if asset := readTestAsset(t, stub, "9811111111"); asset.Balance != 500 {
+		t.Errorf("balance of 9811111111 is %d, want the update rolled back to 500", asset.Balance)
+	}
+	if asset := readTestAsset(t, stub, "9822222222"); asset.Balance != 100 {
+		t.Errorf("balance of 9822222222 is %d, want 100", asset.Balance)
+	}
+	if len(stub.events) != events {
+		t.Errorf("failed batch emitted %d events", len(stub.events)-events)
+	}
+}
+
+func TestExecuteBatchReportsEveryInvalidOperation(t *testing.T) {
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 500)
+
+	ops := `[
+		{"Op": "update", "MSISDN": "9811111111", "Balance": 400},
+		{"Op": "create", "MSISDN": "9833333333"},
+		{"Op": "transfer", "From": "9811111111", "To": "9811111111", "Amount": 10},
+		{"Op": "delete", "MSISDN": "9811111111"}
+	]`
+	err := stub.transact(func(ctx *auditContext) error {
+		return new(SmartContract).ExecuteBatch(ctx, ops)
+	})
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("ExecuteBatch returned %v, want a *BatchError", err)
+	}
+	var indexes []int
+	for _, item := range batchErr.Items {
+		indexes = append(indexes, item.Index)
+	}
+	if len(indexes) != 3 || indexes[0] != 2 || indexes[1] != 3 || indexes[2] != 4 {
+		t.Errorf("invalid operations are %v, want 2, 3 and 4", indexes)
+	}
+	if !strings.HasPrefix(err.Error(), batchErrorPrefix) {
+		t.Errorf("error %q does not start with %q", err, batchErrorPrefix)
+	}
+}
+
+func TestExecuteBatchIdentifiesInvalidItem(t *testing.T) {
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 500)
+
+	ops := `[
+		{"Op": "update", "MSISDN": "9811111111", "Balance": 400, "TransType": "DEBIT"},
+		{"Op": "update", "MSISDN": "9811111111", "Balance": 300, "Status": "Closed"},
+		{"Op": "update", "MSISDN": "9811111111", "Balance": 200, "TransType": "DEBIT"}
+	]`
+	err := stub.transact(func(ctx *auditContext) error {
+		return new(SmartContract).ExecuteBatch(ctx, ops)
+	})
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("ExecuteBatch returned %v, want a *BatchError", err)
+	}
+	if len(batchErr.Items) != 1 {
+		t.Fatalf("batch error items are %+v, want only the second operation", batchErr.Items)
+	}
+	item := batchErr.Items[0]
+	if item.Index != 2 || item.MSISDN != "9811111111" || !strings.Contains(item.Message, "Closed") {
+		t.Errorf("batch error item is %+v, want index 2 naming the invalid status", item)
+	}
+}
+
+func TestExecuteBatchTransferRequiresActiveAssets(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 500)
+	createTestAsset(t, stub, "D001", "9822222222", 100)
+	createTestAsset(t, stub, "D001", "9833333333", 100)
+	err := stub.transact(func(ctx *auditContext) error {
+		if err := s.UpdateAsset(ctx, "9811111111", "500", "Frozen", "", "", "", "", ""); err != nil {
+			return err
+		}
+		return s.UpdateAsset(ctx, "9833333333", "100", "Deleted", "", "", "", "", "")
+	})
+	if err != nil {
+		t.Fatalf("preparing assets returned error: %v", err)
+	}
+
+	for name, ops := range map[string]string{
+		"frozen sender":     `[{"Op": "transfer", "From": "9811111111", "To": "9822222222", "Amount": 100}]`,
+		"deleted recipient": `[{"Op": "transfer", "From": "9822222222", "To": "9833333333", "Amount": 50}]`,
+	} {
+		err := stub.transact(func(ctx *auditContext) error {
+			return s.ExecuteBatch(ctx, ops)
+		})
+		var batchErr *BatchError
+		if !errors.As(err, &batchErr) || len(batchErr.Items) != 1 || !strings.Contains(batchErr.Items[0].Message, ErrStatusMismatch.Error()) {
+			t.Errorf("%s: ExecuteBatch returned %v, want a status mismatch for the transfer", name, err)
+		}
+	}
+	for msisdn, want := range map[string]int64{"9811111111": 500, "9822222222": 100, "9833333333": 100} {
+		if asset := readTestAsset(t, stub, msisdn); asset.Balance != want {
+			t.Errorf("balance of %s is %d, want %d", msisdn, asset.Balance, want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// LedgerTime is the timestamp of a transaction alongside the wall clock of the
+// peer that executed it
+type LedgerTime struct {
+	TxTimestamp time.Time `json:"TxTimestamp"`
+	PeerTime    time.Time `json:"PeerTime"`
+}
+
+// GetLedgerTime returns the timestamp of this transaction, which is set by the
+// client that created it, and the executing peer's clock, for diagnosing clock
+// skew. It is only meant to be evaluated: PeerTime differs between endorsers.
+func (s *SmartContract) GetLedgerTime(ctx contractapi.TransactionContextInterface) (*LedgerTime, error) {
+	txTimestamp, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LedgerTime{TxTimestamp: txTimestamp, PeerTime: time.Now().UTC()}, nil
+}
+
+// Ping answers "pong" without touching the world state, so callers can check
+// that the chaincode is installed and responsive
+func (s *SmartContract) Ping(ctx contractapi.TransactionContextInterface) (string, error) {
+	return "pong", nil
+}
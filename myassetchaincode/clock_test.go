@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetLedgerTime(t *testing.T) {
+	stub := newLedgerStub()
+
+	var ledgerTime *LedgerTime
+	err := stub.transact(func(ctx *auditContext) error {
+		var err error
+		ledgerTime, err = new(SmartContract).GetLedgerTime(ctx)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("GetLedgerTime returned error: %v", err)
+	}
+
+	if !ledgerTime.TxTimestamp.Equal(stub.now) {
+		t.Errorf("TxTimestamp = %v, want the stubbed transaction timestamp %v", ledgerTime.TxTimestamp, stub.now)
+	}
+	if skew := time.Since(ledgerTime.PeerTime); skew < 0 || skew > time.Minute {
+		t.Errorf("PeerTime = %v, want the peer clock", ledgerTime.PeerTime)
+	}
+}
+
+func TestPing(t *testing.T) {
+	stub := newLedgerStub()
+
+	var pong string
+	err := stub.transact(func(ctx *auditContext) error {
+		var err error
+		pong, err = new(SmartContract).Ping(ctx)
+		return err
+	})
+	if err != nil || pong != "pong" {
+		t.Errorf("Ping returned %q, %v, want pong", pong, err)
+	}
+	if len(stub.State) != 0 {
+		t.Errorf("Ping wrote %d keys, want none", len(stub.State))
+	}
+}
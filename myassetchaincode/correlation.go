@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// correlationIDTransientKey is the transient field the REST API passes the
+// correlation ID of the request that sent a transaction in
+const correlationIDTransientKey = "correlationID"
+
+// logCorrelationID runs before every transaction and logs its ID with the
+// correlation ID it was sent with, if any, so a request can be traced from the
+// REST API into the chaincode logs. It never fails the transaction.
+func logCorrelationID(ctx contractapi.TransactionContextInterface) error {
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil
+	}
+
+	if correlationID := transient[correlationIDTransientKey]; len(correlationID) > 0 {
+		fmt.Printf("Transaction %s correlation ID %q\n", ctx.GetStub().GetTxID(), correlationID)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// diffableFields are the asset fields DiffUpdate accepts, which are the ones
+// UpdateAsset can change. The MPIN in particular is left out, so a preview
+// cannot be used to guess it around the VerifyMPIN lockout.
+var diffableFields = map[string]bool{
+	"Balance":     true,
+	"Status":      true,
+	"TransType":   true,
+	"Remarks":     true,
+	"ExternalRef": true,
+	"Category":    true,
+}
+
+// DiffUpdate previews an update without applying it. proposedJSON is a JSON
+// object of the fields in diffableFields; the result maps each field whose
+// proposed value differs from the current one to its [old, new] values. The
+// contract API cannot return untyped values, so each value is returned JSON encoded.
+func (s *SmartContract) DiffUpdate(ctx contractapi.TransactionContextInterface, msisdn, proposedJSON string) (map[string][2]string, error) {
+	asset, err := s.ReadAsset(ctx, msisdn)
+	if err != nil {
+		return nil, fmt.Errorf("error reading asset: %v", err)
+	}
+
+	var proposed map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(proposedJSON), &proposed); err != nil {
+		return nil, fmt.Errorf("error parsing proposed update: %v", err)
+	}
+
+	currentJSON, err := json.Marshal(asset)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling asset: %v", err)
+	}
+	var current map[string]json.RawMessage
+	if err := json.Unmarshal(currentJSON, &current); err != nil {
+		return nil, fmt.Errorf("error unmarshalling asset: %v", err)
+	}
+
+	diff := make(map[string][2]string)
+	for field, newValue := range proposed {
+		if !diffableFields[field] {
+			return nil, fmt.Errorf("asset field %q cannot be updated: must be one of Balance, Status, TransType, Remarks, ExternalRef, Category", field)
+		}
+		oldValue := current[field]
+
+		oldCanonical, err := canonicalJSON(oldValue)
+		if err != nil {
+			return nil, fmt.Errorf("error comparing field %s: %v", field, err)
+		}
+		newCanonical, err := canonicalJSON(newValue)
+		if err != nil {
+			return nil, fmt.Errorf("error comparing field %s: %v", field, err)
+		}
+
+		if !bytes.Equal(oldCanonical, newCanonical) {
+			diff[field] = [2]string{string(oldCanonical), string(newCanonical)}
+		}
+	}
+
+	return diff, nil
+}
+
+// canonicalJSON re-encodes a JSON value so that equal values compare equal
+// regardless of whitespace or object key order
+func canonicalJSON(value json.RawMessage) ([]byte, error) {
+	var decoded interface{}
+	if err := json.Unmarshal(value, &decoded); err != nil {
+		return nil, err
+	}
+	return json.Marshal(decoded)
+}
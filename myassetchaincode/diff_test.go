@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffUpdateReportsOnlyChangedFields(t *testing.T) {
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 100)
+
+	var diff map[string][2]string
+	err := stub.transact(func(ctx *auditContext) error {
+		var err error
+		diff, err = new(SmartContract).DiffUpdate(ctx, "9811111111", `{"Balance": 100.0, "Remarks": "", "Status": "Frozen"}`)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("DiffUpdate returned error: %v", err)
+	}
+
+	if len(diff) != 1 {
+		t.Errorf("diff is %v, want only Status", diff)
+	}
+	if diff["Status"] != [2]string{`"Active"`, `"Frozen"`} {
+		t.Errorf("Status diff is %v, want Active to Frozen", diff["Status"])
+	}
+	if asset := readTestAsset(t, stub, "9811111111"); asset.Status != "Active" {
+		t.Errorf("DiffUpdate changed the status to %s", asset.Status)
+	}
+}
+
+func TestDiffUpdateRejectsUnknownField(t *testing.T) {
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 100)
+
+	err := stub.transact(func(ctx *auditContext) error {
+		_, err := new(SmartContract).DiffUpdate(ctx, "9811111111", `{"Colour": "red"}`)
+		return err
+	})
+	if err == nil || !strings.Contains(err.Error(), "Colour") {
+		t.Errorf("DiffUpdate returned %v, want an unknown field error naming Colour", err)
+	}
+}
+
+func TestDiffUpdateRejectsFieldsUpdateAssetCannotChange(t *testing.T) {
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 100)
+	mpin := readTestAsset(t, stub, "9811111111").MPIN
+
+	for _, proposed := range []string{
+		`{"MPIN": "` + mpin + `"}`,
+		`{"MPIN": "0000"}`,
+		`{"Status": "Frozen", "DealerID": "D002"}`,
+		`{"CreatedAt": "2030-01-01T00:00:00Z"}`,
+		`{"SchemaVersion": 1}`,
+	} {
+		var diff map[string][2]string
+		err := stub.transact(func(ctx *auditContext) error {
+			var err error
+			diff, err = new(SmartContract).DiffUpdate(ctx, "9811111111", proposed)
+			return err
+		})
+		if err == nil || !strings.Contains(err.Error(), "cannot be updated") {
+			t.Errorf("DiffUpdate(%s) returned %v, %v, want a cannot be updated error", proposed, diff, err)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// encryptionKeyEnv names the environment variable holding the base64 encoded
+// 32-byte key for field-level encryption. Encryption is off when it is unset.
+const encryptionKeyEnv = "FIELD_ENCRYPTION_KEY"
+
+// encryptedPrefix marks an encrypted field value, so values written before
+// encryption was enabled are still read as plaintext
+const encryptedPrefix = "enc:"
+
+// fieldCipher returns the AEAD and nonce-derivation key for field encryption,
+// or a nil AEAD when no key is configured
+func fieldCipher() (cipher.AEAD, []byte, error) {
+	encoded := os.Getenv(encryptionKeyEnv)
+	if encoded == "" {
+		return nil, nil, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != 32 {
+		return nil, nil, fmt.Errorf("%s must be a base64 encoded 32-byte key", encryptionKeyEnv)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating field cipher: %v", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating field cipher: %v", err)
+	}
+
+	return aead, key, nil
+}
+
+// transformFields rewrites every string field of the struct v points to that
+// is tagged encrypt:"true"
+func transformFields(v interface{}, transform func(name, value string) (string, error)) error {
+	value := reflect.ValueOf(v).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if field.Tag.Get("encrypt") != "true" || field.Type.Kind() != reflect.String {
+			continue
+		}
+
+		transformed, err := transform(field.Name, value.Field(i).String())
+		if err != nil {
+			return err
+		}
+		value.Field(i).SetString(transformed)
+	}
+	return nil
+}
+
+// encryptFields encrypts the tagged fields of the struct v points to. Every
+// endorsing peer must produce the same bytes, so the nonce is derived from the
+// key, field name and plaintext instead of drawn at random; equal plaintexts
+// therefore encrypt to equal ciphertexts.
+func encryptFields(v interface{}) error {
+	aead, key, err := fieldCipher()
+	if err != nil || aead == nil {
+		return err
+	}
+
+	return transformFields(v, func(name, plaintext string) (string, error) {
+		if plaintext == "" {
+			return "", nil
+		}
+
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(name))
+		mac.Write([]byte{0})
+		mac.Write([]byte(plaintext))
+		nonce := mac.Sum(nil)[:aead.NonceSize()]
+
+		sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(name))
+		return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+	})
+}
+
+// decryptFields decrypts the tagged fields of the struct v points to
+func decryptFields(v interface{}) error {
+	aead, _, err := fieldCipher()
+	if err != nil {
+		return err
+	}
+
+	return transformFields(v, func(name, value string) (string, error) {
+		if !strings.HasPrefix(value, encryptedPrefix) {
+			return value, nil
+		}
+		if aead == nil {
+			return "", fmt.Errorf("field %s is encrypted but %s is not set", name, encryptionKeyEnv)
+		}
+
+		sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+		if err != nil || len(sealed) < aead.NonceSize() {
+			return "", fmt.Errorf("error decoding encrypted field %s", name)
+		}
+
+		nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+		plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(name))
+		if err != nil {
+			return "", fmt.Errorf("error decrypting field %s: %v", name, err)
+		}
+		return string(plaintext), nil
+	})
+}
+
+// marshalAsset encodes an asset for the world state with its tagged fields
+// encrypted, leaving the asset itself unchanged
+func marshalAsset(asset *Asset) ([]byte, error) {
+	stored := *asset
+	stored.SchemaVersion = currentSchemaVersion
+	// Derived on read from the transaction time
+	stored.MPINRotationRequired = false
+	if err := encryptFields(&stored); err != nil {
+		return nil, err
+	}
+	return json.Marshal(stored)
+}
+
+// unmarshalAsset decodes an asset read from the world state, decrypts its
+// tagged fields and migrates it to the current schema version
+func unmarshalAsset(data []byte, asset *Asset) error {
+	if err := json.Unmarshal(data, asset); err != nil {
+		return err
+	}
+	if err := decryptFields(asset); err != nil {
+		return err
+	}
+	migrateAsset(asset)
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+// testEncryptionKey is a fixed 32-byte key for field encryption
+var testEncryptionKey = base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+
+func TestFieldEncryptionRoundTrip(t *testing.T) {
+	t.Setenv(encryptionKeyEnv, testEncryptionKey)
+
+	stub := newLedgerStub()
+	stub.TransientMap = map[string][]byte{mpinTransientKey: []byte("4321")}
+	createTestAsset(t, stub, "D001", "9811111111", 100)
+	err := stub.transact(func(ctx *auditContext) error {
+		return new(SmartContract).UpdateAsset(ctx, "9811111111", "200", "Active", "CREDIT", "customer requested refund", "", "", "")
+	})
+	if err != nil {
+		t.Fatalf("UpdateAsset returned error: %v", err)
+	}
+
+	stored := string(stub.State["9811111111"])
+	for _, plaintext := range []string{"4321", "customer requested refund"} {
+		if strings.Contains(stored, plaintext) {
+			t.Errorf("stored bytes contain the plaintext %q: %s", plaintext, stored)
+		}
+	}
+	if !strings.Contains(stored, `"Remarks":"`+encryptedPrefix) {
+		t.Errorf("stored Remarks is not marked encrypted: %s", stored)
+	}
+
+	asset := readTestAsset(t, stub, "9811111111")
+	if asset.Remarks != "customer requested refund" || asset.MPIN != "4321" {
+		t.Errorf("read back Remarks %q and MPIN %q, want the plaintexts", asset.Remarks, asset.MPIN)
+	}
+
+	// CouchDB only sees ciphertext, so selectors on the plaintext match nothing
+	results, err := stub.queryDocuments(`{"selector":{"Remarks":{"$regex":"refund"}}}`)
+	if err != nil {
+		t.Fatalf("query returned error: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("query on the plaintext Remarks matched %d documents, want none", len(results))
+	}
+}
+
+func TestFieldEncryptionRequiresKeyToRead(t *testing.T) {
+	t.Setenv(encryptionKeyEnv, testEncryptionKey)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 100)
+
+	t.Setenv(encryptionKeyEnv, "")
+	err := stub.transact(func(ctx *auditContext) error {
+		_, err := new(SmartContract).ReadAsset(ctx, "9811111111")
+		return err
+	})
+	if err == nil || !strings.Contains(err.Error(), encryptionKeyEnv) {
+		t.Errorf("ReadAsset without the key returned %v, want an error naming %s", err, encryptionKeyEnv)
+	}
+}
+
+func TestFieldEncryptionReadsPlaintextRecords(t *testing.T) {
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 100)
+
+	// Records written before encryption was enabled stay readable
+	t.Setenv(encryptionKeyEnv, testEncryptionKey)
+	if asset := readTestAsset(t, stub, "9811111111"); asset.MPIN != "1234" {
+		t.Errorf("MPIN of a plaintext record is %q, want 1234", asset.MPIN)
+	}
+}
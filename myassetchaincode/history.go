@@ -0,0 +1,262 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// assetVersion is the state of an asset as written by one transaction
+type assetVersion struct {
+	txID     string
+	at       time.Time
+	isDelete bool
+	asset    Asset
+
+	// parseError is set, wrapping ErrUnreadableHistoryValue, when the value
+	// does not decode as an asset; asset is then empty
+	parseError error
+}
+
+// assetVersions returns every version of an asset from its key history,
+// oldest first, decoded like ReadAsset decodes the current one. Values that
+// do not decode as an asset are kept with their parseError set, so each
+// caller decides whether it can do without them.
+func assetVersions(ctx contractapi.TransactionContextInterface, msisdn string) ([]assetVersion, error) {
+	resultsIterator, err := ctx.GetStub().GetHistoryForKey(msisdn)
+	if err != nil {
+		return nil, fmt.Errorf("error getting asset history: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	var versions []assetVersion
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("error iterating through history: %v", err)
+		}
+
+		version := assetVersion{txID: queryResponse.TxId, isDelete: queryResponse.IsDelete}
+		version.at, err = ptypes.Timestamp(queryResponse.Timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("error converting timestamp: %v", err)
+		}
+		if !version.isDelete {
+			if err := unmarshalAsset(queryResponse.Value, &version.asset); err != nil {
+				version.parseError = fmt.Errorf("%w: %v", ErrUnreadableHistoryValue, err)
+			}
+		}
+		versions = append(versions, version)
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].at.Before(versions[j].at)
+	})
+
+	return versions, nil
+}
+
+// BalancePoint is the balance of an asset as written by one transaction
+type BalancePoint struct {
+	Timestamp time.Time `json:"Timestamp"`
+	Balance   int64     `json:"Balance"`
+}
+
+// balancePoints returns the balance written by each version, skipping deletes
+// and values that could not be decoded
+func balancePoints(versions []assetVersion) []BalancePoint {
+	points := []BalancePoint{}
+	for _, version := range versions {
+		if !version.isDelete && version.parseError == nil {
+			points = append(points, BalancePoint{Timestamp: version.at, Balance: version.asset.Balance})
+		}
+	}
+	return points
+}
+
+// GetBalanceSeries returns the balance of an asset after each transaction in
+// its history, oldest first, for charting its balance over time. Versions
+// that cannot be decoded are left out of the series.
+func (s *SmartContract) GetBalanceSeries(ctx contractapi.TransactionContextInterface, msisdn string) ([]BalancePoint, error) {
+	msisdn = normalizeMSISDN(msisdn)
+
+	exists, err := s.AssetExists(ctx, msisdn)
+	if err != nil {
+		return nil, fmt.Errorf("error checking asset existence: %v", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("asset with MSISDN %s does not exist", msisdn)
+	}
+
+	versions, err := assetVersions(ctx, msisdn)
+	if err != nil {
+		return nil, err
+	}
+
+	return balancePoints(versions), nil
+}
+
+// repeatsTransaction reports whether current carries the same transaction
+// fields as previous, meaning its write was not a transaction of its own
+func repeatsTransaction(previous, current *Asset) bool {
+	return current.Balance == previous.Balance && current.TransAmount == previous.TransAmount &&
+		current.TransType == previous.TransType && current.Remarks == previous.Remarks
+}
+
+// GetTransactionsByCategory returns the history entries of an asset whose
+// transaction was recorded with category, oldest first. Writes that repeat the
+// previous transaction, such as metadata changes, are left out.
+func (s *SmartContract) GetTransactionsByCategory(ctx contractapi.TransactionContextInterface, msisdn, category string) ([]*AssetHistoryEntry, error) {
+	if category == "" {
+		return nil, fmt.Errorf("category is required")
+	}
+	if err := validateCategory(category); err != nil {
+		return nil, err
+	}
+
+	history, err := s.GetAssetHistory(ctx, msisdn)
+	if err != nil {
+		return nil, err
+	}
+	// The history is newest first, but repeats are only seen going forward
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].Timestamp.Before(history[j].Timestamp)
+	})
+
+	transactions := []*AssetHistoryEntry{}
+	var previous *Asset
+	for _, entry := range history {
+		if entry.Value == nil {
+			previous = nil
+			continue
+		}
+		if entry.Value.Category == category && (previous == nil || !repeatsTransaction(previous, entry.Value)) {
+			transactions = append(transactions, entry)
+		}
+		previous = entry.Value
+	}
+
+	return transactions, nil
+}
+
+// AssetBundle is the current state of an asset together with its complete
+// history, read in one transaction so the two are consistent
+type AssetBundle struct {
+	Asset       *Asset               `json:"Asset"`
+	History     []*AssetHistoryEntry `json:"History"`
+	TxID        string               `json:"TxID"`
+	GeneratedAt time.Time            `json:"GeneratedAt"`
+}
+
+// ExportAssetBundle returns an asset and every entry of its history as a
+// self-contained bundle for dispute resolution. The MPIN is left out of the
+// asset as it is of the history entries.
+func (s *SmartContract) ExportAssetBundle(ctx contractapi.TransactionContextInterface, msisdn string) (*AssetBundle, error) {
+	asset, err := s.ReadAsset(ctx, msisdn)
+	if err != nil {
+		return nil, fmt.Errorf("error reading asset: %v", err)
+	}
+	asset.MPIN = ""
+
+	history, err := s.GetAssetHistory(ctx, msisdn)
+	if err != nil {
+		return nil, err
+	}
+	if history == nil {
+		history = []*AssetHistoryEntry{}
+	}
+
+	generatedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AssetBundle{
+		Asset:       asset,
+		History:     history,
+		TxID:        ctx.GetStub().GetTxID(),
+		GeneratedAt: generatedAt,
+	}, nil
+}
+
+// HistoryHashChain chains a hash over every history entry of an asset, oldest
+// first, so a client that stores Root can later detect a changed history
+type HistoryHashChain struct {
+	Root   string   `json:"Root"`
+	Hashes []string `json:"Hashes"`
+}
+
+// hashChainLink is what each entry's hash covers: the previous hash and the
+// entry exactly as the peer returns it. Its JSON encoding is the hash input.
+type hashChainLink struct {
+	Previous  string `json:"Previous"`
+	TxID      string `json:"TxID"`
+	Timestamp string `json:"Timestamp"`
+	IsDelete  bool   `json:"IsDelete"`
+	Value     []byte `json:"Value"`
+
+	// at orders the entries and is not hashed
+	at time.Time
+}
+
+// GetHistoryHashChain returns the hex SHA-256 hash of each history entry of an
+// asset and the final hash as Root. Each hash is taken over the JSON encoding
+// of a hashChainLink holding the previous hash (empty for the first entry),
+// the TxID, the RFC3339Nano timestamp, the delete flag and the raw value.
+func (s *SmartContract) GetHistoryHashChain(ctx contractapi.TransactionContextInterface, msisdn string) (*HistoryHashChain, error) {
+	msisdn = normalizeMSISDN(msisdn)
+
+	resultsIterator, err := ctx.GetStub().GetHistoryForKey(msisdn)
+	if err != nil {
+		return nil, fmt.Errorf("error getting asset history: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	var links []hashChainLink
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("error iterating through history: %v", err)
+		}
+
+		timestamp, err := ptypes.Timestamp(queryResponse.Timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("error converting timestamp: %v", err)
+		}
+
+		links = append(links, hashChainLink{
+			TxID:      queryResponse.TxId,
+			Timestamp: timestamp.UTC().Format(time.RFC3339Nano),
+			IsDelete:  queryResponse.IsDelete,
+			Value:     queryResponse.Value,
+			at:        timestamp,
+		})
+	}
+
+	// The history is newest first, but the chain must only grow at its end
+	// so the hashes a client stored stay valid as entries are added
+	sort.SliceStable(links, func(i, j int) bool {
+		return links[i].at.Before(links[j].at)
+	})
+
+	chain := &HistoryHashChain{Hashes: []string{}}
+	for _, link := range links {
+		link.Previous = chain.Root
+		linkJSON, err := json.Marshal(link)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding history entry: %v", err)
+		}
+
+		sum := sha256.Sum256(linkJSON)
+		chain.Root = hex.EncodeToString(sum[:])
+		chain.Hashes = append(chain.Hashes, chain.Root)
+	}
+
+	return chain, nil
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+)
+
+func TestGetBalanceSeries(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 500)
+	want := []BalancePoint{{Timestamp: stub.now, Balance: 500}}
+
+	updates := []func(ctx *auditContext) error{
+		func(ctx *auditContext) error {
+			return s.UpdateAsset(ctx, "9811111111", "700", "Active", "CREDIT", "", "", "", "")
+		},
+		func(ctx *auditContext) error {
+			return s.AdjustBalance(ctx, "9811111111", -200, "DEBIT", "", "", "", "")
+		},
+		func(ctx *auditContext) error {
+			return s.UpdateAsset(ctx, "9811111111", "1000", "Active", "CREDIT", "", "", "", "")
+		},
+	}
+	for i, balance := range []int64{700, 500, 1000} {
+		if err := stub.transact(updates[i]); err != nil {
+			t.Fatalf("update %d returned error: %v", i+1, err)
+		}
+		want = append(want, BalancePoint{Timestamp: stub.now, Balance: balance})
+	}
+
+	var series []BalancePoint
+	err := stub.transact(func(ctx *auditContext) error {
+		var err error
+		series, err = s.GetBalanceSeries(ctx, "+91 98111 11111")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("GetBalanceSeries returned error: %v", err)
+	}
+	if len(series) != len(want) {
+		t.Fatalf("series has %d points, want %d: %+v", len(series), len(want), series)
+	}
+	for i := range want {
+		if series[i].Balance != want[i].Balance || !series[i].Timestamp.Equal(want[i].Timestamp) {
+			t.Errorf("point %d is %d at %v, want %d at %v", i, series[i].Balance, series[i].Timestamp.Format(time.RFC3339), want[i].Balance, want[i].Timestamp.Format(time.RFC3339))
+		}
+	}
+
+	err = stub.transact(func(ctx *auditContext) error {
+		_, err := s.GetBalanceSeries(ctx, "9800000000")
+		return err
+	})
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("GetBalanceSeries of an unknown MSISDN returned %v, want a does not exist error", err)
+	}
+}
+
+func TestGetTransactionsByCategory(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 500)
+
+	type update struct {
+		balance  string
+		category string
+	}
+	var topups []time.Time
+	for _, u := range []update{{"700", "topup"}, {"650", "purchase"}, {"", ""}, {"900", "topup"}, {"890", "fee"}} {
+		err := stub.transact(func(ctx *auditContext) error {
+			if u.balance == "" {
+				// A metadata change rewrites the asset with the purchase fields unchanged
+				return s.SetAssetMetadata(ctx, "9811111111", "kiosk", "north")
+			}
+			return s.UpdateAsset(ctx, "9811111111", u.balance, "Active", "CREDIT", "", "", "", u.category)
+		})
+		if err != nil {
+			t.Fatalf("update %+v returned error: %v", u, err)
+		}
+		if u.category == "topup" {
+			topups = append(topups, stub.now)
+		}
+	}
+
+	transactions := func(category string) []*AssetHistoryEntry {
+		t.Helper()
+		var entries []*AssetHistoryEntry
+		err := stub.transact(func(ctx *auditContext) error {
+			var err error
+			entries, err = s.GetTransactionsByCategory(ctx, "9811111111", category)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("GetTransactionsByCategory(%s) returned error: %v", category, err)
+		}
+		return entries
+	}
+
+	got := transactions("topup")
+	if len(got) != 2 {
+		t.Fatalf("topup transactions are %d, want 2", len(got))
+	}
+	for i, entry := range got {
+		if entry.Value.Category != "topup" || !entry.Timestamp.Equal(topups[i]) {
+			t.Errorf("topup %d is %s at %v, want topup at %v", i, entry.Value.Category, entry.Timestamp, topups[i])
+		}
+	}
+	if got := transactions("purchase"); len(got) != 1 || got[0].Value.Balance != 650 || got[0].Value.Metadata["kiosk"] != "" {
+		t.Errorf("purchase transactions are %+v, want only the purchase itself", got)
+	}
+	if got := transactions("refund"); len(got) != 0 {
+		t.Errorf("refund transactions are %+v, want none", got)
+	}
+
+	err := stub.transact(func(ctx *auditContext) error {
+		_, err := s.GetTransactionsByCategory(ctx, "9811111111", "gift")
+		return err
+	})
+	if err == nil {
+		t.Error("GetTransactionsByCategory accepted the unknown category gift")
+	}
+}
+
+func TestExportAssetBundle(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 500)
+	for _, delta := range []int64{200, -100} {
+		err := stub.transact(func(ctx *auditContext) error {
+			return s.AdjustBalance(ctx, "9811111111", delta, "", "", "", "", "")
+		})
+		if err != nil {
+			t.Fatalf("AdjustBalance returned error: %v", err)
+		}
+	}
+
+	var bundle *AssetBundle
+	var txID string
+	err := stub.transact(func(ctx *auditContext) error {
+		var err error
+		txID = ctx.GetStub().GetTxID()
+		bundle, err = s.ExportAssetBundle(ctx, "9811111111")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("ExportAssetBundle returned error: %v", err)
+	}
+
+	if bundle.Asset.MSISDN != "9811111111" || bundle.Asset.Balance != 600 {
+		t.Errorf("bundled asset is %+v, want 9811111111 with balance 600", bundle.Asset)
+	}
+	if bundle.Asset.MPIN != "" {
+		t.Error("bundled asset includes the MPIN")
+	}
+	var balances []int64
+	for _, entry := range bundle.History {
+		balances = append(balances, entry.Value.Balance)
+		if entry.Value.MPIN != "" {
+			t.Errorf("history entry %s includes the MPIN", entry.TxID)
+		}
+	}
+	if len(balances) != 3 || balances[0] != 600 || balances[1] != 700 || balances[2] != 500 {
+		t.Errorf("bundled history balances are %v, want 600, 700, 500", balances)
+	}
+	if bundle.TxID != txID || !bundle.GeneratedAt.Equal(stub.now) {
+		t.Errorf("bundle generated by %s at %v, want %s at %v", bundle.TxID, bundle.GeneratedAt, txID, stub.now)
+	}
+
+	err = stub.transact(func(ctx *auditContext) error {
+		_, err := s.ExportAssetBundle(ctx, "9800000000")
+		return err
+	})
+	if err == nil {
+		t.Error("ExportAssetBundle of a missing asset succeeded")
+	}
+}
+
+func TestGetHistoryHashChain(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 500)
+	for _, delta := range []int64{200, -100} {
+		err := stub.transact(func(ctx *auditContext) error {
+			return s.AdjustBalance(ctx, "9811111111", delta, "", "", "", "", "")
+		})
+		if err != nil {
+			t.Fatalf("AdjustBalance returned error: %v", err)
+		}
+	}
+
+	hashChain := func() *HistoryHashChain {
+		t.Helper()
+		var chain *HistoryHashChain
+		err := stub.transact(func(ctx *auditContext) error {
+			var err error
+			chain, err = s.GetHistoryHashChain(ctx, "9811111111")
+			return err
+		})
+		if err != nil {
+			t.Fatalf("GetHistoryHashChain returned error: %v", err)
+		}
+		return chain
+	}
+
+	chain := hashChain()
+	if len(chain.Hashes) != 3 || chain.Root != chain.Hashes[2] {
+		t.Fatalf("chain is %+v, want 3 hashes ending with the root", chain)
+	}
+	if again := hashChain(); strings.Join(again.Hashes, ",") != strings.Join(chain.Hashes, ",") {
+		t.Errorf("chain changed between reads from %v to %v", chain.Hashes, again.Hashes)
+	}
+
+	// The first link chains from an empty hash over the creating entry
+	created := stub.history["9811111111"][2]
+	createdAt, _ := ptypes.Timestamp(created.Timestamp)
+	linkJSON, _ := json.Marshal(hashChainLink{TxID: created.TxId, Timestamp: createdAt.UTC().Format(time.RFC3339Nano), Value: created.Value})
+	if sum := sha256.Sum256(linkJSON); chain.Hashes[0] != hex.EncodeToString(sum[:]) {
+		t.Errorf("first hash is %s, want the hash of the creating entry", chain.Hashes[0])
+	}
+
+	// A new entry extends the chain without changing the stored hashes
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.AdjustBalance(ctx, "9811111111", 50, "", "", "", "", "")
+	})
+	if err != nil {
+		t.Fatalf("AdjustBalance returned error: %v", err)
+	}
+	extended := hashChain()
+	if len(extended.Hashes) != 4 || strings.Join(extended.Hashes[:3], ",") != strings.Join(chain.Hashes, ",") {
+		t.Errorf("extended chain is %v, want %v followed by one new hash", extended.Hashes, chain.Hashes)
+	}
+
+	// Changing the second entry changes its hash and every later one
+	tampered := stub.history["9811111111"][2]
+	value := bytes.Replace(tampered.Value, []byte(`"Balance":700`), []byte(`"Balance":7000`), 1)
+	if bytes.Equal(value, tampered.Value) {
+		t.Fatalf("second entry %s has no balance of 700", tampered.Value)
+	}
+	tampered.Value = value
+	changed := hashChain()
+	if changed.Hashes[0] != extended.Hashes[0] {
+		t.Error("hash of the entry before the changed one changed")
+	}
+	for i := 1; i < 4; i++ {
+		if changed.Hashes[i] == extended.Hashes[i] {
+			t.Errorf("hash %d is unchanged after the second entry changed", i)
+		}
+	}
+}
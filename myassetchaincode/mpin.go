@@ -0,0 +1,207 @@
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// MPIN lockout: after maxMPINAttempts wrong MPINs in a row VerifyMPIN rejects
+// every attempt for mpinLockoutDuration
+const (
+	maxMPINAttempts     = 5
+	mpinLockoutDuration = 30 * time.Minute
+)
+
+// newMPINTransientKey is the transient map field carrying the new MPIN on ChangeMPIN
+const newMPINTransientKey = "NEW_MPIN"
+
+// mpinMaxAgeEnv names the environment variable holding the number of days an
+// MPIN stays valid before it must be changed. MPINs do not expire when it is
+// unset. Every endorsing peer must be configured with the same value.
+const mpinMaxAgeEnv = "MPIN_MAX_AGE_DAYS"
+
+// ErrMPINExpired is returned by VerifyMPIN for a correct MPIN that is due for rotation
+var ErrMPINExpired = errors.New("MPIN has expired and must be changed")
+
+// ErrMPINLocked is returned by VerifyMPIN while the MPIN is locked after repeated failures
+var ErrMPINLocked = errors.New("MPIN is locked after too many failed attempts")
+
+// VerifyMPIN reports whether the MPIN in the "MPIN" transient field matches the
+// asset's. Wrong attempts are counted and lock the MPIN once they reach
+// maxMPINAttempts; a correct attempt or an expired lockout resets the count.
+// A wrong MPIN is not an error, so the attempt is committed. A correct MPIN
+// older than MPIN_MAX_AGE_DAYS is rejected until it is changed with ChangeMPIN.
+func (s *SmartContract) VerifyMPIN(ctx contractapi.TransactionContextInterface, msisdn string) (bool, error) {
+	mpin, err := getTransientMPIN(ctx, mpinTransientKey)
+	if err != nil {
+		return false, err
+	}
+
+	asset, err := s.ReadAsset(ctx, msisdn)
+	if err != nil {
+		return false, fmt.Errorf("error reading asset: %v", err)
+	}
+
+	now, err := getTxTimestamp(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	matched, changed, err := checkMPIN(asset, mpin, now)
+	if err != nil {
+		return false, err
+	}
+	if matched && asset.MPINRotationRequired {
+		return false, fmt.Errorf("%w: asset %s must change its MPIN", ErrMPINExpired, asset.MSISDN)
+	}
+	if changed {
+		if err := putAsset(ctx, asset); err != nil {
+			return false, err
+		}
+		if err := setAssetEvent(ctx, "AssetUpdated", asset); err != nil {
+			return false, err
+		}
+	}
+
+	return matched, nil
+}
+
+// ChangeMPIN replaces the MPIN of an asset with the one in the "NEW_MPIN"
+// transient field, provided the "MPIN" transient field holds the current MPIN.
+// The current MPIN is checked and counted like VerifyMPIN, but may have
+// expired. It reports whether the MPIN was changed.
+func (s *SmartContract) ChangeMPIN(ctx contractapi.TransactionContextInterface, msisdn string) (bool, error) {
+	mpin, err := getTransientMPIN(ctx, mpinTransientKey)
+	if err != nil {
+		return false, err
+	}
+	newMPIN, err := getTransientMPIN(ctx, newMPINTransientKey)
+	if err != nil {
+		return false, err
+	}
+
+	asset, err := s.ReadAsset(ctx, msisdn)
+	if err != nil {
+		return false, fmt.Errorf("error reading asset: %v", err)
+	}
+
+	now, err := getTxTimestamp(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	matched, changed, err := checkMPIN(asset, mpin, now)
+	if err != nil {
+		return false, err
+	}
+	if !matched {
+		if !changed {
+			return false, nil
+		}
+		if err := putAsset(ctx, asset); err != nil {
+			return false, err
+		}
+		return false, setAssetEvent(ctx, "AssetUpdated", asset)
+	}
+	if newMPIN == mpin {
+		return false, fmt.Errorf("new MPIN must differ from the current MPIN")
+	}
+
+	asset.MPIN = newMPIN
+	asset.MPINSetAt = now
+
+	if err := putAsset(ctx, asset); err != nil {
+		return false, err
+	}
+	return true, setAssetEvent(ctx, "AssetUpdated", asset)
+}
+
+// checkMPIN compares mpin with the asset's, counting a wrong attempt and
+// locking the MPIN after maxMPINAttempts. It reports whether the MPIN matched
+// and whether the asset was modified and must be stored.
+func checkMPIN(asset *Asset, mpin string, now time.Time) (bool, bool, error) {
+	if mpinLocked(asset, now) {
+		return false, false, fmt.Errorf("%w: asset %s is locked until %s", ErrMPINLocked, asset.MSISDN, asset.MPINLockedUntil.Format(time.RFC3339))
+	}
+
+	changed := false
+	// The lockout has expired, start counting afresh
+	if !asset.MPINLockedUntil.IsZero() {
+		asset.FailedAttempts = 0
+		asset.MPINLockedUntil = time.Time{}
+		changed = true
+	}
+
+	if subtle.ConstantTimeCompare([]byte(mpin), []byte(asset.MPIN)) == 1 {
+		if asset.FailedAttempts != 0 {
+			asset.FailedAttempts = 0
+			changed = true
+		}
+		return true, changed, nil
+	}
+
+	asset.FailedAttempts++
+	if asset.FailedAttempts >= maxMPINAttempts {
+		asset.MPINLockedUntil = now.Add(mpinLockoutDuration)
+	}
+
+	return false, true, nil
+}
+
+// IsMPINExpired reports whether an asset's MPIN is older than MPIN_MAX_AGE_DAYS
+// and must be changed before VerifyMPIN accepts it again
+func (s *SmartContract) IsMPINExpired(ctx contractapi.TransactionContextInterface, msisdn string) (bool, error) {
+	asset, err := s.ReadAsset(ctx, msisdn)
+	if err != nil {
+		return false, fmt.Errorf("error reading asset: %v", err)
+	}
+
+	return asset.MPINRotationRequired, nil
+}
+
+// IsMPINLocked reports whether VerifyMPIN currently rejects attempts for an asset
+func (s *SmartContract) IsMPINLocked(ctx contractapi.TransactionContextInterface, msisdn string) (bool, error) {
+	asset, err := s.ReadAsset(ctx, msisdn)
+	if err != nil {
+		return false, fmt.Errorf("error reading asset: %v", err)
+	}
+
+	now, err := getTxTimestamp(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return mpinLocked(asset, now), nil
+}
+
+// mpinLocked reports whether an asset's MPIN lockout is in force at now
+func mpinLocked(asset *Asset, now time.Time) bool {
+	return now.Before(asset.MPINLockedUntil)
+}
+
+// mpinRotationRequired reports whether an asset's MPIN is older than the
+// configured maximum age at now. Assets created before MPINSetAt was recorded
+// count their MPIN age from CreatedAt.
+func mpinRotationRequired(asset *Asset, now time.Time) (bool, error) {
+	value := os.Getenv(mpinMaxAgeEnv)
+	if value == "" {
+		return false, nil
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days <= 0 {
+		return false, fmt.Errorf("%s must be a positive integer, got %q", mpinMaxAgeEnv, value)
+	}
+
+	setAt := asset.MPINSetAt
+	if setAt.IsZero() {
+		setAt = asset.CreatedAt
+	}
+
+	return !now.Before(setAt.AddDate(0, 0, days)), nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// verifyTestMPIN submits VerifyMPIN with mpin in the transient map
+func verifyTestMPIN(t *testing.T, stub *ledgerStub, msisdn, mpin string) (bool, error) {
+	t.Helper()
+	stub.TransientMap[mpinTransientKey] = []byte(mpin)
+	var matched bool
+	err := stub.transact(func(ctx *auditContext) error {
+		var err error
+		matched, err = new(SmartContract).VerifyMPIN(ctx, msisdn)
+		return err
+	})
+	return matched, err
+}
+
+func TestVerifyMPINLocksAfterRepeatedFailures(t *testing.T) {
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 100)
+
+	var lockedAt time.Time
+	for attempt := 1; attempt <= maxMPINAttempts; attempt++ {
+		matched, err := verifyTestMPIN(t, stub, "9811111111", "0000")
+		lockedAt = stub.now
+		if err != nil || matched {
+			t.Fatalf("wrong attempt %d returned %v, %v, want false and no error", attempt, matched, err)
+		}
+		if asset := readTestAsset(t, stub, "9811111111"); asset.FailedAttempts != attempt {
+			t.Fatalf("after wrong attempt %d FailedAttempts is %d", attempt, asset.FailedAttempts)
+		}
+	}
+
+	// Even the correct MPIN is rejected while locked
+	if _, err := verifyTestMPIN(t, stub, "9811111111", "1234"); !errors.Is(err, ErrMPINLocked) {
+		t.Fatalf("VerifyMPIN while locked returned %v, want ErrMPINLocked", err)
+	}
+	var locked bool
+	err := stub.transact(func(ctx *auditContext) error {
+		var err error
+		locked, err = new(SmartContract).IsMPINLocked(ctx, "9811111111")
+		return err
+	})
+	if err != nil || !locked {
+		t.Errorf("IsMPINLocked returned %v, %v, want true", locked, err)
+	}
+	if asset := readTestAsset(t, stub, "9811111111"); !asset.MPINLockedUntil.Equal(lockedAt.Add(mpinLockoutDuration)) {
+		t.Errorf("locked until %s, want %s", asset.MPINLockedUntil, lockedAt.Add(mpinLockoutDuration))
+	}
+
+	// Once the lockout expires the correct MPIN is accepted and the count resets
+	stub.now = lockedAt.Add(mpinLockoutDuration)
+	matched, err := verifyTestMPIN(t, stub, "9811111111", "1234")
+	if err != nil || !matched {
+		t.Fatalf("VerifyMPIN after the lockout returned %v, %v, want true", matched, err)
+	}
+	if asset := readTestAsset(t, stub, "9811111111"); asset.FailedAttempts != 0 || !asset.MPINLockedUntil.IsZero() {
+		t.Errorf("after the lockout FailedAttempts is %d and locked until %s, want both reset", asset.FailedAttempts, asset.MPINLockedUntil)
+	}
+}
+
+func TestVerifyMPINSuccessResetsFailures(t *testing.T) {
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 100)
+
+	for attempt := 1; attempt < maxMPINAttempts; attempt++ {
+		if matched, err := verifyTestMPIN(t, stub, "9811111111", "0000"); err != nil || matched {
+			t.Fatalf("wrong attempt %d returned %v, %v", attempt, matched, err)
+		}
+	}
+	if matched, err := verifyTestMPIN(t, stub, "9811111111", "1234"); err != nil || !matched {
+		t.Fatalf("correct attempt returned %v, %v, want true", matched, err)
+	}
+	if asset := readTestAsset(t, stub, "9811111111"); asset.FailedAttempts != 0 {
+		t.Errorf("FailedAttempts is %d after a correct MPIN, want 0", asset.FailedAttempts)
+	}
+
+	// A fresh run of failures is needed to lock again
+	if _, err := verifyTestMPIN(t, stub, "9811111111", "0000"); err != nil {
+		t.Fatalf("wrong attempt returned error: %v", err)
+	}
+	if asset := readTestAsset(t, stub, "9811111111"); !asset.MPINLockedUntil.IsZero() {
+		t.Errorf("asset locked until %s after one failure", asset.MPINLockedUntil.Format(time.RFC3339))
+	}
+}
+
+// mpinTestExpired reports IsMPINExpired for an asset in its own transaction
+func mpinTestExpired(t *testing.T, stub *ledgerStub, msisdn string) bool {
+	t.Helper()
+	var expired bool
+	err := stub.transact(func(ctx *auditContext) error {
+		var err error
+		expired, err = new(SmartContract).IsMPINExpired(ctx, msisdn)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("IsMPINExpired returned error: %v", err)
+	}
+	return expired
+}
+
+func TestMPINExpiry(t *testing.T) {
+	t.Setenv(mpinMaxAgeEnv, "30")
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 100)
+
+	// A fresh MPIN verifies and needs no rotation
+	if matched, err := verifyTestMPIN(t, stub, "9811111111", "1234"); err != nil || !matched {
+		t.Fatalf("VerifyMPIN of a fresh MPIN returned %v, %v, want true", matched, err)
+	}
+	if mpinTestExpired(t, stub, "9811111111") || readTestAsset(t, stub, "9811111111").MPINRotationRequired {
+		t.Error("fresh MPIN is reported as expired")
+	}
+
+	stub.now = stub.now.AddDate(0, 0, 30)
+	if !mpinTestExpired(t, stub, "9811111111") || !readTestAsset(t, stub, "9811111111").MPINRotationRequired {
+		t.Error("MPIN older than MPIN_MAX_AGE_DAYS is not reported as expired")
+	}
+	if _, err := verifyTestMPIN(t, stub, "9811111111", "1234"); !errors.Is(err, ErrMPINExpired) {
+		t.Fatalf("VerifyMPIN of an expired MPIN returned %v, want ErrMPINExpired", err)
+	}
+
+	// Changing the MPIN restarts its age
+	stub.TransientMap[newMPINTransientKey] = []byte("5678")
+	var changed bool
+	err := stub.transact(func(ctx *auditContext) error {
+		var err error
+		changed, err = new(SmartContract).ChangeMPIN(ctx, "9811111111")
+		return err
+	})
+	if err != nil || !changed {
+		t.Fatalf("ChangeMPIN of an expired MPIN returned %v, %v, want true", changed, err)
+	}
+	changedAt := stub.now
+	if asset := readTestAsset(t, stub, "9811111111"); !asset.MPINSetAt.Equal(changedAt) || asset.MPINRotationRequired {
+		t.Errorf("after ChangeMPIN MPINSetAt is %s and rotation required is %v, want %s and false", asset.MPINSetAt, asset.MPINRotationRequired, changedAt)
+	}
+	if matched, err := verifyTestMPIN(t, stub, "9811111111", "5678"); err != nil || !matched {
+		t.Errorf("VerifyMPIN of the new MPIN returned %v, %v, want true", matched, err)
+	}
+}
+
+func TestMPINExpiryInvalidSetting(t *testing.T) {
+	t.Setenv(mpinMaxAgeEnv, "monthly")
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 100)
+
+	err := stub.transact(func(ctx *auditContext) error {
+		_, err := new(SmartContract).IsMPINExpired(ctx, "9811111111")
+		return err
+	})
+	if err == nil || !strings.Contains(err.Error(), mpinMaxAgeEnv) {
+		t.Errorf("IsMPINExpired with %s=monthly returned %v, want a configuration error", mpinMaxAgeEnv, err)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import "strings"
+
+const (
+	// defaultCountryCode is the country calling code stripped from international MSISDNs
+	defaultCountryCode = "91"
+	// nationalNumberLength is the number of digits in a national MSISDN
+	nationalNumberLength = 10
+)
+
+// normalizeMSISDN returns the canonical ledger key for an MSISDN so that the
+// same number always maps to the same key. The rules are applied in order:
+//
+//   - spaces, dashes, dots and parentheses are removed
+//   - a leading "+" or "00" international prefix is removed
+//   - the default country code is removed when what follows is a national number
+//   - a leading trunk "0" is removed when what follows is a national number
+//
+// For example "+91 12345 67890", "911234567890", "01234567890" and
+// "1234567890" all normalize to "1234567890". Values that match none of the
+// rules are returned with only the separators removed.
+func normalizeMSISDN(msisdn string) string {
+	normalized := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')', '\t':
+			return -1
+		}
+		return r
+	}, msisdn)
+
+	if strings.HasPrefix(normalized, "+") {
+		normalized = normalized[1:]
+	} else if strings.HasPrefix(normalized, "00") {
+		normalized = normalized[2:]
+	}
+
+	if len(normalized) == len(defaultCountryCode)+nationalNumberLength && strings.HasPrefix(normalized, defaultCountryCode) {
+		normalized = normalized[len(defaultCountryCode):]
+	}
+
+	if len(normalized) == nationalNumberLength+1 && strings.HasPrefix(normalized, "0") {
+		normalized = normalized[1:]
+	}
+
+	return normalized
+}
@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestNormalizeMSISDN(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"1234567890", "1234567890"},
+		{"911234567890", "1234567890"},
+		{"+911234567890", "1234567890"},
+		{"+91 12345 67890", "1234567890"},
+		{"00911234567890", "1234567890"},
+		{"01234567890", "1234567890"},
+		{"(123) 456-7890", "1234567890"},
+		{"123.456.7890", "1234567890"},
+		{"+441234567890", "441234567890"},
+		{"12345", "12345"},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeMSISDN(tt.input); got != tt.want {
+			t.Errorf("normalizeMSISDN(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeMSISDNAppliedOnWriteAndRead(t *testing.T) {
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "+91 12345 67890", 100)
+
+	if _, ok := stub.State["1234567890"]; !ok {
+		t.Fatal("asset was not stored under the normalized key")
+	}
+	for _, msisdn := range []string{"1234567890", "911234567890", "+911234567890"} {
+		if asset := readTestAsset(t, stub, msisdn); asset.MSISDN != "1234567890" {
+			t.Errorf("ReadAsset(%q) returned MSISDN %s, want 1234567890", msisdn, asset.MSISDN)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// adminTokenHeader carries the shared secret required by admin endpoints
+const adminTokenHeader = "X-Admin-Token"
+
+// adminAuth is a middleware that only lets requests through when they present
+// the configured admin token. Admin endpoints are disabled when no token is set.
+func adminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin endpoints are disabled"})
+			return
+		}
+
+		provided := c.GetHeader(adminTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid admin token"})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import "time"
+
+// AuditEntry records a write to an asset. Status and Balance are those the
+// asset was written with and are empty when Deleted is set.
+type AuditEntry struct {
+	Timestamp time.Time `json:"Timestamp" example:"2024-01-15T10:30:00Z"`
+	TxID      string    `json:"TxID" example:"3f2a9c1e8b7d4a6f0e5c2b1a9d8e7f6c5b4a3d2e1f0a9b8c7d6e5f4a3b2c1d0e"`
+	Function  string    `json:"Function" example:"UpdateAsset"`
+	MSISDN    string    `json:"MSISDN" example:"9876543210"`
+	Deleted   bool      `json:"Deleted" example:"false"`
+	Status    string    `json:"Status,omitempty" example:"Active"`
+	Balance   int64     `json:"Balance,omitempty" example:"1500"`
+}
+
+// AuditLogPage is one page of the audit log, oldest first
+type AuditLogPage struct {
+	Entries             []*AuditEntry `json:"Entries"`
+	FetchedRecordsCount int32         `json:"FetchedRecordsCount"`
+	Bookmark            string        `json:"Bookmark"`
+}
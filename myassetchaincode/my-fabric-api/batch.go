@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// BatchOperation is one step of an atomic batch. Op is create, update or
+// transfer: create and update use the asset fields, transfer moves Amount
+// from the From asset to the To asset.
+type BatchOperation struct {
+	Op        string `json:"Op" binding:"required" example:"transfer"`
+	DealerID  string `json:"DealerID,omitempty" example:"D001"`
+	MSISDN    string `json:"MSISDN,omitempty" example:"9876543210"`
+	MPIN      string `json:"MPIN,omitempty" example:"5678"`
+	Balance   int64  `json:"Balance,omitempty" example:"1500"`
+	Status    string `json:"Status,omitempty" example:"Active"`
+	TransType string `json:"TransType,omitempty" example:"CREDIT"`
+	Remarks   string `json:"Remarks,omitempty" example:"monthly top-up"`
+	Label     string `json:"Label,omitempty" example:"Main street kiosk"`
+	From      string `json:"From,omitempty" example:"9876543210"`
+	To        string `json:"To,omitempty" example:"1234567890"`
+	Amount    int64  `json:"Amount,omitempty" example:"250"`
+
+	// ExternalRef and Category are recorded by update and on both sides of a transfer
+	ExternalRef string `json:"ExternalRef,omitempty" example:"PSP-20240115-000123"`
+	Category    string `json:"Category,omitempty" example:"topup"`
+
+	// ParentMSISDN makes a create fail unless that asset exists, and links the new asset to it
+	ParentMSISDN string `json:"ParentMSISDN,omitempty" example:"1234567890"`
+}
+
+// BatchItemError identifies a failed batch operation by its 1-based Index
+type BatchItemError struct {
+	Index   int    `json:"Index" example:"2"`
+	MSISDN  string `json:"MSISDN" example:"9876543210"`
+	Message string `json:"Message" example:"invalid status \"Closed\": must be one of Active, Frozen, Suspended, Deleted"`
+}
+
+// BatchErrorResponse is the body of a failed batch, listing the operations that failed
+type BatchErrorResponse struct {
+	Error string           `json:"error" example:"batch failed"`
+	Items []BatchItemError `json:"items"`
+}
+
+// errBatchFailed matches the prefix of the chaincode's BatchError message,
+// which is followed by the JSON encoded item errors
+const errBatchFailed = "batch failed: "
+
+// buildBatch encodes batch operations for ExecuteBatch. The MPINs of created
+// assets are moved into transient fields named "MPIN:<msisdn>" so they are
+// kept out of the proposal and logs. Operations carrying an MPIN they cannot
+// use are returned as item errors.
+func buildBatch(ops []BatchOperation) (string, map[string][]byte, []BatchItemError, error) {
+	transient := make(map[string][]byte)
+	var invalid []BatchItemError
+	for i := range ops {
+		if ops[i].MPIN == "" {
+			continue
+		}
+		if ops[i].Op != "create" {
+			invalid = append(invalid, BatchItemError{Index: i + 1, MSISDN: ops[i].MSISDN, Message: "MPIN is only accepted on create"})
+			continue
+		}
+		transient["MPIN:"+ops[i].MSISDN] = []byte(ops[i].MPIN)
+		ops[i].MPIN = ""
+	}
+	if len(invalid) > 0 {
+		return "", nil, invalid, nil
+	}
+
+	opsJSON, err := json.Marshal(ops)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	return string(opsJSON), transient, nil, nil
+}
+
+// batchItemErrors recovers the item errors from a failed ExecuteBatch
+func batchItemErrors(err error) ([]BatchItemError, bool) {
+	message := err.Error()
+	start := strings.Index(message, errBatchFailed)
+	if start < 0 {
+		return nil, false
+	}
+
+	// The gateway may append to the chaincode message, so only the first JSON value is read
+	var items []BatchItemError
+	decoder := json.NewDecoder(strings.NewReader(message[start+len(errBatchFailed):]))
+	if err := decoder.Decode(&items); err != nil || len(items) == 0 {
+		return nil, false
+	}
+	return items, true
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestBatchItemErrors(t *testing.T) {
+	err := errors.New(`Failed to submit: Multiple errors occurred: - Transaction processing for endorser [peer0.org1.example.com:7051]: ` +
+		`Chaincode status Code: (500) UNKNOWN. Description: batch failed: ` +
+		`[{"Index":2,"MSISDN":"9811111111","Message":"invalid status \"Closed\""}] - Transaction processing for endorser [peer1.org1.example.com:7051]`)
+
+	items, ok := batchItemErrors(err)
+	if !ok {
+		t.Fatalf("batchItemErrors did not recognise %q", err)
+	}
+	if len(items) != 1 || items[0].Index != 2 || items[0].MSISDN != "9811111111" || items[0].Message != `invalid status "Closed"` {
+		t.Errorf("item errors are %+v, want the second operation", items)
+	}
+
+	for _, message := range []string{"asset 9811111111 does not exist", "batch failed: not json", "batch failed: []"} {
+		if items, ok := batchItemErrors(errors.New(message)); ok {
+			t.Errorf("batchItemErrors(%q) returned %+v, want no item errors", message, items)
+		}
+	}
+}
+
+func TestBuildBatch(t *testing.T) {
+	ops := []BatchOperation{
+		{Op: "create", DealerID: "D001", MSISDN: "9833333333", MPIN: "4321", Balance: 50},
+		{Op: "transfer", From: "9811111111", To: "9833333333", Amount: 20},
+	}
+	opsJSON, transient, invalid, err := buildBatch(ops)
+	if err != nil || len(invalid) > 0 {
+		t.Fatalf("buildBatch returned %+v, %v", invalid, err)
+	}
+	if string(transient["MPIN:9833333333"]) != "4321" {
+		t.Errorf("transient fields are %v, want the MPIN under MPIN:9833333333", transient)
+	}
+
+	var encoded []map[string]interface{}
+	if err := json.Unmarshal([]byte(opsJSON), &encoded); err != nil {
+		t.Fatalf("error unmarshalling operations: %v", err)
+	}
+	if _, ok := encoded[0]["MPIN"]; ok || len(encoded) != 2 {
+		t.Errorf("encoded operations are %v, want both without the MPIN", encoded)
+	}
+}
+
+func TestBuildBatchIdentifiesInvalidItem(t *testing.T) {
+	ops := []BatchOperation{
+		{Op: "create", DealerID: "D001", MSISDN: "9833333333", MPIN: "4321"},
+		{Op: "update", MSISDN: "9811111111", MPIN: "1111", Balance: 400},
+		{Op: "transfer", From: "9811111111", To: "9833333333", Amount: 20},
+	}
+	_, _, invalid, err := buildBatch(ops)
+	if err != nil {
+		t.Fatalf("buildBatch returned error: %v", err)
+	}
+	if len(invalid) != 1 || invalid[0].Index != 2 || invalid[0].MSISDN != "9811111111" {
+		t.Errorf("item errors are %+v, want the second operation", invalid)
+	}
+}
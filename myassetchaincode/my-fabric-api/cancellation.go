@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// errRequestCancelled reports that a long iteration stopped early because the
+// client disconnected or the request timed out
+var errRequestCancelled = errors.New("request cancelled before it completed")
+
+// checkCancelled returns errRequestCancelled once ctx is done. The gateway
+// SDK does not take a context, so a transaction already sent to the peer runs
+// to completion; iterations call this between transactions instead.
+func checkCancelled(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %w", errRequestCancelled, ctx.Err())
+	default:
+		return nil
+	}
+}
+
+// cancelledStatus is the status to report a cancelled request with: 504 when
+// it ran past its route timeout, 408 when the client went away
+func cancelledStatus(err error) int {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusRequestTimeout
+}
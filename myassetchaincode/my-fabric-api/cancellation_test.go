@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cancellingLedger is a pagedLedger that cancels the request after serving
+// its first page, as if the client disconnected mid-export
+type cancellingLedger struct {
+	pagedLedger
+	cancel context.CancelFunc
+	calls  int
+}
+
+func (l *cancellingLedger) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	l.calls++
+	l.cancel()
+	return l.pagedLedger.EvaluateTransaction(name, args...)
+}
+
+func TestExportStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ledger := &cancellingLedger{cancel: cancel}
+	for i := 0; i < 3*exportPageSize; i++ {
+		ledger.assets = append(ledger.assets, &Asset{MSISDN: fmt.Sprintf("98%08d", i)})
+	}
+
+	r := gin.New()
+	r.GET("/assets/export.jsonl", func(c *gin.Context) {
+		exportAssetsJSONL(c, ledger)
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/assets/export.jsonl", nil).WithContext(ctx))
+
+	if ledger.calls != 1 {
+		t.Errorf("export fetched %d pages, want it to stop after the first", ledger.calls)
+	}
+	lines := 0
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		lines++
+	}
+	if lines != exportPageSize {
+		t.Errorf("export wrote %d assets, want the %d of the first page", lines, exportPageSize)
+	}
+}
+
+func TestApplyImportStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var rows []importRow
+	for i := 0; i < 5; i++ {
+		rows = append(rows, importRow{line: i + 2, msisdn: fmt.Sprintf("98%08d", i)})
+	}
+
+	var applied []string
+	report := applyImport(ctx, rows, func(asset Asset) error {
+		applied = append(applied, asset.MSISDN)
+		if len(applied) == 2 {
+			cancel()
+		}
+		return nil
+	})
+
+	if len(applied) != 2 {
+		t.Errorf("applied %v, want only the rows before the cancellation", applied)
+	}
+	if report.Succeeded != 2 || report.Failed != 3 || len(report.Rows) != 5 {
+		t.Fatalf("report has %d succeeded and %d failed of %d rows, want 2, 3 and 5", report.Succeeded, report.Failed, len(report.Rows))
+	}
+	for _, row := range report.Rows[2:] {
+		if row.Success || !strings.Contains(row.Error, errRequestCancelled.Error()) {
+			t.Errorf("row %d is %+v, want it failed as cancelled", row.Row, row)
+		}
+	}
+}
+
+func TestCheckCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := checkCancelled(ctx); err != nil {
+		t.Errorf("checkCancelled of a live context returned %v", err)
+	}
+	cancel()
+	if err := checkCancelled(ctx); !errors.Is(err, errRequestCancelled) || !strings.Contains(err.Error(), context.Canceled.Error()) {
+		t.Errorf("checkCancelled of a cancelled context returned %v, want errRequestCancelled", err)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+)
+
+// CommittedTx is a valid transaction of the contract committed to the ledger
+type CommittedTx struct {
+	TxID        string `json:"TxID"`
+	BlockNumber uint64 `json:"BlockNumber"`
+}
+
+// committedTxLog is a bounded ring buffer of the most recently committed
+// transactions, filled from block events
+type committedTxLog struct {
+	mu      sync.Mutex
+	entries []CommittedTx
+	next    int
+	full    bool
+}
+
+// newCommittedTxLog returns a log holding up to size transactions
+func newCommittedTxLog(size int) *committedTxLog {
+	return &committedTxLog{entries: make([]CommittedTx, size)}
+}
+
+// add records a transaction, dropping the oldest once the log is full
+func (l *committedTxLog) add(tx CommittedTx) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries[l.next] = tx
+	l.next = (l.next + 1) % len(l.entries)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// list returns the recorded transactions, oldest first
+func (l *committedTxLog) list() []CommittedTx {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.full {
+		return append([]CommittedTx{}, l.entries[:l.next]...)
+	}
+	return append(append([]CommittedTx{}, l.entries[l.next:]...), l.entries[:l.next]...)
+}
+
+// recordBlock adds the valid endorser transactions of chaincodeName in a block
+func (l *committedTxLog) recordBlock(block *cb.Block, chaincodeName string) error {
+	var filter []byte
+	if metadata := block.GetMetadata().GetMetadata(); len(metadata) > int(cb.BlockMetadataIndex_TRANSACTIONS_FILTER) {
+		filter = metadata[cb.BlockMetadataIndex_TRANSACTIONS_FILTER]
+	}
+
+	for i, data := range block.GetData().GetData() {
+		if i < len(filter) && pb.TxValidationCode(filter[i]) != pb.TxValidationCode_VALID {
+			continue
+		}
+
+		envelope := &cb.Envelope{}
+		if err := proto.Unmarshal(data, envelope); err != nil {
+			return fmt.Errorf("error unmarshalling envelope %d of block %d: %v", i, block.GetHeader().GetNumber(), err)
+		}
+		payload := &cb.Payload{}
+		if err := proto.Unmarshal(envelope.Payload, payload); err != nil {
+			return fmt.Errorf("error unmarshalling payload %d of block %d: %v", i, block.GetHeader().GetNumber(), err)
+		}
+		channelHeader := &cb.ChannelHeader{}
+		if err := proto.Unmarshal(payload.GetHeader().GetChannelHeader(), channelHeader); err != nil {
+			return fmt.Errorf("error unmarshalling channel header %d of block %d: %v", i, block.GetHeader().GetNumber(), err)
+		}
+		if cb.HeaderType(channelHeader.Type) != cb.HeaderType_ENDORSER_TRANSACTION {
+			continue
+		}
+
+		extension := &pb.ChaincodeHeaderExtension{}
+		if err := proto.Unmarshal(channelHeader.Extension, extension); err != nil {
+			return fmt.Errorf("error unmarshalling header extension %d of block %d: %v", i, block.GetHeader().GetNumber(), err)
+		}
+		if extension.GetChaincodeId().GetName() != chaincodeName {
+			continue
+		}
+
+		l.add(CommittedTx{TxID: channelHeader.TxId, BlockNumber: block.GetHeader().GetNumber()})
+	}
+
+	return nil
+}
+
+// listen subscribes to block events and records the contract's committed
+// transactions until the returned stop function is called
+func (l *committedTxLog) listen(network *gateway.Network, chaincodeName string) (func(), error) {
+	registration, blocks, err := network.RegisterBlockEvent()
+	if err != nil {
+		return nil, fmt.Errorf("error registering for block events: %v", err)
+	}
+
+	go func() {
+		for event := range blocks {
+			if err := l.recordBlock(event.Block, chaincodeName); err != nil {
+				fmt.Printf("Failed to record committed transactions: %s\n", err)
+			}
+		}
+	}()
+
+	return func() { network.Unregister(registration) }, nil
+}
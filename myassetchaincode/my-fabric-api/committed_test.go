@@ -0,0 +1,104 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// testTx describes one transaction of a simulated block
+type testTx struct {
+	txID       string
+	chaincode  string
+	headerType cb.HeaderType
+	validation pb.TxValidationCode
+}
+
+// testBlock builds a block of envelopes for txs with a transactions filter
+// holding their validation codes
+func testBlock(t *testing.T, number uint64, txs ...testTx) *cb.Block {
+	t.Helper()
+	marshal := func(m proto.Message) []byte {
+		data, err := proto.Marshal(m)
+		if err != nil {
+			t.Fatalf("error marshalling %T: %v", m, err)
+		}
+		return data
+	}
+
+	block := &cb.Block{
+		Header:   &cb.BlockHeader{Number: number},
+		Data:     &cb.BlockData{},
+		Metadata: &cb.BlockMetadata{Metadata: make([][]byte, cb.BlockMetadataIndex_TRANSACTIONS_FILTER+1)},
+	}
+	filter := make([]byte, len(txs))
+	for i, tx := range txs {
+		channelHeader := &cb.ChannelHeader{
+			Type:      int32(tx.headerType),
+			TxId:      tx.txID,
+			Extension: marshal(&pb.ChaincodeHeaderExtension{ChaincodeId: &pb.ChaincodeID{Name: tx.chaincode}}),
+		}
+		payload := &cb.Payload{Header: &cb.Header{ChannelHeader: marshal(channelHeader)}}
+		block.Data.Data = append(block.Data.Data, marshal(&cb.Envelope{Payload: marshal(payload)}))
+		filter[i] = byte(tx.validation)
+	}
+	block.Metadata.Metadata[cb.BlockMetadataIndex_TRANSACTIONS_FILTER] = filter
+
+	return block
+}
+
+// committedIDs joins the TxIDs and block numbers of the recorded transactions
+func committedIDs(log *committedTxLog) string {
+	var ids []string
+	for _, tx := range log.list() {
+		ids = append(ids, tx.TxID+"@"+strconv.FormatUint(tx.BlockNumber, 10))
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestCommittedTxLogRecordsContractTransactions(t *testing.T) {
+	log := newCommittedTxLog(10)
+
+	block := testBlock(t, 3,
+		testTx{txID: "tx1", chaincode: contractName, headerType: cb.HeaderType_ENDORSER_TRANSACTION, validation: pb.TxValidationCode_VALID},
+		testTx{txID: "tx2", chaincode: contractName, headerType: cb.HeaderType_ENDORSER_TRANSACTION, validation: pb.TxValidationCode_MVCC_READ_CONFLICT},
+		testTx{txID: "tx3", chaincode: "othercc", headerType: cb.HeaderType_ENDORSER_TRANSACTION, validation: pb.TxValidationCode_VALID},
+		testTx{txID: "tx4", chaincode: contractName, headerType: cb.HeaderType_CONFIG, validation: pb.TxValidationCode_VALID},
+		testTx{txID: "tx5", chaincode: contractName, headerType: cb.HeaderType_ENDORSER_TRANSACTION, validation: pb.TxValidationCode_VALID},
+	)
+	if err := log.recordBlock(block, contractName); err != nil {
+		t.Fatalf("recordBlock returned error: %v", err)
+	}
+
+	if got := committedIDs(log); got != "tx1@3,tx5@3" {
+		t.Errorf("recorded transactions are %s, want tx1@3,tx5@3", got)
+	}
+}
+
+func TestCommittedTxLogIsBounded(t *testing.T) {
+	log := newCommittedTxLog(3)
+
+	for number, txID := range []string{"tx1", "tx2", "tx3", "tx4", "tx5"} {
+		block := testBlock(t, uint64(number), testTx{txID: txID, chaincode: contractName, headerType: cb.HeaderType_ENDORSER_TRANSACTION})
+		if err := log.recordBlock(block, contractName); err != nil {
+			t.Fatalf("recordBlock returned error: %v", err)
+		}
+	}
+
+	if got := committedIDs(log); got != "tx3@2,tx4@3,tx5@4" {
+		t.Errorf("recorded transactions are %s, want the newest three oldest first", got)
+	}
+}
+
+func TestCommittedTxLogRejectsMalformedBlock(t *testing.T) {
+	log := newCommittedTxLog(3)
+	block := &cb.Block{Header: &cb.BlockHeader{Number: 7}, Data: &cb.BlockData{Data: [][]byte{{0xff, 0xff}}}}
+
+	if err := log.recordBlock(block, contractName); err == nil || !strings.Contains(err.Error(), "block 7") {
+		t.Errorf("recordBlock returned %v, want an error naming block 7", err)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// compressedRequest sends a GET to r with the given Accept-Encoding header
+func compressedRequest(r *gin.Engine, path, acceptEncoding string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCompressResponsesGzipsLargeList(t *testing.T) {
+	assets := make([]Asset, 500)
+	for i := range assets {
+		assets[i] = Asset{DealerID: "D001", MSISDN: fmt.Sprintf("98%08d", i), Balance: int64(i), Status: "Active"}
+	}
+	r := gin.New()
+	r.Use(compressResponses())
+	r.GET("/assets", func(c *gin.Context) {
+		c.JSON(http.StatusOK, assets)
+	})
+
+	w := compressedRequest(r, "/assets", "deflate, gzip;q=0.8")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("got status %d with Content-Encoding %q, want a gzipped 200", w.Code, w.Header().Get("Content-Encoding"))
+	}
+	compressedSize := w.Body.Len()
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("response is not gzipped: %v", err)
+	}
+	body, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("error decompressing response: %v", err)
+	}
+	var got []Asset
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("error unmarshalling decompressed response: %v", err)
+	}
+	if len(got) != len(assets) || got[499].MSISDN != assets[499].MSISDN {
+		t.Errorf("decompressed %d assets, want %d", len(got), len(assets))
+	}
+	if compressedSize >= len(body) {
+		t.Errorf("compressed body is %d bytes, want less than the %d uncompressed", compressedSize, len(body))
+	}
+	if w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Errorf("Vary is %q, want Accept-Encoding", w.Header().Get("Vary"))
+	}
+}
+
+func TestCompressResponsesHonorsAcceptEncoding(t *testing.T) {
+	r := gin.New()
+	r.Use(compressResponses())
+	r.GET("/assets", func(c *gin.Context) {
+		c.JSON(http.StatusOK, []Asset{{MSISDN: "9811111111"}})
+	})
+	r.GET("/unchanged", func(c *gin.Context) {
+		c.Status(http.StatusNotModified)
+	})
+
+	for _, acceptEncoding := range []string{"", "deflate", "gzip;q=0", "br, *;q=0"} {
+		w := compressedRequest(r, "/assets", acceptEncoding)
+		if w.Header().Get("Content-Encoding") != "" || !json.Valid(w.Body.Bytes()) {
+			t.Errorf("Accept-Encoding %q got Content-Encoding %q, want plain JSON", acceptEncoding, w.Header().Get("Content-Encoding"))
+		}
+	}
+
+	w := compressedRequest(r, "/unchanged", "gzip")
+	if w.Body.Len() != 0 || w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("bodiless response has %d body bytes and Content-Encoding %q, want neither", w.Body.Len(), w.Header().Get("Content-Encoding"))
+	}
+}
+
+func TestCompressResponsesFlushesStreams(t *testing.T) {
+	release := make(chan struct{})
+	r := gin.New()
+	r.Use(compressResponses())
+	r.GET("/stream", func(c *gin.Context) {
+		c.Header("Content-Type", "text/event-stream")
+		c.Writer.WriteString("data: first\n\n")
+		c.Writer.Flush()
+		// The second event waits until the client has received the first
+		select {
+		case <-release:
+		case <-c.Request.Context().Done():
+			return
+		}
+		c.Writer.WriteString("data: second\n\n")
+	})
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/stream", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := (&http.Client{Transport: &http.Transport{DisableCompression: true}}).Do(req)
+	if err != nil {
+		t.Fatalf("error requesting stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("stream has Content-Encoding %q, want gzip", resp.Header.Get("Content-Encoding"))
+	}
+
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatalf("stream is not gzipped: %v", err)
+	}
+	events := bufio.NewReader(gz)
+	if line, err := events.ReadString('\n'); err != nil || line != "data: first\n" {
+		t.Fatalf("first line is %q, %v, want the flushed event before the handler returns", line, err)
+	}
+	close(release)
+
+	rest, err := io.ReadAll(events)
+	if err != nil {
+		t.Fatalf("error reading the rest of the stream: %v", err)
+	}
+	if string(rest) != "\ndata: second\n\n" {
+		t.Errorf("rest of the stream is %q, want the second event", rest)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errModifiedSince is the error returned when If-Unmodified-Since fails
+const errModifiedSince = "asset was modified after the If-Unmodified-Since time"
+
+// requireUnmodifiedSince is a middleware for full-replace writes of the asset
+// named by the msisdn path parameter. When the request carries
+// If-Unmodified-Since and the asset's Timestamp is later, the write is rejected
+// with 412 Precondition Failed so a stale client cannot overwrite a newer
+// change. An unparseable date is ignored, as HTTP requires. The check reads
+// the asset just before the write is submitted, so a write committed in
+// between is still possible.
+func requireUnmodifiedSince(commits *commitTracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		since, err := http.ParseTime(c.GetHeader("If-Unmodified-Since"))
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		if checkUnmodifiedSince(c, requestContract(c), commits, since) {
+			c.Next()
+		}
+	}
+}
+
+// checkUnmodifiedSince reads the asset named by the msisdn path parameter and
+// reports whether it is unmodified since the given time, aborting the request
+// when it is not or cannot be read
+func checkUnmodifiedSince(c *gin.Context, contract evaluator, commits *commitTracker, since time.Time) bool {
+	msisdn := c.Param("msisdn")
+	if err := commits.wait(msisdn); err != nil {
+		c.AbortWithStatusJSON(http.StatusGatewayTimeout, gin.H{"error": err.Error()})
+		return false
+	}
+
+	asset, err := readAsset(contract, nil, msisdn)
+	if err != nil {
+		c.AbortWithStatusJSON(statusForError(err), gin.H{"error": err.Error()})
+		return false
+	}
+
+	// HTTP dates have a resolution of one second
+	if asset.Timestamp.Truncate(time.Second).After(since) {
+		c.AbortWithStatusJSON(http.StatusPreconditionFailed, gin.H{
+			"error": fmt.Sprintf("%s: last modified %s", errModifiedSince, asset.Timestamp.UTC().Format(http.TimeFormat)),
+		})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// conditionalUpdate sends an update of msisdn guarded by If-Unmodified-Since
+// through checkUnmodifiedSince and reports the response and whether the
+// update handler ran
+func conditionalUpdate(ledger evaluator, msisdn, ifUnmodifiedSince string) (*httptest.ResponseRecorder, bool) {
+	updated := false
+	r := gin.New()
+	r.POST("/updateAsset/:msisdn", func(c *gin.Context) {
+		since, err := http.ParseTime(c.GetHeader("If-Unmodified-Since"))
+		if err != nil || checkUnmodifiedSince(c, ledger, nil, since) {
+			c.Next()
+		}
+	}, func(c *gin.Context) {
+		updated = true
+		c.JSON(http.StatusOK, gin.H{"message": "Asset updated successfully"})
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/updateAsset/"+msisdn, nil)
+	req.Header.Set("If-Unmodified-Since", ifUnmodifiedSince)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w, updated
+}
+
+func TestCheckUnmodifiedSince(t *testing.T) {
+	modified := time.Date(2024, 1, 15, 10, 30, 0, 250000000, time.UTC)
+	ledger := &fakeLedger{assets: map[string]Asset{"9811111111": {MSISDN: "9811111111", Balance: 700, Timestamp: modified}}}
+
+	// The Last-Modified the client read, and any later time, let the update through
+	for _, since := range []time.Time{modified, modified.Add(time.Hour)} {
+		w, updated := conditionalUpdate(ledger, "9811111111", since.Format(http.TimeFormat))
+		if w.Code != http.StatusOK || !updated {
+			t.Errorf("update unmodified since %s got status %d, updated %v, want it applied", since, w.Code, updated)
+		}
+	}
+
+	w, updated := conditionalUpdate(ledger, "9811111111", modified.Add(-time.Second).Format(http.TimeFormat))
+	if w.Code != http.StatusPreconditionFailed || updated {
+		t.Fatalf("update of an asset modified since got status %d, updated %v, want a rejected 412", w.Code, updated)
+	}
+	var body ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("error unmarshalling response: %v", err)
+	}
+	if !strings.Contains(body.Error, errModifiedSince) || !strings.Contains(body.Error, modified.Format(http.TimeFormat)) {
+		t.Errorf("error is %q, want it to give the last modification time", body.Error)
+	}
+
+	// A missing header or unparseable date leaves the update unconditional
+	for _, header := range []string{"", "yesterday"} {
+		if w, updated := conditionalUpdate(ledger, "9811111111", header); w.Code != http.StatusOK || !updated {
+			t.Errorf("update with If-Unmodified-Since %q got status %d, updated %v, want it applied", header, w.Code, updated)
+		}
+	}
+
+	if w, updated := conditionalUpdate(ledger, "9800000000", modified.Format(http.TimeFormat)); w.Code == http.StatusOK || updated {
+		t.Errorf("update of a missing asset got status %d, updated %v, want it rejected", w.Code, updated)
+	}
+}
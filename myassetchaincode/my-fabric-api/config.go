@@ -0,0 +1,155 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds the runtime settings of the REST API, read from the environment
+type Config struct {
+	GatewayConnectAttempts int
+	GatewayConnectInterval time.Duration
+	GatewayCommitTimeout   time.Duration
+	GatewayEndorseTimeout  time.Duration
+	DiscoveryAsLocalhost   bool
+	MaxConcurrentSubmits   int
+	QueueSubmits           bool
+	AssetProjection        bool
+	AdminToken             string
+	ReadYourWrites         bool
+	CommitWaitTimeout      time.Duration
+	SwaggerHost            string
+	SwaggerBasePath        string
+	MaxHistoryDepth        int
+	FabricIdentity         string
+	FabricIdentities       []string
+	FabricMSPID            string
+	FabricCertPath         string
+	FabricKeyPath          string
+	WalletPath             string
+	WalletLabel            string
+	CommittedTxBuffer      int
+	LogRequestBodies       bool
+	RedactFields           []string
+	StatementSigningKey    string
+	StreamHeartbeat        time.Duration
+	ErrorLanguage          string
+	CompressResponses      bool
+	ReadOnly               bool
+	LedgerGaugeInterval    time.Duration
+	RequestTimeout         time.Duration
+	RouteTimeouts          map[string]time.Duration
+	TreasuryMSISDN         string
+	ReadyTimeout           time.Duration
+	ResponseFieldCase      string
+}
+
+// loadConfig builds the Config from environment variables, falling back to defaults
+func loadConfig() Config {
+	return Config{
+		GatewayConnectAttempts: getEnvInt("GATEWAY_CONNECT_ATTEMPTS", 5),
+		GatewayConnectInterval: getEnvDuration("GATEWAY_CONNECT_INTERVAL", 2*time.Second),
+		GatewayCommitTimeout:   getEnvDuration("GATEWAY_COMMIT_TIMEOUT", defaultGatewayCommitTimeout),
+		GatewayEndorseTimeout:  getEnvDuration("GATEWAY_ENDORSEMENT_TIMEOUT", 0),
+		DiscoveryAsLocalhost:   getEnvBool("GATEWAY_DISCOVERY_AS_LOCALHOST", false),
+		MaxConcurrentSubmits:   getEnvInt("MAX_CONCURRENT_SUBMITS", 20),
+		QueueSubmits:           getEnv("SUBMIT_OVERFLOW_MODE", "queue") == "queue",
+		AssetProjection:        getEnvBool("ASSET_PROJECTION_ENABLED", false),
+		AdminToken:             getEnv("ADMIN_TOKEN", ""),
+		ReadYourWrites:         getEnvBool("READ_YOUR_WRITES", false),
+		CommitWaitTimeout:      getEnvDuration("COMMIT_WAIT_TIMEOUT", 30*time.Second),
+		SwaggerHost:            getEnv("SWAGGER_HOST", "localhost:8080"),
+		SwaggerBasePath:        getEnv("SWAGGER_BASE_PATH", "/v1"),
+		MaxHistoryDepth:        getEnvInt("MAX_HISTORY_DEPTH", 1000),
+		FabricIdentity:         getEnv("FABRIC_IDENTITY", "appUser"),
+		FabricIdentities:       getEnvList("FABRIC_IDENTITIES"),
+		FabricMSPID:            getEnv("FABRIC_MSP_ID", "Org1MSP"),
+		FabricCertPath:         getEnv("FABRIC_CERT_PATH", ""),
+		FabricKeyPath:          getEnv("FABRIC_KEY_PATH", ""),
+		WalletPath:             getEnv("WALLET_PATH", ""),
+		WalletLabel:            getEnv("WALLET_LABEL", ""),
+		CommittedTxBuffer:      getEnvInt("COMMITTED_TX_BUFFER", 1000),
+		LogRequestBodies:       getEnvBool("LOG_REQUEST_BODIES", false),
+		RedactFields:           getEnvList("REDACT_FIELDS"),
+		StatementSigningKey:    getEnv("STATEMENT_SIGNING_KEY", ""),
+		StreamHeartbeat:        getEnvDuration("STREAM_HEARTBEAT_INTERVAL", 15*time.Second),
+		ErrorLanguage:          getEnv("ERROR_LANGUAGE", defaultLanguage),
+		CompressResponses:      getEnvBool("COMPRESS_RESPONSES", false),
+		ReadOnly:               getEnvBool("READ_ONLY", false),
+		LedgerGaugeInterval:    getEnvDuration("LEDGER_GAUGE_INTERVAL", time.Minute),
+		RequestTimeout:         getEnvDuration("REQUEST_TIMEOUT", 0),
+		RouteTimeouts:          getEnvDurationMap("ROUTE_TIMEOUTS"),
+		TreasuryMSISDN:         getEnv("TREASURY_MSISDN", ""),
+		ReadyTimeout:           getEnvDuration("READY_TIMEOUT", 5*time.Second),
+		ResponseFieldCase:      getEnv("RESPONSE_FIELD_CASE", fieldCasePascal),
+	}
+}
+
+// getEnv returns the value of an environment variable or the default when unset
+func getEnv(key, def string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return def
+}
+
+// getEnvList returns a comma-separated environment variable as a list, or nil when unset
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(getEnv(key, ""), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvInt returns an integer environment variable or the default when unset or invalid
+func getEnvInt(key string, def int) int {
+	value, err := strconv.Atoi(getEnv(key, ""))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// getEnvBool returns a boolean environment variable (e.g. "true", "1") or the default when unset or invalid
+func getEnvBool(key string, def bool) bool {
+	value, err := strconv.ParseBool(getEnv(key, ""))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// getEnvDuration returns a duration environment variable (e.g. "2s") or the default when unset or invalid
+func getEnvDuration(key string, def time.Duration) time.Duration {
+	value, err := time.ParseDuration(getEnv(key, ""))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// getEnvDurationMap returns a comma-separated list of key=duration pairs
+// (e.g. "GET /assets=30s") as a map, skipping invalid pairs, or nil when unset
+func getEnvDurationMap(key string) map[string]time.Duration {
+	var values map[string]time.Duration
+	for _, pair := range getEnvList(key) {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		duration, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			continue
+		}
+		if values == nil {
+			values = make(map[string]time.Duration)
+		}
+		values[strings.TrimSpace(name)] = duration
+	}
+	return values
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+// commitTracker gives read-your-writes consistency: it remembers the latest
+// submitted transaction per MSISDN so reads can wait until it has committed
+type commitTracker struct {
+	mu      sync.Mutex
+	pending map[string]chan struct{}
+	timeout time.Duration
+}
+
+// newCommitTracker returns a tracker whose waits give up after timeout
+func newCommitTracker(timeout time.Duration) *commitTracker {
+	return &commitTracker{pending: make(map[string]chan struct{}), timeout: timeout}
+}
+
+// submit submits a transaction that writes msisdn and tracks its commit.
+// A nil tracker submits without tracking.
+func (t *commitTracker) submit(contract *correlatedContract, msisdn, name string, args ...string) ([]byte, error) {
+	return t.submitTransient(contract, msisdn, name, nil, args...)
+}
+
+// submitTransient is submit with transient data, which is passed to the
+// chaincode but not recorded in the transaction
+func (t *commitTracker) submitTransient(contract *correlatedContract, msisdn, name string, transient map[string][]byte, args ...string) ([]byte, error) {
+	txn, err := contract.CreateTransaction(name, contract.withTransient(transient))
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return txn.Submit(args...)
+	}
+
+	return t.track(msisdn, txn.RegisterCommitEvent(), func() ([]byte, error) {
+		return txn.Submit(args...)
+	})
+}
+
+// track runs submit as the latest write to msisdn, so that reads wait until
+// its commit event arrives on commits or the timeout passes
+func (t *commitTracker) track(msisdn string, commits <-chan *fab.TxStatusEvent, submit func() ([]byte, error)) ([]byte, error) {
+	done := make(chan struct{})
+	var once sync.Once
+	finish := func() {
+		once.Do(func() {
+			close(done)
+			t.mu.Lock()
+			if t.pending[msisdn] == done {
+				delete(t.pending, msisdn)
+			}
+			t.mu.Unlock()
+		})
+	}
+
+	t.mu.Lock()
+	t.pending[msisdn] = done
+	t.mu.Unlock()
+
+	go func() {
+		select {
+		case event := <-commits:
+			if event != nil && event.TxValidationCode != peer.TxValidationCode_VALID {
+				fmt.Printf("Transaction %s for %s committed as %s\n", event.TxID, msisdn, event.TxValidationCode)
+			}
+		case <-time.After(t.timeout):
+		}
+		finish()
+	}()
+
+	result, err := submit()
+	if err != nil {
+		finish()
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// wait blocks until the latest tracked transaction for msisdn has committed.
+// It returns an error if that takes longer than the configured timeout.
+func (t *commitTracker) wait(msisdn string) error {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	done, ok := t.pending[msisdn]
+	t.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(t.timeout):
+		return fmt.Errorf("timed out after %s waiting for the last write to %s to commit", t.timeout, msisdn)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+// laggingLedger only shows a submitted asset to reads once its commit has
+// been delivered, like a peer that has not yet received the block
+type laggingLedger struct {
+	mu        sync.Mutex
+	committed map[string]Asset
+}
+
+func (l *laggingLedger) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	asset, ok := l.committed[args[0]]
+	if name != "ReadAsset" || !ok {
+		return nil, errors.New("asset with MSISDN " + args[0] + " does not exist")
+	}
+	return json.Marshal(asset)
+}
+
+// create returns a submit function that commits asset after delay and then
+// sends its commit event
+func (l *laggingLedger) create(asset Asset, delay time.Duration, commits chan<- *fab.TxStatusEvent) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		go func() {
+			time.Sleep(delay)
+			l.mu.Lock()
+			l.committed[asset.MSISDN] = asset
+			l.mu.Unlock()
+			commits <- &fab.TxStatusEvent{TxID: "tx1", TxValidationCode: peer.TxValidationCode_VALID}
+		}()
+		return nil, nil
+	}
+}
+
+func TestCreateThenReadWaitsForCommit(t *testing.T) {
+	ledger := &laggingLedger{committed: make(map[string]Asset)}
+	tracker := newCommitTracker(time.Second)
+	commits := make(chan *fab.TxStatusEvent, 1)
+
+	created := Asset{MSISDN: "9811111111", DealerID: "D001", Balance: 300, Status: "Active"}
+	if _, err := tracker.track(created.MSISDN, commits, ledger.create(created, 50*time.Millisecond, commits)); err != nil {
+		t.Fatalf("track returned error: %v", err)
+	}
+
+	if err := tracker.wait(created.MSISDN); err != nil {
+		t.Fatalf("wait returned error: %v", err)
+	}
+	asset, err := readAsset(ledger, nil, created.MSISDN)
+	if err != nil {
+		t.Fatalf("read after create returned error: %v", err)
+	}
+	if asset.Balance != 300 || asset.DealerID != "D001" {
+		t.Errorf("read after create returned %+v, want the new asset", asset)
+	}
+}
+
+func TestCreateThenReadWithoutConsistencyMode(t *testing.T) {
+	ledger := &laggingLedger{committed: make(map[string]Asset)}
+	var tracker *commitTracker
+	commits := make(chan *fab.TxStatusEvent, 1)
+
+	created := Asset{MSISDN: "9811111111", Balance: 300}
+	ledger.create(created, 50*time.Millisecond, commits)()
+
+	// Without a tracker reads do not wait, so the peer may not have the asset yet
+	if err := tracker.wait(created.MSISDN); err != nil {
+		t.Fatalf("wait without a tracker returned error: %v", err)
+	}
+	if _, err := readAsset(ledger, nil, created.MSISDN); err == nil {
+		t.Error("read before the commit found the asset")
+	}
+	<-commits
+}
+
+func TestFailedSubmitDoesNotBlockReads(t *testing.T) {
+	tracker := newCommitTracker(time.Second)
+
+	_, err := tracker.track("9811111111", make(chan *fab.TxStatusEvent), func() ([]byte, error) {
+		return nil, errors.New("endorsement failed")
+	})
+	if err == nil {
+		t.Fatal("track of a failed submit succeeded")
+	}
+
+	start := time.Now()
+	if err := tracker.wait("9811111111"); err != nil {
+		t.Fatalf("wait returned error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("wait after a failed submit took %s, want it to return at once", elapsed)
+	}
+}
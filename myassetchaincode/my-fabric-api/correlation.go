@@ -0,0 +1,118 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+)
+
+// correlationIDHeader carries the ID that ties a request to the chaincode
+// transactions it sends. It is echoed on every response.
+const correlationIDHeader = "X-Correlation-ID"
+
+// correlationIDKey is the gin context key the correlation ID of a request is stored under
+const correlationIDKey = "correlationID"
+
+// correlationIDTransientKey is the transient field the chaincode reads the
+// correlation ID from
+const correlationIDTransientKey = "correlationID"
+
+// maxCorrelationIDLength bounds client supplied correlation IDs, which end up
+// in the chaincode logs
+const maxCorrelationIDLength = 128
+
+// withCorrelationID takes the correlation ID of a request from
+// correlationIDHeader, or generates one when it is missing or unusable, and
+// returns it in the response header
+func withCorrelationID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(correlationIDHeader)
+		if !validCorrelationID(id) {
+			id = newCorrelationID()
+		}
+
+		c.Set(correlationIDKey, id)
+		c.Header(correlationIDHeader, id)
+		c.Next()
+	}
+}
+
+// validCorrelationID reports whether id is non-empty, not too long and only
+// uses letters, digits and the separators - _ . :
+func validCorrelationID(id string) bool {
+	if id == "" || len(id) > maxCorrelationIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newCorrelationID returns a random 128-bit hex encoded ID
+func newCorrelationID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(id)
+}
+
+// correlatedContract is the contract of a request. Every transaction it
+// creates carries the request's correlation ID as transient data.
+type correlatedContract struct {
+	*gateway.Contract
+	correlationID string
+}
+
+// EvaluateTransaction evaluates a transaction carrying the correlation ID
+func (c *correlatedContract) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	txn, err := c.CreateTransaction(name)
+	if err != nil {
+		return nil, err
+	}
+	return txn.Evaluate(args...)
+}
+
+// SubmitTransaction submits a transaction carrying the correlation ID
+func (c *correlatedContract) SubmitTransaction(name string, args ...string) ([]byte, error) {
+	txn, err := c.CreateTransaction(name)
+	if err != nil {
+		return nil, err
+	}
+	return txn.Submit(args...)
+}
+
+// CreateTransaction creates a transaction carrying the correlation ID. A
+// WithTransient option replaces it, so pass transient data through
+// withTransient instead.
+func (c *correlatedContract) CreateTransaction(name string, options ...gateway.TransactionOption) (*gateway.Transaction, error) {
+	return c.Contract.CreateTransaction(name, append([]gateway.TransactionOption{c.withTransient(nil)}, options...)...)
+}
+
+// withTransient returns an option setting the transient data of a
+// transaction along with the correlation ID
+func (c *correlatedContract) withTransient(data map[string][]byte) gateway.TransactionOption {
+	return gateway.WithTransient(c.transient(data))
+}
+
+// transient returns data with the correlation ID added, leaving data unchanged
+func (c *correlatedContract) transient(data map[string][]byte) map[string][]byte {
+	if c.correlationID == "" {
+		return data
+	}
+
+	transient := make(map[string][]byte, len(data)+1)
+	for key, value := range data {
+		transient[key] = value
+	}
+	transient[correlationIDTransientKey] = []byte(c.correlationID)
+	return transient
+}
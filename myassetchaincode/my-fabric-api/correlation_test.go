@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+)
+
+// correlatedRequest sends a request with the given correlation ID header and
+// returns the transient data the request's contract would send along with
+// an MPIN, and the response
+func correlatedRequest(t *testing.T, correlationID string) (map[string][]byte, *httptest.ResponseRecorder) {
+	t.Helper()
+	var transient map[string][]byte
+	r := gin.New()
+	r.Use(withCorrelationID())
+	r.POST("/", func(c *gin.Context) {
+		c.Set(contractKey, &gateway.Contract{})
+	}, func(c *gin.Context) {
+		transient = requestContract(c).transient(map[string][]byte{"MPIN": []byte("1234")})
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	if correlationID != "" {
+		req.Header.Set(correlationIDHeader, correlationID)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("request got status %d: %s", w.Code, w.Body)
+	}
+	return transient, w
+}
+
+func TestCorrelationIDPassedToTransientData(t *testing.T) {
+	transient, w := correlatedRequest(t, "req-42.checkout:7")
+
+	if got := string(transient[correlationIDTransientKey]); got != "req-42.checkout:7" {
+		t.Errorf("transient correlation ID is %q, want the request's", got)
+	}
+	if string(transient["MPIN"]) != "1234" {
+		t.Errorf("transient data is %v, want the MPIN kept", transient)
+	}
+	if got := w.Header().Get(correlationIDHeader); got != "req-42.checkout:7" {
+		t.Errorf("response correlation ID is %q, want the request's", got)
+	}
+}
+
+func TestCorrelationIDGenerated(t *testing.T) {
+	for _, header := range []string{"", "has spaces", strings.Repeat("x", maxCorrelationIDLength+1)} {
+		transient, w := correlatedRequest(t, header)
+
+		id := w.Header().Get(correlationIDHeader)
+		if len(id) != 32 || id == header {
+			t.Errorf("header %q got correlation ID %q, want a generated one", header, id)
+		}
+		if got := string(transient[correlationIDTransientKey]); got != id {
+			t.Errorf("header %q passed %q to the chaincode, want the returned ID %q", header, got, id)
+		}
+	}
+
+	first, _ := correlatedRequest(t, "")
+	second, _ := correlatedRequest(t, "")
+	if string(first[correlationIDTransientKey]) == string(second[correlationIDTransientKey]) {
+		t.Error("two requests were given the same correlation ID")
+	}
+}
+
+func TestCorrelatedContractWithoutID(t *testing.T) {
+	data := map[string][]byte{"MPIN": []byte("1234")}
+	if transient := (&correlatedContract{}).transient(data); len(transient) != 1 {
+		t.Errorf("transient data is %v, want it unchanged without a correlation ID", transient)
+	}
+}
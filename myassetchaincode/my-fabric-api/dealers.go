@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// DealerAssets is a dealer with the assets belonging to it
+type DealerAssets struct {
+	DealerID string   `json:"dealerID"`
+	Assets   []*Asset `json:"assets"`
+}
+
+// dealerTree evaluates GetAllAssets and nests the assets under their dealers
+func dealerTree(contract evaluator) ([]DealerAssets, error) {
+	// Invoke Fabric Chaincode
+	response, err := contract.EvaluateTransaction("GetAllAssets")
+	if err != nil {
+		return nil, err
+	}
+
+	var assets []*Asset
+	if err := json.Unmarshal(response, &assets); err != nil {
+		return nil, err
+	}
+
+	return groupByDealer(assets), nil
+}
+
+// groupByDealer nests assets under their DealerID, with dealers sorted by ID
+// and each dealer's assets in their original order
+func groupByDealer(assets []*Asset) []DealerAssets {
+	byDealer := make(map[string][]*Asset)
+	for _, asset := range assets {
+		byDealer[asset.DealerID] = append(byDealer[asset.DealerID], asset)
+	}
+
+	tree := make([]DealerAssets, 0, len(byDealer))
+	for dealerID, dealerAssets := range byDealer {
+		tree = append(tree, DealerAssets{DealerID: dealerID, Assets: dealerAssets})
+	}
+	sort.Slice(tree, func(i, j int) bool {
+		return tree[i].DealerID < tree[j].DealerID
+	})
+
+	return tree
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestDealerTree(t *testing.T) {
+	ledger := &fakeLedger{assets: map[string]Asset{
+		"9811111111": {MSISDN: "9811111111", DealerID: "D002", Balance: 100},
+		"9822222222": {MSISDN: "9822222222", DealerID: "D001", Balance: 200},
+		"9833333333": {MSISDN: "9833333333", DealerID: "D002", Balance: 300},
+	}}
+
+	tree, err := dealerTree(ledger)
+	if err != nil {
+		t.Fatalf("dealerTree returned error: %v", err)
+	}
+
+	if len(tree) != 2 || tree[0].DealerID != "D001" || tree[1].DealerID != "D002" {
+		t.Fatalf("tree is %+v, want D001 then D002", tree)
+	}
+	for dealer, want := range map[int]string{0: "9822222222", 1: "9811111111,9833333333"} {
+		var msisdns []string
+		for _, asset := range tree[dealer].Assets {
+			if asset.DealerID != tree[dealer].DealerID {
+				t.Errorf("asset %s of %s is nested under %s", asset.MSISDN, asset.DealerID, tree[dealer].DealerID)
+			}
+			msisdns = append(msisdns, asset.MSISDN)
+		}
+		sort.Strings(msisdns)
+		if got := strings.Join(msisdns, ","); got != want {
+			t.Errorf("assets of %s are %s, want %s", tree[dealer].DealerID, got, want)
+		}
+	}
+
+	body, err := json.Marshal(tree)
+	if err != nil {
+		t.Fatalf("error marshalling tree: %v", err)
+	}
+	var decoded []map[string]json.RawMessage
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		t.Fatalf("error unmarshalling tree: %v", err)
+	}
+	if _, ok := decoded[0]["dealerID"]; !ok {
+		t.Errorf("tree JSON %s has no dealerID field", body)
+	}
+	if _, ok := decoded[0]["assets"]; !ok {
+		t.Errorf("tree JSON %s has no assets field", body)
+	}
+}
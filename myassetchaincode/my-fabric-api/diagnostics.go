@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// diagnosticDialTimeout bounds each reachability probe
+const diagnosticDialTimeout = 3 * time.Second
+
+// DiagnosticCheck is the result of probing one component of the network
+type DiagnosticCheck struct {
+	Name      string `json:"Name"`
+	URL       string `json:"URL,omitempty"`
+	Reachable bool   `json:"Reachable"`
+	LatencyMs int64  `json:"LatencyMs"`
+	Error     string `json:"Error,omitempty"`
+}
+
+// DiagnosticReport describes whether the connection profile actually works
+type DiagnosticReport struct {
+	Chaincode DiagnosticCheck   `json:"Chaincode"`
+	Peers     []DiagnosticCheck `json:"Peers"`
+	Orderers  []DiagnosticCheck `json:"Orderers"`
+}
+
+// connectionProfile is the subset of connection.yaml needed for diagnostics
+type connectionProfile struct {
+	Peers map[string]struct {
+		URL string `yaml:"url"`
+	} `yaml:"peers"`
+	Orderers map[string]struct {
+		URL string `yaml:"url"`
+	} `yaml:"orderers"`
+}
+
+// loadConnectionProfile reads the peer and orderer endpoints from a connection profile
+func loadConnectionProfile(path string) (*connectionProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading connection profile: %v", err)
+	}
+
+	var profile connectionProfile
+	if err := yaml.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("error parsing connection profile: %v", err)
+	}
+
+	return &profile, nil
+}
+
+// runDiagnostics evaluates a no-op chaincode call and dials every peer and
+// orderer in the profile, recording reachability and latency
+func runDiagnostics(evaluate func() error, profile *connectionProfile, dial func(address string) error) DiagnosticReport {
+	report := DiagnosticReport{
+		Chaincode: probe(contractName, "", evaluate),
+		Peers:     []DiagnosticCheck{},
+		Orderers:  []DiagnosticCheck{},
+	}
+
+	for _, name := range sortedKeys(profile.Peers) {
+		endpoint := profile.Peers[name].URL
+		report.Peers = append(report.Peers, probe(name, endpoint, func() error { return dialEndpoint(endpoint, dial) }))
+	}
+	for _, name := range sortedKeys(profile.Orderers) {
+		endpoint := profile.Orderers[name].URL
+		report.Orderers = append(report.Orderers, probe(name, endpoint, func() error { return dialEndpoint(endpoint, dial) }))
+	}
+
+	return report
+}
+
+// probe times a single check
+func probe(name, endpoint string, check func() error) DiagnosticCheck {
+	start := time.Now()
+	err := check()
+
+	result := DiagnosticCheck{
+		Name:      name,
+		URL:       endpoint,
+		Reachable: err == nil,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// dialEndpoint dials the host:port of a grpc(s):// endpoint URL
+func dialEndpoint(endpoint string, dial func(address string) error) error {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid endpoint URL: %v", err)
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("endpoint URL %q has no host", endpoint)
+	}
+
+	return dial(parsed.Host)
+}
+
+// tcpDial opens and closes a TCP connection to address
+func tcpDial(address string) error {
+	conn, err := net.DialTimeout("tcp", address, diagnosticDialTimeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// sortedKeys returns the keys of a map in ascending order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
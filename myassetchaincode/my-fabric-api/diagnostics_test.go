@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v2"
+)
+
+const testConnectionProfile = `
+peers:
+  peer1.org1.example.com:
+    url: grpcs://peer1.org1.example.com:7051
+  peer0.org1.example.com:
+    url: grpcs://peer0.org1.example.com:7051
+orderers:
+  orderer.example.com:
+    url: grpcs://orderer.example.com:7050
+`
+
+func TestRunDiagnosticsReport(t *testing.T) {
+	var profile connectionProfile
+	if err := yaml.Unmarshal([]byte(testConnectionProfile), &profile); err != nil {
+		t.Fatalf("error parsing connection profile: %v", err)
+	}
+
+	var dialed []string
+	dial := func(address string) error {
+		dialed = append(dialed, address)
+		if address == "orderer.example.com:7050" {
+			return errors.New("connection refused")
+		}
+		return nil
+	}
+	report := runDiagnostics(func() error { return nil }, &profile, dial)
+
+	if report.Chaincode.Name != contractName || !report.Chaincode.Reachable {
+		t.Errorf("chaincode check is %+v, want %s reachable", report.Chaincode, contractName)
+	}
+	if len(report.Peers) != 2 || report.Peers[0].Name != "peer0.org1.example.com" || report.Peers[1].Name != "peer1.org1.example.com" {
+		t.Fatalf("peer checks are %+v, want peer0 and peer1 in order", report.Peers)
+	}
+	for _, peer := range report.Peers {
+		if !peer.Reachable || peer.Error != "" || peer.URL == "" {
+			t.Errorf("peer check is %+v, want reachable with its URL", peer)
+		}
+	}
+	if len(report.Orderers) != 1 || report.Orderers[0].Reachable || report.Orderers[0].Error != "connection refused" {
+		t.Errorf("orderer checks are %+v, want one unreachable orderer", report.Orderers)
+	}
+	if len(dialed) != 3 || dialed[0] != "peer0.org1.example.com:7051" {
+		t.Errorf("dialed %v, want the host:port of every endpoint", dialed)
+	}
+
+	// The report keeps its JSON shape even when the chaincode call fails
+	report = runDiagnostics(func() error { return errors.New("chaincode unavailable") }, &connectionProfile{}, dial)
+	reportJSON, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("error marshalling report: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(reportJSON, &decoded); err != nil {
+		t.Fatalf("error unmarshalling report: %v", err)
+	}
+	for _, field := range []string{"Chaincode", "Peers", "Orderers"} {
+		if _, ok := decoded[field]; !ok {
+			t.Errorf("report JSON %s has no %s field", reportJSON, field)
+		}
+	}
+	if report.Chaincode.Reachable || report.Chaincode.Error != "chaincode unavailable" {
+		t.Errorf("chaincode check is %+v, want unreachable with the error", report.Chaincode)
+	}
+}
+
+func TestAdminAuth(t *testing.T) {
+	r := gin.New()
+	r.GET("/admin/diagnostics", adminAuth("secret"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for token, want := range map[string]int{"": http.StatusUnauthorized, "wrong": http.StatusUnauthorized, "secret": http.StatusOK} {
+		req := httptest.NewRequest(http.MethodGet, "/admin/diagnostics", nil)
+		req.Header.Set(adminTokenHeader, token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("token %q got status %d, want %d", token, w.Code, want)
+		}
+	}
+}
+
+func TestAdminAuthDisabledWithoutToken(t *testing.T) {
+	r := gin.New()
+	r.GET("/admin/diagnostics", adminAuth(""), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/diagnostics", nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
@@ -14,7 +14,4295 @@ const docTemplate = `{
     },
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
-    "paths": {}
+    "paths": {
+        "/admin/applyRate": {
+            "post": {
+                "description": "Adjust the balance of every Active asset by RatePercent of its balance (negative for fees)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Apply interest or a fee to all assets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin token",
+                        "name": "X-Admin-Token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Rate to apply",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.ApplyRateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Number of assets adjusted",
+                        "schema": {
+                            "$ref": "#/definitions/main.ApplyRateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/auditLog": {
+            "get": {
+                "description": "List every write to an asset in chronological order, one page at a time. An asset written several times in one transaction has a single entry.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Page through the audit log",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin token",
+                        "name": "X-Admin-Token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Entries per page",
+                        "name": "pageSize",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bookmark returned with the previous page",
+                        "name": "bookmark",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Audit log page",
+                        "schema": {
+                            "$ref": "#/definitions/main.AuditLogPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/committedTxs": {
+            "get": {
+                "description": "List the IDs of the most recently committed valid transactions of the contract, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "List committed transactions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin token",
+                        "name": "X-Admin-Token",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Committed transactions",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.CommittedTx"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Block event listener disabled",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/dealers/{dealerID}/quota": {
+            "put": {
+                "description": "Set the maximum number of active assets a dealer may have; 0 removes the limit",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Set a dealer quota",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin token",
+                        "name": "X-Admin-Token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Dealer ID",
+                        "name": "dealerID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Quota",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.SetDealerQuotaRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Dealer quota updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/main.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/deleteAll": {
+            "post": {
+                "description": "Delete all active assets, for resetting test networks. Confirm must be CONFIRM_DELETE_ALL.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Delete every asset",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin token",
+                        "name": "X-Admin-Token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Confirmation",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.DeleteAllRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Number of assets deleted",
+                        "schema": {
+                            "$ref": "#/definitions/main.DeleteAllResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/diagnostics": {
+            "get": {
+                "description": "Evaluate a no-op chaincode call and report which peers and orderers in the connection profile are reachable",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Diagnose the network connection",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin token",
+                        "name": "X-Admin-Token",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Diagnostic report",
+                        "schema": {
+                            "$ref": "#/definitions/main.DiagnosticReport"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/duplicates": {
+            "get": {
+                "description": "Get clusters of asset MSISDNs stored under different keys that normalize to the same number, for review before merging them",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Find duplicate assets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin token",
+                        "name": "X-Admin-Token",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Clusters of duplicate MSISDNs",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "array",
+                                "items": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/duplicates/merge": {
+            "post": {
+                "description": "Move the balance of one asset of a duplicate cluster into another and mark it Deleted. The keys are used exactly as GET /admin/duplicates reports them, so legacy assets stored under unnormalized MSISDNs can be merged.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Merge duplicate assets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin token",
+                        "name": "X-Admin-Token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Keys of the source and target assets",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.MergeDuplicatesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Assets merged successfully",
+                        "schema": {
+                            "$ref": "#/definitions/main.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/freezeByQuery": {
+            "post": {
+                "description": "Freeze every asset matched by a CouchDB rich query, e.g. all assets over a balance threshold. Assets already Frozen or Deleted are not counted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Freeze all assets matching a rich query",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin token",
+                        "name": "X-Admin-Token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Query selecting the assets to freeze",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.BulkFreezeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Number of assets frozen",
+                        "schema": {
+                            "$ref": "#/definitions/main.BulkFreezeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/raw/{key}": {
+            "get": {
+                "description": "Return the bytes stored under a key verbatim, base64 encoded, plus the JSON value when they are valid JSON",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get the raw world state value of a key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin token",
+                        "name": "X-Admin-Token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "World state key",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Raw value",
+                        "schema": {
+                            "$ref": "#/definitions/main.RawStateResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/snapshots/{snapshotID}": {
+            "get": {
+                "description": "Get every asset as of the time the snapshot was created",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get a snapshot",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin token",
+                        "name": "X-Admin-Token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Snapshot ID",
+                        "name": "snapshotID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Snapshot",
+                        "schema": {
+                            "$ref": "#/definitions/main.Snapshot"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Store every active asset under one ledger key as a consistent point-in-time copy. Snapshot IDs cannot be reused.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Snapshot all assets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin token",
+                        "name": "X-Admin-Token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Snapshot ID",
+                        "name": "snapshotID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Snapshot created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/main.MessageResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/standingInstructions/apply": {
+            "post": {
+                "description": "Apply every standing instruction whose next run has passed and advance it by one interval. Meant to be called by a scheduler; failed instructions are retried on the next call.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Apply due standing instructions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin token",
+                        "name": "X-Admin-Token",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Applied and failed instructions",
+                        "schema": {
+                            "$ref": "#/definitions/main.StandingInstructionReport"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/stats": {
+            "get": {
+                "description": "Get the asset count, total balance, lowest and highest MSISDN and distinct dealer count",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get ledger statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin token",
+                        "name": "X-Admin-Token",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ledger statistics",
+                        "schema": {
+                            "$ref": "#/definitions/main.LedgerStats"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/timeskew": {
+            "get": {
+                "description": "Evaluate a chaincode call returning its transaction timestamp and the peer clock, and report how far each is from the server clock",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Measure clock skew",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin token",
+                        "name": "X-Admin-Token",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Clock skew",
+                        "schema": {
+                            "$ref": "#/definitions/main.TimeSkewReport"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/approveUpdate/{requestID}": {
+            "post": {
+                "description": "Apply a pending update; the approving identity must differ from the requester",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Approve a pending update",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the pending update",
+                        "name": "requestID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Update approved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/main.MessageResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Self-approval is not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets": {
+            "get": {
+                "description": "List all assets, or only those whose Label contains q (case-insensitive; requires CouchDB).\nPassing sort, order, pageSize or bookmark returns a sorted AssetPage instead of an array.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "List assets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Label substring to search for",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "MSISDN",
+                        "description": "Sort field: Balance, MSISDN or Status",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "asc",
+                        "description": "Sort order: asc or desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Assets per page",
+                        "name": "pageSize",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bookmark returned with the previous page",
+                        "name": "bookmark",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Assets",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.Asset"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/attention": {
+            "get": {
+                "description": "Get the assets that are flagged, frozen with a nonzero balance, or below the minimum balance",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get assets needing attention",
+                "responses": {
+                    "200": {
+                        "description": "Assets needing attention",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.Asset"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/averageBalance": {
+            "get": {
+                "description": "Get the mean balance of the assets with the given status, or of all assets; 0 when none match",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get average balance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only include assets with this status (Active, Frozen, Suspended or Deleted)",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Average balance",
+                        "schema": {
+                            "$ref": "#/definitions/main.AverageBalanceResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Status",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/balanceHistogram": {
+            "get": {
+                "description": "Count the assets in each balance range of the given size, keyed by \"\u003clow\u003e-\u003chigh\u003e\" with both bounds included. Empty ranges are left out.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get a balance histogram",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Size of each balance range",
+                        "name": "bucket",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Assets per balance range",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/byExternalRef/{ref}": {
+            "get": {
+                "description": "Get the assets whose last update carried the given external payment reference (requires CouchDB)",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Find assets by external reference",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "External reference",
+                        "name": "ref",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Matching assets",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.Asset"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/createdBetween": {
+            "get": {
+                "description": "Get the assets created at or after from and before to",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get assets created within a date range",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start of the range (RFC 3339)",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range, exclusive (RFC 3339)",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Assets created in the range",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.Asset"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/export.jsonl": {
+            "get": {
+                "description": "Stream every asset as newline-delimited JSON, one asset per line",
+                "produces": [
+                    "application/x-ndjson"
+                ],
+                "summary": "Export all assets as JSONL",
+                "responses": {
+                    "200": {
+                        "description": "One asset per line",
+                        "schema": {
+                            "$ref": "#/definitions/main.Asset"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/geojson": {
+            "get": {
+                "description": "Get a GeoJSON FeatureCollection with a Point feature for every asset that has a location; assets without one are skipped",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get asset locations as GeoJSON",
+                "responses": {
+                    "200": {
+                        "description": "Located assets",
+                        "schema": {
+                            "$ref": "#/definitions/main.FeatureCollection"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/import": {
+            "post": {
+                "description": "Apply a CSV of updates (header MSISDN,Balance,Status,TransType,Remarks), one transaction per row, and report the result of each row",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Import balance adjustments from CSV",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "CSV file of updates",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Per-row results",
+                        "schema": {
+                            "$ref": "#/definitions/main.ImportReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/mostActive": {
+            "get": {
+                "description": "Get the n assets with the most transactions in their history, most first. Reads the history of every asset, so it is expensive on large ledgers.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get the most active assets",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of assets, 1 to 100 (default 10)",
+                        "name": "n",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Most active assets",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.AssetActivity"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/reconcile": {
+            "get": {
+                "description": "Compare the transaction histories of two assets by TxID and timestamp",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Reconcile two asset histories",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "MSISDN of the first asset",
+                        "name": "a",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "MSISDN of the second asset",
+                        "name": "b",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "History diff",
+                        "schema": {
+                            "$ref": "#/definitions/main.HistoryDiff"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/search/byPrefix/{prefix}": {
+            "get": {
+                "description": "Get the assets whose normalized MSISDN starts with the given digits, in MSISDN order",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Find assets by MSISDN prefix",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Leading digits of the MSISDN, without country code",
+                        "name": "prefix",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Matching assets",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.Asset"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/stream": {
+            "get": {
+                "description": "Stream asset creates, updates, reassignments, archives, restores and deletes as Server-Sent Events named after the chaincode event, with the asset as data. A transaction that changes several assets sends a single AssetsChanged event listing their MSISDNs instead. Heartbeat comments are sent while idle.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "summary": "Stream asset changes",
+                "responses": {
+                    "200": {
+                        "description": "Stream of asset events",
+                        "schema": {
+                            "$ref": "#/definitions/main.Asset"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/top": {
+            "get": {
+                "description": "Get the n assets with the highest balances, highest first",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get the top assets by balance",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of assets, 1 to 100 (default 10)",
+                        "name": "n",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Top assets",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.Asset"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/totalBalance": {
+            "get": {
+                "description": "Get the sum of the balances of all assets",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get total balance",
+                "responses": {
+                    "200": {
+                        "description": "Total balance",
+                        "schema": {
+                            "$ref": "#/definitions/main.TotalBalanceResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/{msisdn}": {
+            "delete": {
+                "description": "Hard-delete an asset, optionally purging its private data from a collection. Public state history is kept.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Delete an asset",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "MSISDN of the asset to delete",
+                        "name": "msisdn",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Private data collection to purge the asset from",
+                        "name": "purgeCollection",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Asset deleted successfully",
+                        "schema": {
+                            "$ref": "#/definitions/main.MessageResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/{msisdn}/adjust": {
+            "post": {
+                "description": "Add Delta (negative to deduct) to the current balance in a single transaction",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Adjust an asset balance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "MSISDN of the asset to adjust",
+                        "name": "msisdn",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Balance delta",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.AdjustBalanceRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only adjust if the asset currently has this status",
+                        "name": "expectedStatus",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Balance adjusted successfully",
+                        "schema": {
+                            "$ref": "#/definitions/main.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Approval Required",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Asset Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Status Does Not Match",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/{msisdn}/audit": {
+            "get": {
+                "description": "Replay the transaction amounts in the asset history and compare the result with the stored balance",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Audit an asset balance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "MSISDN of the asset to audit",
+                        "name": "msisdn",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Audit result",
+                        "schema": {
+                            "$ref": "#/definitions/main.AuditResult"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/{msisdn}/balanceSeries": {
+            "get": {
+                "description": "Get the balance after each transaction in the asset history, oldest first, for charting",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get an asset's balance over time",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "MSISDN of the asset",
+                        "name": "msisdn",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Balance series",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.BalancePoint"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/{msisdn}/bundle": {
+            "get": {
+                "description": "Get the current state of an asset and its complete history, read in one transaction, signed with the server's statement key for dispute resolution",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Export a signed asset bundle",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "MSISDN of the asset",
+                        "name": "msisdn",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Signed bundle",
+                        "schema": {
+                            "$ref": "#/definitions/main.SignedBundle"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Statement signing disabled",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/{msisdn}/changeMPIN": {
+            "post": {
+                "description": "Replace the MPIN of an asset, given its current MPIN. Wrong current MPINs count towards the lockout like verification.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Change an asset MPIN",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "MSISDN of the asset",
+                        "name": "msisdn",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Current and new MPIN",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.ChangeMPINRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Whether the MPIN was changed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ChangeMPINResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "MPIN Locked",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/{msisdn}/diff": {
+            "post": {
+                "description": "Compare proposed values of the fields UpdateAsset can change, such as Balance and Status, with the current state and return each changed field as [old, new], without applying anything. Other fields are rejected.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Preview an update",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "MSISDN of the asset",
+                        "name": "msisdn",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Proposed asset fields, e.g. Balance and Status",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Changed fields",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "type": "object"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/{msisdn}/hashChain": {
+            "get": {
+                "description": "Get a SHA-256 hash per history entry, each chained with the previous one, and the final Root. Store Root and compare it later to detect a changed history.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get the hash chain of an asset's history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "MSISDN of the asset",
+                        "name": "msisdn",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Hash chain",
+                        "schema": {
+                            "$ref": "#/definitions/main.HistoryHashChain"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/{msisdn}/location": {
+            "put": {
+                "description": "Set the coordinates of an asset for showing it on a map; 0, 0 clears them",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Set asset location",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "MSISDN of the asset",
+                        "name": "msisdn",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Latitude and longitude",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.SetLocationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Asset location updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/main.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/{msisdn}/metadata": {
+            "get": {
+                "description": "Get the metadata key-values attached to an asset",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get asset metadata",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "MSISDN of the asset",
+                        "name": "msisdn",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Asset metadata",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Set a metadata key on an asset; an empty value removes the key",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Set asset metadata",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "MSISDN of the asset",
+                        "name": "msisdn",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Metadata key and value",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.SetMetadataRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Asset metadata updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/main.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/{msisdn}/mpinLocked": {
+            "get": {
+                "description": "Report whether MPIN verification is locked after repeated wrong MPINs",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get whether an asset MPIN is locked",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "MSISDN of the asset",
+                        "name": "msisdn",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Whether the MPIN is locked",
+                        "schema": {
+                            "$ref": "#/definitions/main.MPINLockResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/{msisdn}/reversals": {
+            "get": {
+                "description": "Get the transactions reversed on an asset and the transactions that reversed them",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "List the reversals of an asset",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "MSISDN of the asset",
+                        "name": "msisdn",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Reversals",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.Reversal"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/{msisdn}/reverse": {
+            "post": {
+                "description": "Apply the inverse of the balance change an earlier transaction made to the asset, recorded with TransType REVERSAL. A transaction can only be reversed once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Reverse a transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "MSISDN of the asset",
+                        "name": "msisdn",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Transaction to reverse",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.ReverseTransactionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transaction reversed successfully",
+                        "schema": {
+                            "$ref": "#/definitions/main.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Approval Required",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Asset Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Already Reversed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/{msisdn}/standingInstruction": {
+            "get": {
+                "description": "Get the recurring balance adjustment of an asset and when it next runs",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get an asset's standing instruction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "MSISDN of the asset",
+                        "name": "msisdn",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Standing instruction",
+                        "schema": {
+                            "$ref": "#/definitions/main.StandingInstruction"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Set a recurring balance adjustment, replacing any previous one. It is applied by POST /admin/standingInstructions/apply.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Set an asset's standing instruction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "MSISDN of the asset",
+                        "name": "msisdn",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Standing instruction",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.SetStandingInstructionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Standing instruction set successfully",
+                        "schema": {
+                            "$ref": "#/definitions/main.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Cancel the recurring balance adjustment of an asset",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Remove an asset's standing instruction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "MSISDN of the asset",
+                        "name": "msisdn",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Standing instruction removed successfully",
+                        "schema": {
+                            "$ref": "#/definitions/main.MessageResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/{msisdn}/statement": {
+            "get": {
+                "description": "Get the transaction history and balance of an asset as a statement signed with the server key",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get a signed asset statement",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "MSISDN of the asset",
+                        "name": "msisdn",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Signed statement",
+                        "schema": {
+                            "$ref": "#/definitions/main.SignedStatement"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Statement signing disabled",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/{msisdn}/transactions": {
+            "get": {
+                "description": "Get the history entries of an asset whose transaction was recorded with the given category, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get an asset's transactions in a category",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "MSISDN of the asset",
+                        "name": "msisdn",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Category: fee, topup, purchase or refund",
+                        "name": "category",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Matching transactions",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.AssetHistoryEntry"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/{msisdn}/verifyMPIN": {
+            "post": {
+                "description": "Check an MPIN against the asset's. After repeated wrong MPINs verification is locked for a while.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Verify an asset MPIN",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "MSISDN of the asset",
+                        "name": "msisdn",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "MPIN to verify",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.VerifyMPINRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Whether the MPIN matched",
+                        "schema": {
+                            "$ref": "#/definitions/main.VerifyMPINResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "MPIN Expired",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "MPIN Locked",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/createAsset": {
+            "post": {
+                "description": "Create a new asset with the provided details",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Create an asset",
+                "parameters": [
+                    {
+                        "description": "Asset details",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateAssetRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Asset created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/main.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Dealer Quota Exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Concurrent Create, Retry",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/dealers": {
+            "get": {
+                "description": "Get every dealer with active assets, with its asset count and total balance, sorted by DealerID",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "List dealers",
+                "responses": {
+                    "200": {
+                        "description": "Dealer directory",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.DealerSummary"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/dealers/balances": {
+            "get": {
+                "description": "Get the sum of the asset balances of each dealer, keyed by DealerID",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get balance totals per dealer",
+                "responses": {
+                    "200": {
+                        "description": "Balance per dealer",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/dealers/tree": {
+            "get": {
+                "description": "Get every asset nested under its dealer, sorted by DealerID",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get assets grouped by dealer",
+                "responses": {
+                    "200": {
+                        "description": "Assets per dealer",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.DealerAssets"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/dealers/{dealerID}/usage": {
+            "get": {
+                "description": "Get the number of active assets of a dealer and its quota",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get dealer quota usage",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Dealer ID",
+                        "name": "dealerID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Dealer usage",
+                        "schema": {
+                            "$ref": "#/definitions/main.DealerUsage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/getAssetHistory/{msisdn}": {
+            "get": {
+                "description": "Get transaction history of an asset by MSISDN with the value each transaction wrote; an entry whose value cannot be decoded carries a ParseError instead",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get asset history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "MSISDN of the asset to get history",
+                        "name": "msisdn",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transaction history",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.AssetHistoryEntry"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "History Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Report that the API is up and whether it is in read-only maintenance mode",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Check API health",
+                "responses": {
+                    "200": {
+                        "description": "API health",
+                        "schema": {
+                            "$ref": "#/definitions/main.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/readAsset/{msisdn}": {
+            "get": {
+                "description": "Get details of an asset by MSISDN",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Read asset details",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "MSISDN of the asset to get details",
+                        "name": "msisdn",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated list of fields to return, e.g. Balance,Status",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a previously returned representation",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Asset details",
+                        "schema": {
+                            "$ref": "#/definitions/main.Asset"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Last write not yet committed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Evaluate the chaincode's Ping and report whether the chaincode is installed and responsive within READY_TIMEOUT",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Check chaincode readiness",
+                "responses": {
+                    "200": {
+                        "description": "Chaincode ready",
+                        "schema": {
+                            "$ref": "#/definitions/main.ReadinessResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Chaincode not ready",
+                        "schema": {
+                            "$ref": "#/definitions/main.ReadinessResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/requestUpdate/{msisdn}": {
+            "post": {
+                "description": "Store an update for approval by a second identity and return its request ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Request a high-value update",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "MSISDN of the asset to update",
+                        "name": "msisdn",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated asset details",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.UpdateAssetRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Request ID",
+                        "schema": {
+                            "$ref": "#/definitions/main.RequestIDResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Asset Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/segments/{segment}/members": {
+            "get": {
+                "description": "Get the MSISDNs of the assets in a segment in ascending order",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "List segment members",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Segment name",
+                        "name": "segment",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "MSISDNs in the segment",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/segments/{segment}/members/{msisdn}": {
+            "put": {
+                "description": "Add an asset to a named segment; adding an existing member has no effect",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Add an asset to a segment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Segment name",
+                        "name": "segment",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "MSISDN of the asset",
+                        "name": "msisdn",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Asset added to segment successfully",
+                        "schema": {
+                            "$ref": "#/definitions/main.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove an asset from a named segment; removing a non-member has no effect",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Remove an asset from a segment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Segment name",
+                        "name": "segment",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "MSISDN of the asset",
+                        "name": "msisdn",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Asset removed from segment successfully",
+                        "schema": {
+                            "$ref": "#/definitions/main.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/transactions/batch": {
+            "post": {
+                "description": "Apply an ordered list of create, update and transfer operations in one transaction; if any operation fails none of them are applied and the failed operations are listed by 1-based index",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Execute operations atomically",
+                "parameters": [
+                    {
+                        "description": "Operations in the order to apply them",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.BatchOperation"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Batch executed successfully",
+                        "schema": {
+                            "$ref": "#/definitions/main.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid or Failed Operations",
+                        "schema": {
+                            "$ref": "#/definitions/main.BatchErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Approval Required",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Asset Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/transfers": {
+            "post": {
+                "description": "Move Amount from one asset to another and Fee from the sender to the treasury asset (TREASURY_MSISDN) in one transaction. The sender must cover Amount plus Fee.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Transfer balance between assets",
+                "parameters": [
+                    {
+                        "description": "Transfer",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.TransferRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transfer completed successfully",
+                        "schema": {
+                            "$ref": "#/definitions/main.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Approval Required",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Asset Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/transfers/check": {
+            "get": {
+                "description": "Report whether moving amount between two assets would succeed, and why not, without performing it",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Check whether a transfer is possible",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "MSISDN of the source asset",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "MSISDN of the destination asset",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Amount to transfer",
+                        "name": "amount",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transfer feasibility",
+                        "schema": {
+                            "$ref": "#/definitions/main.TransferCheck"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/updateAsset/{msisdn}": {
+            "post": {
+                "description": "Update an existing asset with the provided details",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Update an asset",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "MSISDN of the asset to update",
+                        "name": "msisdn",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated asset details",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.UpdateAssetRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only update if the asset currently has this status",
+                        "name": "expectedStatus",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only update if the asset has not been modified since this HTTP date",
+                        "name": "If-Unmodified-Since",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Asset updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/main.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Approval Required",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Asset Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Status Does Not Match or Modified Since",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "main.AdjustBalanceRequest": {
+            "type": "object",
+            "required": [
+                "Delta"
+            ],
+            "properties": {
+                "Category": {
+                    "type": "string",
+                    "example": "topup"
+                },
+                "Delta": {
+                    "type": "integer",
+                    "example": 100
+                },
+                "ExternalRef": {
+                    "type": "string",
+                    "example": "PSP-20240115-000123"
+                },
+                "Remarks": {
+                    "type": "string",
+                    "example": "bonus"
+                },
+                "TransType": {
+                    "type": "string",
+                    "example": "CREDIT"
+                }
+            }
+        },
+        "main.ApplyRateRequest": {
+            "type": "object",
+            "required": [
+                "TransType"
+            ],
+            "properties": {
+                "RatePercent": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "TransType": {
+                    "type": "string",
+                    "example": "INTEREST"
+                }
+            }
+        },
+        "main.ApplyRateResponse": {
+            "type": "object",
+            "properties": {
+                "adjusted": {
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "main.Asset": {
+            "type": "object",
+            "properties": {
+                "Balance": {
+                    "type": "integer",
+                    "example": 1500
+                },
+                "Category": {
+                    "description": "Category classifies the last update: fee, topup, purchase or refund",
+                    "type": "string",
+                    "example": "topup"
+                },
+                "CreatedAt": {
+                    "type": "string",
+                    "example": "2024-01-01T09:00:00Z"
+                },
+                "DealerID": {
+                    "type": "string",
+                    "example": "D001"
+                },
+                "ExternalRef": {
+                    "description": "ExternalRef is the payment processor reference of the last update, if any",
+                    "type": "string",
+                    "example": "PSP-20240115-000123"
+                },
+                "FailedAttempts": {
+                    "description": "FailedAttempts counts consecutive wrong MPINs, which lock verification until MPINLockedUntil",
+                    "type": "integer",
+                    "example": 0
+                },
+                "Flagged": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "Label": {
+                    "type": "string",
+                    "example": "Main street kiosk"
+                },
+                "Latitude": {
+                    "description": "Latitude and Longitude locate the asset on a map; both are omitted when it has no location",
+                    "type": "number",
+                    "example": 28.6139
+                },
+                "LockedUntil": {
+                    "type": "string",
+                    "example": "0001-01-01T00:00:00Z"
+                },
+                "Longitude": {
+                    "type": "number",
+                    "example": 77.209
+                },
+                "MPIN": {
+                    "type": "string",
+                    "example": "5678"
+                },
+                "MPINLockedUntil": {
+                    "type": "string",
+                    "example": "0001-01-01T00:00:00Z"
+                },
+                "MPINRotationRequired": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "MPINSetAt": {
+                    "description": "MPINRotationRequired is set once the MPIN set at MPINSetAt has reached its maximum age",
+                    "type": "string",
+                    "example": "2024-01-01T09:00:00Z"
+                },
+                "MSISDN": {
+                    "type": "string",
+                    "example": "9876543210"
+                },
+                "Metadata": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "ParentMSISDN": {
+                    "description": "ParentMSISDN is the master asset this sub-wallet was created under, if any",
+                    "type": "string",
+                    "example": "9876543210"
+                },
+                "PreviousMSISDN": {
+                    "description": "PreviousMSISDN and ReassignedTo link the two keys of an asset moved to a new MSISDN",
+                    "type": "string"
+                },
+                "ReassignedTo": {
+                    "type": "string"
+                },
+                "Remarks": {
+                    "type": "string",
+                    "example": "monthly top-up"
+                },
+                "ReversedTxID": {
+                    "description": "ReversedTxID is the transaction the last update reversed, if it was a reversal",
+                    "type": "string",
+                    "example": "3f2a9c1e8b7d4a6f0e5c2b1a9d8e7f6c5b4a3d2e1f0a9b8c7d6e5f4a3b2c1d0e"
+                },
+                "SchemaVersion": {
+                    "description": "SchemaVersion is the version of the chaincode's record layout",
+                    "type": "integer",
+                    "example": 1
+                },
+                "Status": {
+                    "type": "string",
+                    "example": "Active"
+                },
+                "Timestamp": {
+                    "type": "string",
+                    "example": "2024-01-15T10:30:00Z"
+                },
+                "TransAmount": {
+                    "type": "integer",
+                    "example": 500
+                },
+                "TransType": {
+                    "type": "string",
+                    "example": "CREDIT"
+                }
+            }
+        },
+        "main.AssetActivity": {
+            "type": "object",
+            "properties": {
+                "DealerID": {
+                    "type": "string",
+                    "example": "D001"
+                },
+                "MSISDN": {
+                    "type": "string",
+                    "example": "9876543210"
+                },
+                "Transactions": {
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "main.AssetHistoryEntry": {
+            "type": "object",
+            "properties": {
+                "IsDelete": {
+                    "description": "Value is the asset as written by the transaction, without its MPIN. It\nis omitted for a delete and for a value the chaincode could not decode,\nwhich ParseError explains.",
+                    "type": "boolean",
+                    "example": false
+                },
+                "ParseError": {
+                    "type": "string",
+                    "example": "history value could not be decoded: unexpected end of JSON input"
+                },
+                "Timestamp": {
+                    "type": "string",
+                    "example": "2024-01-15T10:30:00Z"
+                },
+                "TxID": {
+                    "type": "string",
+                    "example": "3f1d0c5e9a7b42d8b6e0f4a2c1d9e8b7a6f5e4d3c2b1a09f8e7d6c5b4a3f2e1d"
+                },
+                "Value": {
+                    "$ref": "#/definitions/main.Asset"
+                }
+            }
+        },
+        "main.AuditEntry": {
+            "type": "object",
+            "properties": {
+                "Balance": {
+                    "type": "integer",
+                    "example": 1500
+                },
+                "Deleted": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "Function": {
+                    "type": "string",
+                    "example": "UpdateAsset"
+                },
+                "MSISDN": {
+                    "type": "string",
+                    "example": "9876543210"
+                },
+                "Status": {
+                    "type": "string",
+                    "example": "Active"
+                },
+                "Timestamp": {
+                    "type": "string",
+                    "example": "2024-01-15T10:30:00Z"
+                },
+                "TxID": {
+                    "type": "string",
+                    "example": "3f2a9c1e8b7d4a6f0e5c2b1a9d8e7f6c5b4a3d2e1f0a9b8c7d6e5f4a3b2c1d0e"
+                }
+            }
+        },
+        "main.AuditLogPage": {
+            "type": "object",
+            "properties": {
+                "Bookmark": {
+                    "type": "string"
+                },
+                "Entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.AuditEntry"
+                    }
+                },
+                "FetchedRecordsCount": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.AuditResult": {
+            "type": "object",
+            "properties": {
+                "ComputedBalance": {
+                    "type": "integer"
+                },
+                "Consistent": {
+                    "type": "boolean"
+                },
+                "FirstMismatchTxID": {
+                    "type": "string"
+                },
+                "InitialBalance": {
+                    "type": "integer"
+                },
+                "MSISDN": {
+                    "type": "string"
+                },
+                "StoredBalance": {
+                    "type": "integer"
+                },
+                "Transactions": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.AverageBalanceResponse": {
+            "type": "object",
+            "properties": {
+                "averageBalance": {
+                    "type": "number",
+                    "example": 1250.5
+                }
+            }
+        },
+        "main.BalancePoint": {
+            "type": "object",
+            "properties": {
+                "Balance": {
+                    "type": "integer",
+                    "example": 1500
+                },
+                "Timestamp": {
+                    "type": "string",
+                    "example": "2024-01-15T10:30:00Z"
+                }
+            }
+        },
+        "main.BatchErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "batch failed"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.BatchItemError"
+                    }
+                }
+            }
+        },
+        "main.BatchItemError": {
+            "type": "object",
+            "properties": {
+                "Index": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "MSISDN": {
+                    "type": "string",
+                    "example": "9876543210"
+                },
+                "Message": {
+                    "type": "string",
+                    "example": "invalid status \"Closed\": must be one of Active, Frozen, Suspended, Deleted"
+                }
+            }
+        },
+        "main.BatchOperation": {
+            "type": "object",
+            "required": [
+                "Op"
+            ],
+            "properties": {
+                "Amount": {
+                    "type": "integer",
+                    "example": 250
+                },
+                "Balance": {
+                    "type": "integer",
+                    "example": 1500
+                },
+                "Category": {
+                    "type": "string",
+                    "example": "topup"
+                },
+                "DealerID": {
+                    "type": "string",
+                    "example": "D001"
+                },
+                "ExternalRef": {
+                    "description": "ExternalRef and Category are recorded by update and on both sides of a transfer",
+                    "type": "string",
+                    "example": "PSP-20240115-000123"
+                },
+                "From": {
+                    "type": "string",
+                    "example": "9876543210"
+                },
+                "Label": {
+                    "type": "string",
+                    "example": "Main street kiosk"
+                },
+                "MPIN": {
+                    "type": "string",
+                    "example": "5678"
+                },
+                "MSISDN": {
+                    "type": "string",
+                    "example": "9876543210"
+                },
+                "Op": {
+                    "type": "string",
+                    "example": "transfer"
+                },
+                "ParentMSISDN": {
+                    "description": "ParentMSISDN makes a create fail unless that asset exists, and links the new asset to it",
+                    "type": "string",
+                    "example": "1234567890"
+                },
+                "Remarks": {
+                    "type": "string",
+                    "example": "monthly top-up"
+                },
+                "Status": {
+                    "type": "string",
+                    "example": "Active"
+                },
+                "To": {
+                    "type": "string",
+                    "example": "1234567890"
+                },
+                "TransType": {
+                    "type": "string",
+                    "example": "CREDIT"
+                }
+            }
+        },
+        "main.BulkFreezeRequest": {
+            "type": "object",
+            "required": [
+                "Query"
+            ],
+            "properties": {
+                "Query": {
+                    "type": "string",
+                    "example": "{\"selector\":{\"Balance\":{\"$gt\":100000}}}"
+                }
+            }
+        },
+        "main.BulkFreezeResponse": {
+            "type": "object",
+            "properties": {
+                "frozen": {
+                    "type": "integer",
+                    "example": 7
+                }
+            }
+        },
+        "main.ChangeMPINRequest": {
+            "type": "object",
+            "required": [
+                "MPIN",
+                "NewMPIN"
+            ],
+            "properties": {
+                "MPIN": {
+                    "type": "string",
+                    "example": "5678"
+                },
+                "NewMPIN": {
+                    "type": "string",
+                    "example": "8765"
+                }
+            }
+        },
+        "main.ChangeMPINResponse": {
+            "type": "object",
+            "properties": {
+                "changed": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "main.CommittedTx": {
+            "type": "object",
+            "properties": {
+                "BlockNumber": {
+                    "type": "integer"
+                },
+                "TxID": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.CreateAssetRequest": {
+            "type": "object",
+            "properties": {
+                "Balance": {
+                    "type": "integer",
+                    "example": 1500
+                },
+                "DealerID": {
+                    "type": "string",
+                    "example": "D001"
+                },
+                "Label": {
+                    "type": "string",
+                    "example": "Main street kiosk"
+                },
+                "MPIN": {
+                    "type": "string",
+                    "example": "5678"
+                },
+                "MSISDN": {
+                    "type": "string",
+                    "example": "9876543210"
+                },
+                "ParentMSISDN": {
+                    "description": "ParentMSISDN creates the asset as a sub-wallet of an existing master asset",
+                    "type": "string",
+                    "example": "9876543210"
+                },
+                "Remarks": {
+                    "type": "string",
+                    "example": "new dealer SIM"
+                },
+                "Status": {
+                    "type": "string",
+                    "example": "Active"
+                },
+                "TransType": {
+                    "type": "string",
+                    "example": "CREATE"
+                }
+            }
+        },
+        "main.DealerAssets": {
+            "type": "object",
+            "properties": {
+                "assets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Asset"
+                    }
+                },
+                "dealerID": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.DealerSummary": {
+            "type": "object",
+            "properties": {
+                "AssetCount": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "DealerID": {
+                    "type": "string",
+                    "example": "D001"
+                },
+                "TotalBalance": {
+                    "type": "integer",
+                    "example": 18000
+                }
+            }
+        },
+        "main.DealerUsage": {
+            "type": "object",
+            "properties": {
+                "Count": {
+                    "type": "integer"
+                },
+                "DealerID": {
+                    "type": "string"
+                },
+                "Quota": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.DeleteAllRequest": {
+            "type": "object",
+            "required": [
+                "Confirm"
+            ],
+            "properties": {
+                "Confirm": {
+                    "type": "string",
+                    "example": "CONFIRM_DELETE_ALL"
+                }
+            }
+        },
+        "main.DeleteAllResponse": {
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "main.DiagnosticCheck": {
+            "type": "object",
+            "properties": {
+                "Error": {
+                    "type": "string"
+                },
+                "LatencyMs": {
+                    "type": "integer"
+                },
+                "Name": {
+                    "type": "string"
+                },
+                "Reachable": {
+                    "type": "boolean"
+                },
+                "URL": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.DiagnosticReport": {
+            "type": "object",
+            "properties": {
+                "Chaincode": {
+                    "$ref": "#/definitions/main.DiagnosticCheck"
+                },
+                "Orderers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.DiagnosticCheck"
+                    }
+                },
+                "Peers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.DiagnosticCheck"
+                    }
+                }
+            }
+        },
+        "main.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "asset_not_found"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "asset with MSISDN 9876543210 does not exist"
+                }
+            }
+        },
+        "main.Feature": {
+            "type": "object",
+            "properties": {
+                "geometry": {
+                    "$ref": "#/definitions/main.Point"
+                },
+                "properties": {
+                    "$ref": "#/definitions/main.FeatureProperties"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "Feature"
+                }
+            }
+        },
+        "main.FeatureCollection": {
+            "type": "object",
+            "properties": {
+                "features": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Feature"
+                    }
+                },
+                "type": {
+                    "type": "string",
+                    "example": "FeatureCollection"
+                }
+            }
+        },
+        "main.FeatureProperties": {
+            "type": "object",
+            "properties": {
+                "Balance": {
+                    "type": "integer",
+                    "example": 1500
+                },
+                "DealerID": {
+                    "type": "string",
+                    "example": "D001"
+                },
+                "Label": {
+                    "type": "string",
+                    "example": "Main street kiosk"
+                },
+                "MSISDN": {
+                    "type": "string",
+                    "example": "9876543210"
+                },
+                "Status": {
+                    "type": "string",
+                    "example": "Active"
+                }
+            }
+        },
+        "main.HealthResponse": {
+            "type": "object",
+            "properties": {
+                "readOnly": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "main.HistoryDiff": {
+            "type": "object",
+            "properties": {
+                "A": {
+                    "type": "string"
+                },
+                "B": {
+                    "type": "string"
+                },
+                "Common": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.AssetHistoryEntry"
+                    }
+                },
+                "OnlyInA": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.AssetHistoryEntry"
+                    }
+                },
+                "OnlyInB": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.AssetHistoryEntry"
+                    }
+                }
+            }
+        },
+        "main.HistoryHashChain": {
+            "type": "object",
+            "properties": {
+                "Hashes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "Root": {
+                    "type": "string",
+                    "example": "9f2c4e1a7b3d5f6e8a0c2b4d6f8e1a3c5b7d9f0e2a4c6b8d0f1e3a5c7b9d2f4e"
+                }
+            }
+        },
+        "main.ImportReport": {
+            "type": "object",
+            "properties": {
+                "Failed": {
+                    "type": "integer"
+                },
+                "Rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.ImportRowResult"
+                    }
+                },
+                "Succeeded": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.ImportRowResult": {
+            "type": "object",
+            "properties": {
+                "Error": {
+                    "type": "string"
+                },
+                "MSISDN": {
+                    "type": "string"
+                },
+                "Row": {
+                    "type": "integer"
+                },
+                "Success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "main.LedgerStats": {
+            "type": "object",
+            "properties": {
+                "AssetCount": {
+                    "type": "integer"
+                },
+                "DealerCount": {
+                    "type": "integer"
+                },
+                "FrozenCount": {
+                    "type": "integer"
+                },
+                "HighestMSISDN": {
+                    "type": "string"
+                },
+                "LowestMSISDN": {
+                    "type": "string"
+                },
+                "TotalBalance": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.MPINLockResponse": {
+            "type": "object",
+            "properties": {
+                "locked": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "main.MergeDuplicatesRequest": {
+            "type": "object",
+            "required": [
+                "Source",
+                "Target"
+            ],
+            "properties": {
+                "Source": {
+                    "type": "string",
+                    "example": "+91 98765 43210"
+                },
+                "Target": {
+                    "type": "string",
+                    "example": "9876543210"
+                }
+            }
+        },
+        "main.MessageResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Asset updated successfully"
+                }
+            }
+        },
+        "main.Point": {
+            "type": "object",
+            "properties": {
+                "coordinates": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    },
+                    "example": [
+                        77.209,
+                        28.6139
+                    ]
+                },
+                "type": {
+                    "type": "string",
+                    "example": "Point"
+                }
+            }
+        },
+        "main.RawStateResponse": {
+            "type": "object",
+            "properties": {
+                "base64": {
+                    "type": "string",
+                    "example": "eyJNU0lTRE4iOiI5ODc2NTQzMjEwIn0="
+                },
+                "json": {
+                    "type": "object"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "9876543210"
+                }
+            }
+        },
+        "main.ReadinessResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "chaincode did not answer Ping within 5s"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ready"
+                }
+            }
+        },
+        "main.RequestIDResponse": {
+            "type": "object",
+            "properties": {
+                "requestID": {
+                    "type": "string",
+                    "example": "3f1d0c5e9a7b42d8b6e0f4a2c1d9e8b7a6f5e4d3c2b1a09f8e7d6c5b4a3f2e1d"
+                }
+            }
+        },
+        "main.Reversal": {
+            "type": "object",
+            "properties": {
+                "OriginalTxID": {
+                    "type": "string",
+                    "example": "3f2a9c1e8b7d4a6f0e5c2b1a9d8e7f6c5b4a3d2e1f0a9b8c7d6e5f4a3b2c1d0e"
+                },
+                "ReversalTxID": {
+                    "type": "string",
+                    "example": "9b8c7d6e5f4a3b2c1d0e3f2a9c1e8b7d4a6f0e5c2b1a9d8e7f6c5b4a3d2e1f0a"
+                }
+            }
+        },
+        "main.ReverseTransactionRequest": {
+            "type": "object",
+            "required": [
+                "OriginalTxID"
+            ],
+            "properties": {
+                "OriginalTxID": {
+                    "type": "string",
+                    "example": "3f2a9c1e8b7d4a6f0e5c2b1a9d8e7f6c5b4a3d2e1f0a9b8c7d6e5f4a3b2c1d0e"
+                },
+                "Remarks": {
+                    "type": "string",
+                    "example": "charged twice"
+                }
+            }
+        },
+        "main.SetDealerQuotaRequest": {
+            "type": "object",
+            "properties": {
+                "Quota": {
+                    "type": "integer",
+                    "example": 500
+                }
+            }
+        },
+        "main.SetLocationRequest": {
+            "type": "object",
+            "properties": {
+                "Latitude": {
+                    "type": "number",
+                    "example": 28.6139
+                },
+                "Longitude": {
+                    "type": "number",
+                    "example": 77.209
+                }
+            }
+        },
+        "main.SetMetadataRequest": {
+            "type": "object",
+            "required": [
+                "Key"
+            ],
+            "properties": {
+                "Key": {
+                    "type": "string"
+                },
+                "Value": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.SetStandingInstructionRequest": {
+            "type": "object",
+            "required": [
+                "Amount",
+                "Interval"
+            ],
+            "properties": {
+                "Amount": {
+                    "type": "integer",
+                    "example": -50
+                },
+                "FirstRun": {
+                    "type": "string",
+                    "example": "2024-02-01T00:00:00Z"
+                },
+                "Interval": {
+                    "type": "string",
+                    "example": "monthly"
+                }
+            }
+        },
+        "main.SignedBundle": {
+            "type": "object",
+            "properties": {
+                "Algorithm": {
+                    "type": "string",
+                    "example": "ECDSA-SHA256"
+                },
+                "Bundle": {
+                    "type": "object"
+                },
+                "KeyID": {
+                    "type": "string",
+                    "example": "9c1f0e6d2b7a4c3e8f5d1a0b6c9e2f47"
+                },
+                "Signature": {
+                    "type": "string",
+                    "format": "base64"
+                }
+            }
+        },
+        "main.SignedStatement": {
+            "type": "object",
+            "properties": {
+                "Algorithm": {
+                    "type": "string",
+                    "example": "ECDSA-SHA256"
+                },
+                "KeyID": {
+                    "type": "string",
+                    "example": "9c1f0e6d2b7a4c3e8f5d1a0b6c9e2f47"
+                },
+                "Signature": {
+                    "type": "string",
+                    "format": "base64"
+                },
+                "Statement": {
+                    "type": "object"
+                }
+            }
+        },
+        "main.Snapshot": {
+            "type": "object",
+            "properties": {
+                "Assets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Asset"
+                    }
+                },
+                "CreatedAt": {
+                    "type": "string",
+                    "example": "2024-01-31T23:59:59Z"
+                },
+                "SnapshotID": {
+                    "type": "string",
+                    "example": "2024-01-31-eod"
+                }
+            }
+        },
+        "main.StandingInstruction": {
+            "type": "object",
+            "properties": {
+                "Amount": {
+                    "type": "integer",
+                    "example": -50
+                },
+                "Interval": {
+                    "type": "string",
+                    "example": "monthly"
+                },
+                "MSISDN": {
+                    "type": "string",
+                    "example": "9876543210"
+                },
+                "NextRun": {
+                    "type": "string",
+                    "example": "2024-02-01T00:00:00Z"
+                }
+            }
+        },
+        "main.StandingInstructionReport": {
+            "type": "object",
+            "properties": {
+                "Applied": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "Failed": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "main.TimeSkewReport": {
+            "type": "object",
+            "properties": {
+                "PeerSkewMs": {
+                    "type": "integer",
+                    "example": 1250
+                },
+                "PeerTime": {
+                    "type": "string",
+                    "example": "2024-01-15T10:30:01.250Z"
+                },
+                "RoundTripMs": {
+                    "type": "integer",
+                    "example": 40
+                },
+                "ServerTime": {
+                    "type": "string",
+                    "example": "2024-01-15T10:30:00Z"
+                },
+                "TxTimestamp": {
+                    "type": "string",
+                    "example": "2024-01-15T10:30:00.002Z"
+                },
+                "TxTimestampSkewMs": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "main.TotalBalanceResponse": {
+            "type": "object",
+            "properties": {
+                "totalBalance": {
+                    "type": "integer",
+                    "example": 2500
+                }
+            }
+        },
+        "main.TransferCheck": {
+            "type": "object",
+            "properties": {
+                "Possible": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "Reason": {
+                    "type": "string",
+                    "example": "insufficient funds: asset with MSISDN 9876543210 has a balance of 100"
+                }
+            }
+        },
+        "main.TransferRequest": {
+            "type": "object",
+            "required": [
+                "Amount",
+                "From",
+                "To"
+            ],
+            "properties": {
+                "Amount": {
+                    "type": "integer",
+                    "example": 250
+                },
+                "Fee": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "From": {
+                    "type": "string",
+                    "example": "9876543210"
+                },
+                "To": {
+                    "type": "string",
+                    "example": "1234567890"
+                }
+            }
+        },
+        "main.UpdateAssetRequest": {
+            "type": "object",
+            "properties": {
+                "Balance": {
+                    "type": "integer",
+                    "example": 2000
+                },
+                "Category": {
+                    "type": "string",
+                    "example": "topup"
+                },
+                "ExternalRef": {
+                    "type": "string",
+                    "example": "PSP-20240115-000123"
+                },
+                "MSISDN": {
+                    "type": "string",
+                    "example": "9876543210"
+                },
+                "Remarks": {
+                    "type": "string",
+                    "example": "monthly top-up"
+                },
+                "Status": {
+                    "type": "string",
+                    "example": "Active"
+                },
+                "TransType": {
+                    "type": "string",
+                    "example": "CREDIT"
+                }
+            }
+        },
+        "main.VerifyMPINRequest": {
+            "type": "object",
+            "required": [
+                "MPIN"
+            ],
+            "properties": {
+                "MPIN": {
+                    "type": "string",
+                    "example": "5678"
+                }
+            }
+        },
+        "main.VerifyMPINResponse": {
+            "type": "object",
+            "properties": {
+                "verified": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        }
+    }
 }`
 
 // SwaggerInfo holds exported Swagger Info so clients can modify it
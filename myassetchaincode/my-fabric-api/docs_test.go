@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// documentedResponses maps each documented endpoint to the value its handler
+// responds with on success
+var documentedResponses = map[string]interface{}{
+	"POST /admin/applyRate":                       ApplyRateResponse{},
+	"GET /admin/auditLog":                         AuditLogPage{},
+	"GET /admin/committedTxs":                     []CommittedTx{},
+	"PUT /admin/dealers/{dealerID}/quota":         MessageResponse{},
+	"POST /admin/deleteAll":                       DeleteAllResponse{},
+	"GET /admin/diagnostics":                      DiagnosticReport{},
+	"GET /admin/duplicates":                       [][]string{},
+	"POST /admin/duplicates/merge":                MessageResponse{},
+	"POST /admin/freezeByQuery":                   BulkFreezeResponse{},
+	"GET /admin/raw/{key}":                        RawStateResponse{},
+	"GET /admin/snapshots/{snapshotID}":           Snapshot{},
+	"PUT /admin/snapshots/{snapshotID}":           MessageResponse{},
+	"POST /admin/standingInstructions/apply":      StandingInstructionReport{},
+	"GET /admin/stats":                            LedgerStats{},
+	"GET /admin/timeskew":                         TimeSkewReport{},
+	"POST /approveUpdate/{requestID}":             MessageResponse{},
+	"GET /assets":                                 []Asset{},
+	"GET /assets/attention":                       []Asset{},
+	"GET /assets/averageBalance":                  AverageBalanceResponse{},
+	"GET /assets/balanceHistogram":                map[string]int{},
+	"GET /assets/byExternalRef/{ref}":             []Asset{},
+	"GET /assets/createdBetween":                  []Asset{},
+	"GET /assets/export.jsonl":                    Asset{},
+	"GET /assets/geojson":                         FeatureCollection{},
+	"POST /assets/import":                         ImportReport{},
+	"GET /assets/mostActive":                      []AssetActivity{},
+	"GET /assets/reconcile":                       HistoryDiff{},
+	"GET /assets/search/byPrefix/{prefix}":        []Asset{},
+	"GET /assets/stream":                          Asset{},
+	"GET /assets/top":                             []Asset{},
+	"GET /assets/totalBalance":                    TotalBalanceResponse{},
+	"DELETE /assets/{msisdn}":                     MessageResponse{},
+	"POST /assets/{msisdn}/adjust":                MessageResponse{},
+	"GET /assets/{msisdn}/audit":                  AuditResult{},
+	"GET /assets/{msisdn}/balanceSeries":          []BalancePoint{},
+	"GET /assets/{msisdn}/bundle":                 SignedBundle{},
+	"POST /assets/{msisdn}/changeMPIN":            ChangeMPINResponse{},
+	"POST /assets/{msisdn}/diff":                  map[string][2]json.RawMessage{},
+	"GET /assets/{msisdn}/hashChain":              HistoryHashChain{},
+	"PUT /assets/{msisdn}/location":               MessageResponse{},
+	"GET /assets/{msisdn}/metadata":               map[string]string{},
+	"POST /assets/{msisdn}/metadata":              MessageResponse{},
+	"GET /assets/{msisdn}/mpinLocked":             MPINLockResponse{},
+	"GET /assets/{msisdn}/reversals":              []Reversal{},
+	"POST /assets/{msisdn}/reverse":               MessageResponse{},
+	"GET /assets/{msisdn}/standingInstruction":    StandingInstruction{},
+	"PUT /assets/{msisdn}/standingInstruction":    MessageResponse{},
+	"DELETE /assets/{msisdn}/standingInstruction": MessageResponse{},
+	"GET /assets/{msisdn}/statement":              SignedStatement{},
+	"GET /assets/{msisdn}/transactions":           []*AssetHistoryEntry{},
+	"POST /assets/{msisdn}/verifyMPIN":            VerifyMPINResponse{},
+	"POST /createAsset":                           MessageResponse{},
+	"GET /dealers":                                []DealerSummary{},
+	"GET /dealers/balances":                       map[string]int{},
+	"GET /dealers/tree":                           []DealerAssets{},
+	"GET /dealers/{dealerID}/usage":               DealerUsage{},
+	"GET /getAssetHistory/{msisdn}":               []*AssetHistoryEntry{},
+	"GET /healthz":                                HealthResponse{},
+	"GET /readAsset/{msisdn}":                     Asset{},
+	"GET /readyz":                                 ReadinessResponse{},
+	"POST /requestUpdate/{msisdn}":                RequestIDResponse{},
+	"GET /segments/{segment}/members":             []string{},
+	"PUT /segments/{segment}/members/{msisdn}":    MessageResponse{},
+	"DELETE /segments/{segment}/members/{msisdn}": MessageResponse{},
+	"POST /transactions/batch":                    MessageResponse{},
+	"POST /transfers":                             MessageResponse{},
+	"GET /transfers/check":                        TransferCheck{},
+	"POST /updateAsset/{msisdn}":                  MessageResponse{},
+}
+
+// swaggerSpec is the subset of the generated spec checked against the handlers
+type swaggerSpec struct {
+	Paths map[string]map[string]struct {
+		Responses map[string]struct {
+			Schema map[string]interface{} `json:"schema"`
+		} `json:"responses"`
+	} `json:"paths"`
+	Definitions map[string]struct {
+		Properties map[string]interface{} `json:"properties"`
+	} `json:"definitions"`
+}
+
+func loadSwaggerSpec(t *testing.T) *swaggerSpec {
+	t.Helper()
+	data, err := os.ReadFile("docs/swagger.json")
+	if err != nil {
+		t.Fatalf("error reading generated spec: %v", err)
+	}
+	var spec swaggerSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatalf("error parsing generated spec: %v", err)
+	}
+	return &spec
+}
+
+// jsonFieldNames returns the JSON names of the fields of a struct type
+func jsonFieldNames(typ reflect.Type) []string {
+	var names []string
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkSchema reports where schema does not describe values of typ
+func checkSchema(t *testing.T, spec *swaggerSpec, where string, schema map[string]interface{}, typ reflect.Type) {
+	t.Helper()
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == reflect.TypeOf(json.RawMessage{}) || typ.Kind() == reflect.Interface {
+		return
+	}
+
+	want := map[reflect.Kind]string{
+		reflect.String: "string", reflect.Bool: "boolean",
+		reflect.Int: "integer", reflect.Int32: "integer", reflect.Int64: "integer", reflect.Uint64: "integer",
+		reflect.Float64: "number", reflect.Slice: "array", reflect.Array: "array", reflect.Map: "object",
+	}[typ.Kind()]
+	if typ == reflect.TypeOf(time.Time{}) {
+		want = "string"
+	}
+
+	if want == "" && typ.Kind() == reflect.Struct {
+		ref := "#/definitions/main." + typ.Name()
+		if schema["$ref"] != ref {
+			t.Errorf("%s: schema %v, want $ref %s", where, schema, ref)
+			return
+		}
+		definition, ok := spec.Definitions["main."+typ.Name()]
+		if !ok {
+			t.Errorf("%s: spec has no definition of %s", where, typ.Name())
+			return
+		}
+		var properties []string
+		for name := range definition.Properties {
+			properties = append(properties, name)
+		}
+		sort.Strings(properties)
+		if got, fields := strings.Join(properties, ","), strings.Join(jsonFieldNames(typ), ","); got != fields {
+			t.Errorf("%s: definition of %s has properties %s, want %s", where, typ.Name(), got, fields)
+		}
+		return
+	}
+
+	if schema["type"] != want {
+		t.Errorf("%s: schema %v, want type %s for %s", where, schema, want, typ)
+		return
+	}
+	switch typ.Kind() {
+	case reflect.Slice, reflect.Array:
+		items, _ := schema["items"].(map[string]interface{})
+		checkSchema(t, spec, where+" items", items, typ.Elem())
+	case reflect.Map:
+		values, _ := schema["additionalProperties"].(map[string]interface{})
+		checkSchema(t, spec, where+" values", values, typ.Elem())
+	}
+}
+
+func TestSpecResponsesMatchHandlerTypes(t *testing.T) {
+	spec := loadSwaggerSpec(t)
+
+	documented := make(map[string]bool)
+	for path, operations := range spec.Paths {
+		for method, operation := range operations {
+			endpoint := strings.ToUpper(method) + " " + path
+			documented[endpoint] = true
+
+			response, ok := documentedResponses[endpoint]
+			if !ok {
+				t.Errorf("%s is documented but has no expected response type", endpoint)
+				continue
+			}
+			success, ok := operation.Responses["200"]
+			if !ok {
+				t.Errorf("%s documents no 200 response", endpoint)
+				continue
+			}
+			checkSchema(t, spec, endpoint, success.Schema, reflect.TypeOf(response))
+
+			for code, failure := range operation.Responses {
+				if code >= "400" && failure.Schema == nil {
+					t.Errorf("%s documents %s without a response schema", endpoint, code)
+				}
+			}
+		}
+	}
+
+	for endpoint := range documentedResponses {
+		if !documented[endpoint] {
+			t.Errorf("%s is not documented", endpoint)
+		}
+	}
+}
+
+func TestSpecErrorResponseMatchesHandlers(t *testing.T) {
+	spec := loadSwaggerSpec(t)
+
+	// Handlers report errors as gin.H{"error": ...}
+	definition, ok := spec.Definitions["main.ErrorResponse"]
+	if !ok {
+		t.Fatal("spec has no ErrorResponse definition")
+	}
+	if _, ok := definition.Properties["error"]; !ok {
+		t.Errorf("ErrorResponse properties %v have no error field", definition.Properties)
+	}
+	checkSchema(t, spec, "ErrorResponse", map[string]interface{}{"$ref": "#/definitions/main.ErrorResponse"}, reflect.TypeOf(ErrorResponse{}))
+}
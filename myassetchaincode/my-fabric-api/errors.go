@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errUpdateNonexistentAsset matches the message of the chaincode's
+// ErrUpdateNonexistentAsset, which reaches the API only as text
+const errUpdateNonexistentAsset = "cannot update nonexistent asset"
+
+// errApprovalRequired matches the message of the chaincode's ErrApprovalRequired
+const errApprovalRequired = "update requires approval by a second party"
+
+// errBalanceBelowMinimum matches the message of the chaincode's ErrBalanceBelowMinimum
+const errBalanceBelowMinimum = "balance would fall below the minimum"
+
+// errDealerQuotaExceeded matches the message of the chaincode's ErrDealerQuotaExceeded
+const errDealerQuotaExceeded = "dealer asset quota exceeded"
+
+// errTransactionAmountExceeded matches the message of the chaincode's ErrTransactionAmountExceeded
+const errTransactionAmountExceeded = "transaction amount exceeds the maximum"
+
+// errAlreadyReversed matches the message of the chaincode's ErrAlreadyReversed
+const errAlreadyReversed = "transaction has already been reversed"
+
+// errBalanceOverflow matches the message of the chaincode's ErrBalanceOverflow
+const errBalanceOverflow = "balance arithmetic overflows"
+
+// errMPINLocked matches the message of the chaincode's ErrMPINLocked
+const errMPINLocked = "MPIN is locked after too many failed attempts"
+
+// errMPINExpired matches the message of the chaincode's ErrMPINExpired
+const errMPINExpired = "MPIN has expired and must be changed"
+
+// errDeleteAllNotConfirmed matches the message of the chaincode's ErrDeleteAllNotConfirmed
+const errDeleteAllNotConfirmed = "deleting all assets requires confirmation"
+
+// errStatusMismatch matches the message of the chaincode's ErrStatusMismatch
+const errStatusMismatch = "asset status does not match the expected status"
+
+// errParentNotFound matches the message of the chaincode's ErrParentNotFound
+const errParentNotFound = "parent asset not found"
+
+// errInvalidStatus prefixes the chaincode's error for a Status outside the allowed set
+const errInvalidStatus = "invalid status"
+
+// errInvalidCategory prefixes the chaincode's error for a Category outside the allowed set
+const errInvalidCategory = "invalid category"
+
+// errInvalidInterval prefixes the chaincode's error for a standing instruction interval outside the allowed set
+const errInvalidInterval = "invalid interval"
+
+// errInvalidQuery prefixes the chaincode's error for a malformed rich query
+const errInvalidQuery = "invalid query"
+
+// mvccConflictCodes are the validation codes a transaction is rejected with at
+// commit when a concurrent transaction changed the keys it read
+var mvccConflictCodes = []string{"MVCC_READ_CONFLICT", "PHANTOM_READ_CONFLICT"}
+
+// isMVCCConflict reports whether a submit failed because of a concurrent write
+func isMVCCConflict(err error) bool {
+	for _, code := range mvccConflictCodes {
+		if strings.Contains(err.Error(), code) {
+			return true
+		}
+	}
+	return false
+}
+
+// statusForError maps a chaincode error to the HTTP status to report it with
+func statusForError(err error) int {
+	switch {
+	case isMVCCConflict(err),
+		strings.Contains(err.Error(), errAlreadyReversed):
+		return http.StatusConflict
+	case strings.Contains(err.Error(), errUpdateNonexistentAsset):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), errApprovalRequired),
+		strings.Contains(err.Error(), errDealerQuotaExceeded),
+		strings.Contains(err.Error(), errMPINExpired):
+		return http.StatusForbidden
+	case strings.Contains(err.Error(), errMPINLocked):
+		return http.StatusLocked
+	case strings.Contains(err.Error(), errStatusMismatch):
+		return http.StatusPreconditionFailed
+	case strings.Contains(err.Error(), errInvalidStatus),
+		strings.Contains(err.Error(), errInvalidCategory),
+		strings.Contains(err.Error(), errInvalidInterval),
+		strings.Contains(err.Error(), errInvalidQuery),
+		strings.Contains(err.Error(), errRuleViolation),
+		strings.Contains(err.Error(), errParentNotFound),
+		strings.Contains(err.Error(), errBalanceBelowMinimum),
+		strings.Contains(err.Error(), errTransactionAmountExceeded),
+		strings.Contains(err.Error(), errBalanceOverflow),
+		strings.Contains(err.Error(), errDeleteAllNotConfirmed):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// respondCreateError reports a failed CreateAsset submit. Two concurrent
+// creates of the same MSISDN both pass the existence check, so the loser
+// fails at commit with an MVCC conflict and is told to retry.
+func respondCreateError(c *gin.Context, msisdn string, err error) {
+	if isMVCCConflict(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("asset with MSISDN %s was written by a concurrent request, retry the request", msisdn)})
+		return
+	}
+	if violations, ok := ruleViolations(err); ok {
+		c.JSON(http.StatusBadRequest, RuleViolationResponse{Error: "asset violates business rules", Violations: violations})
+		return
+	}
+	c.JSON(statusForError(err), gin.H{"error": err.Error()})
+}
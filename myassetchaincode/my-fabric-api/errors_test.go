@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestStatusForErrorNonexistentAsset(t *testing.T) {
+	// As returned by the gateway for an UpdateAsset of a missing MSISDN
+	err := errors.New("Transaction processing for endorser [peer0.org1.example.com:7051]: Chaincode status Code: (500) UNKNOWN. Description: cannot update nonexistent asset with MSISDN 9800000000")
+
+	if status := statusForError(err); status != http.StatusNotFound {
+		t.Errorf("statusForError = %d, want %d", status, http.StatusNotFound)
+	}
+}
+
+func TestRespondCreateErrorMVCCConflict(t *testing.T) {
+	// As returned by the gateway when a concurrent create of the same MSISDN committed first
+	err := errors.New("Failed to submit: transaction 5c2f0e1b invalidated with status code: MVCC_READ_CONFLICT")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	respondCreateError(c, "9811111111", err)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status is %d, want %d", w.Code, http.StatusConflict)
+	}
+	var body ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("error unmarshalling response: %v", err)
+	}
+	if !strings.Contains(body.Error, "9811111111") || !strings.Contains(body.Error, "retry") {
+		t.Errorf("error is %q, want it to name the MSISDN and suggest a retry", body.Error)
+	}
+}
+
+func TestStatusForErrorPhantomReadConflict(t *testing.T) {
+	err := errors.New("transaction invalidated with status code: PHANTOM_READ_CONFLICT")
+
+	if status := statusForError(err); status != http.StatusConflict {
+		t.Errorf("statusForError = %d, want %d", status, http.StatusConflict)
+	}
+}
+
+func TestStatusForErrorTransactionAmountExceeded(t *testing.T) {
+	// As returned by the gateway for an UpdateAsset above MAX_TRANSACTION_AMOUNT
+	err := errors.New("Transaction processing for endorser [peer0.org1.example.com:7051]: Chaincode status Code: (500) UNKNOWN. Description: transaction amount exceeds the maximum: 5001 exceeds 5000")
+
+	if status := statusForError(err); status != http.StatusBadRequest {
+		t.Errorf("statusForError = %d, want %d", status, http.StatusBadRequest)
+	}
+}
+
+func TestStatusForErrorStatusMismatch(t *testing.T) {
+	// As returned by the gateway for an UpdateAsset whose expectedStatus differs
+	err := errors.New("Transaction processing for endorser [peer0.org1.example.com:7051]: Chaincode status Code: (500) UNKNOWN. Description: asset status does not match the expected status: asset with MSISDN 9811111111 is Frozen, expected Active")
+
+	if status := statusForError(err); status != http.StatusPreconditionFailed {
+		t.Errorf("statusForError = %d, want %d", status, http.StatusPreconditionFailed)
+	}
+}
+
+func TestStatusForErrorParentNotFound(t *testing.T) {
+	// As returned by the gateway for a CreateAsset under a missing parent
+	err := errors.New("Transaction processing for endorser [peer0.org1.example.com:7051]: Chaincode status Code: (500) UNKNOWN. Description: parent asset not found: 9800000000")
+
+	if status := statusForError(err); status != http.StatusBadRequest {
+		t.Errorf("statusForError = %d, want %d", status, http.StatusBadRequest)
+	}
+}
+
+func TestStatusForErrorAlreadyReversed(t *testing.T) {
+	// As returned by the gateway for a second ReverseTransaction of the same transaction
+	err := errors.New("Transaction processing for endorser [peer0.org1.example.com:7051]: Chaincode status Code: (500) UNKNOWN. Description: transaction has already been reversed: tx2 was reversed by tx3")
+
+	if status := statusForError(err); status != http.StatusConflict {
+		t.Errorf("statusForError = %d, want %d", status, http.StatusConflict)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etagRequest serves asset through respondWithETag with the given If-None-Match
+func etagRequest(asset Asset, ifNoneMatch string) *httptest.ResponseRecorder {
+	r := gin.New()
+	r.GET("/readAsset/:msisdn", func(c *gin.Context) {
+		respondWithETag(c, asset)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/readAsset/"+asset.MSISDN, nil)
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestReadAssetETag(t *testing.T) {
+	asset := Asset{MSISDN: "9876543210", Balance: 1500, Status: "Active"}
+
+	w := etagRequest(asset, "")
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" {
+		t.Fatalf("got status %d with ETag %q, want 200 with an ETag", w.Code, etag)
+	}
+	if again := etagRequest(asset, "").Header().Get("ETag"); again != etag {
+		t.Errorf("ETag changed from %s to %s for the same asset", etag, again)
+	}
+
+	for _, ifNoneMatch := range []string{etag, "W/" + etag, `"other", ` + etag, "*"} {
+		w := etagRequest(asset, ifNoneMatch)
+		if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+			t.Errorf("If-None-Match %s got status %d with %d body bytes, want an empty 304", ifNoneMatch, w.Code, w.Body.Len())
+		}
+		if w.Header().Get("ETag") != etag {
+			t.Errorf("304 carries ETag %q, want %s", w.Header().Get("ETag"), etag)
+		}
+	}
+
+	// A changed asset no longer matches the client's ETag
+	asset.Balance = 2000
+	w = etagRequest(asset, etag)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Errorf("changed asset got status %d with ETag %s, want 200 with a new ETag", w.Code, w.Header().Get("ETag"))
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportAssetsJSONL streams every asset from contract as newline-delimited
+// JSON, fetching the ledger one page at a time so the full set is never
+// buffered. It stops fetching pages once the client has gone away.
+func exportAssetsJSONL(c *gin.Context, contract evaluator) {
+	encoder := json.NewEncoder(c.Writer)
+	bookmark := ""
+	for {
+		// Stop fetching pages for a client that has gone away
+		if err := checkCancelled(c.Request.Context()); err != nil {
+			fmt.Printf("Export stopped: %s\n", err)
+			return
+		}
+
+		// Invoke Fabric Chaincode one page at a time so the full set is never buffered
+		response, err := contract.EvaluateTransaction("GetAssetsPage", strconv.Itoa(exportPageSize), bookmark)
+		if err != nil {
+			abortStream(c, err)
+			return
+		}
+
+		var page AssetPage
+		if err := json.Unmarshal(response, &page); err != nil {
+			abortStream(c, err)
+			return
+		}
+
+		c.Header("Content-Type", "application/x-ndjson")
+		for _, asset := range page.Assets {
+			if err := encoder.Encode(asset); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+
+		if page.Bookmark == "" || int(page.FetchedRecordsCount) < exportPageSize {
+			return
+		}
+		bookmark = page.Bookmark
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pagedLedger answers GetAssetsPage from a fixed list of assets, using the
+// index of the next asset as the bookmark
+type pagedLedger struct {
+	assets []*Asset
+}
+
+func (l *pagedLedger) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	if name != "GetAssetsPage" {
+		return nil, errors.New("unexpected transaction " + name)
+	}
+	pageSize, _ := strconv.Atoi(args[0])
+	start, _ := strconv.Atoi(args[1])
+
+	end := start + pageSize
+	if end > len(l.assets) {
+		end = len(l.assets)
+	}
+	page := AssetPage{Assets: l.assets[start:end], FetchedRecordsCount: int32(end - start)}
+	if end < len(l.assets) {
+		page.Bookmark = strconv.Itoa(end)
+	}
+	return json.Marshal(page)
+}
+
+func TestExportAssetsJSONL(t *testing.T) {
+	ledger := &pagedLedger{}
+	for i := 0; i < exportPageSize+50; i++ {
+		ledger.assets = append(ledger.assets, &Asset{MSISDN: fmt.Sprintf("98%08d", i), Balance: int64(i)})
+	}
+
+	r := gin.New()
+	r.GET("/assets/export.jsonl", func(c *gin.Context) {
+		exportAssetsJSONL(c, ledger)
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/assets/export.jsonl", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status is %d, want %d", w.Code, http.StatusOK)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/x-ndjson" {
+		t.Errorf("Content-Type is %q, want application/x-ndjson", contentType)
+	}
+
+	count := 0
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var asset Asset
+		if err := json.Unmarshal(scanner.Bytes(), &asset); err != nil {
+			t.Fatalf("line %d does not parse as an Asset: %v", count+1, err)
+		}
+		if want := fmt.Sprintf("98%08d", count); asset.MSISDN != want {
+			t.Errorf("line %d has MSISDN %s, want %s", count+1, asset.MSISDN, want)
+		}
+		count++
+	}
+	if count != len(ledger.assets) {
+		t.Errorf("exported %d lines, want %d", count, len(ledger.assets))
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+
+	"myassetchaincode/docs"
+)
+
+// fieldCaseHeader lets a client pick the field naming of a response,
+// overriding RESPONSE_FIELD_CASE
+const fieldCaseHeader = "X-Field-Case"
+
+// Field naming styles accepted by RESPONSE_FIELD_CASE and fieldCaseHeader
+const (
+	fieldCasePascal = "pascal"
+	fieldCaseSnake  = "snake"
+)
+
+// documentedFields holds the JSON property names of every type in the
+// Swagger definitions, which are the names a response may be renamed from
+type documentedFields struct {
+	names map[string]bool
+	// maps are the properties holding maps, such as Metadata, whose keys are
+	// data and are kept even when they look like a field name
+	maps map[string]bool
+}
+
+// documentedFieldNames reads the documentedFields from the Swagger definitions
+func documentedFieldNames() (documentedFields, error) {
+	var spec struct {
+		Definitions map[string]struct {
+			Properties map[string]struct {
+				AdditionalProperties json.RawMessage `json:"additionalProperties"`
+			} `json:"properties"`
+		} `json:"definitions"`
+	}
+	if err := json.Unmarshal([]byte(docs.SwaggerInfo.ReadDoc()), &spec); err != nil {
+		return documentedFields{}, fmt.Errorf("error decoding the Swagger definitions: %v", err)
+	}
+
+	fields := documentedFields{names: make(map[string]bool), maps: make(map[string]bool)}
+	for _, definition := range spec.Definitions {
+		for name, property := range definition.Properties {
+			fields.names[name] = true
+			if property.AdditionalProperties != nil {
+				fields.maps[name] = true
+			}
+		}
+	}
+	return fields, nil
+}
+
+// renameResponseFields is a middleware that rewrites the field names of JSON
+// responses to snake_case when fieldCaseHeader or, failing that, fallback asks
+// for it. Only documented names are rewritten, so map keys are kept.
+// Responses that are not JSON, including streams, are written through.
+func renameResponseFields(fallback string, fields documentedFields) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", fieldCaseHeader)
+
+		fieldCase := strings.ToLower(c.GetHeader(fieldCaseHeader))
+		if fieldCase == "" {
+			fieldCase = fallback
+		}
+		if fieldCase != fieldCaseSnake {
+			c.Next()
+			return
+		}
+
+		writer := &jsonBodyWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		if writer.body.Len() == 0 {
+			return
+		}
+		writer.ResponseWriter.Write(snakeCaseBody(writer.body.Bytes(), fields))
+	}
+}
+
+// snakeCaseBody renames the fields of a JSON body to snake_case. Bodies that
+// are not valid JSON are returned unchanged.
+func snakeCaseBody(body []byte, fields documentedFields) []byte {
+	// UseNumber keeps int64 balances exact
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return body
+	}
+
+	renamed, err := json.Marshal(snakeCaseValue(value, fields))
+	if err != nil {
+		return body
+	}
+	return renamed
+}
+
+// snakeCaseValue renames the documented object keys within value
+func snakeCaseValue(value interface{}, fields documentedFields) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		renamed := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if entries, ok := item.(map[string]interface{}); ok && fields.maps[key] {
+				for entry, entryValue := range entries {
+					entries[entry] = snakeCaseValue(entryValue, fields)
+				}
+			} else {
+				item = snakeCaseValue(item, fields)
+			}
+			if fields.names[key] {
+				key = snakeCase(key)
+			}
+			renamed[key] = item
+		}
+		return renamed
+	case []interface{}:
+		for i, item := range v {
+			v[i] = snakeCaseValue(item, fields)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// snakeCase converts a PascalCase or camelCase name to snake_case, keeping
+// acronyms together: "DealerID" becomes "dealer_id" and "MPINSetAt" becomes
+// "mpin_set_at"
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			previous := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextIsLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// jsonBodyWriter holds back JSON response bodies so they can be rewritten
+// once the handler is done. Other content types, including streams, are
+// written through.
+type jsonBodyWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	decided   bool
+	buffering bool
+}
+
+// Write buffers the body of a JSON response
+func (w *jsonBodyWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.decided = true
+		w.buffering = strings.HasPrefix(w.Header().Get("Content-Type"), "application/json")
+	}
+	if w.buffering {
+		return w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString buffers the body of a JSON response
+func (w *jsonBodyWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSnakeCase(t *testing.T) {
+	for name, want := range map[string]string{
+		"MSISDN":        "msisdn",
+		"DealerID":      "dealer_id",
+		"MPINSetAt":     "mpin_set_at",
+		"TransAmount":   "trans_amount",
+		"SchemaVersion": "schema_version",
+		"dealerID":      "dealer_id",
+		"Top10Assets":   "top10_assets",
+		"status":        "status",
+	} {
+		if got := snakeCase(name); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+// fieldCaseRouter returns a router with renameResponseFields in front of an
+// asset route and a plain text route
+func fieldCaseRouter(t *testing.T, fallback string, asset Asset) *gin.Engine {
+	t.Helper()
+	fieldNames, err := documentedFieldNames()
+	if err != nil {
+		t.Fatalf("documentedFieldNames returned error: %v", err)
+	}
+	r := gin.New()
+	r.Use(renameResponseFields(fallback, fieldNames))
+	r.GET("/readAsset/:msisdn", func(c *gin.Context) {
+		c.JSON(http.StatusOK, asset)
+	})
+	r.GET("/metrics", func(c *gin.Context) {
+		c.String(http.StatusOK, "DealerID 1")
+	})
+	return r
+}
+
+func TestRenameResponseFields(t *testing.T) {
+	asset := Asset{
+		MSISDN:      "9876543210",
+		DealerID:    "D001",
+		Balance:     math.MaxInt64,
+		TransAmount: 100,
+		Metadata:    map[string]string{"DealerID": "kept", "plan": "prepaid"},
+	}
+
+	readAsset := func(r *gin.Engine, header string) map[string]json.RawMessage {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/readAsset/9876543210", nil)
+		if header != "" {
+			req.Header.Set(fieldCaseHeader, header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("readAsset got status %d, want %d", w.Code, http.StatusOK)
+		}
+		if vary := w.Header().Get("Vary"); vary != fieldCaseHeader {
+			t.Errorf("Vary is %q, want %s", vary, fieldCaseHeader)
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(w.Body.Bytes(), &fields); err != nil {
+			t.Fatalf("error unmarshalling %s: %v", w.Body, err)
+		}
+		return fields
+	}
+	balance := strconv.FormatInt(math.MaxInt64, 10)
+
+	pascal := fieldCaseRouter(t, fieldCasePascal, asset)
+	snake := fieldCaseRouter(t, fieldCaseSnake, asset)
+	for name, fields := range map[string]map[string]json.RawMessage{
+		"pascal config":               readAsset(pascal, ""),
+		"snake config, pascal header": readAsset(snake, "Pascal"),
+	} {
+		if string(fields["MSISDN"]) != `"9876543210"` || string(fields["DealerID"]) != `"D001"` || string(fields["Balance"]) != balance {
+			t.Errorf("%s: asset is %v, want PascalCase fields", name, fields)
+		}
+		if _, ok := fields["dealer_id"]; ok {
+			t.Errorf("%s: asset has a snake_case field", name)
+		}
+	}
+
+	for name, fields := range map[string]map[string]json.RawMessage{
+		"snake config":                readAsset(snake, ""),
+		"pascal config, snake header": readAsset(pascal, "snake"),
+	} {
+		if string(fields["msisdn"]) != `"9876543210"` || string(fields["dealer_id"]) != `"D001"` || string(fields["trans_amount"]) != "100" {
+			t.Errorf("%s: asset is %v, want snake_case fields", name, fields)
+		}
+		// Balances stay exact through the rewrite
+		if string(fields["balance"]) != balance {
+			t.Errorf("%s: balance is %s, want %s", name, fields["balance"], balance)
+		}
+		if _, ok := fields["DealerID"]; ok {
+			t.Errorf("%s: asset still has the DealerID field", name)
+		}
+		// Metadata keys are data, not field names
+		var metadata map[string]string
+		if err := json.Unmarshal(fields["metadata"], &metadata); err != nil || metadata["DealerID"] != "kept" || metadata["plan"] != "prepaid" {
+			t.Errorf("%s: metadata is %s, want its keys unchanged", name, fields["metadata"])
+		}
+	}
+
+	w := httptest.NewRecorder()
+	snake.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Body.String(), "DealerID 1") {
+		t.Errorf("plain text response is %d %q, want it written through", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+)
+
+// defaultGatewayCommitTimeout matches the gateway's own commit timeout
+const defaultGatewayCommitTimeout = 5 * time.Minute
+
+// gatewayConfigProvider loads the connection profile at path with the gateway
+// settings of cfg layered over it: GATEWAY_ENDORSEMENT_TIMEOUT bounds how long
+// each peer has to endorse and GATEWAY_DISCOVERY_AS_LOCALHOST rewrites
+// discovered addresses. Settings left unset keep the profile's own values.
+func gatewayConfigProvider(path string, cfg Config) core.ConfigProvider {
+	overrides := make(map[string]interface{})
+	if cfg.GatewayEndorseTimeout > 0 {
+		overrides["client.peer.timeout.response"] = cfg.GatewayEndorseTimeout
+	}
+	if cfg.DiscoveryAsLocalhost {
+		overrides["entityMatchers"] = localhostEntityMatchers()
+	}
+
+	profile := config.FromFile(path)
+	if len(overrides) == 0 {
+		return profile
+	}
+
+	return func() ([]core.ConfigBackend, error) {
+		backends, err := profile()
+		if err != nil {
+			return nil, err
+		}
+		// The gateway accepts a single backend, so the overrides wrap it
+		for i, backend := range backends {
+			backends[i] = &overrideBackend{ConfigBackend: backend, overrides: overrides}
+		}
+		return backends, nil
+	}
+}
+
+// gatewayOptions returns the gateway.Connect options configured by cfg
+func gatewayOptions(cfg Config) []gateway.Option {
+	var options []gateway.Option
+	if cfg.GatewayCommitTimeout > 0 {
+		options = append(options, gateway.WithTimeout(cfg.GatewayCommitTimeout))
+	}
+	return options
+}
+
+// overrideBackend answers lookups of the overridden keys itself and passes the
+// rest to the wrapped backend
+type overrideBackend struct {
+	core.ConfigBackend
+	overrides map[string]interface{}
+}
+
+// Lookup returns the override for key, if any, or the wrapped backend's value
+func (b *overrideBackend) Lookup(key string) (interface{}, bool) {
+	if value, ok := b.overrides[key]; ok {
+		return value, true
+	}
+	return b.ConfigBackend.Lookup(key)
+}
+
+// localhostEntityMatchers maps the peer and orderer addresses returned by
+// service discovery to localhost on the same port, for networks running in
+// Docker on the API host. TLS still verifies against the original host name.
+func localhostEntityMatchers() map[string][]map[string]string {
+	mappings := []map[string]string{{
+		"pattern":                             "([^:]+):(\\d+)",
+		"urlSubstitutionExp":                  "localhost:${2}",
+		"sslTargetOverrideUrlSubstitutionExp": "${1}",
+		"mappedHost":                          "${1}",
+	}}
+	return map[string][]map[string]string{
+		"peer":    mappings,
+		"orderer": mappings,
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+)
+
+const gatewayTestProfile = `
+name: test-network-org1
+version: 1.0.0
+client:
+  organization: Org1
+organizations:
+  Org1:
+    mspid: Org1MSP
+    peers:
+    - peer0.org1.example.com
+peers:
+  peer0.org1.example.com:
+    url: grpc://localhost:7051
+`
+
+// writeGatewayTestProfile writes gatewayTestProfile to dir and returns its path
+func writeGatewayTestProfile(t *testing.T, dir string) string {
+	t.Helper()
+	path := filepath.Join(dir, "connection-org1.yaml")
+	if err := os.WriteFile(path, []byte(gatewayTestProfile), 0600); err != nil {
+		t.Fatalf("error writing connection profile: %v", err)
+	}
+	return path
+}
+
+func TestGatewayOptionsAppliedAtConnect(t *testing.T) {
+	dir := t.TempDir()
+	profile := writeGatewayTestProfile(t, dir)
+	certPath, keyPath, _ := writeTestCredentials(t, dir)
+
+	for _, tt := range []struct {
+		commitTimeout, want time.Duration
+	}{
+		{commitTimeout: 42 * time.Second, want: 42 * time.Second},
+		{commitTimeout: 0, want: defaultGatewayCommitTimeout},
+	} {
+		cfg := Config{FabricMSPID: "Org1MSP", FabricCertPath: certPath, FabricKeyPath: keyPath, GatewayCommitTimeout: tt.commitTimeout}
+		wallet, label, _, err := identityWallet(cfg, "appUser")
+		if err != nil {
+			t.Fatalf("identityWallet returned error: %v", err)
+		}
+
+		// Connecting builds the SDK without contacting the peers
+		gw, err := gateway.Connect(
+			gateway.WithConfig(gatewayConfigProvider(profile, cfg)),
+			gateway.WithIdentity(wallet, label),
+			gatewayOptions(cfg)...,
+		)
+		if err != nil {
+			t.Fatalf("gateway.Connect returned error: %v", err)
+		}
+		defer gw.Close()
+
+		// The gateway keeps its commit timeout unexported
+		timeout := time.Duration(reflect.ValueOf(gw).Elem().FieldByName("options").Elem().FieldByName("Timeout").Int())
+		if timeout != tt.want {
+			t.Errorf("with GatewayCommitTimeout %s the gateway commit timeout is %s, want %s", tt.commitTimeout, timeout, tt.want)
+		}
+	}
+}
+
+// localhostMatchers returns the entity matchers set by DiscoveryAsLocalhost, if any
+func localhostMatchers(value interface{}) map[string][]map[string]string {
+	matchers, _ := value.(map[string][]map[string]string)
+	return matchers
+}
+
+func TestGatewayConfigProviderOverrides(t *testing.T) {
+	profile := writeGatewayTestProfile(t, t.TempDir())
+
+	backends, err := gatewayConfigProvider(profile, Config{GatewayEndorseTimeout: 7 * time.Second, DiscoveryAsLocalhost: true})()
+	if err != nil {
+		t.Fatalf("error loading configuration: %v", err)
+	}
+	if len(backends) != 1 {
+		t.Fatalf("got %d configuration backends, want 1", len(backends))
+	}
+	backend := backends[0]
+
+	if timeout, ok := backend.Lookup("client.peer.timeout.response"); !ok || timeout != 7*time.Second {
+		t.Errorf("endorsement timeout is %v, %v, want 7s", timeout, ok)
+	}
+	matchers, _ := backend.Lookup("entityMatchers")
+	if peers := localhostMatchers(matchers)["peer"]; len(peers) != 1 || peers[0]["urlSubstitutionExp"] != "localhost:${2}" {
+		t.Errorf("entity matchers are %v, want discovered peers mapped to localhost", matchers)
+	}
+	if organization, ok := backend.Lookup("client.organization"); !ok || organization != "Org1" {
+		t.Errorf("client.organization is %v, %v, want the profile's Org1", organization, ok)
+	}
+
+	// Without settings the profile is used as is
+	backends, err = gatewayConfigProvider(profile, Config{})()
+	if err != nil {
+		t.Fatalf("error loading configuration: %v", err)
+	}
+	if _, ok := backends[0].Lookup("client.peer.timeout.response"); ok {
+		t.Error("endorsement timeout is set without GATEWAY_ENDORSEMENT_TIMEOUT")
+	}
+	if _, ok := backends[0].Lookup("entityMatchers"); ok {
+		t.Error("entity matchers are set without GATEWAY_DISCOVERY_AS_LOCALHOST")
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import "encoding/json"
+
+// FeatureCollection is a GeoJSON (RFC 7946) collection of asset features
+type FeatureCollection struct {
+	Type     string    `json:"type" example:"FeatureCollection"`
+	Features []Feature `json:"features"`
+}
+
+// Feature is a GeoJSON feature locating one asset
+type Feature struct {
+	Type       string            `json:"type" example:"Feature"`
+	Geometry   Point             `json:"geometry"`
+	Properties FeatureProperties `json:"properties"`
+}
+
+// Point is a GeoJSON point. Coordinates are longitude then latitude, as RFC 7946 requires.
+type Point struct {
+	Type        string    `json:"type" example:"Point"`
+	Coordinates []float64 `json:"coordinates" example:"77.2090,28.6139"`
+}
+
+// FeatureProperties describes the asset at a feature
+type FeatureProperties struct {
+	MSISDN   string `json:"MSISDN" example:"9876543210"`
+	DealerID string `json:"DealerID" example:"D001"`
+	Status   string `json:"Status" example:"Active"`
+	Balance  int64  `json:"Balance" example:"1500"`
+	Label    string `json:"Label" example:"Main street kiosk"`
+}
+
+// locatedAssets reads every asset from the ledger and returns the ones that
+// have a location as a FeatureCollection
+func locatedAssets(contract evaluator) (FeatureCollection, error) {
+	// Invoke Fabric Chaincode
+	response, err := contract.EvaluateTransaction("GetAllAssets")
+	if err != nil {
+		return FeatureCollection{}, err
+	}
+
+	var assets []*Asset
+	if err := json.Unmarshal(response, &assets); err != nil {
+		return FeatureCollection{}, err
+	}
+
+	return assetFeatures(assets), nil
+}
+
+// assetFeatures returns a feature for every asset that has a location, in
+// the order given. An asset at 0, 0 has no location.
+func assetFeatures(assets []*Asset) FeatureCollection {
+	collection := FeatureCollection{Type: "FeatureCollection", Features: []Feature{}}
+	for _, asset := range assets {
+		if asset.Latitude == 0 && asset.Longitude == 0 {
+			continue
+		}
+
+		collection.Features = append(collection.Features, Feature{
+			Type:     "Feature",
+			Geometry: Point{Type: "Point", Coordinates: []float64{asset.Longitude, asset.Latitude}},
+			Properties: FeatureProperties{
+				MSISDN:   asset.MSISDN,
+				DealerID: asset.DealerID,
+				Status:   asset.Status,
+				Balance:  asset.Balance,
+				Label:    asset.Label,
+			},
+		})
+	}
+	return collection
+}
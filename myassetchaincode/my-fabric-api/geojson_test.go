@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestLocatedAssets(t *testing.T) {
+	ledger := &fakeLedger{assets: map[string]Asset{
+		"9811111111": {MSISDN: "9811111111", DealerID: "D001", Status: "Active", Balance: 100, Label: "kiosk", Latitude: 28.6139, Longitude: 77.209},
+		"9822222222": {MSISDN: "9822222222", DealerID: "D002", Status: "Active", Balance: 200},
+		"9833333333": {MSISDN: "9833333333", DealerID: "D002", Status: "Frozen", Balance: 300, Latitude: -33.8688, Longitude: 151.2093},
+	}}
+
+	collection, err := locatedAssets(ledger)
+	if err != nil {
+		t.Fatalf("locatedAssets returned error: %v", err)
+	}
+	body, err := json.Marshal(collection)
+	if err != nil {
+		t.Fatalf("error marshalling collection: %v", err)
+	}
+
+	var decoded struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Type     string `json:"type"`
+			Geometry struct {
+				Type        string    `json:"type"`
+				Coordinates []float64 `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		t.Fatalf("error unmarshalling %s: %v", body, err)
+	}
+	if decoded.Type != "FeatureCollection" {
+		t.Errorf("collection type is %q, want FeatureCollection", decoded.Type)
+	}
+	if len(decoded.Features) != 2 {
+		t.Fatalf("collection has %d features, want the 2 located assets: %s", len(decoded.Features), body)
+	}
+
+	want := map[string][2]float64{"9811111111": {77.209, 28.6139}, "9833333333": {151.2093, -33.8688}}
+	for _, feature := range decoded.Features {
+		msisdn, _ := feature.Properties["MSISDN"].(string)
+		coordinates, ok := want[msisdn]
+		if !ok {
+			t.Errorf("feature for %q is not a located asset", msisdn)
+			continue
+		}
+		delete(want, msisdn)
+		if feature.Type != "Feature" || feature.Geometry.Type != "Point" {
+			t.Errorf("feature for %s is a %s of a %s, want a Feature of a Point", msisdn, feature.Type, feature.Geometry.Type)
+		}
+		// GeoJSON orders coordinates longitude first
+		if len(feature.Geometry.Coordinates) != 2 || feature.Geometry.Coordinates[0] != coordinates[0] || feature.Geometry.Coordinates[1] != coordinates[1] {
+			t.Errorf("coordinates of %s are %v, want %v", msisdn, feature.Geometry.Coordinates, coordinates)
+		}
+		asset := ledger.assets[msisdn]
+		if feature.Properties["DealerID"] != asset.DealerID || feature.Properties["Status"] != asset.Status || feature.Properties["Balance"] != float64(asset.Balance) {
+			t.Errorf("properties of %s are %v, want those of %+v", msisdn, feature.Properties, asset)
+		}
+	}
+}
+
+func TestLocatedAssetsNone(t *testing.T) {
+	ledger := &fakeLedger{assets: map[string]Asset{"9811111111": {MSISDN: "9811111111"}}}
+
+	collection, err := locatedAssets(ledger)
+	if err != nil {
+		t.Fatalf("locatedAssets returned error: %v", err)
+	}
+	body, err := json.Marshal(collection)
+	if err != nil {
+		t.Fatalf("error marshalling collection: %v", err)
+	}
+	if string(body) != `{"type":"FeatureCollection","features":[]}` {
+		t.Errorf("collection without located assets is %s, want an empty features array", body)
+	}
+}
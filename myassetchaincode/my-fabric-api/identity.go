@@ -0,0 +1,148 @@
+package main
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+)
+
+// loadX509Identity builds the gateway identity of mspID from a PEM encoded
+// signing certificate and private key, such as the signcerts and keystore files
+// of an MSP directory. It also returns when the certificate expires.
+func loadX509Identity(mspID, certPath, keyPath string) (*gateway.X509Identity, time.Time, error) {
+	if mspID == "" {
+		return nil, time.Time{}, fmt.Errorf("FABRIC_MSP_ID is not set")
+	}
+	if certPath == "" {
+		return nil, time.Time{}, fmt.Errorf("FABRIC_CERT_PATH is not set")
+	}
+	if keyPath == "" {
+		return nil, time.Time{}, fmt.Errorf("FABRIC_KEY_PATH is not set")
+	}
+
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("error reading certificate: %v", err)
+	}
+	cert, err := parseCertificatePEM(certPEM)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("error parsing certificate %s: %v", certPath, err)
+	}
+
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("error reading private key: %v", err)
+	}
+	key, err := parsePrivateKeyPEM(keyPEM)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("error parsing private key %s: %v", keyPath, err)
+	}
+
+	// Catch a key from a different enrollment before the peers reject every signature
+	public, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !public.Equal(cert.PublicKey) {
+		return nil, time.Time{}, fmt.Errorf("private key %s does not match certificate %s", keyPath, certPath)
+	}
+
+	return gateway.NewX509Identity(mspID, string(certPEM), string(keyPEM)), cert.NotAfter, nil
+}
+
+// identityWallet returns a wallet holding the credentials of identity and the
+// label they are stored under, along with when the certificate expires. With
+// WALLET_PATH set the filesystem wallet there is used, and an identity missing
+// from it is enrolled from FABRIC_CERT_PATH and FABRIC_KEY_PATH when those are
+// set. Otherwise the files are loaded into an in-memory wallet.
+func identityWallet(cfg Config, identity string) (*gateway.Wallet, string, time.Time, error) {
+	if cfg.WalletPath == "" {
+		// All identities share the file credentials until per-identity credentials are configured
+		id, expires, err := loadX509Identity(cfg.FabricMSPID, cfg.FabricCertPath, cfg.FabricKeyPath)
+		if err != nil {
+			return nil, "", time.Time{}, err
+		}
+		wallet := gateway.NewInMemoryWallet()
+		if err := wallet.Put(identity, id); err != nil {
+			return nil, "", time.Time{}, err
+		}
+		return wallet, identity, expires, nil
+	}
+
+	label := identity
+	if identity == cfg.FabricIdentity && cfg.WalletLabel != "" {
+		label = cfg.WalletLabel
+	}
+
+	wallet, err := gateway.NewFileSystemWallet(cfg.WalletPath)
+	if err != nil {
+		return nil, "", time.Time{}, fmt.Errorf("error opening wallet %s: %v", cfg.WalletPath, err)
+	}
+
+	if !wallet.Exists(label) {
+		if cfg.FabricCertPath == "" {
+			return nil, "", time.Time{}, fmt.Errorf("identity %q is not in wallet %s; enroll it or set FABRIC_CERT_PATH and FABRIC_KEY_PATH to import it", label, cfg.WalletPath)
+		}
+		id, _, err := loadX509Identity(cfg.FabricMSPID, cfg.FabricCertPath, cfg.FabricKeyPath)
+		if err != nil {
+			return nil, "", time.Time{}, fmt.Errorf("error enrolling %q into wallet %s: %v", label, cfg.WalletPath, err)
+		}
+		if err := wallet.Put(label, id); err != nil {
+			return nil, "", time.Time{}, fmt.Errorf("error enrolling %q into wallet %s: %v", label, cfg.WalletPath, err)
+		}
+	}
+
+	stored, err := wallet.Get(label)
+	if err != nil {
+		return nil, "", time.Time{}, fmt.Errorf("error reading %q from wallet %s: %v", label, cfg.WalletPath, err)
+	}
+	x509ID, ok := stored.(*gateway.X509Identity)
+	if !ok {
+		return nil, "", time.Time{}, fmt.Errorf("identity %q in wallet %s is not an X.509 identity", label, cfg.WalletPath)
+	}
+	cert, err := parseCertificatePEM([]byte(x509ID.Certificate()))
+	if err != nil {
+		return nil, "", time.Time{}, fmt.Errorf("error parsing certificate of %q in wallet %s: %v", label, cfg.WalletPath, err)
+	}
+
+	return wallet, label, cert.NotAfter, nil
+}
+
+// parseCertificatePEM decodes a PEM encoded X.509 certificate
+func parseCertificatePEM(certPEM []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("not a PEM encoded certificate")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// parsePrivateKeyPEM decodes a PEM encoded PKCS#8, PKCS#1 or SEC 1 private key
+func parsePrivateKeyPEM(keyPEM []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("key is not PEM encoded")
+	}
+
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
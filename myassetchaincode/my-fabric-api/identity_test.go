@@ -0,0 +1,165 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+)
+
+// writeTestCredentials writes a self-signed certificate and its private key
+// as PEM files in dir and returns their paths and the certificate's expiry
+func writeTestCredentials(t *testing.T, dir string) (string, string, time.Time) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	notAfter := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "appUser"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("error creating certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("error encoding key: %v", err)
+	}
+
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "priv_sk")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0600); err != nil {
+		t.Fatalf("error writing certificate: %v", err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatalf("error writing key: %v", err)
+	}
+	return certPath, keyPath, notAfter
+}
+
+func TestLoadX509Identity(t *testing.T) {
+	certPath, keyPath, notAfter := writeTestCredentials(t, t.TempDir())
+
+	id, expires, err := loadX509Identity("Org1MSP", certPath, keyPath)
+	if err != nil {
+		t.Fatalf("loadX509Identity returned error: %v", err)
+	}
+
+	certPEM, _ := os.ReadFile(certPath)
+	keyPEM, _ := os.ReadFile(keyPath)
+	if id.MspID != "Org1MSP" || id.Certificate() != string(certPEM) || id.Key() != string(keyPEM) {
+		t.Errorf("identity is %+v, want Org1MSP with the certificate and key files", id)
+	}
+	if !expires.Equal(notAfter) {
+		t.Errorf("expiry is %s, want %s", expires, notAfter)
+	}
+}
+
+func TestLoadX509IdentityErrors(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath, _ := writeTestCredentials(t, dir)
+	otherCertPath, _, _ := writeTestCredentials(t, t.TempDir())
+	garbagePath := filepath.Join(dir, "garbage.pem")
+	if err := os.WriteFile(garbagePath, []byte("not a certificate"), 0600); err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+
+	tests := []struct {
+		name, mspID, certPath, keyPath, wantErr string
+	}{
+		{"no MSP ID", "", certPath, keyPath, "FABRIC_MSP_ID is not set"},
+		{"no certificate path", "Org1MSP", "", keyPath, "FABRIC_CERT_PATH is not set"},
+		{"no key path", "Org1MSP", certPath, "", "FABRIC_KEY_PATH is not set"},
+		{"missing certificate", "Org1MSP", filepath.Join(dir, "missing.pem"), keyPath, "error reading certificate"},
+		{"malformed certificate", "Org1MSP", garbagePath, keyPath, "error parsing certificate"},
+		{"malformed key", "Org1MSP", certPath, garbagePath, "error parsing private key"},
+		{"mismatched key", "Org1MSP", otherCertPath, keyPath, "does not match certificate"},
+	}
+	for _, tt := range tests {
+		if _, _, err := loadX509Identity(tt.mspID, tt.certPath, tt.keyPath); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: loadX509Identity returned %v, want an error containing %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestIdentityWalletLoadsByLabel(t *testing.T) {
+	certPath, keyPath, notAfter := writeTestCredentials(t, t.TempDir())
+	certPEM, _ := os.ReadFile(certPath)
+	keyPEM, _ := os.ReadFile(keyPath)
+
+	walletPath := t.TempDir()
+	stored, err := gateway.NewFileSystemWallet(walletPath)
+	if err != nil {
+		t.Fatalf("error creating wallet: %v", err)
+	}
+	if err := stored.Put("admin", gateway.NewX509Identity("Org1MSP", string(certPEM), string(keyPEM))); err != nil {
+		t.Fatalf("error storing identity: %v", err)
+	}
+
+	cfg := Config{FabricIdentity: "appUser", WalletPath: walletPath, WalletLabel: "admin"}
+	wallet, label, expires, err := identityWallet(cfg, "appUser")
+	if err != nil {
+		t.Fatalf("identityWallet returned error: %v", err)
+	}
+	if label != "admin" || !expires.Equal(notAfter) {
+		t.Errorf("got label %s expiring %s, want admin expiring %s", label, expires, notAfter)
+	}
+	id, err := wallet.Get(label)
+	if err != nil {
+		t.Fatalf("error reading %s from the wallet: %v", label, err)
+	}
+	if x509ID, ok := id.(*gateway.X509Identity); !ok || x509ID.MspID != "Org1MSP" || x509ID.Certificate() != string(certPEM) {
+		t.Errorf("wallet identity is %+v, want the stored Org1MSP identity", id)
+	}
+
+	// WALLET_LABEL only renames the default identity
+	if _, _, _, err := identityWallet(cfg, "auditor"); err == nil || !strings.Contains(err.Error(), `"auditor" is not in wallet`) {
+		t.Errorf("identityWallet of an unenrolled identity returned %v, want a not in wallet error", err)
+	}
+}
+
+func TestIdentityWalletEnrollsFromFiles(t *testing.T) {
+	certPath, keyPath, notAfter := writeTestCredentials(t, t.TempDir())
+	cfg := Config{
+		FabricMSPID:    "Org1MSP",
+		FabricCertPath: certPath,
+		FabricKeyPath:  keyPath,
+		FabricIdentity: "appUser",
+		WalletPath:     t.TempDir(),
+	}
+
+	wallet, label, expires, err := identityWallet(cfg, "appUser")
+	if err != nil {
+		t.Fatalf("identityWallet returned error: %v", err)
+	}
+	if label != "appUser" || !wallet.Exists("appUser") || !expires.Equal(notAfter) {
+		t.Errorf("got label %s expiring %s, want appUser enrolled into the wallet expiring %s", label, expires, notAfter)
+	}
+
+	// Later starts read the enrolled identity without the files
+	cfg.FabricCertPath, cfg.FabricKeyPath = "", ""
+	if _, label, _, err := identityWallet(cfg, "appUser"); err != nil || label != "appUser" {
+		t.Errorf("identityWallet from the wallet returned %s, %v, want appUser", label, err)
+	}
+
+	// A failed enrollment names the label and the wallet
+	cfg.FabricCertPath, cfg.FabricKeyPath = certPath, filepath.Join(t.TempDir(), "missing")
+	if _, _, _, err := identityWallet(cfg, "auditor"); err == nil || !strings.Contains(err.Error(), `error enrolling "auditor" into wallet`) {
+		t.Errorf("identityWallet with a missing key returned %v, want an enrollment error", err)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// importHeader is the header row expected at the top of an import CSV
+var importHeader = []string{"MSISDN", "Balance", "Status", "TransType", "Remarks"}
+
+// ImportRowResult is the outcome of applying one CSV row
+type ImportRowResult struct {
+	Row     int    `json:"Row"`
+	MSISDN  string `json:"MSISDN"`
+	Success bool   `json:"Success"`
+	Error   string `json:"Error,omitempty"`
+}
+
+// ImportReport summarizes a CSV import, with one result per data row
+type ImportReport struct {
+	Succeeded int               `json:"Succeeded"`
+	Failed    int               `json:"Failed"`
+	Rows      []ImportRowResult `json:"Rows"`
+}
+
+// importRow is a parsed CSV data row; err is set when the row is malformed
+type importRow struct {
+	line   int
+	msisdn string
+	update UpdateAssetRequest
+	err    error
+}
+
+// importAssetsCSV parses the CSV uploaded as the "file" form field and applies
+// each row with apply, responding with the per-row report
+func importAssetsCSV(c *gin.Context, apply func(asset Asset) error) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	rows, err := parseImportCSV(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, applyImport(c.Request.Context(), rows, apply))
+}
+
+// parseImportCSV validates the header and parses each data row into an update.
+// Malformed rows are returned with err set so they can be reported per row.
+func parseImportCSV(r io.Reader) ([]importRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error reading CSV header: %v", err)
+	}
+	if len(header) != len(importHeader) {
+		return nil, fmt.Errorf("CSV header must be %s", strings.Join(importHeader, ","))
+	}
+	for i, column := range header {
+		if !strings.EqualFold(strings.TrimSpace(column), importHeader[i]) {
+			return nil, fmt.Errorf("CSV header must be %s", strings.Join(importHeader, ","))
+		}
+	}
+
+	var rows []importRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CSV row %d: %v", line, err)
+		}
+
+		row := importRow{line: line}
+		if len(record) != len(importHeader) {
+			row.err = fmt.Errorf("expected %d fields, got %d", len(importHeader), len(record))
+			rows = append(rows, row)
+			continue
+		}
+
+		row.msisdn = strings.TrimSpace(record[0])
+		balance, err := strconv.ParseInt(strings.TrimSpace(record[1]), 10, 64)
+		switch {
+		case row.msisdn == "":
+			row.err = fmt.Errorf("MSISDN is required")
+		case err != nil:
+			row.err = fmt.Errorf("invalid Balance %q", record[1])
+		}
+		row.update = UpdateAssetRequest{
+			Balance:   balance,
+			Status:    strings.TrimSpace(record[2]),
+			TransType: strings.TrimSpace(record[3]),
+			Remarks:   record[4],
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// applyImport applies each valid row with apply, one transaction per row, and
+// reports the outcome of every row. Once ctx is cancelled the remaining rows
+// are reported as failed without being applied.
+func applyImport(ctx context.Context, rows []importRow, apply func(asset Asset) error) *ImportReport {
+	report := &ImportReport{Rows: []ImportRowResult{}}
+	for _, row := range rows {
+		result := ImportRowResult{Row: row.line, MSISDN: row.msisdn}
+
+		err := row.err
+		if err == nil {
+			err = checkCancelled(ctx)
+		}
+		if err == nil {
+			err = apply(row.update.toAsset(row.msisdn))
+		}
+
+		if err != nil {
+			result.Error = err.Error()
+			report.Failed++
+		} else {
+			result.Success = true
+			report.Succeeded++
+		}
+		report.Rows = append(report.Rows, result)
+	}
+
+	return report
+}
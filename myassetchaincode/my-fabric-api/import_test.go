@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// uploadCSV posts csvData as the file form field of an import request
+func uploadCSV(t *testing.T, r *gin.Engine, csvData string) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "adjustments.csv")
+	if err != nil {
+		t.Fatalf("error creating form file: %v", err)
+	}
+	part.Write([]byte(csvData))
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/assets/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestImportAssetsCSV(t *testing.T) {
+	var applied []Asset
+	r := gin.New()
+	r.POST("/assets/import", func(c *gin.Context) {
+		importAssetsCSV(c, func(asset Asset) error {
+			applied = append(applied, asset)
+			return nil
+		})
+	})
+
+	w := uploadCSV(t, r, "MSISDN,Balance,Status,TransType,Remarks\n"+
+		"9811111111,1500,Active,CREDIT,top-up\n"+
+		"9822222222,lots,Active,CREDIT,typo\n")
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status is %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	var report ImportReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("error unmarshalling report: %v", err)
+	}
+
+	if report.Succeeded != 1 || report.Failed != 1 || len(report.Rows) != 2 {
+		t.Fatalf("report is %+v, want one success and one failure", report)
+	}
+	if row := report.Rows[0]; !row.Success || row.Row != 2 || row.MSISDN != "9811111111" {
+		t.Errorf("first row result is %+v, want success for 9811111111 on line 2", row)
+	}
+	if row := report.Rows[1]; row.Success || row.Row != 3 || row.Error == "" {
+		t.Errorf("second row result is %+v, want a failure with an error on line 3", row)
+	}
+	if len(applied) != 1 || applied[0].MSISDN != "9811111111" || applied[0].Balance != 1500 || applied[0].Remarks != "top-up" {
+		t.Errorf("applied %+v, want only the valid row", applied)
+	}
+}
+
+func TestImportAssetsCSVRejectsBadHeader(t *testing.T) {
+	r := gin.New()
+	r.POST("/assets/import", func(c *gin.Context) {
+		importAssetsCSV(c, func(asset Asset) error {
+			t.Errorf("row for %s was applied despite the bad header", asset.MSISDN)
+			return nil
+		})
+	})
+
+	w := uploadCSV(t, r, "Number,Amount\n9811111111,1500\n")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status is %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestParseImportCSVRowShape(t *testing.T) {
+	rows, err := parseImportCSV(bytes.NewBufferString("MSISDN,Balance,Status,TransType,Remarks\n9811111111,1500\n,100,Active,CREDIT,\n"))
+	if err != nil {
+		t.Fatalf("parseImportCSV returned error: %v", err)
+	}
+	if len(rows) != 2 || rows[0].err == nil || rows[1].err == nil {
+		t.Errorf("rows are %+v, want a short row and a row without an MSISDN both rejected", rows)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ledgerGauges exposes the state of the ledger, as reported by GetLedgerStats,
+// alongside the request metrics
+type ledgerGauges struct {
+	assets       prometheus.Gauge
+	totalBalance prometheus.Gauge
+	frozen       prometheus.Gauge
+	refreshed    prometheus.Gauge
+}
+
+// newLedgerGauges creates the ledger gauges and registers them with registerer
+func newLedgerGauges(registerer prometheus.Registerer) *ledgerGauges {
+	g := &ledgerGauges{
+		assets: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "ledger_assets",
+			Help: "Assets in the world state.",
+		}),
+		totalBalance: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "ledger_total_balance",
+			Help: "Sum of the balances of all assets.",
+		}),
+		frozen: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "ledger_frozen_assets",
+			Help: "Assets with status Frozen.",
+		}),
+		refreshed: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "ledger_gauges_last_refresh_timestamp_seconds",
+			Help: "Unix time the ledger gauges were last refreshed successfully.",
+		}),
+	}
+	registerer.MustRegister(g.assets, g.totalBalance, g.frozen, g.refreshed)
+	return g
+}
+
+// refresh sets the gauges from the GetLedgerStats result returned by evaluate
+func (g *ledgerGauges) refresh(evaluate func() ([]byte, error), now time.Time) error {
+	response, err := evaluate()
+	if err != nil {
+		return fmt.Errorf("error reading ledger stats: %v", err)
+	}
+
+	var stats LedgerStats
+	if err := json.Unmarshal(response, &stats); err != nil {
+		return fmt.Errorf("error decoding ledger stats: %v", err)
+	}
+
+	g.assets.Set(float64(stats.AssetCount))
+	g.totalBalance.Set(float64(stats.TotalBalance))
+	g.frozen.Set(float64(stats.FrozenCount))
+	g.refreshed.Set(float64(now.Unix()))
+	return nil
+}
+
+// run refreshes the gauges immediately and then every interval until the
+// returned stop function is called. A failed refresh keeps the previous values.
+func (g *ledgerGauges) run(evaluate func() ([]byte, error), interval time.Duration) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := g.refresh(evaluate, time.Now()); err != nil {
+				fmt.Printf("Failed to refresh ledger gauges: %s\n", err)
+			}
+
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ledgerGaugeValues reads the unlabelled ledger gauges from registry
+func ledgerGaugeValues(t *testing.T, registry *prometheus.Registry) map[string]float64 {
+	t.Helper()
+	values := make(map[string]float64)
+	for _, name := range []string{"ledger_assets", "ledger_total_balance", "ledger_frozen_assets", "ledger_gauges_last_refresh_timestamp_seconds"} {
+		values[name] = metricSamples(t, registry, name)[""]
+	}
+	return values
+}
+
+func TestLedgerGaugesRefresh(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	gauges := newLedgerGauges(registry)
+	stats := LedgerStats{AssetCount: 3, TotalBalance: 1500, FrozenCount: 1, DealerCount: 2}
+	evaluate := func() ([]byte, error) {
+		return json.Marshal(stats)
+	}
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := gauges.refresh(evaluate, now); err != nil {
+		t.Fatalf("refresh returned error: %v", err)
+	}
+	want := map[string]float64{
+		"ledger_assets":                                3,
+		"ledger_total_balance":                         1500,
+		"ledger_frozen_assets":                         1,
+		"ledger_gauges_last_refresh_timestamp_seconds": float64(now.Unix()),
+	}
+	for name, value := range ledgerGaugeValues(t, registry) {
+		if value != want[name] {
+			t.Errorf("%s = %v, want %v", name, value, want[name])
+		}
+	}
+
+	// A failed refresh keeps the values of the last successful one
+	err := gauges.refresh(func() ([]byte, error) {
+		return nil, errors.New("peer unavailable")
+	}, now.Add(time.Minute))
+	if err == nil {
+		t.Error("refresh with a failing evaluate returned no error")
+	}
+	for name, value := range ledgerGaugeValues(t, registry) {
+		if value != want[name] {
+			t.Errorf("%s = %v after a failed refresh, want %v kept", name, value, want[name])
+		}
+	}
+}
+
+func TestLedgerGaugesRunRefreshesImmediately(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	gauges := newLedgerGauges(registry)
+	refreshed := make(chan struct{}, 1)
+	stop := gauges.run(func() ([]byte, error) {
+		select {
+		case refreshed <- struct{}{}:
+		default:
+		}
+		return json.Marshal(LedgerStats{AssetCount: 2, TotalBalance: 700})
+	}, time.Hour)
+	defer stop()
+
+	select {
+	case <-refreshed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the gauges were not refreshed before the first interval")
+	}
+	// The gauges are set once evaluate returns
+	deadline := time.Now().Add(5 * time.Second)
+	for ledgerGaugeValues(t, registry)["ledger_assets"] != 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("ledger_assets was not set from the first refresh")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := ledgerGaugeValues(t, registry)["ledger_total_balance"]; got != 700 {
+		t.Errorf("ledger_total_balance = %v, want 700", got)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// submitLimiter bounds the number of concurrent SubmitTransaction calls.
+// When saturated it either queues callers or rejects them, depending on queue.
+type submitLimiter struct {
+	slots chan struct{}
+	queue bool
+}
+
+// newSubmitLimiter returns a limiter allowing max concurrent submissions,
+// or nil (no limit) when max is not positive
+func newSubmitLimiter(max int, queue bool) *submitLimiter {
+	if max <= 0 {
+		return nil
+	}
+	return &submitLimiter{slots: make(chan struct{}, max), queue: queue}
+}
+
+// acquire reserves a submission slot. It returns false if the limiter is
+// saturated in reject mode, or if done is closed while waiting in queue mode.
+func (l *submitLimiter) acquire(done <-chan struct{}) bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	if !l.queue {
+		return false
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-done:
+		return false
+	}
+}
+
+// release frees a slot reserved by acquire
+func (l *submitLimiter) release() {
+	<-l.slots
+}
+
+// limitSubmissions is a middleware that holds a limiter slot for the duration
+// of the request, answering 429 Too Many Requests when none is available
+func limitSubmissions(l *submitLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if l == nil {
+			c.Next()
+			return
+		}
+
+		if !l.acquire(c.Request.Context().Done()) {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "too many concurrent submissions, please retry later"})
+			return
+		}
+		defer l.release()
+
+		c.Next()
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// saturatedRouter returns a router whose only route holds its limiter slot
+// until release is closed, and a channel signalled once the slot is held
+func saturatedRouter(l *submitLimiter, release <-chan struct{}) (*gin.Engine, <-chan struct{}) {
+	held := make(chan struct{}, 1)
+	r := gin.New()
+	r.POST("/submit", limitSubmissions(l), func(c *gin.Context) {
+		held <- struct{}{}
+		<-release
+		c.Status(http.StatusOK)
+	})
+	return r, held
+}
+
+func TestLimitSubmissionsRejectsWhenSaturated(t *testing.T) {
+	release := make(chan struct{})
+	r, held := saturatedRouter(newSubmitLimiter(1, false), release)
+
+	done := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/submit", nil))
+		done <- w.Code
+	}()
+	<-held
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/submit", nil))
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("overflow request got status %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+
+	close(release)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("first request got status %d, want %d", code, http.StatusOK)
+	}
+}
+
+func TestLimitSubmissionsQueuesWhenSaturated(t *testing.T) {
+	release := make(chan struct{})
+	r, held := saturatedRouter(newSubmitLimiter(1, true), release)
+
+	done := make(chan int, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/submit", nil))
+			done <- w.Code
+		}()
+	}
+	<-held
+
+	select {
+	case <-held:
+		t.Fatal("second request ran while the only slot was held")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		if code := <-done; code != http.StatusOK {
+			t.Errorf("queued request got status %d, want %d", code, http.StatusOK)
+		}
+	}
+}
+
+func TestLimitSubmissionsQueueGivesUpWhenCancelled(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	r, held := saturatedRouter(newSubmitLimiter(1, true), release)
+
+	go r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/submit", nil))
+	<-held
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/submit", nil).WithContext(ctx))
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("cancelled queued request got status %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+}
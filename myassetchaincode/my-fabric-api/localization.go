@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultLanguage is the language error messages are written in
+const defaultLanguage = "en"
+
+// errorCodes pairs a fragment of an error message with the code of the error.
+// The first matching fragment wins.
+var errorCodes = []struct {
+	fragment string
+	code     string
+}{
+	{errUpdateNonexistentAsset, "asset_not_found"},
+	{"does not exist", "asset_not_found"},
+	{errApprovalRequired, "approval_required"},
+	{errBalanceBelowMinimum, "balance_below_minimum"},
+	{errDealerQuotaExceeded, "dealer_quota_exceeded"},
+	{errTransactionAmountExceeded, "transaction_amount_exceeded"},
+	{errBalanceOverflow, "balance_overflow"},
+	{errMPINLocked, "mpin_locked"},
+	{errMPINExpired, "mpin_expired"},
+	{errStatusMismatch, "status_mismatch"},
+	{errAlreadyReversed, "already_reversed"},
+	{errInvalidStatus, "invalid_status"},
+	{errInvalidCategory, "invalid_category"},
+	{errInvalidInterval, "invalid_interval"},
+	{errInvalidQuery, "invalid_query"},
+	{errParentNotFound, "parent_not_found"},
+	{errModifiedSince, "modified_since"},
+	{"asset violates business rules", "business_rule_violation"},
+	{"MVCC_READ_CONFLICT", "concurrent_write"},
+	{"PHANTOM_READ_CONFLICT", "concurrent_write"},
+	{"written by a concurrent request", "concurrent_write"},
+	{"too many concurrent submissions", "too_many_requests"},
+	{"invalid admin token", "invalid_admin_token"},
+	{"admin endpoints are disabled", "admin_disabled"},
+	{readOnlyMessage, "read_only"},
+}
+
+// errorMessages is the catalog of localized messages per error code and
+// language. English responses keep the original, more detailed message.
+var errorMessages = map[string]map[string]string{
+	"asset_not_found": {
+		"fr": "l'actif n'existe pas",
+		"es": "el activo no existe",
+	},
+	"approval_required": {
+		"fr": "la mise à jour doit être approuvée par une deuxième personne",
+		"es": "la actualización requiere la aprobación de una segunda persona",
+	},
+	"balance_below_minimum": {
+		"fr": "le solde passerait sous le minimum",
+		"es": "el saldo quedaría por debajo del mínimo",
+	},
+	"dealer_quota_exceeded": {
+		"fr": "le quota d'actifs du revendeur est atteint",
+		"es": "se ha superado la cuota de activos del distribuidor",
+	},
+	"transaction_amount_exceeded": {
+		"fr": "le montant de la transaction dépasse le maximum",
+		"es": "el importe de la transacción supera el máximo",
+	},
+	"balance_overflow": {
+		"fr": "le solde dépasserait la valeur maximale représentable",
+		"es": "el saldo superaría el valor máximo representable",
+	},
+	"mpin_locked": {
+		"fr": "le MPIN est bloqué après trop de tentatives échouées",
+		"es": "el MPIN está bloqueado tras demasiados intentos fallidos",
+	},
+	"mpin_expired": {
+		"fr": "le MPIN a expiré et doit être changé",
+		"es": "el MPIN ha caducado y debe cambiarse",
+	},
+	"status_mismatch": {
+		"fr": "le statut de l'actif ne correspond pas au statut attendu",
+		"es": "el estado del activo no coincide con el estado esperado",
+	},
+	"invalid_status": {
+		"fr": "statut invalide",
+		"es": "estado no válido",
+	},
+	"invalid_category": {
+		"fr": "catégorie invalide",
+		"es": "categoría no válida",
+	},
+	"already_reversed": {
+		"fr": "la transaction a déjà été annulée",
+		"es": "la transacción ya fue revertida",
+	},
+	"invalid_interval": {
+		"fr": "intervalle invalide",
+		"es": "intervalo no válido",
+	},
+	"invalid_query": {
+		"fr": "requête invalide",
+		"es": "consulta no válida",
+	},
+	"modified_since": {
+		"fr": "l'actif a été modifié après la date If-Unmodified-Since",
+		"es": "el activo se modificó después de la fecha If-Unmodified-Since",
+	},
+	"parent_not_found": {
+		"fr": "actif parent introuvable",
+		"es": "no se encontró el activo padre",
+	},
+	"business_rule_violation": {
+		"fr": "l'actif enfreint les règles métier",
+		"es": "el activo infringe las reglas de negocio",
+	},
+	"concurrent_write": {
+		"fr": "l'actif a été modifié par une requête concurrente, veuillez réessayer",
+		"es": "el activo fue modificado por una solicitud concurrente, vuelva a intentarlo",
+	},
+	"too_many_requests": {
+		"fr": "trop de soumissions simultanées, veuillez réessayer plus tard",
+		"es": "demasiados envíos simultáneos, vuelva a intentarlo más tarde",
+	},
+	"invalid_admin_token": {
+		"fr": "jeton d'administration invalide",
+		"es": "token de administración no válido",
+	},
+	"admin_disabled": {
+		"fr": "les points d'accès d'administration sont désactivés",
+		"es": "los endpoints de administración están deshabilitados",
+	},
+	"read_only": {
+		"fr": "l'API est en lecture seule pour maintenance, veuillez réessayer plus tard",
+		"es": "la API está en modo de solo lectura por mantenimiento, vuelva a intentarlo más tarde",
+	},
+}
+
+// errorCode returns the catalog code of an error message, or "" when it has none
+func errorCode(message string) string {
+	for _, entry := range errorCodes {
+		if strings.Contains(message, entry.fragment) {
+			return entry.code
+		}
+	}
+	return ""
+}
+
+// localizeErrors is a middleware that adds the catalog code to JSON error
+// responses and, when the client prefers a language other than English,
+// replaces the message with its translation and keeps the original as detail.
+// fallback is used when none of the Accept-Language languages is supported.
+func localizeErrors(fallback string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writer := &errorBodyWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		if writer.body.Len() == 0 {
+			return
+		}
+		language := preferredLanguage(c.GetHeader("Accept-Language"), fallback)
+		writer.ResponseWriter.Write(localizeErrorBody(writer.body.Bytes(), language))
+	}
+}
+
+// localizeErrorBody rewrites an error response body for language. Bodies
+// without a known error are returned unchanged.
+func localizeErrorBody(body []byte, language string) []byte {
+	var response map[string]interface{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return body
+	}
+	message, ok := response["error"].(string)
+	if !ok {
+		return body
+	}
+	code := errorCode(message)
+	if code == "" {
+		return body
+	}
+
+	response["code"] = code
+	if localized, ok := errorMessages[code][language]; ok {
+		response["error"] = localized
+		response["detail"] = message
+	}
+
+	localizedBody, err := json.Marshal(response)
+	if err != nil {
+		return body
+	}
+	return localizedBody
+}
+
+// preferredLanguage returns the supported language the Accept-Language header
+// ranks highest, or fallback when it names none
+func preferredLanguage(header, fallback string) string {
+	type weighted struct {
+		language string
+		quality  float64
+	}
+
+	var languages []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		// Only the primary subtag matters, so fr-CA is served French
+		language, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if language == "" {
+			continue
+		}
+
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if q, err := strconv.ParseFloat(value, 64); err == nil {
+				quality = q
+			}
+		}
+		if quality > 0 {
+			languages = append(languages, weighted{language, quality})
+		}
+	}
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].quality > languages[j].quality
+	})
+
+	for _, candidate := range languages {
+		if isSupportedLanguage(candidate.language) {
+			return candidate.language
+		}
+	}
+	return fallback
+}
+
+// isSupportedLanguage reports whether error messages are available in language
+func isSupportedLanguage(language string) bool {
+	if language == defaultLanguage {
+		return true
+	}
+	for _, messages := range errorMessages {
+		if _, ok := messages[language]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// errorBodyWriter holds back the body of error responses so it can be
+// rewritten once the handler is done. Other responses, including streams,
+// are written through.
+type errorBodyWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write buffers the body of an error response
+func (w *errorBodyWriter) Write(data []byte) (int, error) {
+	if w.Status() >= 400 {
+		return w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString buffers the body of an error response
+func (w *errorBodyWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// localizedRequest serves GET /readAsset/:msisdn, which fails for every MSISDN
+// but 9811111111, through localizeErrors and decodes the response body
+func localizedRequest(t *testing.T, msisdn, acceptLanguage string) (int, map[string]string) {
+	t.Helper()
+	r := gin.New()
+	r.Use(localizeErrors(defaultLanguage))
+	r.GET("/readAsset/:msisdn", func(c *gin.Context) {
+		if c.Param("msisdn") != "9811111111" {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "asset with MSISDN " + c.Param("msisdn") + " does not exist"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"MSISDN": "9811111111"})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/readAsset/"+msisdn, nil)
+	if acceptLanguage != "" {
+		req.Header.Set("Accept-Language", acceptLanguage)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var body map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response %q is not a JSON object: %v", w.Body.String(), err)
+	}
+	return w.Code, body
+}
+
+func TestLocalizeErrorsFrench(t *testing.T) {
+	status, body := localizedRequest(t, "9800000000", "fr")
+
+	if status != http.StatusInternalServerError {
+		t.Errorf("status is %d, want it kept at 500", status)
+	}
+	if body["error"] != "l'actif n'existe pas" || body["code"] != "asset_not_found" {
+		t.Errorf("response is %v, want the French asset_not_found message", body)
+	}
+	if body["detail"] != "asset with MSISDN 9800000000 does not exist" {
+		t.Errorf("detail is %q, want the original message", body["detail"])
+	}
+}
+
+func TestLocalizeErrorsLanguageSelection(t *testing.T) {
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{"fr-CA", "l'actif n'existe pas"},
+		{"de, es;q=0.8, fr;q=0.5", "el activo no existe"},
+		{"fr;q=0, es;q=0.1", "el activo no existe"},
+		// Unsupported languages fall back to the English original
+		{"de", "asset with MSISDN 9800000000 does not exist"},
+		{"", "asset with MSISDN 9800000000 does not exist"},
+	}
+
+	for _, tt := range tests {
+		_, body := localizedRequest(t, "9800000000", tt.acceptLanguage)
+		if body["error"] != tt.want || body["code"] != "asset_not_found" {
+			t.Errorf("Accept-Language %q got %v, want error %q with its code", tt.acceptLanguage, body, tt.want)
+		}
+	}
+}
+
+func TestLocalizeErrorsLeavesSuccessAlone(t *testing.T) {
+	status, body := localizedRequest(t, "9811111111", "fr")
+	if status != http.StatusOK || body["MSISDN"] != "9811111111" || len(body) != 1 {
+		t.Errorf("got %d %v, want the asset unchanged", status, body)
+	}
+}
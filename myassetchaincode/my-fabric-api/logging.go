@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// redactedValue replaces the value of every redacted field in logged bodies
+const redactedValue = "[REDACTED]"
+
+// alwaysRedactedFields are masked in logged bodies whatever the configuration
+var alwaysRedactedFields = []string{"MPIN", "NewMPIN"}
+
+// logRequestBodies is a middleware that logs the JSON body of each request
+// with the listed fields masked. Field names match case-insensitively at any
+// depth. Bodies that are not JSON are logged by size only.
+func logRequestBodies(redactFields []string) gin.HandlerFunc {
+	redact := make(map[string]bool)
+	for _, field := range append(alwaysRedactedFields, redactFields...) {
+		redact[strings.ToLower(field)] = true
+	}
+
+	return func(c *gin.Context) {
+		if c.Request.Body == nil {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.Next()
+			return
+		}
+		// Put the body back for the handler
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		if len(body) > 0 {
+			fmt.Printf("%s %s body: %s\n", c.Request.Method, c.Request.URL.Path, sanitizeBody(body, redact))
+		}
+
+		c.Next()
+	}
+}
+
+// sanitizeBody returns a JSON body with the values of redacted fields masked
+func sanitizeBody(body []byte, redact map[string]bool) string {
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return fmt.Sprintf("<%d bytes, not JSON>", len(body))
+	}
+
+	sanitized, err := json.Marshal(redactValue(value, redact))
+	if err != nil {
+		return fmt.Sprintf("<%d bytes>", len(body))
+	}
+	return string(sanitized)
+}
+
+// redactValue masks the redacted fields of the objects within a decoded JSON value
+func redactValue(value interface{}, redact map[string]bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if redact[strings.ToLower(key)] {
+				v[key] = redactedValue
+			} else {
+				v[key] = redactValue(field, redact)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item, redact)
+		}
+	}
+	return value
+}
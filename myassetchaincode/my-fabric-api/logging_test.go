@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// captureStdout returns what fn prints to standard output
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("error creating pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	fn()
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("error reading captured output: %v", err)
+	}
+	return string(out)
+}
+
+func TestLogRequestBodiesRedactsConfiguredFields(t *testing.T) {
+	body := `{"MSISDN":"9876543210","Remarks":"call me on 555-0100","MPIN":"1234","Items":[{"remarks":"secret"}]}`
+	var handled string
+
+	r := gin.New()
+	r.Use(logRequestBodies([]string{"Remarks"}))
+	r.POST("/createAsset", func(c *gin.Context) {
+		received, _ := io.ReadAll(c.Request.Body)
+		handled = string(received)
+	})
+
+	logged := captureStdout(t, func() {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/createAsset", strings.NewReader(body)))
+	})
+
+	if handled != body {
+		t.Errorf("handler received %s, want the original body", handled)
+	}
+
+	prefix := "POST /createAsset body: "
+	if !strings.HasPrefix(logged, prefix) {
+		t.Fatalf("logged %q, want a line starting with %q", logged, prefix)
+	}
+	var sanitized struct {
+		MSISDN  string
+		Remarks string
+		MPIN    string
+		Items   []map[string]string
+	}
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(logged, prefix)), &sanitized); err != nil {
+		t.Fatalf("logged body is not JSON: %v", err)
+	}
+	if sanitized.Remarks != redactedValue || sanitized.MPIN != redactedValue || sanitized.Items[0]["remarks"] != redactedValue {
+		t.Errorf("logged %+v, want Remarks, MPIN and nested remarks redacted", sanitized)
+	}
+	if sanitized.MSISDN != "9876543210" {
+		t.Errorf("logged MSISDN %q, want it left as is", sanitized.MSISDN)
+	}
+	if strings.Contains(logged, "555-0100") || strings.Contains(logged, "1234") {
+		t.Errorf("logged %q leaks a redacted value", logged)
+	}
+}
+
+func TestSanitizeBodyNotJSON(t *testing.T) {
+	if got := sanitizeBody([]byte("MPIN=1234"), map[string]bool{"mpin": true}); got != "<9 bytes, not JSON>" {
+		t.Errorf("sanitizeBody returned %q, want the size only", got)
+	}
+}
@@ -1,44 +1,362 @@
 package main
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
 	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
 	"github.com/golang/protobuf/ptypes"
 	"github.com/swaggo/gin-swagger"
 	"github.com/swaggo/files"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"myassetchaincode/docs"
 )
 
 const (
 	channelName    = "mychannel"
 	contractName   = "myassetchaincode"
 	connectionFile = "connection.yaml"
+	exportPageSize = 100
+)
+
+// Number of assets GET /assets/top and /assets/mostActive return by default
+// and at most, matching the chaincode's bound
+const (
+	defaultTopAssets = 10
+	maxTopAssets     = 100
 )
 
 // Asset describes the structure of an asset
 type Asset struct {
-	DealerID     string    `json:"DealerID"`
-	MSISDN       string    `json:"MSISDN"`
-	MPIN         string    `json:"MPIN"`
-	Balance      int       `json:"Balance"`
-	Status       string    `json:"Status"`
-	TransAmount  int       `json:"TransAmount"`
-	TransType    string    `json:"TransType"`
-	Remarks      string    `json:"Remarks"`
-	Timestamp    time.Time `json:"Timestamp"`
+	DealerID    string            `json:"DealerID" example:"D001"`
+	MSISDN      string            `json:"MSISDN" example:"9876543210"`
+	MPIN        string            `json:"MPIN" example:"5678"`
+	Balance     int64             `json:"Balance" example:"1500"`
+	Status      string            `json:"Status" example:"Active"`
+	TransAmount int64             `json:"TransAmount" example:"500"`
+	TransType   string            `json:"TransType" example:"CREDIT"`
+	Remarks     string            `json:"Remarks" example:"monthly top-up"`
+	Timestamp   time.Time         `json:"Timestamp" example:"2024-01-15T10:30:00Z"`
+	LockedUntil time.Time         `json:"LockedUntil" example:"0001-01-01T00:00:00Z"`
+	Metadata    map[string]string `json:"Metadata"`
+	CreatedAt   time.Time         `json:"CreatedAt" example:"2024-01-01T09:00:00Z"`
+	Flagged     bool              `json:"Flagged" example:"false"`
+	Label       string            `json:"Label" example:"Main street kiosk"`
+
+	// PreviousMSISDN and ReassignedTo link the two keys of an asset moved to a new MSISDN
+	PreviousMSISDN string `json:"PreviousMSISDN"`
+	ReassignedTo   string `json:"ReassignedTo"`
+
+	// FailedAttempts counts consecutive wrong MPINs, which lock verification until MPINLockedUntil
+	FailedAttempts  int       `json:"FailedAttempts" example:"0"`
+	MPINLockedUntil time.Time `json:"MPINLockedUntil" example:"0001-01-01T00:00:00Z"`
+
+	// MPINRotationRequired is set once the MPIN set at MPINSetAt has reached its maximum age
+	MPINSetAt            time.Time `json:"MPINSetAt" example:"2024-01-01T09:00:00Z"`
+	MPINRotationRequired bool      `json:"MPINRotationRequired" example:"false"`
+
+	// ExternalRef is the payment processor reference of the last update, if any
+	ExternalRef string `json:"ExternalRef" example:"PSP-20240115-000123"`
+
+	// Category classifies the last update: fee, topup, purchase or refund
+	Category string `json:"Category" example:"topup"`
+
+	// ParentMSISDN is the master asset this sub-wallet was created under, if any
+	ParentMSISDN string `json:"ParentMSISDN,omitempty" example:"9876543210"`
+
+	// ReversedTxID is the transaction the last update reversed, if it was a reversal
+	ReversedTxID string `json:"ReversedTxID,omitempty" example:"3f2a9c1e8b7d4a6f0e5c2b1a9d8e7f6c5b4a3d2e1f0a9b8c7d6e5f4a3b2c1d0e"`
+
+	// Latitude and Longitude locate the asset on a map; both are omitted when it has no location
+	Latitude  float64 `json:"Latitude,omitempty" example:"28.6139"`
+	Longitude float64 `json:"Longitude,omitempty" example:"77.2090"`
+
+	// SchemaVersion is the version of the chaincode's record layout
+	SchemaVersion int `json:"SchemaVersion" example:"1"`
 }
 
 // AssetHistoryEntry describes an entry in the asset transaction history
 type AssetHistoryEntry struct {
-	TxID      string    `json:"TxID"`
-	Timestamp time.Time `json:"Timestamp"`
+	TxID      string    `json:"TxID" example:"3f1d0c5e9a7b42d8b6e0f4a2c1d9e8b7a6f5e4d3c2b1a09f8e7d6c5b4a3f2e1d"`
+	Timestamp time.Time `json:"Timestamp" example:"2024-01-15T10:30:00Z"`
+
+	// Value is the asset as written by the transaction, without its MPIN. It
+	// is omitted for a delete and for a value the chaincode could not decode,
+	// which ParseError explains.
+	IsDelete   bool   `json:"IsDelete" example:"false"`
+	Value      *Asset `json:"Value,omitempty"`
+	ParseError string `json:"ParseError,omitempty" example:"history value could not be decoded: unexpected end of JSON input"`
+}
+
+// CreateAssetRequest holds the client-settable fields accepted when creating an asset.
+// Server-managed fields such as Timestamp and TransAmount are not bindable.
+type CreateAssetRequest struct {
+	DealerID  string `json:"DealerID" example:"D001"`
+	MSISDN    string `json:"MSISDN" example:"9876543210"`
+	MPIN      string `json:"MPIN" example:"5678"`
+	Balance   int64  `json:"Balance" example:"1500"`
+	Status    string `json:"Status" example:"Active"`
+	TransType string `json:"TransType" example:"CREATE"`
+	Remarks   string `json:"Remarks" example:"new dealer SIM"`
+	Label     string `json:"Label" example:"Main street kiosk"`
+
+	// ParentMSISDN creates the asset as a sub-wallet of an existing master asset
+	ParentMSISDN string `json:"ParentMSISDN,omitempty" example:"9876543210"`
+}
+
+// UpdateAssetRequest holds the client-settable fields accepted when updating an
+// asset. The asset is named by the path; MSISDN is optional and must match it.
+type UpdateAssetRequest struct {
+	MSISDN      string `json:"MSISDN,omitempty" example:"9876543210"`
+	Balance     int64  `json:"Balance" example:"2000"`
+	Status      string `json:"Status" example:"Active"`
+	TransType   string `json:"TransType" example:"CREDIT"`
+	Remarks     string `json:"Remarks" example:"monthly top-up"`
+	ExternalRef string `json:"ExternalRef" example:"PSP-20240115-000123"`
+	Category    string `json:"Category" example:"topup"`
+}
+
+// SetMetadataRequest holds a single metadata key and value to set on an asset
+type SetMetadataRequest struct {
+	Key   string `json:"Key" binding:"required"`
+	Value string `json:"Value"`
+}
+
+// SetLocationRequest holds the coordinates of an asset in decimal degrees; 0, 0 clears them
+type SetLocationRequest struct {
+	Latitude  float64 `json:"Latitude" example:"28.6139"`
+	Longitude float64 `json:"Longitude" example:"77.2090"`
+}
+
+// AdjustBalanceRequest holds a balance delta and the transaction details recorded with it
+type AdjustBalanceRequest struct {
+	Delta       int64  `json:"Delta" binding:"required" example:"100"`
+	TransType   string `json:"TransType" example:"CREDIT"`
+	Remarks     string `json:"Remarks" example:"bonus"`
+	ExternalRef string `json:"ExternalRef" example:"PSP-20240115-000123"`
+	Category    string `json:"Category" example:"topup"`
+}
+
+// ReverseTransactionRequest names the transaction to reverse and the remarks recorded with the reversal
+type ReverseTransactionRequest struct {
+	OriginalTxID string `json:"OriginalTxID" binding:"required" example:"3f2a9c1e8b7d4a6f0e5c2b1a9d8e7f6c5b4a3d2e1f0a9b8c7d6e5f4a3b2c1d0e"`
+	Remarks      string `json:"Remarks" example:"charged twice"`
+}
+
+// Reversal links a reversed transaction to the transaction that reversed it
+type Reversal struct {
+	OriginalTxID string `json:"OriginalTxID" example:"3f2a9c1e8b7d4a6f0e5c2b1a9d8e7f6c5b4a3d2e1f0a9b8c7d6e5f4a3b2c1d0e"`
+	ReversalTxID string `json:"ReversalTxID" example:"9b8c7d6e5f4a3b2c1d0e3f2a9c1e8b7d4a6f0e5c2b1a9d8e7f6c5b4a3d2e1f0a"`
+}
+
+// VerifyMPINRequest holds an MPIN to check against an asset's
+type VerifyMPINRequest struct {
+	MPIN string `json:"MPIN" binding:"required" example:"5678"`
+}
+
+// ChangeMPINRequest holds the current MPIN and its replacement
+type ChangeMPINRequest struct {
+	MPIN    string `json:"MPIN" binding:"required" example:"5678"`
+	NewMPIN string `json:"NewMPIN" binding:"required" example:"8765"`
+}
+
+// DeleteAllRequest holds the confirmation token required to delete every asset
+type DeleteAllRequest struct {
+	Confirm string `json:"Confirm" binding:"required" example:"CONFIRM_DELETE_ALL"`
+}
+
+// ApplyRateRequest holds the percentage and transaction type of a rate applied to all assets
+type ApplyRateRequest struct {
+	RatePercent int    `json:"RatePercent" example:"2"`
+	TransType   string `json:"TransType" binding:"required" example:"INTEREST"`
+}
+
+// BulkFreezeRequest holds the CouchDB rich query selecting the assets to freeze
+type BulkFreezeRequest struct {
+	Query string `json:"Query" binding:"required" example:"{\"selector\":{\"Balance\":{\"$gt\":100000}}}"`
+}
+
+// MergeDuplicatesRequest names two assets of a duplicate cluster by the keys
+// GET /admin/duplicates reported them under
+type MergeDuplicatesRequest struct {
+	Source string `json:"Source" binding:"required" example:"+91 98765 43210"`
+	Target string `json:"Target" binding:"required" example:"9876543210"`
+}
+
+// toAsset maps a create request onto the Asset domain type
+func (r CreateAssetRequest) toAsset() Asset {
+	return Asset{
+		DealerID:     r.DealerID,
+		MSISDN:       r.MSISDN,
+		MPIN:         r.MPIN,
+		Balance:      r.Balance,
+		Status:       r.Status,
+		TransType:    r.TransType,
+		Remarks:      r.Remarks,
+		Label:        r.Label,
+		ParentMSISDN: r.ParentMSISDN,
+	}
+}
+
+// checkMSISDN rejects a body MSISDN naming a different asset than the path MSISDN
+func (r UpdateAssetRequest) checkMSISDN(msisdn string) error {
+	if r.MSISDN != "" && normalizeMSISDN(r.MSISDN) != normalizeMSISDN(msisdn) {
+		return fmt.Errorf("body MSISDN %s does not match path MSISDN %s", r.MSISDN, msisdn)
+	}
+	return nil
+}
+
+// bindUpdateRequest binds the UpdateAssetRequest of a request naming the asset
+// in its msisdn path parameter. It responds 400 and returns false when the
+// body is invalid or names a different asset.
+func bindUpdateRequest(c *gin.Context) (Asset, bool) {
+	var req UpdateAssetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return Asset{}, false
+	}
+
+	msisdn := c.Param("msisdn")
+	if err := req.checkMSISDN(msisdn); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return Asset{}, false
+	}
+	return req.toAsset(msisdn), true
+}
+
+// toAsset maps an update request onto the Asset domain type for the given MSISDN
+func (r UpdateAssetRequest) toAsset(msisdn string) Asset {
+	return Asset{
+		MSISDN:      msisdn,
+		Balance:     r.Balance,
+		Status:      r.Status,
+		TransType:   r.TransType,
+		Remarks:     r.Remarks,
+		ExternalRef: r.ExternalRef,
+		Category:    r.Category,
+	}
+}
+
+// AssetPage is one page of assets returned by a paginated query
+type AssetPage struct {
+	Assets              []*Asset `json:"Assets"`
+	FetchedRecordsCount int32    `json:"FetchedRecordsCount"`
+	Bookmark            string   `json:"Bookmark"`
+}
+
+// LedgerStats is a sanity report over all active assets
+type LedgerStats struct {
+	AssetCount    int    `json:"AssetCount"`
+	TotalBalance  int64  `json:"TotalBalance"`
+	LowestMSISDN  string `json:"LowestMSISDN"`
+	HighestMSISDN string `json:"HighestMSISDN"`
+	DealerCount   int    `json:"DealerCount"`
+	FrozenCount   int    `json:"FrozenCount"`
+}
+
+// AssetActivity is an asset with the number of history entries written for it
+type AssetActivity struct {
+	MSISDN       string `json:"MSISDN" example:"9876543210"`
+	DealerID     string `json:"DealerID" example:"D001"`
+	Transactions int    `json:"Transactions" example:"42"`
+}
+
+// DealerSummary is a dealer with the number and total balance of its active assets
+type DealerSummary struct {
+	DealerID     string `json:"DealerID" example:"D001"`
+	AssetCount   int    `json:"AssetCount" example:"12"`
+	TotalBalance int64  `json:"TotalBalance" example:"18000"`
+}
+
+// TransferCheck is whether a transfer would currently succeed and, if not, why
+type TransferCheck struct {
+	Possible bool   `json:"Possible" example:"false"`
+	Reason   string `json:"Reason,omitempty" example:"insufficient funds: asset with MSISDN 9876543210 has a balance of 100"`
+}
+
+// TransferRequest moves Amount between two assets, charging the sender Fee
+// on top, which is credited to the configured treasury asset
+type TransferRequest struct {
+	From   string `json:"From" binding:"required" example:"9876543210"`
+	To     string `json:"To" binding:"required" example:"1234567890"`
+	Amount int64  `json:"Amount" binding:"required" example:"250"`
+	Fee    int64  `json:"Fee" example:"5"`
+}
+
+// BalancePoint is the balance of an asset as written by one transaction
+type BalancePoint struct {
+	Timestamp time.Time `json:"Timestamp" example:"2024-01-15T10:30:00Z"`
+	Balance   int64     `json:"Balance" example:"1500"`
+}
+
+// HistoryHashChain is the chained SHA-256 hash of each history entry of an
+// asset, oldest first; Root is the last one
+type HistoryHashChain struct {
+	Root   string   `json:"Root" example:"9f2c4e1a7b3d5f6e8a0c2b4d6f8e1a3c5b7d9f0e2a4c6b8d0f1e3a5c7b9d2f4e"`
+	Hashes []string `json:"Hashes"`
+}
+
+// AuditResult compares the balance replayed from an asset's history with its stored balance
+type AuditResult struct {
+	MSISDN            string `json:"MSISDN"`
+	InitialBalance    int64  `json:"InitialBalance"`
+	ComputedBalance   int64  `json:"ComputedBalance"`
+	StoredBalance     int64  `json:"StoredBalance"`
+	Transactions      int    `json:"Transactions"`
+	Consistent        bool   `json:"Consistent"`
+	FirstMismatchTxID string `json:"FirstMismatchTxID,omitempty"`
+}
+
+// DealerUsage reports how many active assets a dealer has against its quota; a Quota of 0 means unlimited
+type DealerUsage struct {
+	DealerID string `json:"DealerID"`
+	Count    int    `json:"Count"`
+	Quota    int    `json:"Quota"`
+}
+
+// Snapshot is every active asset as of CreatedAt
+type Snapshot struct {
+	SnapshotID string    `json:"SnapshotID" example:"2024-01-31-eod"`
+	CreatedAt  time.Time `json:"CreatedAt" example:"2024-01-31T23:59:59Z"`
+	Assets     []*Asset  `json:"Assets"`
+}
+
+// SetDealerQuotaRequest holds the maximum number of active assets of a dealer
+type SetDealerQuotaRequest struct {
+	Quota int `json:"Quota" example:"500"`
+}
+
+// StandingInstruction is a recurring balance adjustment of an asset, applied
+// every Interval (daily, weekly or monthly) starting at NextRun
+type StandingInstruction struct {
+	MSISDN   string    `json:"MSISDN" example:"9876543210"`
+	Amount   int64     `json:"Amount" example:"-50"`
+	Interval string    `json:"Interval" example:"monthly"`
+	NextRun  time.Time `json:"NextRun" example:"2024-02-01T00:00:00Z"`
+}
+
+// SetStandingInstructionRequest holds a recurring adjustment. Amount is
+// negative for a fee; FirstRun defaults to one interval from now.
+type SetStandingInstructionRequest struct {
+	Amount   int64  `json:"Amount" binding:"required" example:"-50"`
+	Interval string `json:"Interval" binding:"required" example:"monthly"`
+	FirstRun string `json:"FirstRun" example:"2024-02-01T00:00:00Z"`
+}
+
+// StandingInstructionReport lists the MSISDNs whose due instructions were
+// applied, and the failed ones as "<msisdn>: <reason>"
+type StandingInstructionReport struct {
+	Applied []string `json:"Applied"`
+	Failed  []string `json:"Failed"`
 }
 
 // SmartContract provides functions for managing an Asset
@@ -52,48 +370,146 @@ type SmartContract struct {
 // @host localhost:8080
 // @BasePath /v1
 func main() {
+	cfg := loadConfig()
 	r := gin.Default()
-
-	// Setup Fabric Gateway
-	gw, err := gateway.Connect(
-		gateway.WithConfig(config.FromFile(connectionFile)),
-		gateway.WithIdentity(&gateway.X509Identity{}),
-	)
+	// Metrics wrap everything else so they see the final, localized response
+	metrics := newAPIMetrics(prometheus.DefaultRegisterer)
+	r.Use(metrics.record())
+	r.Use(withCorrelationID())
+	if cfg.CompressResponses {
+		// Outside localizeErrors so error bodies are rewritten before they are compressed
+		r.Use(compressResponses())
+	}
+	// Registered early so errors from every later middleware are localized too
+	r.Use(localizeErrors(cfg.ErrorLanguage))
+	// RESPONSE_FIELD_CASE=snake or an X-Field-Case header renames response fields for integrators
+	fieldNames, err := documentedFieldNames()
 	if err != nil {
-		fmt.Printf("Failed to connect to gateway: %s\n", err)
+		fmt.Printf("Failed to load response field names: %s\n", err)
 		return
 	}
-	defer gw.Close()
+	r.Use(renameResponseFields(cfg.ResponseFieldCase, fieldNames))
+	r.Use(validateMSISDNParam())
+	// READ_ONLY blocks every write during maintenance while reads keep working
+	r.Use(rejectWrites(cfg.ReadOnly))
+	// Exports may need longer than reads, so ROUTE_TIMEOUTS overrides REQUEST_TIMEOUT per route
+	r.Use(withRouteTimeouts(cfg.RequestTimeout, cfg.RouteTimeouts))
+	if cfg.LogRequestBodies {
+		// MPIN is always masked; REDACT_FIELDS adds fields such as Remarks
+		r.Use(logRequestBodies(cfg.RedactFields))
+	}
+
+	// Setup Fabric Gateway connections per identity, retrying while the peers are still starting
+	contracts := newContractPool(func(identity string) (*gateway.Gateway, time.Time, error) {
+		// Credentials are read on every dial so a renewed certificate is picked up on reconnect
+		wallet, label, expires, err := identityWallet(cfg, identity)
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("failed to load identity %s: %v", identity, err)
+		}
 
-	network, err := gw.GetNetwork(channelName)
+		gw, err := connectWithRetry(func() (*gateway.Gateway, error) {
+			return gateway.Connect(
+				gateway.WithConfig(gatewayConfigProvider(connectionFile, cfg)),
+				gateway.WithIdentity(wallet, label),
+				gatewayOptions(cfg)...,
+			)
+		}, cfg.GatewayConnectAttempts, cfg.GatewayConnectInterval)
+		return gw, expires, err
+	}, cfg.FabricIdentity, cfg.FabricIdentities)
+	defer contracts.close()
+
+	conn, err := contracts.get(cfg.FabricIdentity)
 	if err != nil {
-		fmt.Printf("Failed to get network: %s\n", err)
+		fmt.Printf("Failed to connect to gateway: %s\n", err)
 		return
 	}
 
-	contract := network.GetContract(contractName)
+	// The default identity's contract serves the background event listeners
+	contract := conn.contract
+	systemContract := conn.network.GetContractWithName(contractName, "org.hyperledger.fabric")
+	r.Use(withContract(contracts))
+	submits := newSubmitLimiter(cfg.MaxConcurrentSubmits, cfg.QueueSubmits)
+
+	// Let reads wait for preceding writes to the same asset to commit
+	var commits *commitTracker
+	if cfg.ReadYourWrites {
+		commits = newCommitTracker(cfg.CommitWaitTimeout)
+	}
+
+	// Keep a local projection of asset state warm from chaincode events
+	var projection *assetProjection
+	if cfg.AssetProjection {
+		projection = newAssetProjection()
+		stop, err := projection.listen(contract)
+		if err != nil {
+			fmt.Printf("Failed to start asset projection: %s\n", err)
+			return
+		}
+		defer stop()
+
+		if err := projection.resync(contract); err != nil {
+			fmt.Printf("Failed to resync asset projection: %s\n", err)
+		}
+	}
+
+	// Record committed transaction IDs from block events for exactly-once consumers
+	var committedTxs *committedTxLog
+	if cfg.CommittedTxBuffer > 0 {
+		committedTxs = newCommittedTxLog(cfg.CommittedTxBuffer)
+		stop, err := committedTxs.listen(conn.network, contractName)
+		if err != nil {
+			fmt.Printf("Failed to start block event listener: %s\n", err)
+			return
+		}
+		defer stop()
+	}
+
+	// Expose ledger state gauges on /metrics, refreshed from GetLedgerStats
+	if cfg.LedgerGaugeInterval > 0 {
+		gauges := newLedgerGauges(prometheus.DefaultRegisterer)
+		stop := gauges.run(func() ([]byte, error) {
+			return contract.EvaluateTransaction("GetLedgerStats")
+		}, cfg.LedgerGaugeInterval)
+		defer stop()
+	}
+
+	// Sign asset statements with the server key so recipients can verify them
+	var statements *statementSigner
+	if cfg.StatementSigningKey != "" {
+		statements, err = loadStatementSigner(cfg.StatementSigningKey)
+		if err != nil {
+			fmt.Printf("Failed to load statement signing key: %s\n", err)
+			return
+		}
+	}
 
 	// Create Asset Endpoint
 	// @Summary Create an asset
 	// @Description Create a new asset with the provided details
 	// @Accept json
 	// @Produce json
-	// @Param input body Asset true "Asset details"
-	// @Success 200 {string} string "Asset created successfully"
-	// @Failure 400 {object} string "Bad Request"
-	// @Failure 500 {object} string "Internal Server Error"
+	// @Param input body CreateAssetRequest true "Asset details"
+	// @Success 200 {object} MessageResponse "Asset created successfully"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 403 {object} ErrorResponse "Dealer Quota Exceeded"
+	// @Failure 409 {object} ErrorResponse "Concurrent Create, Retry"
+	// @Failure 429 {object} ErrorResponse "Too Many Requests"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
 	// @Router /createAsset [post]
-	r.POST("/createAsset", func(c *gin.Context) {
-		var asset Asset
-		if err := c.ShouldBindJSON(&asset); err != nil {
+	r.POST("/createAsset", limitSubmissions(submits), func(c *gin.Context) {
+		var req CreateAssetRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		asset := req.toAsset()
 
 		// Invoke Fabric Chaincode
-		_, err := contract.SubmitTransaction("CreateAsset", asset.DealerID, asset.MSISDN, asset.MPIN, asset.Balance, asset.Status, asset.TransType, asset.Remarks)
+		// The MPIN goes in the transient map so it is kept out of the proposal and logs
+		transient := map[string][]byte{"MPIN": []byte(asset.MPIN)}
+		_, err := commits.submitTransient(requestContract(c), asset.MSISDN, "CreateAsset", transient, asset.DealerID, asset.MSISDN, strconv.FormatInt(asset.Balance, 10), asset.Status, asset.TransType, asset.Remarks, asset.Label, asset.ParentMSISDN)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			respondCreateError(c, asset.MSISDN, err)
 			return
 		}
 
@@ -106,14 +522,112 @@ func main() {
 	// @Accept json
 	// @Produce json
 	// @Param msisdn path string true "MSISDN of the asset to update"
-	// @Param input body Asset true "Updated asset details"
-	// @Success 200 {string} string "Asset updated successfully"
-	// @Failure 400 {object} string "Bad Request"
-	// @Failure 500 {object} string "Internal Server Error"
+	// @Param input body UpdateAssetRequest true "Updated asset details"
+	// @Param expectedStatus query string false "Only update if the asset currently has this status"
+	// @Param If-Unmodified-Since header string false "Only update if the asset has not been modified since this HTTP date"
+	// @Success 200 {object} MessageResponse "Asset updated successfully"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 403 {object} ErrorResponse "Approval Required"
+	// @Failure 404 {object} ErrorResponse "Asset Not Found"
+	// @Failure 412 {object} ErrorResponse "Status Does Not Match or Modified Since"
+	// @Failure 429 {object} ErrorResponse "Too Many Requests"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
 	// @Router /updateAsset/{msisdn} [post]
-	r.POST("/updateAsset/:msisdn", func(c *gin.Context) {
-		var asset Asset
-		if err := c.ShouldBindJSON(&asset); err != nil {
+	r.POST("/updateAsset/:msisdn", limitSubmissions(submits), requireUnmodifiedSince(commits), func(c *gin.Context) {
+		asset, ok := bindUpdateRequest(c)
+		if !ok {
+			return
+		}
+
+		// Invoke Fabric Chaincode
+		_, err := commits.submit(requestContract(c), asset.MSISDN, "UpdateAsset", asset.MSISDN, strconv.FormatInt(asset.Balance, 10), asset.Status, asset.TransType, asset.Remarks, c.Query("expectedStatus"), asset.ExternalRef, asset.Category)
+		if err != nil {
+			if violations, ok := ruleViolations(err); ok {
+				c.JSON(http.StatusBadRequest, RuleViolationResponse{Error: "asset violates business rules", Violations: violations})
+				return
+			}
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Asset updated successfully"})
+	})
+
+	// Import Asset Updates Endpoint
+	// @Summary Import balance adjustments from CSV
+	// @Description Apply a CSV of updates (header MSISDN,Balance,Status,TransType,Remarks), one transaction per row, and report the result of each row
+	// @Accept multipart/form-data
+	// @Produce json
+	// @Param file formData file true "CSV file of updates"
+	// @Success 200 {object} ImportReport "Per-row results"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 429 {object} ErrorResponse "Too Many Requests"
+	// @Router /assets/import [post]
+	r.POST("/assets/import", limitSubmissions(submits), func(c *gin.Context) {
+		importAssetsCSV(c, func(asset Asset) error {
+			// Invoke Fabric Chaincode
+			_, err := commits.submit(requestContract(c), asset.MSISDN, "UpdateAsset", asset.MSISDN, strconv.FormatInt(asset.Balance, 10), asset.Status, asset.TransType, asset.Remarks, "", asset.ExternalRef, asset.Category)
+			return err
+		})
+	})
+
+	// Adjust Balance Endpoint
+	// @Summary Adjust an asset balance
+	// @Description Add Delta (negative to deduct) to the current balance in a single transaction
+	// @Accept json
+	// @Produce json
+	// @Param msisdn path string true "MSISDN of the asset to adjust"
+	// @Param input body AdjustBalanceRequest true "Balance delta"
+	// @Param expectedStatus query string false "Only adjust if the asset currently has this status"
+	// @Success 200 {object} MessageResponse "Balance adjusted successfully"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 403 {object} ErrorResponse "Approval Required"
+	// @Failure 404 {object} ErrorResponse "Asset Not Found"
+	// @Failure 412 {object} ErrorResponse "Status Does Not Match"
+	// @Failure 429 {object} ErrorResponse "Too Many Requests"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/{msisdn}/adjust [post]
+	r.POST("/assets/:msisdn/adjust", limitSubmissions(submits), func(c *gin.Context) {
+		var req AdjustBalanceRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		msisdn := c.Param("msisdn")
+
+		// Invoke Fabric Chaincode
+		_, err := commits.submit(requestContract(c), msisdn, "AdjustBalance", msisdn, strconv.FormatInt(req.Delta, 10), req.TransType, req.Remarks, c.Query("expectedStatus"), req.ExternalRef, req.Category)
+		if err != nil {
+			if violations, ok := ruleViolations(err); ok {
+				c.JSON(http.StatusBadRequest, RuleViolationResponse{Error: "asset violates business rules", Violations: violations})
+				return
+			}
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Balance adjusted successfully"})
+	})
+
+	// Reverse Transaction Endpoint
+	// @Summary Reverse a transaction
+	// @Description Apply the inverse of the balance change an earlier transaction made to the asset, recorded with TransType REVERSAL. A transaction can only be reversed once.
+	// @Accept json
+	// @Produce json
+	// @Param msisdn path string true "MSISDN of the asset"
+	// @Param input body ReverseTransactionRequest true "Transaction to reverse"
+	// @Success 200 {object} MessageResponse "Transaction reversed successfully"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 403 {object} ErrorResponse "Approval Required"
+	// @Failure 404 {object} ErrorResponse "Asset Not Found"
+	// @Failure 409 {object} ErrorResponse "Already Reversed"
+	// @Failure 429 {object} ErrorResponse "Too Many Requests"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/{msisdn}/reverse [post]
+	r.POST("/assets/:msisdn/reverse", limitSubmissions(submits), func(c *gin.Context) {
+		var req ReverseTransactionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
@@ -121,78 +635,2003 @@ func main() {
 		msisdn := c.Param("msisdn")
 
 		// Invoke Fabric Chaincode
-		_, err := contract.SubmitTransaction("UpdateAsset", msisdn, asset.Balance, asset.Status, asset.TransType, asset.Remarks)
+		_, err := commits.submit(requestContract(c), msisdn, "ReverseTransaction", msisdn, req.OriginalTxID, req.Remarks)
+		if err != nil {
+			if violations, ok := ruleViolations(err); ok {
+				c.JSON(http.StatusBadRequest, RuleViolationResponse{Error: "asset violates business rules", Violations: violations})
+				return
+			}
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Transaction reversed successfully"})
+	})
+
+	// Get Reversals Endpoint
+	// @Summary List the reversals of an asset
+	// @Description Get the transactions reversed on an asset and the transactions that reversed them
+	// @Produce json
+	// @Param msisdn path string true "MSISDN of the asset"
+	// @Success 200 {array} Reversal "Reversals"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/{msisdn}/reversals [get]
+	r.GET("/assets/:msisdn/reversals", func(c *gin.Context) {
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).EvaluateTransaction("GetReversals", c.Param("msisdn"))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"message": "Asset updated successfully"})
+		var reversals []Reversal
+		if err := json.Unmarshal(response, &reversals); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, reversals)
 	})
 
-	// Read Asset Endpoint
-	// @Summary Read asset details
-	// @Description Get details of an asset by MSISDN
+	// Verify MPIN Endpoint
+	// @Summary Verify an asset MPIN
+	// @Description Check an MPIN against the asset's. After repeated wrong MPINs verification is locked for a while.
+	// @Accept json
 	// @Produce json
-	// @Param msisdn path string true "MSISDN of the asset to get details"
-	// @Success 200 {object} Asset "Asset details"
-	// @Failure 400 {object} string "Bad Request"
-	// @Failure 500 {object} string "Internal Server Error"
-	// @Router /readAsset/{msisdn} [get]
-	r.GET("/readAsset/:msisdn", func(c *gin.Context) {
+	// @Param msisdn path string true "MSISDN of the asset"
+	// @Param input body VerifyMPINRequest true "MPIN to verify"
+	// @Success 200 {object} VerifyMPINResponse "Whether the MPIN matched"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 403 {object} ErrorResponse "MPIN Expired"
+	// @Failure 423 {object} ErrorResponse "MPIN Locked"
+	// @Failure 429 {object} ErrorResponse "Too Many Requests"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/{msisdn}/verifyMPIN [post]
+	r.POST("/assets/:msisdn/verifyMPIN", limitSubmissions(submits), func(c *gin.Context) {
+		var req VerifyMPINRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
 		msisdn := c.Param("msisdn")
 
 		// Invoke Fabric Chaincode
-		response, err := contract.EvaluateTransaction("ReadAsset", msisdn)
+		// The MPIN goes in the transient map so it is kept out of the proposal and logs
+		transient := map[string][]byte{"MPIN": []byte(req.MPIN)}
+		response, err := commits.submitTransient(requestContract(c), msisdn, "VerifyMPIN", transient, msisdn)
 		if err != nil {
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		var verified bool
+		if err := json.Unmarshal(response, &verified); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
-		var asset Asset
-		if err := json.Unmarshal(response, &asset); err != nil {
+		c.JSON(http.StatusOK, VerifyMPINResponse{Verified: verified})
+	})
+
+	// Change MPIN Endpoint
+	// @Summary Change an asset MPIN
+	// @Description Replace the MPIN of an asset, given its current MPIN. Wrong current MPINs count towards the lockout like verification.
+	// @Accept json
+	// @Produce json
+	// @Param msisdn path string true "MSISDN of the asset"
+	// @Param input body ChangeMPINRequest true "Current and new MPIN"
+	// @Success 200 {object} ChangeMPINResponse "Whether the MPIN was changed"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 423 {object} ErrorResponse "MPIN Locked"
+	// @Failure 429 {object} ErrorResponse "Too Many Requests"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/{msisdn}/changeMPIN [post]
+	r.POST("/assets/:msisdn/changeMPIN", limitSubmissions(submits), func(c *gin.Context) {
+		var req ChangeMPINRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		msisdn := c.Param("msisdn")
+
+		// Invoke Fabric Chaincode
+		// Both MPINs go in the transient map so they are kept out of the proposal and logs
+		transient := map[string][]byte{"MPIN": []byte(req.MPIN), "NEW_MPIN": []byte(req.NewMPIN)}
+		response, err := commits.submitTransient(requestContract(c), msisdn, "ChangeMPIN", transient, msisdn)
+		if err != nil {
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		var changed bool
+		if err := json.Unmarshal(response, &changed); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
-		c.JSON(http.StatusOK, asset)
+		c.JSON(http.StatusOK, ChangeMPINResponse{Changed: changed})
 	})
 
-	// Get Asset History Endpoint
-	// @Summary Get asset history
-	// @Description Get transaction history of an asset by MSISDN
+	// Get MPIN Lock Endpoint
+	// @Summary Get whether an asset MPIN is locked
+	// @Description Report whether MPIN verification is locked after repeated wrong MPINs
 	// @Produce json
-	// @Param msisdn path string true "MSISDN of the asset to get history"
-	// @Success 200 {array} AssetHistoryEntry "Transaction history"
-	// @Failure 400 {object} string "Bad Request"
-	// @Failure 500 {object} string "Internal Server Error"
-	// @Router /getAssetHistory/{msisdn} [get]
-	r.GET("/getAssetHistory/:msisdn", func(c *gin.Context) {
+	// @Param msisdn path string true "MSISDN of the asset"
+	// @Success 200 {object} MPINLockResponse "Whether the MPIN is locked"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/{msisdn}/mpinLocked [get]
+	r.GET("/assets/:msisdn/mpinLocked", func(c *gin.Context) {
 		msisdn := c.Param("msisdn")
 
 		// Invoke Fabric Chaincode
-		response, err := contract.EvaluateTransaction("GetAssetHistory", msisdn)
+		response, err := requestContract(c).EvaluateTransaction("IsMPINLocked", msisdn)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
-		var historyRes []*AssetHistoryEntry
-		if err := json.Unmarshal(response, &historyRes); err != nil {
+		var locked bool
+		if err := json.Unmarshal(response, &locked); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
-		c.JSON(http.StatusOK, historyRes)
+		c.JSON(http.StatusOK, MPINLockResponse{Locked: locked})
 	})
 
-	// Swagger documentation routes
-	// @router /swagger/*any [get]
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	// Execute Batch Endpoint
+	// @Summary Execute operations atomically
+	// @Description Apply an ordered list of create, update and transfer operations in one transaction; if any operation fails none of them are applied and the failed operations are listed by 1-based index
+	// @Accept json
+	// @Produce json
+	// @Param input body []BatchOperation true "Operations in the order to apply them"
+	// @Success 200 {object} MessageResponse "Batch executed successfully"
+	// @Failure 400 {object} BatchErrorResponse "Invalid or Failed Operations"
+	// @Failure 403 {object} ErrorResponse "Approval Required"
+	// @Failure 404 {object} ErrorResponse "Asset Not Found"
+	// @Failure 429 {object} ErrorResponse "Too Many Requests"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /transactions/batch [post]
+	r.POST("/transactions/batch", limitSubmissions(submits), func(c *gin.Context) {
+		var ops []BatchOperation
+		if err := c.ShouldBindJSON(&ops); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if len(ops) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "batch must contain at least one operation"})
+			return
+		}
 
-	// Run the REST API
-	err = r.Run(":8080")
-	if err != nil {
-		fmt.Printf("Failed to start REST API: %s\n", err)
+		opsJSON, transient, invalid, err := buildBatch(ops)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if len(invalid) > 0 {
+			c.JSON(http.StatusBadRequest, BatchErrorResponse{Error: "batch failed", Items: invalid})
+			return
+		}
+
+		// Invoke Fabric Chaincode
+		contract := requestContract(c)
+		txn, err := contract.CreateTransaction("ExecuteBatch", contract.withTransient(transient))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if _, err := txn.Submit(opsJSON); err != nil {
+			if items, ok := batchItemErrors(err); ok {
+				// A failed operation is the client's to fix unless it maps to a more specific status
+				status := statusForError(err)
+				if status == http.StatusInternalServerError {
+					status = http.StatusBadRequest
+				}
+				c.JSON(status, BatchErrorResponse{Error: "batch failed", Items: items})
+				return
+			}
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Batch executed successfully"})
+	})
+
+	// Request Update Endpoint
+	// @Summary Request a high-value update
+	// @Description Store an update for approval by a second identity and return its request ID
+	// @Accept json
+	// @Produce json
+	// @Param msisdn path string true "MSISDN of the asset to update"
+	// @Param input body UpdateAssetRequest true "Updated asset details"
+	// @Success 200 {object} RequestIDResponse "Request ID"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 404 {object} ErrorResponse "Asset Not Found"
+	// @Failure 429 {object} ErrorResponse "Too Many Requests"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /requestUpdate/{msisdn} [post]
+	r.POST("/requestUpdate/:msisdn", limitSubmissions(submits), func(c *gin.Context) {
+		asset, ok := bindUpdateRequest(c)
+		if !ok {
+			return
+		}
+
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).SubmitTransaction("RequestUpdate", asset.MSISDN, strconv.FormatInt(asset.Balance, 10), asset.Status, asset.TransType, asset.Remarks, asset.ExternalRef, asset.Category)
+		if err != nil {
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, RequestIDResponse{RequestID: string(response)})
+	})
+
+	// Approve Update Endpoint
+	// @Summary Approve a pending update
+	// @Description Apply a pending update; the approving identity must differ from the requester
+	// @Produce json
+	// @Param requestID path string true "ID of the pending update"
+	// @Success 200 {object} MessageResponse "Update approved successfully"
+	// @Failure 403 {object} ErrorResponse "Self-approval is not allowed"
+	// @Failure 429 {object} ErrorResponse "Too Many Requests"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /approveUpdate/{requestID} [post]
+	r.POST("/approveUpdate/:requestID", limitSubmissions(submits), func(c *gin.Context) {
+		requestID := c.Param("requestID")
+
+		// Invoke Fabric Chaincode
+		_, err := requestContract(c).SubmitTransaction("ApproveUpdate", requestID)
+		if err != nil {
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Update approved successfully"})
+	})
+
+	// Read Asset Endpoint
+	// @Summary Read asset details
+	// @Description Get details of an asset by MSISDN
+	// @Produce json
+	// @Param msisdn path string true "MSISDN of the asset to get details"
+	// @Param fields query string false "Comma-separated list of fields to return, e.g. Balance,Status"
+	// @Param If-None-Match header string false "ETag of a previously returned representation"
+	// @Success 200 {object} Asset "Asset details"
+	// @Success 304 "Not Modified"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Failure 504 {object} ErrorResponse "Last write not yet committed"
+	// @Router /readAsset/{msisdn} [get]
+	r.GET("/readAsset/:msisdn", func(c *gin.Context) {
+		msisdn := c.Param("msisdn")
+
+		fields, err := parseFields(c.Query("fields"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := commits.wait(msisdn); err != nil {
+			c.JSON(http.StatusGatewayTimeout, gin.H{"error": err.Error()})
+			return
+		}
+
+		asset, err := readAsset(requestContract(c), projection, msisdn)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var body interface{} = asset
+		if len(fields) > 0 {
+			body = projectAsset(asset, fields)
+		}
+
+		if !asset.Timestamp.IsZero() {
+			// Clients echo this in If-Unmodified-Since to guard their next update
+			c.Header("Last-Modified", asset.Timestamp.UTC().Format(http.TimeFormat))
+		}
+
+		// Let polling clients skip the body when the asset has not changed
+		respondWithETag(c, body)
+	})
+
+	// Get Asset History Endpoint
+	// @Summary Get asset history
+	// @Description Get transaction history of an asset by MSISDN with the value each transaction wrote; an entry whose value cannot be decoded carries a ParseError instead
+	// @Produce json
+	// @Param msisdn path string true "MSISDN of the asset to get history"
+	// @Success 200 {array} AssetHistoryEntry "Transaction history"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 413 {object} ErrorResponse "History Too Large"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /getAssetHistory/{msisdn} [get]
+	r.GET("/getAssetHistory/:msisdn", func(c *gin.Context) {
+		msisdn := c.Param("msisdn")
+
+		// Refuse histories too large to build in one response; 0 disables the check
+		if cfg.MaxHistoryDepth > 0 {
+			response, err := requestContract(c).EvaluateTransaction("GetHistoryCount", msisdn)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+
+			var count int
+			if err := json.Unmarshal(response, &count); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if count > cfg.MaxHistoryDepth {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("history of %s has %d entries, more than the limit of %d", msisdn, count, cfg.MaxHistoryDepth)})
+				return
+			}
+		}
+
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).EvaluateTransaction("GetAssetHistory", msisdn)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var historyRes []*AssetHistoryEntry
+		if err := json.Unmarshal(response, &historyRes); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, historyRes)
+	})
+
+	// Asset Statement Endpoint
+	// @Summary Get a signed asset statement
+	// @Description Get the transaction history and balance of an asset as a statement signed with the server key
+	// @Produce json
+	// @Param msisdn path string true "MSISDN of the asset"
+	// @Success 200 {object} SignedStatement "Signed statement"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 404 {object} ErrorResponse "Statement signing disabled"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/{msisdn}/statement [get]
+	r.GET("/assets/:msisdn/statement", func(c *gin.Context) {
+		if statements == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "statement signing is disabled"})
+			return
+		}
+
+		statement, err := buildStatement(requestContract(c), c.Param("msisdn"), time.Now().UTC())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		signed, err := statements.sign(*statement)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, signed)
+	})
+
+	// Asset Bundle Endpoint
+	// @Summary Export a signed asset bundle
+	// @Description Get the current state of an asset and its complete history, read in one transaction, signed with the server's statement key for dispute resolution
+	// @Produce json
+	// @Param msisdn path string true "MSISDN of the asset"
+	// @Success 200 {object} SignedBundle "Signed bundle"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 404 {object} ErrorResponse "Statement signing disabled"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/{msisdn}/bundle [get]
+	r.GET("/assets/:msisdn/bundle", func(c *gin.Context) {
+		if statements == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "statement signing is disabled"})
+			return
+		}
+
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).EvaluateTransaction("ExportAssetBundle", c.Param("msisdn"))
+		if err != nil {
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		var bundle AssetBundle
+		if err := json.Unmarshal(response, &bundle); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		signed, err := statements.signBundle(bundle)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, signed)
+	})
+
+	// Audit Asset Endpoint
+	// @Summary Audit an asset balance
+	// @Description Replay the transaction amounts in the asset history and compare the result with the stored balance
+	// @Produce json
+	// @Param msisdn path string true "MSISDN of the asset to audit"
+	// @Success 200 {object} AuditResult "Audit result"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/{msisdn}/audit [get]
+	r.GET("/assets/:msisdn/audit", func(c *gin.Context) {
+		msisdn := c.Param("msisdn")
+
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).EvaluateTransaction("AuditAsset", msisdn)
+		if err != nil {
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		var result AuditResult
+		if err := json.Unmarshal(response, &result); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	})
+
+	// Balance Series Endpoint
+	// @Summary Get an asset's balance over time
+	// @Description Get the balance after each transaction in the asset history, oldest first, for charting
+	// @Produce json
+	// @Param msisdn path string true "MSISDN of the asset"
+	// @Success 200 {array} BalancePoint "Balance series"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/{msisdn}/balanceSeries [get]
+	r.GET("/assets/:msisdn/balanceSeries", func(c *gin.Context) {
+		msisdn := c.Param("msisdn")
+
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).EvaluateTransaction("GetBalanceSeries", msisdn)
+		if err != nil {
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		var series []BalancePoint
+		if err := json.Unmarshal(response, &series); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, series)
+	})
+
+	// History Hash Chain Endpoint
+	// @Summary Get the hash chain of an asset's history
+	// @Description Get a SHA-256 hash per history entry, each chained with the previous one, and the final Root. Store Root and compare it later to detect a changed history.
+	// @Produce json
+	// @Param msisdn path string true "MSISDN of the asset"
+	// @Success 200 {object} HistoryHashChain "Hash chain"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/{msisdn}/hashChain [get]
+	r.GET("/assets/:msisdn/hashChain", func(c *gin.Context) {
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).EvaluateTransaction("GetHistoryHashChain", c.Param("msisdn"))
+		if err != nil {
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		var chain HistoryHashChain
+		if err := json.Unmarshal(response, &chain); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, chain)
+	})
+
+	// Transactions By Category Endpoint
+	// @Summary Get an asset's transactions in a category
+	// @Description Get the history entries of an asset whose transaction was recorded with the given category, oldest first
+	// @Produce json
+	// @Param msisdn path string true "MSISDN of the asset"
+	// @Param category query string true "Category: fee, topup, purchase or refund"
+	// @Success 200 {array} AssetHistoryEntry "Matching transactions"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/{msisdn}/transactions [get]
+	r.GET("/assets/:msisdn/transactions", func(c *gin.Context) {
+		msisdn := c.Param("msisdn")
+		category := c.Query("category")
+		if category == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "category is required"})
+			return
+		}
+
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).EvaluateTransaction("GetTransactionsByCategory", msisdn, category)
+		if err != nil {
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		var transactions []*AssetHistoryEntry
+		if err := json.Unmarshal(response, &transactions); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, transactions)
+	})
+
+	// Diff Update Endpoint
+	// @Summary Preview an update
+	// @Description Compare proposed values of the fields UpdateAsset can change, such as Balance and Status, with the current state and return each changed field as [old, new], without applying anything. Other fields are rejected.
+	// @Accept json
+	// @Produce json
+	// @Param msisdn path string true "MSISDN of the asset"
+	// @Param input body object true "Proposed asset fields, e.g. Balance and Status"
+	// @Success 200 {object} map[string][]interface{} "Changed fields"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/{msisdn}/diff [post]
+	r.POST("/assets/:msisdn/diff", func(c *gin.Context) {
+		proposed, err := c.GetRawData()
+		if err != nil || !json.Valid(proposed) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "body must be a JSON object of asset fields"})
+			return
+		}
+
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).EvaluateTransaction("DiffUpdate", c.Param("msisdn"), string(proposed))
+		if err != nil {
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		// The chaincode returns each value JSON encoded
+		var encoded map[string][2]string
+		if err := json.Unmarshal(response, &encoded); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		diff := make(map[string][2]json.RawMessage, len(encoded))
+		for field, values := range encoded {
+			diff[field] = [2]json.RawMessage{json.RawMessage(values[0]), json.RawMessage(values[1])}
+		}
+
+		c.JSON(http.StatusOK, diff)
+	})
+
+	// Reconcile Asset Histories Endpoint
+	// @Summary Reconcile two asset histories
+	// @Description Compare the transaction histories of two assets by TxID and timestamp
+	// @Produce json
+	// @Param a query string true "MSISDN of the first asset"
+	// @Param b query string true "MSISDN of the second asset"
+	// @Success 200 {object} HistoryDiff "History diff"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/reconcile [get]
+	r.GET("/assets/reconcile", func(c *gin.Context) {
+		a, b := c.Query("a"), c.Query("b")
+		if a == "" || b == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "both a and b MSISDNs are required"})
+			return
+		}
+
+		historyA, err := getAssetHistory(requestContract(c), a)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		historyB, err := getAssetHistory(requestContract(c), b)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, diffHistories(a, b, historyA, historyB))
+	})
+
+	// Delete Asset Endpoint
+	// @Summary Delete an asset
+	// @Description Hard-delete an asset, optionally purging its private data from a collection. Public state history is kept.
+	// @Produce json
+	// @Param msisdn path string true "MSISDN of the asset to delete"
+	// @Param purgeCollection query string false "Private data collection to purge the asset from"
+	// @Success 200 {object} MessageResponse "Asset deleted successfully"
+	// @Failure 429 {object} ErrorResponse "Too Many Requests"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/{msisdn} [delete]
+	r.DELETE("/assets/:msisdn", limitSubmissions(submits), func(c *gin.Context) {
+		msisdn := c.Param("msisdn")
+
+		// Invoke Fabric Chaincode
+		_, err := commits.submit(requestContract(c), msisdn, "DeleteAsset", msisdn, c.Query("purgeCollection"))
+		if err != nil {
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Asset deleted successfully"})
+	})
+
+	// Set Asset Metadata Endpoint
+	// @Summary Set asset metadata
+	// @Description Set a metadata key on an asset; an empty value removes the key
+	// @Accept json
+	// @Produce json
+	// @Param msisdn path string true "MSISDN of the asset"
+	// @Param input body SetMetadataRequest true "Metadata key and value"
+	// @Success 200 {object} MessageResponse "Asset metadata updated successfully"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 429 {object} ErrorResponse "Too Many Requests"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/{msisdn}/metadata [post]
+	r.POST("/assets/:msisdn/metadata", limitSubmissions(submits), func(c *gin.Context) {
+		var req SetMetadataRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		msisdn := c.Param("msisdn")
+
+		// Invoke Fabric Chaincode
+		_, err := requestContract(c).SubmitTransaction("SetAssetMetadata", msisdn, req.Key, req.Value)
+		if err != nil {
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Asset metadata updated successfully"})
+	})
+
+	// Set Asset Location Endpoint
+	// @Summary Set asset location
+	// @Description Set the coordinates of an asset for showing it on a map; 0, 0 clears them
+	// @Accept json
+	// @Produce json
+	// @Param msisdn path string true "MSISDN of the asset"
+	// @Param input body SetLocationRequest true "Latitude and longitude"
+	// @Success 200 {object} MessageResponse "Asset location updated successfully"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 429 {object} ErrorResponse "Too Many Requests"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/{msisdn}/location [put]
+	r.PUT("/assets/:msisdn/location", limitSubmissions(submits), func(c *gin.Context) {
+		var req SetLocationRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.Latitude < -90 || req.Latitude > 90 || req.Longitude < -180 || req.Longitude > 180 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Latitude must be between -90 and 90 and Longitude between -180 and 180"})
+			return
+		}
+
+		msisdn := c.Param("msisdn")
+
+		// Invoke Fabric Chaincode
+		_, err := requestContract(c).SubmitTransaction("SetAssetLocation", msisdn, strconv.FormatFloat(req.Latitude, 'f', -1, 64), strconv.FormatFloat(req.Longitude, 'f', -1, 64))
+		if err != nil {
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Asset location updated successfully"})
+	})
+
+	// Get Asset Metadata Endpoint
+	// @Summary Get asset metadata
+	// @Description Get the metadata key-values attached to an asset
+	// @Produce json
+	// @Param msisdn path string true "MSISDN of the asset"
+	// @Success 200 {object} map[string]string "Asset metadata"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/{msisdn}/metadata [get]
+	r.GET("/assets/:msisdn/metadata", func(c *gin.Context) {
+		msisdn := c.Param("msisdn")
+
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).EvaluateTransaction("GetAssetMetadata", msisdn)
+		if err != nil {
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		var metadata map[string]string
+		if err := json.Unmarshal(response, &metadata); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, metadata)
+	})
+
+	// List Assets Endpoint
+	// @Summary List assets
+	// @Description List all assets, or only those whose Label contains q (case-insensitive; requires CouchDB).
+	// @Description Passing sort, order, pageSize or bookmark returns a sorted AssetPage instead of an array.
+	// @Produce json
+	// @Param q query string false "Label substring to search for"
+	// @Param sort query string false "Sort field: Balance, MSISDN or Status" default(MSISDN)
+	// @Param order query string false "Sort order: asc or desc" default(asc)
+	// @Param pageSize query int false "Assets per page" default(100)
+	// @Param bookmark query string false "Bookmark returned with the previous page"
+	// @Success 200 {array} Asset "Assets"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets [get]
+	r.GET("/assets", func(c *gin.Context) {
+		if isPagedListQuery(c) {
+			pageSize, err := strconv.Atoi(c.DefaultQuery("pageSize", strconv.Itoa(exportPageSize)))
+			if err != nil || pageSize <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "pageSize must be a positive integer"})
+				return
+			}
+			sortField, order := c.DefaultQuery("sort", "MSISDN"), c.DefaultQuery("order", "asc")
+			if !sortableFields[sortField] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be one of Balance, MSISDN, Status"})
+				return
+			}
+			if order != "asc" && order != "desc" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "order must be asc or desc"})
+				return
+			}
+
+			// Invoke Fabric Chaincode
+			response, err := requestContract(c).EvaluateTransaction("QueryAssetsPage", c.Query("q"), sortField, order, strconv.Itoa(pageSize), c.Query("bookmark"))
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+
+			var page AssetPage
+			if err := json.Unmarshal(response, &page); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+
+			c.JSON(http.StatusOK, page)
+			return
+		}
+
+		// Skip the full scan for a client that has already gone away
+		if err := checkCancelled(c.Request.Context()); err != nil {
+			c.JSON(cancelledStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		// Invoke Fabric Chaincode
+		var response []byte
+		var err error
+		if q := c.Query("q"); q != "" {
+			response, err = requestContract(c).EvaluateTransaction("SearchAssetsByLabel", q)
+		} else {
+			response, err = requestContract(c).EvaluateTransaction("GetAllAssets")
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var assets []*Asset
+		if err := json.Unmarshal(response, &assets); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, assets)
+	})
+
+	// Get Total Balance Endpoint
+	// @Summary Get total balance
+	// @Description Get the sum of the balances of all assets
+	// @Produce json
+	// @Success 200 {object} TotalBalanceResponse "Total balance"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/totalBalance [get]
+	r.GET("/assets/totalBalance", func(c *gin.Context) {
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).EvaluateTransaction("GetTotalBalance")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var total int64
+		if err := json.Unmarshal(response, &total); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, TotalBalanceResponse{TotalBalance: total})
+	})
+
+	// Get Average Balance Endpoint
+	// @Summary Get average balance
+	// @Description Get the mean balance of the assets with the given status, or of all assets; 0 when none match
+	// @Produce json
+	// @Param status query string false "Only include assets with this status (Active, Frozen, Suspended or Deleted)"
+	// @Success 200 {object} AverageBalanceResponse "Average balance"
+	// @Failure 400 {object} ErrorResponse "Invalid Status"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/averageBalance [get]
+	r.GET("/assets/averageBalance", func(c *gin.Context) {
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).EvaluateTransaction("GetAverageBalance", c.Query("status"))
+		if err != nil {
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		var average float64
+		if err := json.Unmarshal(response, &average); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, AverageBalanceResponse{AverageBalance: average})
+	})
+
+	// Get Balance Histogram Endpoint
+	// @Summary Get a balance histogram
+	// @Description Count the assets in each balance range of the given size, keyed by "<low>-<high>" with both bounds included. Empty ranges are left out.
+	// @Produce json
+	// @Param bucket query int true "Size of each balance range"
+	// @Success 200 {object} map[string]int "Assets per balance range"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/balanceHistogram [get]
+	r.GET("/assets/balanceHistogram", func(c *gin.Context) {
+		bucket, err := strconv.Atoi(c.Query("bucket"))
+		if err != nil || bucket <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bucket must be a positive integer"})
+			return
+		}
+
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).EvaluateTransaction("GetBalanceHistogram", strconv.Itoa(bucket))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var histogram map[string]int
+		if err := json.Unmarshal(response, &histogram); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, histogram)
+	})
+
+	// Get Assets Created Between Endpoint
+	// @Summary Get assets created within a date range
+	// @Description Get the assets created at or after from and before to
+	// @Produce json
+	// @Param from query string true "Start of the range (RFC 3339)"
+	// @Param to query string true "End of the range, exclusive (RFC 3339)"
+	// @Success 200 {array} Asset "Assets created in the range"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/createdBetween [get]
+	r.GET("/assets/createdBetween", func(c *gin.Context) {
+		from, to := c.Query("from"), c.Query("to")
+		if _, err := time.Parse(time.RFC3339, from); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be an RFC 3339 timestamp"})
+			return
+		}
+		if _, err := time.Parse(time.RFC3339, to); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be an RFC 3339 timestamp"})
+			return
+		}
+
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).EvaluateTransaction("GetAssetsCreatedBetween", from, to)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var assets []*Asset
+		if err := json.Unmarshal(response, &assets); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, assets)
+	})
+
+	// Stream Asset Events Endpoint
+	// @Summary Stream asset changes
+	// @Description Stream asset creates, updates, reassignments, archives, restores and deletes as Server-Sent Events named after the chaincode event, with the asset as data. A transaction that changes several assets sends a single AssetsChanged event listing their MSISDNs instead. Heartbeat comments are sent while idle.
+	// @Produce text/event-stream
+	// @Success 200 {object} Asset "Stream of asset events"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/stream [get]
+	r.GET("/assets/stream", func(c *gin.Context) {
+		streamAssetEvents(c, requestContract(c), cfg.StreamHeartbeat)
+	})
+
+	// Get Assets Needing Attention Endpoint
+	// @Summary Get assets needing attention
+	// @Description Get the assets that are flagged, frozen with a nonzero balance, or below the minimum balance
+	// @Produce json
+	// @Success 200 {array} Asset "Assets needing attention"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/attention [get]
+	r.GET("/assets/attention", func(c *gin.Context) {
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).EvaluateTransaction("GetAssetsNeedingAttention")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var assets []*Asset
+		if err := json.Unmarshal(response, &assets); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, assets)
+	})
+
+	// Get Assets By External Reference Endpoint
+	// @Summary Find assets by external reference
+	// @Description Get the assets whose last update carried the given external payment reference (requires CouchDB)
+	// @Produce json
+	// @Param ref path string true "External reference"
+	// @Success 200 {array} Asset "Matching assets"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/byExternalRef/{ref} [get]
+	r.GET("/assets/byExternalRef/:ref", func(c *gin.Context) {
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).EvaluateTransaction("GetAssetsByExternalRef", c.Param("ref"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var assets []*Asset
+		if err := json.Unmarshal(response, &assets); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, assets)
+	})
+
+	// Search Assets By MSISDN Prefix Endpoint
+	// @Summary Find assets by MSISDN prefix
+	// @Description Get the assets whose normalized MSISDN starts with the given digits, in MSISDN order
+	// @Produce json
+	// @Param prefix path string true "Leading digits of the MSISDN, without country code"
+	// @Success 200 {array} Asset "Matching assets"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/search/byPrefix/{prefix} [get]
+	r.GET("/assets/search/byPrefix/:prefix", func(c *gin.Context) {
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).EvaluateTransaction("SearchByMSISDNPrefix", c.Param("prefix"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var assets []*Asset
+		if err := json.Unmarshal(response, &assets); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, assets)
+	})
+
+	// Get Assets GeoJSON Endpoint
+	// @Summary Get asset locations as GeoJSON
+	// @Description Get a GeoJSON FeatureCollection with a Point feature for every asset that has a location; assets without one are skipped
+	// @Produce json
+	// @Success 200 {object} FeatureCollection "Located assets"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/geojson [get]
+	r.GET("/assets/geojson", func(c *gin.Context) {
+		collection, err := locatedAssets(requestContract(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, collection)
+	})
+
+	// Get Top Assets Endpoint
+	// @Summary Get the top assets by balance
+	// @Description Get the n assets with the highest balances, highest first
+	// @Produce json
+	// @Param n query int false "Number of assets, 1 to 100 (default 10)"
+	// @Success 200 {array} Asset "Top assets"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/top [get]
+	r.GET("/assets/top", func(c *gin.Context) {
+		n, err := strconv.Atoi(c.DefaultQuery("n", strconv.Itoa(defaultTopAssets)))
+		if err != nil || n <= 0 || n > maxTopAssets {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("n must be an integer between 1 and %d", maxTopAssets)})
+			return
+		}
+
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).EvaluateTransaction("GetTopAssetsByBalance", strconv.Itoa(n))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var assets []*Asset
+		if err := json.Unmarshal(response, &assets); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, assets)
+	})
+
+	// Get Most Active Assets Endpoint
+	// @Summary Get the most active assets
+	// @Description Get the n assets with the most transactions in their history, most first. Reads the history of every asset, so it is expensive on large ledgers.
+	// @Produce json
+	// @Param n query int false "Number of assets, 1 to 100 (default 10)"
+	// @Success 200 {array} AssetActivity "Most active assets"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/mostActive [get]
+	r.GET("/assets/mostActive", func(c *gin.Context) {
+		n, err := strconv.Atoi(c.DefaultQuery("n", strconv.Itoa(defaultTopAssets)))
+		if err != nil || n <= 0 || n > maxTopAssets {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("n must be an integer between 1 and %d", maxTopAssets)})
+			return
+		}
+
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).EvaluateTransaction("GetMostActiveAssets", strconv.Itoa(n))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var activity []AssetActivity
+		if err := json.Unmarshal(response, &activity); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, activity)
+	})
+
+	// Check Transfer Endpoint
+	// @Summary Check whether a transfer is possible
+	// @Description Report whether moving amount between two assets would succeed, and why not, without performing it
+	// @Produce json
+	// @Param from query string true "MSISDN of the source asset"
+	// @Param to query string true "MSISDN of the destination asset"
+	// @Param amount query int true "Amount to transfer"
+	// @Success 200 {object} TransferCheck "Transfer feasibility"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /transfers/check [get]
+	r.GET("/transfers/check", func(c *gin.Context) {
+		from, to := c.Query("from"), c.Query("to")
+		if from == "" || to == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from and to are required"})
+			return
+		}
+		amount, err := strconv.ParseInt(c.Query("amount"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be an integer"})
+			return
+		}
+
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).EvaluateTransaction("CanTransfer", from, to, strconv.FormatInt(amount, 10))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var check TransferCheck
+		if err := json.Unmarshal(response, &check); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, check)
+	})
+
+	// Transfer Endpoint
+	// @Summary Transfer balance between assets
+	// @Description Move Amount from one asset to another and Fee from the sender to the treasury asset (TREASURY_MSISDN) in one transaction. The sender must cover Amount plus Fee.
+	// @Accept json
+	// @Produce json
+	// @Param input body TransferRequest true "Transfer"
+	// @Success 200 {object} MessageResponse "Transfer completed successfully"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 403 {object} ErrorResponse "Approval Required"
+	// @Failure 404 {object} ErrorResponse "Asset Not Found"
+	// @Failure 429 {object} ErrorResponse "Too Many Requests"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /transfers [post]
+	r.POST("/transfers", limitSubmissions(submits), func(c *gin.Context) {
+		var req TransferRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.Fee > 0 && cfg.TreasuryMSISDN == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "transfer fees are disabled: no treasury asset is configured"})
+			return
+		}
+
+		// Invoke Fabric Chaincode
+		_, err := requestContract(c).SubmitTransaction("TransferWithFee", req.From, req.To, cfg.TreasuryMSISDN, strconv.FormatInt(req.Amount, 10), strconv.FormatInt(req.Fee, 10))
+		if err != nil {
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Transfer completed successfully"})
+	})
+
+	// Get Dealers Endpoint
+	// @Summary List dealers
+	// @Description Get every dealer with active assets, with its asset count and total balance, sorted by DealerID
+	// @Produce json
+	// @Success 200 {array} DealerSummary "Dealer directory"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /dealers [get]
+	r.GET("/dealers", func(c *gin.Context) {
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).EvaluateTransaction("GetDealers")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var dealers []DealerSummary
+		if err := json.Unmarshal(response, &dealers); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, dealers)
+	})
+
+	// Get Balance By Dealer Endpoint
+	// @Summary Get balance totals per dealer
+	// @Description Get the sum of the asset balances of each dealer, keyed by DealerID
+	// @Produce json
+	// @Success 200 {object} map[string]int "Balance per dealer"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /dealers/balances [get]
+	r.GET("/dealers/balances", func(c *gin.Context) {
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).EvaluateTransaction("GetBalanceByDealer")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var totals map[string]int
+		if err := json.Unmarshal(response, &totals); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, totals)
+	})
+
+	// Dealer Tree Endpoint
+	// @Summary Get assets grouped by dealer
+	// @Description Get every asset nested under its dealer, sorted by DealerID
+	// @Produce json
+	// @Success 200 {array} DealerAssets "Assets per dealer"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /dealers/tree [get]
+	r.GET("/dealers/tree", func(c *gin.Context) {
+		tree, err := dealerTree(requestContract(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, tree)
+	})
+
+	// Get Dealer Usage Endpoint
+	// @Summary Get dealer quota usage
+	// @Description Get the number of active assets of a dealer and its quota
+	// @Produce json
+	// @Param dealerID path string true "Dealer ID"
+	// @Success 200 {object} DealerUsage "Dealer usage"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /dealers/{dealerID}/usage [get]
+	r.GET("/dealers/:dealerID/usage", func(c *gin.Context) {
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).EvaluateTransaction("GetDealerUsage", c.Param("dealerID"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var usage DealerUsage
+		if err := json.Unmarshal(response, &usage); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, usage)
+	})
+
+	// Set Standing Instruction Endpoint
+	// @Summary Set an asset's standing instruction
+	// @Description Set a recurring balance adjustment, replacing any previous one. It is applied by POST /admin/standingInstructions/apply.
+	// @Accept json
+	// @Produce json
+	// @Param msisdn path string true "MSISDN of the asset"
+	// @Param input body SetStandingInstructionRequest true "Standing instruction"
+	// @Success 200 {object} MessageResponse "Standing instruction set successfully"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 429 {object} ErrorResponse "Too Many Requests"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/{msisdn}/standingInstruction [put]
+	r.PUT("/assets/:msisdn/standingInstruction", limitSubmissions(submits), func(c *gin.Context) {
+		var req SetStandingInstructionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Invoke Fabric Chaincode
+		_, err := requestContract(c).SubmitTransaction("SetStandingInstruction", c.Param("msisdn"), strconv.FormatInt(req.Amount, 10), req.Interval, req.FirstRun)
+		if err != nil {
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Standing instruction set successfully"})
+	})
+
+	// Get Standing Instruction Endpoint
+	// @Summary Get an asset's standing instruction
+	// @Description Get the recurring balance adjustment of an asset and when it next runs
+	// @Produce json
+	// @Param msisdn path string true "MSISDN of the asset"
+	// @Success 200 {object} StandingInstruction "Standing instruction"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/{msisdn}/standingInstruction [get]
+	r.GET("/assets/:msisdn/standingInstruction", func(c *gin.Context) {
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).EvaluateTransaction("GetStandingInstruction", c.Param("msisdn"))
+		if err != nil {
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		var instruction StandingInstruction
+		if err := json.Unmarshal(response, &instruction); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, instruction)
+	})
+
+	// Remove Standing Instruction Endpoint
+	// @Summary Remove an asset's standing instruction
+	// @Description Cancel the recurring balance adjustment of an asset
+	// @Produce json
+	// @Param msisdn path string true "MSISDN of the asset"
+	// @Success 200 {object} MessageResponse "Standing instruction removed successfully"
+	// @Failure 429 {object} ErrorResponse "Too Many Requests"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/{msisdn}/standingInstruction [delete]
+	r.DELETE("/assets/:msisdn/standingInstruction", limitSubmissions(submits), func(c *gin.Context) {
+		// Invoke Fabric Chaincode
+		_, err := requestContract(c).SubmitTransaction("RemoveStandingInstruction", c.Param("msisdn"))
+		if err != nil {
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Standing instruction removed successfully"})
+	})
+
+	// Add Asset To Segment Endpoint
+	// @Summary Add an asset to a segment
+	// @Description Add an asset to a named segment; adding an existing member has no effect
+	// @Produce json
+	// @Param segment path string true "Segment name"
+	// @Param msisdn path string true "MSISDN of the asset"
+	// @Success 200 {object} MessageResponse "Asset added to segment successfully"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 429 {object} ErrorResponse "Too Many Requests"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /segments/{segment}/members/{msisdn} [put]
+	r.PUT("/segments/:segment/members/:msisdn", limitSubmissions(submits), func(c *gin.Context) {
+		// Invoke Fabric Chaincode
+		_, err := requestContract(c).SubmitTransaction("AddAssetToSegment", c.Param("msisdn"), c.Param("segment"))
+		if err != nil {
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Asset added to segment successfully"})
+	})
+
+	// Remove Asset From Segment Endpoint
+	// @Summary Remove an asset from a segment
+	// @Description Remove an asset from a named segment; removing a non-member has no effect
+	// @Produce json
+	// @Param segment path string true "Segment name"
+	// @Param msisdn path string true "MSISDN of the asset"
+	// @Success 200 {object} MessageResponse "Asset removed from segment successfully"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 429 {object} ErrorResponse "Too Many Requests"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /segments/{segment}/members/{msisdn} [delete]
+	r.DELETE("/segments/:segment/members/:msisdn", limitSubmissions(submits), func(c *gin.Context) {
+		// Invoke Fabric Chaincode
+		_, err := requestContract(c).SubmitTransaction("RemoveAssetFromSegment", c.Param("msisdn"), c.Param("segment"))
+		if err != nil {
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Asset removed from segment successfully"})
+	})
+
+	// Get Segment Members Endpoint
+	// @Summary List segment members
+	// @Description Get the MSISDNs of the assets in a segment in ascending order
+	// @Produce json
+	// @Param segment path string true "Segment name"
+	// @Success 200 {array} string "MSISDNs in the segment"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /segments/{segment}/members [get]
+	r.GET("/segments/:segment/members", func(c *gin.Context) {
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).EvaluateTransaction("GetSegmentMembers", c.Param("segment"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var members []string
+		if err := json.Unmarshal(response, &members); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, members)
+	})
+
+	// Export Assets Endpoint
+	// @Summary Export all assets as JSONL
+	// @Description Stream every asset as newline-delimited JSON, one asset per line
+	// @Produce application/x-ndjson
+	// @Success 200 {object} Asset "One asset per line"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /assets/export.jsonl [get]
+	r.GET("/assets/export.jsonl", func(c *gin.Context) {
+		exportAssetsJSONL(c, requestContract(c))
+	})
+
+	admin := r.Group("/admin", adminAuth(cfg.AdminToken))
+
+	// Diagnostics Endpoint
+	// @Summary Diagnose the network connection
+	// @Description Evaluate a no-op chaincode call and report which peers and orderers in the connection profile are reachable
+	// @Produce json
+	// @Param X-Admin-Token header string true "Admin token"
+	// @Success 200 {object} DiagnosticReport "Diagnostic report"
+	// @Failure 401 {object} ErrorResponse "Unauthorized"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /admin/diagnostics [get]
+	admin.GET("/diagnostics", func(c *gin.Context) {
+		profile, err := loadConnectionProfile(connectionFile)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		report := runDiagnostics(func() error {
+			// The contract API system contract answers GetMetadata without touching the world state
+			_, err := systemContract.EvaluateTransaction("GetMetadata")
+			return err
+		}, profile, tcpDial)
+
+		c.JSON(http.StatusOK, report)
+	})
+
+	// Apply Rate Endpoint
+	// @Summary Apply interest or a fee to all assets
+	// @Description Adjust the balance of every Active asset by RatePercent of its balance (negative for fees)
+	// @Accept json
+	// @Produce json
+	// @Param X-Admin-Token header string true "Admin token"
+	// @Param input body ApplyRateRequest true "Rate to apply"
+	// @Success 200 {object} ApplyRateResponse "Number of assets adjusted"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 401 {object} ErrorResponse "Unauthorized"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /admin/applyRate [post]
+	admin.POST("/applyRate", limitSubmissions(submits), func(c *gin.Context) {
+		var req ApplyRateRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).SubmitTransaction("ApplyRateToAll", strconv.Itoa(req.RatePercent), req.TransType)
+		if err != nil {
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		var adjusted int
+		if err := json.Unmarshal(response, &adjusted); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, ApplyRateResponse{Adjusted: adjusted})
+	})
+
+	// Bulk Freeze Endpoint
+	// @Summary Freeze all assets matching a rich query
+	// @Description Freeze every asset matched by a CouchDB rich query, e.g. all assets over a balance threshold. Assets already Frozen or Deleted are not counted.
+	// @Accept json
+	// @Produce json
+	// @Param X-Admin-Token header string true "Admin token"
+	// @Param input body BulkFreezeRequest true "Query selecting the assets to freeze"
+	// @Success 200 {object} BulkFreezeResponse "Number of assets frozen"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 401 {object} ErrorResponse "Unauthorized"
+	// @Failure 429 {object} ErrorResponse "Too Many Requests"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /admin/freezeByQuery [post]
+	admin.POST("/freezeByQuery", limitSubmissions(submits), func(c *gin.Context) {
+		var req BulkFreezeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).SubmitTransaction("BulkFreezeByQuery", req.Query)
+		if err != nil {
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		var frozen int
+		if err := json.Unmarshal(response, &frozen); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, BulkFreezeResponse{Frozen: frozen})
+	})
+
+	// Apply Standing Instructions Endpoint
+	// @Summary Apply due standing instructions
+	// @Description Apply every standing instruction whose next run has passed and advance it by one interval. Meant to be called by a scheduler; failed instructions are retried on the next call.
+	// @Produce json
+	// @Param X-Admin-Token header string true "Admin token"
+	// @Success 200 {object} StandingInstructionReport "Applied and failed instructions"
+	// @Failure 401 {object} ErrorResponse "Unauthorized"
+	// @Failure 429 {object} ErrorResponse "Too Many Requests"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /admin/standingInstructions/apply [post]
+	admin.POST("/standingInstructions/apply", limitSubmissions(submits), func(c *gin.Context) {
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).SubmitTransaction("ApplyDueStandingInstructions")
+		if err != nil {
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		var report StandingInstructionReport
+		if err := json.Unmarshal(response, &report); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	})
+
+	// Delete All Assets Endpoint
+	// @Summary Delete every asset
+	// @Description Delete all active assets, for resetting test networks. Confirm must be CONFIRM_DELETE_ALL.
+	// @Accept json
+	// @Produce json
+	// @Param X-Admin-Token header string true "Admin token"
+	// @Param input body DeleteAllRequest true "Confirmation"
+	// @Success 200 {object} DeleteAllResponse "Number of assets deleted"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 401 {object} ErrorResponse "Unauthorized"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /admin/deleteAll [post]
+	admin.POST("/deleteAll", limitSubmissions(submits), func(c *gin.Context) {
+		var req DeleteAllRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).SubmitTransaction("DeleteAllAssets", req.Confirm)
+		if err != nil {
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		var deleted int
+		if err := json.Unmarshal(response, &deleted); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, DeleteAllResponse{Deleted: deleted})
+	})
+
+	// Raw State Endpoint
+	// @Summary Get the raw world state value of a key
+	// @Description Return the bytes stored under a key verbatim, base64 encoded, plus the JSON value when they are valid JSON
+	// @Produce json
+	// @Param X-Admin-Token header string true "Admin token"
+	// @Param key path string true "World state key"
+	// @Success 200 {object} RawStateResponse "Raw value"
+	// @Failure 401 {object} ErrorResponse "Unauthorized"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /admin/raw/{key} [get]
+	admin.GET("/raw/:key", func(c *gin.Context) {
+		key := c.Param("key")
+
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).EvaluateTransaction("GetRawState", key)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		encoded := string(response)
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		body := RawStateResponse{Key: key, Base64: encoded}
+		if json.Valid(value) {
+			body.JSON = value
+		}
+		c.JSON(http.StatusOK, body)
+	})
+
+	// Set Dealer Quota Endpoint
+	// @Summary Set a dealer quota
+	// @Description Set the maximum number of active assets a dealer may have; 0 removes the limit
+	// @Accept json
+	// @Produce json
+	// @Param X-Admin-Token header string true "Admin token"
+	// @Param dealerID path string true "Dealer ID"
+	// @Param input body SetDealerQuotaRequest true "Quota"
+	// @Success 200 {object} MessageResponse "Dealer quota updated successfully"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 401 {object} ErrorResponse "Unauthorized"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /admin/dealers/{dealerID}/quota [put]
+	admin.PUT("/dealers/:dealerID/quota", limitSubmissions(submits), func(c *gin.Context) {
+		var req SetDealerQuotaRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Invoke Fabric Chaincode
+		_, err := requestContract(c).SubmitTransaction("SetDealerQuota", c.Param("dealerID"), strconv.Itoa(req.Quota))
+		if err != nil {
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Dealer quota updated successfully"})
+	})
+
+	// Create Snapshot Endpoint
+	// @Summary Snapshot all assets
+	// @Description Store every active asset under one ledger key as a consistent point-in-time copy. Snapshot IDs cannot be reused.
+	// @Produce json
+	// @Param X-Admin-Token header string true "Admin token"
+	// @Param snapshotID path string true "Snapshot ID"
+	// @Success 200 {object} MessageResponse "Snapshot created successfully"
+	// @Failure 401 {object} ErrorResponse "Unauthorized"
+	// @Failure 429 {object} ErrorResponse "Too Many Requests"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /admin/snapshots/{snapshotID} [put]
+	admin.PUT("/snapshots/:snapshotID", limitSubmissions(submits), func(c *gin.Context) {
+		// Invoke Fabric Chaincode
+		_, err := requestContract(c).SubmitTransaction("CreateSnapshot", c.Param("snapshotID"))
+		if err != nil {
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Snapshot created successfully"})
+	})
+
+	// Get Snapshot Endpoint
+	// @Summary Get a snapshot
+	// @Description Get every asset as of the time the snapshot was created
+	// @Produce json
+	// @Param X-Admin-Token header string true "Admin token"
+	// @Param snapshotID path string true "Snapshot ID"
+	// @Success 200 {object} Snapshot "Snapshot"
+	// @Failure 401 {object} ErrorResponse "Unauthorized"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /admin/snapshots/{snapshotID} [get]
+	admin.GET("/snapshots/:snapshotID", func(c *gin.Context) {
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).EvaluateTransaction("GetSnapshot", c.Param("snapshotID"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var snapshot Snapshot
+		if err := json.Unmarshal(response, &snapshot); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, snapshot)
+	})
+
+	// Audit Log Endpoint
+	// @Summary Page through the audit log
+	// @Description List every write to an asset in chronological order, one page at a time. An asset written several times in one transaction has a single entry.
+	// @Produce json
+	// @Param X-Admin-Token header string true "Admin token"
+	// @Param pageSize query int false "Entries per page" default(100)
+	// @Param bookmark query string false "Bookmark returned with the previous page"
+	// @Success 200 {object} AuditLogPage "Audit log page"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 401 {object} ErrorResponse "Unauthorized"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /admin/auditLog [get]
+	admin.GET("/auditLog", func(c *gin.Context) {
+		pageSize, err := strconv.Atoi(c.DefaultQuery("pageSize", strconv.Itoa(exportPageSize)))
+		if err != nil || pageSize <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "pageSize must be a positive integer"})
+			return
+		}
+
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).EvaluateTransaction("GetAuditLog", strconv.Itoa(pageSize), c.Query("bookmark"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var page AuditLogPage
+		if err := json.Unmarshal(response, &page); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, page)
+	})
+
+	// Committed Transactions Endpoint
+	// @Summary List committed transactions
+	// @Description List the IDs of the most recently committed valid transactions of the contract, oldest first
+	// @Produce json
+	// @Param X-Admin-Token header string true "Admin token"
+	// @Success 200 {array} CommittedTx "Committed transactions"
+	// @Failure 401 {object} ErrorResponse "Unauthorized"
+	// @Failure 404 {object} ErrorResponse "Block event listener disabled"
+	// @Router /admin/committedTxs [get]
+	admin.GET("/committedTxs", func(c *gin.Context) {
+		if committedTxs == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "committed transaction tracking is disabled"})
+			return
+		}
+
+		c.JSON(http.StatusOK, committedTxs.list())
+	})
+
+	// Time Skew Endpoint
+	// @Summary Measure clock skew
+	// @Description Evaluate a chaincode call returning its transaction timestamp and the peer clock, and report how far each is from the server clock
+	// @Produce json
+	// @Param X-Admin-Token header string true "Admin token"
+	// @Success 200 {object} TimeSkewReport "Clock skew"
+	// @Failure 401 {object} ErrorResponse "Unauthorized"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /admin/timeskew [get]
+	admin.GET("/timeskew", func(c *gin.Context) {
+		contract := requestContract(c)
+		report, err := measureTimeSkew(func() ([]byte, error) {
+			// Invoke Fabric Chaincode
+			return contract.EvaluateTransaction("GetLedgerTime")
+		}, time.Now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	})
+
+	// Duplicate Assets Endpoint
+	// @Summary Find duplicate assets
+	// @Description Get clusters of asset MSISDNs stored under different keys that normalize to the same number, for review before merging them
+	// @Produce json
+	// @Param X-Admin-Token header string true "Admin token"
+	// @Success 200 {array} []string "Clusters of duplicate MSISDNs"
+	// @Failure 401 {object} ErrorResponse "Unauthorized"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /admin/duplicates [get]
+	admin.GET("/duplicates", func(c *gin.Context) {
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).EvaluateTransaction("FindDuplicateAssets")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var clusters [][]string
+		if err := json.Unmarshal(response, &clusters); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, clusters)
+	})
+
+	// Merge Duplicate Assets Endpoint
+	// @Summary Merge duplicate assets
+	// @Description Move the balance of one asset of a duplicate cluster into another and mark it Deleted. The keys are used exactly as GET /admin/duplicates reports them, so legacy assets stored under unnormalized MSISDNs can be merged.
+	// @Accept json
+	// @Produce json
+	// @Param X-Admin-Token header string true "Admin token"
+	// @Param input body MergeDuplicatesRequest true "Keys of the source and target assets"
+	// @Success 200 {object} MessageResponse "Assets merged successfully"
+	// @Failure 400 {object} ErrorResponse "Bad Request"
+	// @Failure 401 {object} ErrorResponse "Unauthorized"
+	// @Failure 429 {object} ErrorResponse "Too Many Requests"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /admin/duplicates/merge [post]
+	admin.POST("/duplicates/merge", limitSubmissions(submits), func(c *gin.Context) {
+		var req MergeDuplicatesRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Invoke Fabric Chaincode
+		if _, err := requestContract(c).SubmitTransaction("MergeDuplicateAssets", req.Source, req.Target); err != nil {
+			c.JSON(statusForError(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Assets merged successfully"})
+	})
+
+	// Ledger Stats Endpoint
+	// @Summary Get ledger statistics
+	// @Description Get the asset count, total balance, lowest and highest MSISDN and distinct dealer count
+	// @Produce json
+	// @Param X-Admin-Token header string true "Admin token"
+	// @Success 200 {object} LedgerStats "Ledger statistics"
+	// @Failure 401 {object} ErrorResponse "Unauthorized"
+	// @Failure 500 {object} ErrorResponse "Internal Server Error"
+	// @Router /admin/stats [get]
+	admin.GET("/stats", func(c *gin.Context) {
+		// Invoke Fabric Chaincode
+		response, err := requestContract(c).EvaluateTransaction("GetLedgerStats")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var stats LedgerStats
+		if err := json.Unmarshal(response, &stats); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, stats)
+	})
+
+	// Health Endpoint
+	// @Summary Check API health
+	// @Description Report that the API is up and whether it is in read-only maintenance mode
+	// @Produce json
+	// @Success 200 {object} HealthResponse "API health"
+	// @Router /healthz [get]
+	r.GET("/healthz", health(cfg.ReadOnly))
+
+	// Readiness Endpoint
+	// @Summary Check chaincode readiness
+	// @Description Evaluate the chaincode's Ping and report whether the chaincode is installed and responsive within READY_TIMEOUT
+	// @Produce json
+	// @Success 200 {object} ReadinessResponse "Chaincode ready"
+	// @Failure 503 {object} ReadinessResponse "Chaincode not ready"
+	// @Router /readyz [get]
+	r.GET("/readyz", ready(func() ([]byte, error) {
+		return contract.EvaluateTransaction("Ping")
+	}, cfg.ReadyTimeout))
+
+	// Swagger documentation routes
+	// @router /swagger/*any [get]
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	serveSwagger(r, cfg.SwaggerHost, cfg.SwaggerBasePath)
+
+	// Run the REST API
+	err = r.Run(":8080")
+	if err != nil {
+		fmt.Printf("Failed to start REST API: %s\n", err)
+	}
+}
+
+// connectWithRetry calls connect until it succeeds or maxAttempts is reached,
+// doubling the wait after each failed attempt
+func connectWithRetry(connect func() (*gateway.Gateway, error), maxAttempts int, interval time.Duration) (*gateway.Gateway, error) {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	var lastErr error
+	wait := interval
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		gw, err := connect()
+		if err == nil {
+			return gw, nil
+		}
+		lastErr = err
+		fmt.Printf("Gateway connection attempt %d/%d failed: %s\n", attempt, maxAttempts, err)
+
+		if attempt < maxAttempts {
+			time.Sleep(wait)
+			wait *= 2
+		}
+	}
+
+	return nil, fmt.Errorf("failed to connect to gateway after %d attempts: %v", maxAttempts, lastErr)
+}
+
+// serveSwagger serves the API documentation with "Try it out" pointed at the
+// externally visible host and base path, e.g. behind a reverse proxy
+func serveSwagger(r gin.IRoutes, host, basePath string) {
+	docs.SwaggerInfo.Host = host
+	docs.SwaggerInfo.BasePath = basePath
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+}
+
+// readAsset returns an asset from the projection when available, falling back
+// to evaluating ReadAsset on the ledger
+func readAsset(contract evaluator, projection *assetProjection, msisdn string) (Asset, error) {
+	if projection != nil {
+		if asset, ok := projection.get(msisdn); ok {
+			return asset, nil
+		}
+	}
+
+	// Invoke Fabric Chaincode
+	response, err := contract.EvaluateTransaction("ReadAsset", msisdn)
+	if err != nil {
+		return Asset{}, err
+	}
+
+	var asset Asset
+	if err := json.Unmarshal(response, &asset); err != nil {
+		return Asset{}, err
+	}
+
+	if projection != nil {
+		projection.put(asset)
+	}
+
+	return asset, nil
+}
+
+// sortableFields are the asset fields the list endpoint can sort by
+var sortableFields = map[string]bool{
+	"Balance": true,
+	"MSISDN":  true,
+	"Status":  true,
+}
+
+// isPagedListQuery reports whether a list request asks for sorting or paging
+func isPagedListQuery(c *gin.Context) bool {
+	for _, param := range []string{"sort", "order", "pageSize", "bookmark"} {
+		if _, ok := c.GetQuery(param); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseFields splits a comma-separated list of field names and checks each
+// one against the JSON fields of Asset
+func parseFields(param string) ([]string, error) {
+	if param == "" {
+		return nil, nil
+	}
+
+	known, err := assetToMap(Asset{})
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []string
+	for _, field := range strings.Split(param, ",") {
+		field = strings.TrimSpace(field)
+		if _, ok := known[field]; !ok {
+			return nil, fmt.Errorf("unknown field %q", field)
+		}
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// projectAsset reduces an asset to the given (already validated) fields
+func projectAsset(asset Asset, fields []string) map[string]interface{} {
+	all, _ := assetToMap(asset)
+
+	projected := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		projected[field] = all[field]
+	}
+
+	return projected
+}
+
+// assetToMap converts an asset to a map keyed by its JSON field names
+func assetToMap(asset Asset) (map[string]interface{}, error) {
+	assetJSON, err := json.Marshal(asset)
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(assetJSON, &m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// abortStream reports an error on a streaming response. Once the first line
+// has been written the status can no longer change, so the error is only logged.
+func abortStream(c *gin.Context, err error) {
+	if !c.Writer.Written() {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
+	fmt.Printf("Failed to stream response: %s\n", err)
 }
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+)
+
+func TestConnectWithRetrySucceedsAfterFailures(t *testing.T) {
+	connected := &gateway.Gateway{}
+	calls := 0
+	gw, err := connectWithRetry(func() (*gateway.Gateway, error) {
+		calls++
+		if calls <= 2 {
+			return nil, errors.New("peer not ready")
+		}
+		return connected, nil
+	}, 5, time.Millisecond)
+
+	if err != nil {
+		t.Fatalf("connectWithRetry returned error: %v", err)
+	}
+	if gw != connected {
+		t.Errorf("connectWithRetry returned %p, want %p", gw, connected)
+	}
+	if calls != 3 {
+		t.Errorf("connect was called %d times, want 3", calls)
+	}
+}
+
+func TestConnectWithRetryGivesUp(t *testing.T) {
+	calls := 0
+	_, err := connectWithRetry(func() (*gateway.Gateway, error) {
+		calls++
+		return nil, errors.New("peer not ready")
+	}, 3, time.Millisecond)
+
+	if err == nil {
+		t.Fatal("connectWithRetry succeeded, want error")
+	}
+	if calls != 3 {
+		t.Errorf("connect was called %d times, want 3", calls)
+	}
+}
+
+func TestCreateAssetRequestIgnoresServerManagedFields(t *testing.T) {
+	body := `{"DealerID":"D001","MSISDN":"9876543210","Balance":1500,"Status":"Active",` +
+		`"Timestamp":"2001-02-03T04:05:06Z","TransAmount":999,"LastModifiedBy":"mallory"}`
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/createAsset", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	var req CreateAssetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		t.Fatalf("ShouldBindJSON returned error: %v", err)
+	}
+	asset := req.toAsset()
+
+	if !asset.Timestamp.IsZero() {
+		t.Errorf("Timestamp was bound to %v, want it ignored", asset.Timestamp)
+	}
+	if asset.TransAmount != 0 {
+		t.Errorf("TransAmount was bound to %d, want it ignored", asset.TransAmount)
+	}
+	if asset.MSISDN != "9876543210" || asset.Balance != 1500 {
+		t.Errorf("asset has MSISDN %s and balance %d, want 9876543210 and 1500", asset.MSISDN, asset.Balance)
+	}
+}
+
+func TestFieldProjection(t *testing.T) {
+	fields, err := parseFields("Balance, Status")
+	if err != nil {
+		t.Fatalf("parseFields returned error: %v", err)
+	}
+
+	projected := projectAsset(Asset{MSISDN: "9876543210", Balance: 1500, Status: "Active", Remarks: "x"}, fields)
+	if len(projected) != 2 {
+		t.Errorf("projection has %d fields, want 2: %v", len(projected), projected)
+	}
+	if projected["Balance"] != float64(1500) || projected["Status"] != "Active" {
+		t.Errorf("projection is %v, want Balance 1500 and Status Active", projected)
+	}
+}
+
+func TestFieldProjectionUnknownField(t *testing.T) {
+	if _, err := parseFields("Balance,Password"); err == nil || !strings.Contains(err.Error(), "Password") {
+		t.Errorf("parseFields returned %v, want an unknown field error naming Password", err)
+	}
+}
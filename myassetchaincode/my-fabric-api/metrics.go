@@ -0,0 +1,98 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Error categories counted by apiMetrics
+const (
+	errorCategoryValidation  = "validation"
+	errorCategoryNotFound    = "not-found"
+	errorCategoryConflict    = "conflict"
+	errorCategoryFabricError = "fabric-error"
+	errorCategoryOther       = "other"
+)
+
+// payloadSizeBuckets are the histogram buckets for body sizes, 64 B to 1 MiB
+var payloadSizeBuckets = prometheus.ExponentialBuckets(64, 4, 8)
+
+// apiMetrics records the volume, payload sizes and errors of API requests
+type apiMetrics struct {
+	requests      *prometheus.CounterVec
+	requestBytes  *prometheus.HistogramVec
+	responseBytes *prometheus.HistogramVec
+	errors        *prometheus.CounterVec
+}
+
+// newAPIMetrics creates the API metrics and registers them with registerer
+func newAPIMetrics(registerer prometheus.Registerer) *apiMetrics {
+	labels := []string{"method", "route"}
+	m := &apiMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "api_requests_total",
+			Help: "Requests handled, by method, route and status code.",
+		}, append(labels, "status")),
+		requestBytes: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "api_request_body_bytes",
+			Help:    "Size of request bodies in bytes.",
+			Buckets: payloadSizeBuckets,
+		}, labels),
+		responseBytes: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "api_response_body_bytes",
+			Help:    "Size of response bodies in bytes.",
+			Buckets: payloadSizeBuckets,
+		}, labels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "api_errors_total",
+			Help: "Error responses, by route and category (validation, not-found, conflict, fabric-error, other).",
+		}, []string{"route", "category"}),
+	}
+	registerer.MustRegister(m.requests, m.requestBytes, m.responseBytes, m.errors)
+	return m
+}
+
+// record is a middleware that observes every request once it has been handled
+func (m *apiMetrics) record() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		// Label by route template rather than path so MSISDNs do not explode the label set
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+		status := c.Writer.Status()
+
+		m.requests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+		if c.Request.ContentLength >= 0 {
+			m.requestBytes.WithLabelValues(method, route).Observe(float64(c.Request.ContentLength))
+		}
+		if size := c.Writer.Size(); size >= 0 {
+			m.responseBytes.WithLabelValues(method, route).Observe(float64(size))
+		}
+		if status >= 400 {
+			m.errors.WithLabelValues(route, errorCategory(status)).Inc()
+		}
+	}
+}
+
+// errorCategory classifies an error response by its status code, which the
+// handlers derive from the chaincode error through statusForError
+func errorCategory(status int) string {
+	switch {
+	case status == 400 || status == 413:
+		return errorCategoryValidation
+	case status == 404:
+		return errorCategoryNotFound
+	case status == 409:
+		return errorCategoryConflict
+	case status >= 500:
+		return errorCategoryFabricError
+	default:
+		return errorCategoryOther
+	}
+}
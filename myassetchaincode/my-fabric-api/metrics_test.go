@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metricSamples gathers the counter or gauge values, or histogram sample
+// counts, of the named metric from registry keyed by their label values
+// joined in label name order
+func metricSamples(t *testing.T, registry *prometheus.Registry, name string) map[string]float64 {
+	t.Helper()
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("error gathering metrics: %v", err)
+	}
+
+	samples := make(map[string]float64)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			var labels []string
+			for _, label := range metric.GetLabel() {
+				labels = append(labels, label.GetValue())
+			}
+			if histogram := metric.GetHistogram(); histogram != nil {
+				samples[strings.Join(labels, ",")] = float64(histogram.GetSampleCount())
+			} else if gauge := metric.GetGauge(); gauge != nil {
+				samples[strings.Join(labels, ",")] = gauge.GetValue()
+			} else {
+				samples[strings.Join(labels, ",")] = metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return samples
+}
+
+func TestAPIMetricsErrorCategories(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	r := gin.New()
+	r.Use(newAPIMetrics(registry).record())
+	// Fails with the chaincode error in the request body, the way the handlers report it
+	r.POST("/updateAsset", func(c *gin.Context) {
+		message, _ := c.GetRawData()
+		if len(message) == 0 {
+			c.JSON(http.StatusOK, gin.H{"message": "Asset updated successfully"})
+			return
+		}
+		err := errors.New(string(message))
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+	})
+
+	for _, message := range []string{
+		"",
+		"invalid status \"Closed\": must be one of Active, Frozen, Suspended, Deleted",
+		"cannot update nonexistent asset with MSISDN 9800000000",
+		"transaction 5c2f0e1b invalidated with status code: MVCC_READ_CONFLICT",
+		"transaction 6d3a1f2c invalidated with status code: PHANTOM_READ_CONFLICT",
+		"Failed to submit: endorsement failure during invoke",
+	} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/updateAsset", strings.NewReader(message)))
+	}
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nowhere", nil))
+
+	want := map[string]float64{
+		"validation,/updateAsset":   1,
+		"not-found,/updateAsset":    1,
+		"conflict,/updateAsset":     2,
+		"fabric-error,/updateAsset": 1,
+		"not-found,unmatched":       1,
+	}
+	errorCounts := metricSamples(t, registry, "api_errors_total")
+	if len(errorCounts) != len(want) {
+		t.Errorf("api_errors_total is %v, want %v", errorCounts, want)
+	}
+	for labels, count := range want {
+		if errorCounts[labels] != count {
+			t.Errorf("api_errors_total for %s is %v, want %v", labels, errorCounts[labels], count)
+		}
+	}
+
+	if got := metricSamples(t, registry, "api_requests_total")["POST,/updateAsset,200"]; got != 1 {
+		t.Errorf("api_requests_total for the successful update is %v, want 1", got)
+	}
+	if got := metricSamples(t, registry, "api_request_body_bytes")["POST,/updateAsset"]; got != 6 {
+		t.Errorf("api_request_body_bytes observed %v update requests, want 6", got)
+	}
+	if got := metricSamples(t, registry, "api_response_body_bytes")["POST,/updateAsset"]; got != 6 {
+		t.Errorf("api_response_body_bytes observed %v update responses, want 6", got)
+	}
+}
+
+func TestErrorCategory(t *testing.T) {
+	for status, want := range map[int]string{
+		http.StatusBadRequest:            errorCategoryValidation,
+		http.StatusRequestEntityTooLarge: errorCategoryValidation,
+		http.StatusNotFound:              errorCategoryNotFound,
+		http.StatusConflict:              errorCategoryConflict,
+		http.StatusInternalServerError:   errorCategoryFabricError,
+		http.StatusGatewayTimeout:        errorCategoryFabricError,
+		http.StatusForbidden:             errorCategoryOther,
+	} {
+		if got := errorCategory(status); got != want {
+			t.Errorf("errorCategory(%d) = %s, want %s", status, got, want)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+)
+
+// identityHeader selects which of the configured Fabric identities a request
+// is sent as. It is meant to be set by an authenticating proxy, not by end users.
+const identityHeader = "X-Fabric-Identity"
+
+// certRefreshMargin is how long before an identity's certificate expires its
+// connection is replaced
+const certRefreshMargin = 5 * time.Minute
+
+// retiredGatewayGrace is how long a replaced gateway stays open for the
+// requests still using it
+const retiredGatewayGrace = time.Minute
+
+// contractKey is the gin context key the pooled contract for a request is stored under
+const contractKey = "contract"
+
+// gatewayDialer connects a gateway as the given identity and reports when the
+// identity's certificate expires, or the zero time if it does not
+type gatewayDialer func(identity string) (*gateway.Gateway, time.Time, error)
+
+// pooledConnection is a gateway connection for one identity
+type pooledConnection struct {
+	gw       *gateway.Gateway
+	network  *gateway.Network
+	contract *gateway.Contract
+	expires  time.Time
+}
+
+// contractPool vends one reusable contract per Fabric identity and reconnects
+// an identity shortly before its certificate expires
+type contractPool struct {
+	mu              sync.Mutex
+	dial            gatewayDialer
+	defaultIdentity string
+	identities      map[string]bool
+	connections     map[string]*pooledConnection
+	open            func(gw *gateway.Gateway) (*pooledConnection, error)
+	now             func() time.Time
+}
+
+// newContractPool returns a pool that connects with dial. Requests may select
+// any of identities; all others use defaultIdentity.
+func newContractPool(dial gatewayDialer, defaultIdentity string, identities []string) *contractPool {
+	allowed := map[string]bool{defaultIdentity: true}
+	for _, identity := range identities {
+		allowed[identity] = true
+	}
+
+	return &contractPool{
+		dial:            dial,
+		defaultIdentity: defaultIdentity,
+		identities:      allowed,
+		connections:     make(map[string]*pooledConnection),
+		open:            openConnection,
+		now:             time.Now,
+	}
+}
+
+// openConnection opens the contract's channel on a connected gateway
+func openConnection(gw *gateway.Gateway) (*pooledConnection, error) {
+	network, err := gw.GetNetwork(channelName)
+	if err != nil {
+		gw.Close()
+		return nil, fmt.Errorf("failed to get network: %v", err)
+	}
+
+	return &pooledConnection{
+		gw:       gw,
+		network:  network,
+		contract: network.GetContract(contractName),
+	}, nil
+}
+
+// get returns the connection for an identity, connecting on first use and
+// again once the identity's certificate is about to expire
+func (p *contractPool) get(identity string) (*pooledConnection, error) {
+	if !p.identities[identity] {
+		return nil, fmt.Errorf("identity %q is not configured", identity)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	conn, ok := p.connections[identity]
+	if ok && (conn.expires.IsZero() || p.now().Before(conn.expires.Add(-certRefreshMargin))) {
+		return conn, nil
+	}
+
+	gw, expires, err := p.dial(identity)
+	if err != nil {
+		return nil, err
+	}
+	fresh, err := p.open(gw)
+	if err != nil {
+		return nil, err
+	}
+	fresh.expires = expires
+
+	if ok {
+		time.AfterFunc(retiredGatewayGrace, conn.gw.Close)
+	}
+
+	p.connections[identity] = fresh
+	return fresh, nil
+}
+
+// close closes every pooled gateway
+func (p *contractPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for identity, conn := range p.connections {
+		conn.gw.Close()
+		delete(p.connections, identity)
+	}
+}
+
+// withContract resolves the identity of a request from identityHeader and
+// stores its contract for requestContract
+func withContract(p *contractPool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity := c.GetHeader(identityHeader)
+		if identity == "" {
+			identity = p.defaultIdentity
+		}
+
+		conn, err := p.get(identity)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Set(contractKey, conn.contract)
+		c.Next()
+	}
+}
+
+// requestContract returns the contract withContract selected for the request,
+// tagged with the request's correlation ID
+func requestContract(c *gin.Context) *correlatedContract {
+	return &correlatedContract{
+		Contract:      c.MustGet(contractKey).(*gateway.Contract),
+		correlationID: c.GetString(correlationIDKey),
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+)
+
+// testPool returns a pool whose connections each get a contract of their own
+// and whose certificates expire an hour after they are dialled, and a count
+// of dials per identity
+func testPool(now *time.Time) (*contractPool, map[string]int) {
+	dials := make(map[string]int)
+	p := newContractPool(func(identity string) (*gateway.Gateway, time.Time, error) {
+		dials[identity]++
+		return &gateway.Gateway{}, now.Add(time.Hour), nil
+	}, "appUser", []string{"auditor"})
+	p.open = func(gw *gateway.Gateway) (*pooledConnection, error) {
+		return &pooledConnection{gw: gw, contract: &gateway.Contract{}}, nil
+	}
+	p.now = func() time.Time { return *now }
+	return p, dials
+}
+
+// requestedContract returns the contract withContract selects for a request
+// sent with the given identity header
+func requestedContract(t *testing.T, p *contractPool, identity string) *gateway.Contract {
+	t.Helper()
+	var contract *gateway.Contract
+	r := gin.New()
+	r.GET("/", withContract(p), func(c *gin.Context) {
+		contract = c.MustGet(contractKey).(*gateway.Contract)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if identity != "" {
+		req.Header.Set(identityHeader, identity)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("request as %q got status %d: %s", identity, w.Code, w.Body)
+	}
+	return contract
+}
+
+func TestContractPoolReusesContracts(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	p, dials := testPool(&now)
+
+	first := requestedContract(t, p, "")
+	if second := requestedContract(t, p, "appUser"); second != first {
+		t.Error("second request got a different contract for the default identity")
+	}
+	if dials["appUser"] != 1 {
+		t.Errorf("default identity was dialled %d times, want 1", dials["appUser"])
+	}
+
+	if auditor := requestedContract(t, p, "auditor"); auditor == first {
+		t.Error("auditor got the default identity's contract")
+	}
+}
+
+func TestContractPoolRefreshesBeforeExpiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	p, dials := testPool(&now)
+
+	first := requestedContract(t, p, "")
+
+	now = now.Add(time.Hour - certRefreshMargin - time.Second)
+	if contract := requestedContract(t, p, ""); contract != first {
+		t.Error("contract was replaced before its certificate was due to expire")
+	}
+
+	now = now.Add(2 * time.Second)
+	if contract := requestedContract(t, p, ""); contract == first {
+		t.Error("contract was reused after its certificate was due to expire")
+	}
+	if dials["appUser"] != 2 {
+		t.Errorf("default identity was dialled %d times, want 2", dials["appUser"])
+	}
+}
+
+func TestContractPoolRejectsUnknownIdentity(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	p, dials := testPool(&now)
+
+	r := gin.New()
+	r.GET("/", withContract(p), func(c *gin.Context) {
+		t.Error("request with an unknown identity reached the handler")
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(identityHeader, "mallory")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status is %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if len(dials) != 0 {
+		t.Errorf("dialled %v for an unknown identity", dials)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+)
+
+// assetEventFilter matches the chaincode events emitted on asset writes
+const assetEventFilter = "^(Asset(Created|Updated|Archived|Restored|Deleted|Reassigned)|AssetsChanged)$"
+
+// AssetsChanged is the payload of the AssetsChanged event, which a
+// transaction writing several assets emits in place of their states
+type AssetsChanged struct {
+	MSISDNs []string `json:"MSISDNs"`
+}
+
+// evaluator evaluates chaincode transactions; it is satisfied by both
+// *gateway.Contract and *correlatedContract
+type evaluator interface {
+	EvaluateTransaction(name string, args ...string) ([]byte, error)
+}
+
+// assetProjection is an in-memory, read-optimized view of the current asset
+// state, kept up to date from chaincode events
+type assetProjection struct {
+	mu     sync.RWMutex
+	assets map[string]Asset
+}
+
+// newAssetProjection returns an empty projection
+func newAssetProjection() *assetProjection {
+	return &assetProjection{assets: make(map[string]Asset)}
+}
+
+// get returns the projected asset for an MSISDN, if present
+func (p *assetProjection) get(msisdn string) (Asset, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	asset, ok := p.assets[msisdn]
+	return asset, ok
+}
+
+// put stores or replaces the projected state of an asset
+func (p *assetProjection) put(asset Asset) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.assets[asset.MSISDN] = asset
+}
+
+// remove drops an asset that has left the active state
+func (p *assetProjection) remove(msisdn string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.assets, msisdn)
+}
+
+// apply updates the projection from an asset event payload. AssetArchived and
+// AssetDeleted remove the asset; AssetsChanged only names the assets it
+// changed, so they are dropped and read from the ledger on their next read.
+// Every other event carries the new state of its asset. AssetReassigned
+// carries the asset under its new MSISDN, so the soft-deleted old key is
+// dropped as well.
+func (p *assetProjection) apply(event *fab.CCEvent) error {
+	if event.EventName == "AssetsChanged" {
+		var changed AssetsChanged
+		if err := json.Unmarshal(event.Payload, &changed); err != nil {
+			return fmt.Errorf("error unmarshalling %s event payload: %v", event.EventName, err)
+		}
+		for _, msisdn := range changed.MSISDNs {
+			p.remove(msisdn)
+		}
+		return nil
+	}
+
+	var asset Asset
+	if err := json.Unmarshal(event.Payload, &asset); err != nil {
+		return fmt.Errorf("error unmarshalling %s event payload: %v", event.EventName, err)
+	}
+
+	if event.EventName == "AssetArchived" || event.EventName == "AssetDeleted" {
+		p.remove(asset.MSISDN)
+		return nil
+	}
+	if event.EventName == "AssetReassigned" {
+		p.remove(asset.PreviousMSISDN)
+	}
+
+	p.put(asset)
+	return nil
+}
+
+// resync replaces the projection with the current ledger state
+func (p *assetProjection) resync(contract evaluator) error {
+	response, err := contract.EvaluateTransaction("GetAllAssets")
+	if err != nil {
+		return fmt.Errorf("error evaluating GetAllAssets: %v", err)
+	}
+
+	var assets []Asset
+	if err := json.Unmarshal(response, &assets); err != nil {
+		return fmt.Errorf("error unmarshalling assets: %v", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.assets = make(map[string]Asset, len(assets))
+	for _, asset := range assets {
+		p.assets[asset.MSISDN] = asset
+	}
+
+	return nil
+}
+
+// listen subscribes to asset events and applies them until the returned stop
+// function is called
+func (p *assetProjection) listen(contract *gateway.Contract) (func(), error) {
+	registration, events, err := contract.RegisterEvent(assetEventFilter)
+	if err != nil {
+		return nil, fmt.Errorf("error registering for asset events: %v", err)
+	}
+
+	go func() {
+		for event := range events {
+			if err := p.apply(event); err != nil {
+				fmt.Printf("Failed to apply asset event: %s\n", err)
+			}
+		}
+	}()
+
+	return func() { contract.Unregister(registration) }, nil
+}
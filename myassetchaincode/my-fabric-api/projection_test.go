@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+// fakeLedger answers ReadAsset and GetAllAssets from a map of assets and
+// counts the transactions it evaluates
+type fakeLedger struct {
+	assets map[string]Asset
+	calls  int
+}
+
+func (l *fakeLedger) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	l.calls++
+	switch name {
+	case "ReadAsset":
+		asset, ok := l.assets[args[0]]
+		if !ok {
+			return nil, errors.New("asset with MSISDN " + args[0] + " does not exist")
+		}
+		return json.Marshal(asset)
+	case "GetAllAssets":
+		assets := []Asset{}
+		for _, asset := range l.assets {
+			assets = append(assets, asset)
+		}
+		return json.Marshal(assets)
+	}
+	return nil, errors.New("unexpected transaction " + name)
+}
+
+func assetEvent(t *testing.T, name string, payload interface{}) *fab.CCEvent {
+	t.Helper()
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("error marshalling event payload: %v", err)
+	}
+	return &fab.CCEvent{EventName: name, Payload: payloadJSON}
+}
+
+func TestAssetEventFilter(t *testing.T) {
+	filter := regexp.MustCompile(assetEventFilter)
+	for _, name := range []string{"AssetCreated", "AssetUpdated", "AssetArchived", "AssetRestored", "AssetDeleted", "AssetReassigned", "AssetsChanged"} {
+		if !filter.MatchString(name) {
+			t.Errorf("assetEventFilter does not match %s", name)
+		}
+	}
+	if filter.MatchString("AssetsChangedLater") {
+		t.Error("assetEventFilter matches AssetsChangedLater")
+	}
+}
+
+func TestProjectionAppliesAssetEvents(t *testing.T) {
+	p := newAssetProjection()
+
+	if err := p.apply(assetEvent(t, "AssetCreated", Asset{MSISDN: "9876543210", Balance: 100})); err != nil {
+		t.Fatalf("apply AssetCreated returned error: %v", err)
+	}
+	if asset, ok := p.get("9876543210"); !ok || asset.Balance != 100 {
+		t.Fatalf("after AssetCreated got %+v, %v, want balance 100", asset, ok)
+	}
+
+	if err := p.apply(assetEvent(t, "AssetUpdated", Asset{MSISDN: "9876543210", Balance: 250})); err != nil {
+		t.Fatalf("apply AssetUpdated returned error: %v", err)
+	}
+	if asset, _ := p.get("9876543210"); asset.Balance != 250 {
+		t.Errorf("after AssetUpdated balance is %d, want 250", asset.Balance)
+	}
+
+	if err := p.apply(assetEvent(t, "AssetDeleted", Asset{MSISDN: "9876543210"})); err != nil {
+		t.Fatalf("apply AssetDeleted returned error: %v", err)
+	}
+	if _, ok := p.get("9876543210"); ok {
+		t.Error("asset is still projected after AssetDeleted")
+	}
+}
+
+func TestProjectionDropsAssetsChanged(t *testing.T) {
+	p := newAssetProjection()
+	p.put(Asset{MSISDN: "9811111111", Balance: 300})
+	p.put(Asset{MSISDN: "9822222222", Balance: 200})
+	p.put(Asset{MSISDN: "9833333333", Balance: 100})
+
+	event := assetEvent(t, "AssetsChanged", AssetsChanged{MSISDNs: []string{"9811111111", "9822222222"}})
+	if err := p.apply(event); err != nil {
+		t.Fatalf("apply AssetsChanged returned error: %v", err)
+	}
+
+	for _, msisdn := range []string{"9811111111", "9822222222"} {
+		if _, ok := p.get(msisdn); ok {
+			t.Errorf("changed asset %s is still projected", msisdn)
+		}
+	}
+	if _, ok := p.get("9833333333"); !ok {
+		t.Error("unchanged asset 9833333333 was dropped")
+	}
+}
+
+func TestProjectionAppliesAssetReassigned(t *testing.T) {
+	p := newAssetProjection()
+	p.put(Asset{MSISDN: "9811111111", Balance: 300, Status: "Active"})
+
+	event := assetEvent(t, "AssetReassigned", Asset{MSISDN: "9822222222", Balance: 300, Status: "Active", PreviousMSISDN: "9811111111"})
+	if err := p.apply(event); err != nil {
+		t.Fatalf("apply AssetReassigned returned error: %v", err)
+	}
+
+	if asset, ok := p.get("9822222222"); !ok || asset.Balance != 300 {
+		t.Errorf("after AssetReassigned new key has %+v, %v, want balance 300", asset, ok)
+	}
+	// The old key was soft-deleted, so its next read goes to the ledger
+	if _, ok := p.get("9811111111"); ok {
+		t.Error("old key is still projected after AssetReassigned")
+	}
+}
+
+func TestReadAssetFallsBackToLedgerOnMiss(t *testing.T) {
+	ledger := &fakeLedger{assets: map[string]Asset{"9876543210": {MSISDN: "9876543210", Balance: 1500}}}
+	p := newAssetProjection()
+
+	asset, err := readAsset(ledger, p, "9876543210")
+	if err != nil {
+		t.Fatalf("readAsset returned error: %v", err)
+	}
+	if asset.Balance != 1500 || ledger.calls != 1 {
+		t.Fatalf("readAsset returned balance %d after %d ledger calls, want 1500 after 1", asset.Balance, ledger.calls)
+	}
+
+	// The ledger read warmed the projection
+	if _, err := readAsset(ledger, p, "9876543210"); err != nil {
+		t.Fatalf("readAsset returned error: %v", err)
+	}
+	if ledger.calls != 1 {
+		t.Errorf("second read made %d ledger calls, want it served from the projection", ledger.calls)
+	}
+
+	if _, err := readAsset(ledger, p, "9800000000"); err == nil {
+		t.Error("readAsset of a missing asset succeeded")
+	}
+}
+
+func TestProjectionResync(t *testing.T) {
+	ledger := &fakeLedger{assets: map[string]Asset{
+		"9811111111": {MSISDN: "9811111111", Balance: 300},
+		"9822222222": {MSISDN: "9822222222", Balance: 200},
+	}}
+	p := newAssetProjection()
+	p.put(Asset{MSISDN: "9800000000"})
+
+	if err := p.resync(ledger); err != nil {
+		t.Fatalf("resync returned error: %v", err)
+	}
+
+	if _, ok := p.get("9800000000"); ok {
+		t.Error("asset missing from the ledger survived the resync")
+	}
+	if asset, ok := p.get("9811111111"); !ok || asset.Balance != 300 {
+		t.Errorf("after resync got %+v, %v, want balance 300", asset, ok)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadinessResponse reports whether the chaincode answered a ping, and why not
+type ReadinessResponse struct {
+	Status string `json:"status" example:"ready"`
+	Error  string `json:"error,omitempty" example:"chaincode did not answer Ping within 5s"`
+}
+
+// checkReady evaluates the chaincode's Ping and fails unless it answers
+// "pong" within timeout. The gateway call cannot be cancelled, so on timeout
+// it is left to finish in the background.
+func checkReady(evaluate func() ([]byte, error), timeout time.Duration) error {
+	type pingResult struct {
+		response []byte
+		err      error
+	}
+
+	// Buffered so the goroutine can finish after a timeout without blocking
+	results := make(chan pingResult, 1)
+	go func() {
+		response, err := evaluate()
+		results <- pingResult{response, err}
+	}()
+
+	select {
+	case result := <-results:
+		if result.err != nil {
+			return fmt.Errorf("chaincode Ping failed: %v", result.err)
+		}
+		if string(result.response) != "pong" {
+			return fmt.Errorf("chaincode Ping answered %q", result.response)
+		}
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("chaincode did not answer Ping within %s", timeout)
+	}
+}
+
+// ready answers /readyz, reporting 503 Service Unavailable when ping fails
+// the checkReady check
+func ready(ping func() ([]byte, error), timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := checkReady(ping, timeout); err != nil {
+			c.JSON(http.StatusServiceUnavailable, ReadinessResponse{Status: "unavailable", Error: err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, ReadinessResponse{Status: "ready"})
+	}
+}
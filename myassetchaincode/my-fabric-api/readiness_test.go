@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessRequest serves GET /readyz with ping standing in for the chaincode
+func readinessRequest(t *testing.T, ping func() ([]byte, error), timeout time.Duration) (int, ReadinessResponse) {
+	t.Helper()
+	r := gin.New()
+	r.GET("/readyz", ready(ping, timeout))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var response ReadinessResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("error unmarshalling response: %v", err)
+	}
+	return w.Code, response
+}
+
+func TestReadyz(t *testing.T) {
+	code, response := readinessRequest(t, func() ([]byte, error) {
+		return []byte("pong"), nil
+	}, time.Second)
+	if code != http.StatusOK || response.Status != "ready" || response.Error != "" {
+		t.Errorf("ready chaincode got %d %+v, want 200 ready", code, response)
+	}
+
+	// Blocks until the test ends, well past the timeout
+	release := make(chan struct{})
+	defer close(release)
+
+	for _, tt := range []struct {
+		name string
+		ping func() ([]byte, error)
+		want string
+	}{
+		{"ping errors", func() ([]byte, error) { return nil, errors.New("chaincode myassetchaincode not found") }, "chaincode myassetchaincode not found"},
+		{"unexpected answer", func() ([]byte, error) { return []byte("pang"), nil }, `answered "pang"`},
+		{"ping times out", func() ([]byte, error) { <-release; return []byte("pong"), nil }, "within 20ms"},
+	} {
+		code, response := readinessRequest(t, tt.ping, 20*time.Millisecond)
+		if code != http.StatusServiceUnavailable || response.Status != "unavailable" || !strings.Contains(response.Error, tt.want) {
+			t.Errorf("%s: got %d %+v, want 503 with an error containing %q", tt.name, code, response, tt.want)
+		}
+	}
+}
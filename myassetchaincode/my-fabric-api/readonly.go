@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readOnlyMessage is the error returned for writes while the API is read-only
+const readOnlyMessage = "the API is in read-only mode for maintenance, please retry later"
+
+// readOnlySafeRoutes are the non-GET routes that do not write to the ledger
+// and stay available in read-only mode
+var readOnlySafeRoutes = map[string]bool{
+	"POST /assets/:msisdn/diff": true,
+}
+
+// rejectWrites is a middleware that answers every request that could write
+// to the ledger with 503 Service Unavailable when readOnly is set. GET, HEAD
+// and OPTIONS requests and readOnlySafeRoutes are served normally.
+func rejectWrites(readOnly bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !readOnly {
+			c.Next()
+			return
+		}
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		if readOnlySafeRoutes[c.Request.Method+" "+c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": readOnlyMessage})
+	}
+}
+
+// health answers /healthz, reporting whether the API is read-only
+func health(readOnly bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, HealthResponse{Status: "ok", ReadOnly: readOnly})
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readOnlyRouter returns a router with rejectWrites in front of a create, a
+// read and a diff route, and /healthz, counting the requests that reach a
+// handler
+func readOnlyRouter(readOnly bool, reached *int) *gin.Engine {
+	r := gin.New()
+	r.Use(rejectWrites(readOnly))
+	handler := func(c *gin.Context) {
+		*reached++
+		c.Status(http.StatusOK)
+	}
+	r.POST("/createAsset", handler)
+	r.GET("/readAsset/:msisdn", handler)
+	r.POST("/assets/:msisdn/diff", handler)
+	r.GET("/healthz", health(readOnly))
+	return r
+}
+
+func TestReadOnlyRejectsWritesAndServesReads(t *testing.T) {
+	var reached int
+	r := readOnlyRouter(true, &reached)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/createAsset", strings.NewReader(`{"MSISDN":"9876543210"}`)))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("create got status %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	var body ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Error != readOnlyMessage {
+		t.Errorf("create got body %s, want the maintenance message", w.Body)
+	}
+	if reached != 0 {
+		t.Fatal("create reached its handler in read-only mode")
+	}
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/readAsset/9876543210", nil),
+		httptest.NewRequest(http.MethodPost, "/assets/9876543210/diff", nil),
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Errorf("%s %s got status %d, want %d", req.Method, req.URL.Path, w.Code, http.StatusOK)
+		}
+	}
+	if reached != 2 {
+		t.Errorf("%d reads reached their handler, want 2", reached)
+	}
+}
+
+func TestReadOnlyDisabledAllowsWrites(t *testing.T) {
+	var reached int
+	r := readOnlyRouter(false, &reached)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/createAsset", nil))
+	if w.Code != http.StatusOK || reached != 1 {
+		t.Errorf("create got status %d and reached %d handlers, want 200 and 1", w.Code, reached)
+	}
+}
+
+func TestHealthReportsReadOnly(t *testing.T) {
+	for _, readOnly := range []bool{false, true} {
+		var reached int
+		w := httptest.NewRecorder()
+		readOnlyRouter(readOnly, &reached).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+		var body HealthResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("error unmarshalling health: %v", err)
+		}
+		if w.Code != http.StatusOK || body.Status != "ok" || body.ReadOnly != readOnly {
+			t.Errorf("health with read-only %v got status %d and %+v", readOnly, w.Code, body)
+		}
+	}
+}
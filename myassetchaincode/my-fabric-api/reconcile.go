@@ -0,0 +1,73 @@
+package main
+
+import "encoding/json"
+
+// HistoryDiff is the reconciliation of two assets' transaction histories
+type HistoryDiff struct {
+	A       string               `json:"A"`
+	B       string               `json:"B"`
+	OnlyInA []*AssetHistoryEntry `json:"OnlyInA"`
+	OnlyInB []*AssetHistoryEntry `json:"OnlyInB"`
+	Common  []*AssetHistoryEntry `json:"Common"`
+}
+
+// getAssetHistory evaluates GetAssetHistory for msisdn and decodes the entries
+func getAssetHistory(contract evaluator, msisdn string) ([]*AssetHistoryEntry, error) {
+	response, err := contract.EvaluateTransaction("GetAssetHistory", msisdn)
+	if err != nil {
+		return nil, err
+	}
+
+	var history []*AssetHistoryEntry
+	if err := json.Unmarshal(response, &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// diffHistories matches entries of both histories by TxID and timestamp,
+// keeping the order in which each history returned them
+func diffHistories(a, b string, historyA, historyB []*AssetHistoryEntry) *HistoryDiff {
+	diff := &HistoryDiff{
+		A:       a,
+		B:       b,
+		OnlyInA: []*AssetHistoryEntry{},
+		OnlyInB: []*AssetHistoryEntry{},
+		Common:  []*AssetHistoryEntry{},
+	}
+
+	inB := make(map[historyKey]bool, len(historyB))
+	for _, entry := range historyB {
+		inB[keyOf(entry)] = true
+	}
+
+	inA := make(map[historyKey]bool, len(historyA))
+	for _, entry := range historyA {
+		key := keyOf(entry)
+		inA[key] = true
+		if inB[key] {
+			diff.Common = append(diff.Common, entry)
+		} else {
+			diff.OnlyInA = append(diff.OnlyInA, entry)
+		}
+	}
+
+	for _, entry := range historyB {
+		if !inA[keyOf(entry)] {
+			diff.OnlyInB = append(diff.OnlyInB, entry)
+		}
+	}
+
+	return diff
+}
+
+// historyKey identifies a history entry for reconciliation
+type historyKey struct {
+	txID      string
+	timestamp int64
+}
+
+// keyOf returns the reconciliation key of a history entry
+func keyOf(entry *AssetHistoryEntry) historyKey {
+	return historyKey{txID: entry.TxID, timestamp: entry.Timestamp.UnixNano()}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// historyLedger answers GetAssetHistory from fixed histories
+type historyLedger map[string][]*AssetHistoryEntry
+
+func (l historyLedger) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	history, ok := l[args[0]]
+	if name != "GetAssetHistory" || !ok {
+		return nil, errors.New("unexpected transaction " + name)
+	}
+	return json.Marshal(history)
+}
+
+// txIDs joins the TxIDs of history entries in order
+func txIDs(entries []*AssetHistoryEntry) string {
+	ids := []string{}
+	for _, entry := range entries {
+		ids = append(ids, entry.TxID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestReconcileHistories(t *testing.T) {
+	at := func(minute int) time.Time {
+		return time.Date(2024, 1, 1, 0, minute, 0, 0, time.UTC)
+	}
+	ledger := historyLedger{
+		"9811111111": {
+			{TxID: "merge", Timestamp: at(5)},
+			{TxID: "topup", Timestamp: at(3)},
+			{TxID: "shared", Timestamp: at(1)},
+		},
+		"9822222222": {
+			{TxID: "merge", Timestamp: at(5)},
+			{TxID: "refund", Timestamp: at(4)},
+			// Same TxID at a different time is a different transaction
+			{TxID: "shared", Timestamp: at(2)},
+		},
+	}
+
+	historyA, err := getAssetHistory(ledger, "9811111111")
+	if err != nil {
+		t.Fatalf("getAssetHistory returned error: %v", err)
+	}
+	historyB, err := getAssetHistory(ledger, "9822222222")
+	if err != nil {
+		t.Fatalf("getAssetHistory returned error: %v", err)
+	}
+	diff := diffHistories("9811111111", "9822222222", historyA, historyB)
+
+	tests := []struct {
+		name    string
+		entries []*AssetHistoryEntry
+		want    string
+	}{
+		{"Common", diff.Common, "merge"},
+		{"OnlyInA", diff.OnlyInA, "topup,shared"},
+		{"OnlyInB", diff.OnlyInB, "refund,shared"},
+	}
+	for _, tt := range tests {
+		if got := txIDs(tt.entries); got != tt.want {
+			t.Errorf("%s is %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if diff.A != "9811111111" || diff.B != "9822222222" {
+		t.Errorf("diff compares %s and %s, want 9811111111 and 9822222222", diff.A, diff.B)
+	}
+}
+
+func TestReconcileIdenticalHistories(t *testing.T) {
+	history := []*AssetHistoryEntry{{TxID: "tx1", Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}}
+
+	diff := diffHistories("9811111111", "9822222222", history, history)
+	if len(diff.OnlyInA) != 0 || len(diff.OnlyInB) != 0 || len(diff.Common) != 1 {
+		t.Errorf("diff of identical histories is %+v, want everything common", diff)
+	}
+}
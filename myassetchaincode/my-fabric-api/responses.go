@@ -0,0 +1,76 @@
+package main
+
+import "encoding/json"
+
+// ErrorResponse is the body of every error response. Known errors carry a
+// Code; when the message is translated for Accept-Language, Detail keeps the
+// original English message.
+type ErrorResponse struct {
+	Error  string `json:"error" example:"asset with MSISDN 9876543210 does not exist"`
+	Code   string `json:"code,omitempty" example:"asset_not_found"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// MessageResponse is the body of a successful write without a result
+type MessageResponse struct {
+	Message string `json:"message" example:"Asset updated successfully"`
+}
+
+// RequestIDResponse carries the ID of a stored pending update
+type RequestIDResponse struct {
+	RequestID string `json:"requestID" example:"3f1d0c5e9a7b42d8b6e0f4a2c1d9e8b7a6f5e4d3c2b1a09f8e7d6c5b4a3f2e1d"`
+}
+
+// TotalBalanceResponse carries the sum of all asset balances
+type TotalBalanceResponse struct {
+	TotalBalance int64 `json:"totalBalance" example:"2500"`
+}
+
+// HealthResponse reports that the API is up and whether it accepts writes
+type HealthResponse struct {
+	Status   string `json:"status" example:"ok"`
+	ReadOnly bool   `json:"readOnly" example:"false"`
+}
+
+// AverageBalanceResponse carries the mean balance of the matching assets
+type AverageBalanceResponse struct {
+	AverageBalance float64 `json:"averageBalance" example:"1250.5"`
+}
+
+// VerifyMPINResponse carries whether an MPIN matched
+type VerifyMPINResponse struct {
+	Verified bool `json:"verified" example:"true"`
+}
+
+// ChangeMPINResponse carries whether an MPIN was changed
+type ChangeMPINResponse struct {
+	Changed bool `json:"changed" example:"true"`
+}
+
+// MPINLockResponse carries whether MPIN verification is locked
+type MPINLockResponse struct {
+	Locked bool `json:"locked" example:"false"`
+}
+
+// ApplyRateResponse carries the number of assets a rate was applied to
+type ApplyRateResponse struct {
+	Adjusted int `json:"adjusted" example:"42"`
+}
+
+// BulkFreezeResponse carries the number of assets frozen by a rich query
+type BulkFreezeResponse struct {
+	Frozen int `json:"frozen" example:"7"`
+}
+
+// DeleteAllResponse carries the number of assets deleted
+type DeleteAllResponse struct {
+	Deleted int `json:"deleted" example:"42"`
+}
+
+// RawStateResponse carries the bytes stored under a world state key. JSON is
+// only set when the bytes are valid JSON.
+type RawStateResponse struct {
+	Key    string          `json:"key" example:"9876543210"`
+	Base64 string          `json:"base64" example:"eyJNU0lTRE4iOiI5ODc2NTQzMjEwIn0="`
+	JSON   json.RawMessage `json:"json,omitempty" swaggertype:"object"`
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// RuleViolation names a business rule an asset broke and explains how
+type RuleViolation struct {
+	Rule    string `json:"Rule" example:"maxBalance"`
+	Message string `json:"Message" example:"balance 150000 exceeds 100000"`
+}
+
+// RuleViolationResponse is the body of a write rejected by the deployment's
+// business rules, listing every rule the asset broke
+type RuleViolationResponse struct {
+	Error      string          `json:"error" example:"asset violates business rules"`
+	Violations []RuleViolation `json:"violations"`
+}
+
+// errRuleViolation matches the prefix of the chaincode's RuleViolationError
+// message, which is followed by the JSON encoded violations
+const errRuleViolation = "asset violates business rules: "
+
+// ruleViolations recovers the violations from a write the chaincode rejected
+// for breaking its business rules
+func ruleViolations(err error) ([]RuleViolation, bool) {
+	message := err.Error()
+	start := strings.Index(message, errRuleViolation)
+	if start < 0 {
+		return nil, false
+	}
+
+	// The gateway may append to the chaincode message, so only the first JSON value is read
+	var violations []RuleViolation
+	decoder := json.NewDecoder(strings.NewReader(message[start+len(errRuleViolation):]))
+	if err := decoder.Decode(&violations); err != nil || len(violations) == 0 {
+		return nil, false
+	}
+	return violations, true
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRuleViolations(t *testing.T) {
+	// The gateway wraps the chaincode message and may append to it
+	err := errors.New(`endorsement failed: chaincode response 500, asset violates business rules: [{"Rule":"maxBalance","Message":"balance 1500 exceeds 1000"},{"Rule":"msisdnPattern","Message":"MSISDN 9711111111 does not match 98\\d{8}"}] (peer0)`)
+	violations, ok := ruleViolations(err)
+	if !ok {
+		t.Fatalf("ruleViolations(%q) found no violations", err)
+	}
+	if len(violations) != 2 || violations[0].Rule != "maxBalance" || violations[1].Rule != "msisdnPattern" {
+		t.Errorf("violations are %+v, want maxBalance and msisdnPattern", violations)
+	}
+	if violations[1].Message != `MSISDN 9711111111 does not match 98\d{8}` {
+		t.Errorf("message is %q, want the chaincode message", violations[1].Message)
+	}
+
+	for _, message := range []string{
+		"cannot update nonexistent asset",
+		"asset violates business rules: not json",
+		"asset violates business rules: []",
+	} {
+		if violations, ok := ruleViolations(errors.New(message)); ok {
+			t.Errorf("ruleViolations(%q) returned %+v, want none", message, violations)
+		}
+	}
+}
+
+func TestRespondCreateErrorRuleViolation(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	respondCreateError(c, "9711111111", errors.New(`asset violates business rules: [{"Rule":"msisdnPattern","Message":"MSISDN 9711111111 does not match 98\\d{8}"}]`))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status is %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	var response RuleViolationResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("error decoding response: %v", err)
+	}
+	if len(response.Violations) != 1 || response.Violations[0].Rule != "msisdnPattern" {
+		t.Errorf("violations are %+v, want the msisdnPattern rule", response.Violations)
+	}
+}
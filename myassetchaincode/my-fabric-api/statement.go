@@ -0,0 +1,165 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Statement is the transaction statement of an asset as of GeneratedAt
+type Statement struct {
+	MSISDN      string               `json:"MSISDN" example:"9876543210"`
+	DealerID    string               `json:"DealerID" example:"D001"`
+	Balance     int64                `json:"Balance" example:"1500"`
+	GeneratedAt time.Time            `json:"GeneratedAt" example:"2024-01-15T10:30:00Z"`
+	Entries     []*AssetHistoryEntry `json:"Entries"`
+}
+
+// SignedStatement is a statement with the server's signature over its exact
+// bytes. To verify, check Signature over the raw Statement JSON (its SHA-256
+// digest for ECDSA and RSA) with the public key identified by KeyID.
+type SignedStatement struct {
+	Statement json.RawMessage `json:"Statement" swaggertype:"object"`
+	Algorithm string          `json:"Algorithm" example:"ECDSA-SHA256"`
+	KeyID     string          `json:"KeyID" example:"9c1f0e6d2b7a4c3e8f5d1a0b6c9e2f47"`
+	Signature []byte          `json:"Signature" swaggertype:"string" format:"base64"`
+}
+
+// AssetBundle is an asset with its complete history, read by the chaincode
+// in one transaction, for dispute resolution
+type AssetBundle struct {
+	Asset       *Asset               `json:"Asset"`
+	History     []*AssetHistoryEntry `json:"History"`
+	TxID        string               `json:"TxID" example:"3f1d0c5e9a7b42d8b6e0f4a2c1d9e8b7a6f5e4d3c2b1a09f8e7d6c5b4a3f2e1d"`
+	GeneratedAt time.Time            `json:"GeneratedAt" example:"2024-01-15T10:30:00Z"`
+}
+
+// SignedBundle is an asset bundle with the server's signature over its exact
+// bytes, verified the same way as a SignedStatement
+type SignedBundle struct {
+	Bundle    json.RawMessage `json:"Bundle" swaggertype:"object"`
+	Algorithm string          `json:"Algorithm" example:"ECDSA-SHA256"`
+	KeyID     string          `json:"KeyID" example:"9c1f0e6d2b7a4c3e8f5d1a0b6c9e2f47"`
+	Signature []byte          `json:"Signature" swaggertype:"string" format:"base64"`
+}
+
+// statementSigner signs statements and bundles with the server key
+type statementSigner struct {
+	key       crypto.Signer
+	algorithm string
+	keyID     string
+}
+
+// loadStatementSigner reads a PEM encoded ECDSA, RSA or Ed25519 private key from path
+func loadStatementSigner(path string) (*statementSigner, error) {
+	keyPEM, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading statement signing key: %v", err)
+	}
+
+	key, err := parsePrivateKeyPEM(keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing statement signing key %s: %v", path, err)
+	}
+
+	signer := &statementSigner{key: key}
+	switch key.(type) {
+	case *ecdsa.PrivateKey:
+		signer.algorithm = "ECDSA-SHA256"
+	case *rsa.PrivateKey:
+		signer.algorithm = "RSA-PKCS1v15-SHA256"
+	case ed25519.PrivateKey:
+		signer.algorithm = "Ed25519"
+	default:
+		return nil, fmt.Errorf("unsupported statement signing key type %T", key)
+	}
+
+	publicDER, err := x509.MarshalPKIXPublicKey(signer.key.Public())
+	if err != nil {
+		return nil, fmt.Errorf("error encoding statement public key: %v", err)
+	}
+	fingerprint := sha256.Sum256(publicDER)
+	signer.keyID = hex.EncodeToString(fingerprint[:16])
+
+	return signer, nil
+}
+
+// buildStatement reads an asset and its history into a statement generated at now
+func buildStatement(contract evaluator, msisdn string, now time.Time) (*Statement, error) {
+	asset, err := readAsset(contract, nil, msisdn)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := getAssetHistory(contract, msisdn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Statement{
+		MSISDN:      asset.MSISDN,
+		DealerID:    asset.DealerID,
+		Balance:     asset.Balance,
+		GeneratedAt: now,
+		Entries:     history,
+	}, nil
+}
+
+// sign encodes a statement and signs the encoded bytes
+func (s *statementSigner) sign(statement Statement) (*SignedStatement, error) {
+	statementJSON, err := json.Marshal(statement)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := s.signBytes(statementJSON)
+	if err != nil {
+		return nil, fmt.Errorf("error signing statement: %v", err)
+	}
+
+	return &SignedStatement{
+		Statement: statementJSON,
+		Algorithm: s.algorithm,
+		KeyID:     s.keyID,
+		Signature: signature,
+	}, nil
+}
+
+// signBundle encodes an asset bundle and signs the encoded bytes
+func (s *statementSigner) signBundle(bundle AssetBundle) (*SignedBundle, error) {
+	bundleJSON, err := json.Marshal(bundle)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := s.signBytes(bundleJSON)
+	if err != nil {
+		return nil, fmt.Errorf("error signing bundle: %v", err)
+	}
+
+	return &SignedBundle{
+		Bundle:    bundleJSON,
+		Algorithm: s.algorithm,
+		KeyID:     s.keyID,
+		Signature: signature,
+	}, nil
+}
+
+// signBytes signs message with the server key. Ed25519 signs the message
+// itself; the other algorithms sign its SHA-256 digest.
+func (s *statementSigner) signBytes(message []byte) ([]byte, error) {
+	if s.algorithm == "Ed25519" {
+		return s.key.Sign(rand.Reader, message, crypto.Hash(0))
+	}
+	digest := sha256.Sum256(message)
+	return s.key.Sign(rand.Reader, digest[:], crypto.SHA256)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// statementLedger answers ReadAsset and GetAssetHistory for a single asset
+type statementLedger struct {
+	asset   Asset
+	history []*AssetHistoryEntry
+}
+
+func (l *statementLedger) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	if args[0] != l.asset.MSISDN {
+		return nil, errors.New("asset with MSISDN " + args[0] + " does not exist")
+	}
+	switch name {
+	case "ReadAsset":
+		return json.Marshal(l.asset)
+	case "GetAssetHistory":
+		return json.Marshal(l.history)
+	}
+	return nil, errors.New("unexpected transaction " + name)
+}
+
+// testStatementSigner writes a fresh ECDSA key to a PEM file, loads it as the
+// statement signer and returns both
+func testStatementSigner(t *testing.T) (*statementSigner, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("error encoding key: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "statement.key")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatalf("error writing key: %v", err)
+	}
+
+	signer, err := loadStatementSigner(path)
+	if err != nil {
+		t.Fatalf("loadStatementSigner returned error: %v", err)
+	}
+	return signer, key
+}
+
+func TestSignedStatement(t *testing.T) {
+	at := func(minute int) time.Time {
+		return time.Date(2024, 1, 1, 0, minute, 0, 0, time.UTC)
+	}
+	ledger := &statementLedger{
+		asset: Asset{MSISDN: "9811111111", DealerID: "D001", Balance: 700},
+		history: []*AssetHistoryEntry{
+			{TxID: "topup", Timestamp: at(2), Value: &Asset{MSISDN: "9811111111", Balance: 700, TransAmount: 200}},
+			{TxID: "create", Timestamp: at(1), Value: &Asset{MSISDN: "9811111111", Balance: 500}},
+		},
+	}
+	signer, key := testStatementSigner(t)
+
+	statement, err := buildStatement(ledger, "9811111111", at(3))
+	if err != nil {
+		t.Fatalf("buildStatement returned error: %v", err)
+	}
+	signed, err := signer.sign(*statement)
+	if err != nil {
+		t.Fatalf("sign returned error: %v", err)
+	}
+
+	var receipt Statement
+	if err := json.Unmarshal(signed.Statement, &receipt); err != nil {
+		t.Fatalf("error unmarshalling signed statement: %v", err)
+	}
+	if receipt.MSISDN != "9811111111" || receipt.DealerID != "D001" || receipt.Balance != 700 || !receipt.GeneratedAt.Equal(at(3)) {
+		t.Errorf("statement is %+v, want asset 9811111111 of D001 with balance 700 at %s", receipt, at(3))
+	}
+	if got := txIDs(receipt.Entries); got != "topup,create" {
+		t.Errorf("statement entries are %s, want topup,create", got)
+	}
+	if receipt.Entries[0].Value.TransAmount != 200 {
+		t.Errorf("first entry is %+v, want the 200 top-up", receipt.Entries[0].Value)
+	}
+
+	publicDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("error encoding public key: %v", err)
+	}
+	fingerprint := sha256.Sum256(publicDER)
+	if signed.Algorithm != "ECDSA-SHA256" || signed.KeyID != hex.EncodeToString(fingerprint[:16]) {
+		t.Errorf("signed with %s key %s, want ECDSA-SHA256 and the key's fingerprint", signed.Algorithm, signed.KeyID)
+	}
+
+	digest := sha256.Sum256(signed.Statement)
+	if !ecdsa.VerifyASN1(&key.PublicKey, digest[:], signed.Signature) {
+		t.Error("signature does not verify against the statement")
+	}
+	tampered := sha256.Sum256(append([]byte(" "), signed.Statement...))
+	if ecdsa.VerifyASN1(&key.PublicKey, tampered[:], signed.Signature) {
+		t.Error("signature verifies against a modified statement")
+	}
+}
+
+func TestBuildStatementMissingAsset(t *testing.T) {
+	ledger := &statementLedger{asset: Asset{MSISDN: "9811111111"}}
+	if _, err := buildStatement(ledger, "9800000000", time.Now()); err == nil {
+		t.Error("buildStatement of a missing asset succeeded")
+	}
+}
+
+func TestSignedBundle(t *testing.T) {
+	at := func(minute int) time.Time {
+		return time.Date(2024, 1, 1, 0, minute, 0, 0, time.UTC)
+	}
+	bundle := AssetBundle{
+		Asset: &Asset{MSISDN: "9811111111", DealerID: "D001", Balance: 700},
+		History: []*AssetHistoryEntry{
+			{TxID: "topup", Timestamp: at(2), Value: &Asset{MSISDN: "9811111111", Balance: 700, TransAmount: 200}},
+			{TxID: "create", Timestamp: at(1), Value: &Asset{MSISDN: "9811111111", Balance: 500}},
+		},
+		TxID:        "export",
+		GeneratedAt: at(3),
+	}
+	signer, key := testStatementSigner(t)
+
+	signed, err := signer.signBundle(bundle)
+	if err != nil {
+		t.Fatalf("signBundle returned error: %v", err)
+	}
+
+	var received AssetBundle
+	if err := json.Unmarshal(signed.Bundle, &received); err != nil {
+		t.Fatalf("error unmarshalling signed bundle: %v", err)
+	}
+	if received.Asset == nil || received.Asset.MSISDN != "9811111111" || received.Asset.Balance != 700 {
+		t.Errorf("bundled asset is %+v, want 9811111111 with balance 700", received.Asset)
+	}
+	if got := txIDs(received.History); got != "topup,create" {
+		t.Errorf("bundled history is %s, want topup,create", got)
+	}
+	if received.TxID != "export" || !received.GeneratedAt.Equal(at(3)) {
+		t.Errorf("bundle generated by %s at %s, want export at %s", received.TxID, received.GeneratedAt, at(3))
+	}
+
+	if signed.Algorithm != "ECDSA-SHA256" || signed.KeyID != signer.keyID {
+		t.Errorf("signed with %s key %s, want ECDSA-SHA256 and the statement key", signed.Algorithm, signed.KeyID)
+	}
+	digest := sha256.Sum256(signed.Bundle)
+	if !ecdsa.VerifyASN1(&key.PublicKey, digest[:], signed.Signature) {
+		t.Error("signature does not verify against the bundle")
+	}
+	tampered := sha256.Sum256(append([]byte(" "), signed.Bundle...))
+	if ecdsa.VerifyASN1(&key.PublicKey, tampered[:], signed.Signature) {
+		t.Error("signature verifies against a modified bundle")
+	}
+}
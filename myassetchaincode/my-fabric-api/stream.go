@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+// eventSource registers for chaincode events; it is satisfied by both
+// *gateway.Contract and *correlatedContract
+type eventSource interface {
+	RegisterEvent(eventFilter string) (fab.Registration, <-chan *fab.CCEvent, error)
+	Unregister(registration fab.Registration)
+}
+
+// streamAssetEvents sends each asset event from contract to the client as a
+// Server-Sent Event named after the chaincode event, with the asset as its
+// data, or the changed MSISDNs for AssetsChanged. A comment is sent every
+// heartbeatInterval so idle proxies keep the connection open. The event
+// registration is removed when the client leaves.
+func streamAssetEvents(c *gin.Context, contract eventSource, heartbeatInterval time.Duration) {
+	registration, events, err := contract.RegisterEvent(assetEventFilter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("error registering for asset events: %v", err)})
+		return
+	}
+	defer contract.Unregister(registration)
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			var data interface{} = &Asset{}
+			if event.EventName == "AssetsChanged" {
+				data = &AssetsChanged{}
+			}
+			if err := json.Unmarshal(event.Payload, data); err != nil {
+				fmt.Printf("Failed to stream %s event: %s\n", event.EventName, err)
+				continue
+			}
+			c.SSEvent(event.EventName, data)
+		}
+		c.Writer.Flush()
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+// fakeEventSource hands out a single event channel and reports when it is unregistered
+type fakeEventSource struct {
+	filter       string
+	events       chan *fab.CCEvent
+	unregistered chan fab.Registration
+}
+
+func (s *fakeEventSource) RegisterEvent(eventFilter string) (fab.Registration, <-chan *fab.CCEvent, error) {
+	s.filter = eventFilter
+	return "registration", s.events, nil
+}
+
+func (s *fakeEventSource) Unregister(registration fab.Registration) {
+	s.unregistered <- registration
+}
+
+// readSSELine reads the next line of a stream, failing the test on timeout
+func readSSELine(t *testing.T, lines <-chan string) string {
+	t.Helper()
+	select {
+	case line := <-lines:
+		return line
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the stream")
+		return ""
+	}
+}
+
+func TestStreamAssetEvents(t *testing.T) {
+	source := &fakeEventSource{events: make(chan *fab.CCEvent), unregistered: make(chan fab.Registration, 1)}
+	r := gin.New()
+	r.GET("/assets/stream", func(c *gin.Context) {
+		streamAssetEvents(c, source, 50*time.Millisecond)
+	})
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/assets/stream", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("error opening the stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("stream answered %d with %s, want 200 text/event-stream", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if source.filter != assetEventFilter {
+		t.Errorf("registered for %q, want assetEventFilter", source.filter)
+	}
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if scanner.Text() != "" {
+				lines <- scanner.Text()
+			}
+		}
+		close(lines)
+	}()
+
+	// Idle connections get heartbeat comments
+	if line := readSSELine(t, lines); line != ": heartbeat" {
+		t.Errorf("idle stream sent %q, want a heartbeat comment", line)
+	}
+
+	source.events <- assetEvent(t, "AssetUpdated", Asset{MSISDN: "9811111111", Balance: 250})
+	line := readSSELine(t, lines)
+	for line == ": heartbeat" {
+		line = readSSELine(t, lines)
+	}
+	if line != "event:AssetUpdated" {
+		t.Errorf("stream sent %q, want the AssetUpdated event", line)
+	}
+	data := readSSELine(t, lines)
+	if !strings.HasPrefix(data, "data:") || !strings.Contains(data, `"MSISDN":"9811111111"`) || !strings.Contains(data, `"Balance":250`) {
+		t.Errorf("event data is %q, want the updated asset", data)
+	}
+
+	// Leaving the stream removes the registration
+	cancel()
+	select {
+	case registration := <-source.unregistered:
+		if registration != "registration" {
+			t.Errorf("unregistered %v, want the stream's registration", registration)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("registration was not removed after the client left")
+	}
+}
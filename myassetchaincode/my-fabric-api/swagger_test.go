@@ -0,0 +1,35 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestServeSwaggerUsesConfiguredHost(t *testing.T) {
+	t.Setenv("SWAGGER_HOST", "api.example.com")
+	t.Setenv("SWAGGER_BASE_PATH", "/assets-api/v1")
+	cfg := loadConfig()
+
+	r := gin.New()
+	serveSwagger(r, cfg.SwaggerHost, cfg.SwaggerBasePath)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/swagger/doc.json", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status is %d, want %d", w.Code, http.StatusOK)
+	}
+	var spec struct {
+		Host     string `json:"host"`
+		BasePath string `json:"basePath"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("error unmarshalling spec: %v", err)
+	}
+	if spec.Host != "api.example.com" || spec.BasePath != "/assets-api/v1" {
+		t.Errorf("spec has host %q and base path %q, want api.example.com and /assets-api/v1", spec.Host, spec.BasePath)
+	}
+}
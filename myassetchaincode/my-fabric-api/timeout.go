@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// withRouteTimeouts is a middleware that gives each request a deadline: the
+// timeout configured for its route, keyed as "METHOD /route" (e.g.
+// "GET /assets/export.jsonl"), or def for other routes. A timeout of 0 means
+// no deadline. Waiting for a submission slot and the long iterations stop at
+// the deadline; a chaincode call already in flight cannot be interrupted, as
+// the gateway SDK takes no context. A handler that stops without responding
+// is answered with 504 Gateway Timeout.
+func withRouteTimeouts(def time.Duration, routes map[string]time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout, ok := routes[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			timeout = def
+		}
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			c.JSON(http.StatusGatewayTimeout, gin.H{"error": fmt.Sprintf("request exceeded its timeout of %s", timeout)})
+		}
+	}
+}
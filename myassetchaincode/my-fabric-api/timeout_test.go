@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// slowOperation takes work to finish unless the request deadline comes first,
+// reporting the cancellation the way the export handlers do
+func slowOperation(work time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		select {
+		case <-time.After(work):
+			c.JSON(http.StatusOK, gin.H{"message": "done"})
+		case <-c.Request.Context().Done():
+			err := checkCancelled(c.Request.Context())
+			c.JSON(cancelledStatus(err), gin.H{"error": err.Error()})
+		}
+	}
+}
+
+func TestRouteTimeouts(t *testing.T) {
+	r := gin.New()
+	r.Use(withRouteTimeouts(5*time.Second, map[string]time.Duration{
+		"GET /assets":              20 * time.Millisecond,
+		"GET /assets/export.jsonl": 10 * time.Second,
+		"GET /unlimited":           0,
+	}))
+	r.GET("/assets", slowOperation(200*time.Millisecond))
+	r.GET("/assets/export.jsonl", slowOperation(200*time.Millisecond))
+	r.GET("/unlimited", func(c *gin.Context) {
+		if _, ok := c.Request.Context().Deadline(); ok {
+			t.Error("route with a timeout of 0 has a deadline")
+		}
+		c.Status(http.StatusNoContent)
+	})
+	r.GET("/default", func(c *gin.Context) {
+		deadline, ok := c.Request.Context().Deadline()
+		if !ok || time.Until(deadline) > 5*time.Second || time.Until(deadline) < 4*time.Second {
+			t.Errorf("route without its own timeout has deadline %v, %v, want the 5s default", deadline, ok)
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	for _, tt := range []struct {
+		path string
+		want int
+	}{
+		{"/assets", http.StatusGatewayTimeout},
+		{"/assets/export.jsonl", http.StatusOK},
+		{"/unlimited", http.StatusNoContent},
+		{"/default", http.StatusNoContent},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.want {
+			t.Errorf("GET %s got status %d, want %d", tt.path, w.Code, tt.want)
+		}
+	}
+}
+
+func TestRouteTimeoutAnswersSilentHandler(t *testing.T) {
+	r := gin.New()
+	r.Use(withRouteTimeouts(10*time.Millisecond, nil))
+	// Gives up at the deadline without writing a response
+	r.GET("/assets", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/assets", nil))
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusGatewayTimeout)
+	}
+}
+
+func TestCancelledStatus(t *testing.T) {
+	expired, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+	if status := cancelledStatus(checkCancelled(expired)); status != http.StatusGatewayTimeout {
+		t.Errorf("status of a request past its deadline is %d, want %d", status, http.StatusGatewayTimeout)
+	}
+
+	gone, cancel := context.WithCancel(context.Background())
+	cancel()
+	if status := cancelledStatus(checkCancelled(gone)); status != http.StatusRequestTimeout {
+		t.Errorf("status of a request whose client went away is %d, want %d", status, http.StatusRequestTimeout)
+	}
+}
+
+func TestGetEnvDurationMap(t *testing.T) {
+	t.Setenv("ROUTE_TIMEOUTS", "GET /assets/export.jsonl=2m, GET /assets = 5s,broken,POST /import=soon")
+	got := getEnvDurationMap("ROUTE_TIMEOUTS")
+	want := map[string]time.Duration{"GET /assets/export.jsonl": 2 * time.Minute, "GET /assets": 5 * time.Second}
+	if len(got) != len(want) {
+		t.Fatalf("getEnvDurationMap = %v, want %v", got, want)
+	}
+	for route, timeout := range want {
+		if got[route] != timeout {
+			t.Errorf("timeout of %s is %s, want %s", route, got[route], timeout)
+		}
+	}
+
+	t.Setenv("ROUTE_TIMEOUTS", "")
+	if got := getEnvDurationMap("ROUTE_TIMEOUTS"); got != nil {
+		t.Errorf("getEnvDurationMap of an unset variable = %v, want nil", got)
+	}
+}
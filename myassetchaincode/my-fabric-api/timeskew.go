@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// TimeSkewReport compares the server clock with the transaction timestamp
+// and the peer clock seen by the chaincode. Skews are positive when the
+// ledger side is ahead of the server.
+type TimeSkewReport struct {
+	ServerTime        time.Time `json:"ServerTime" example:"2024-01-15T10:30:00Z"`
+	TxTimestamp       time.Time `json:"TxTimestamp" example:"2024-01-15T10:30:00.002Z"`
+	PeerTime          time.Time `json:"PeerTime" example:"2024-01-15T10:30:01.250Z"`
+	TxTimestampSkewMs int64     `json:"TxTimestampSkewMs" example:"2"`
+	PeerSkewMs        int64     `json:"PeerSkewMs" example:"1250"`
+	RoundTripMs       int64     `json:"RoundTripMs" example:"40"`
+}
+
+// ledgerTime is the result of the chaincode's GetLedgerTime
+type ledgerTime struct {
+	TxTimestamp time.Time `json:"TxTimestamp"`
+	PeerTime    time.Time `json:"PeerTime"`
+}
+
+// measureTimeSkew evaluates GetLedgerTime and compares the times it returns
+// with the server clock halfway through the call, which cancels out the
+// network latency when it is symmetric
+func measureTimeSkew(evaluate func() ([]byte, error), now func() time.Time) (*TimeSkewReport, error) {
+	start := now()
+	response, err := evaluate()
+	if err != nil {
+		return nil, err
+	}
+	end := now()
+
+	var ledger ledgerTime
+	if err := json.Unmarshal(response, &ledger); err != nil {
+		return nil, fmt.Errorf("error decoding ledger time: %v", err)
+	}
+
+	roundTrip := end.Sub(start)
+	serverTime := start.Add(roundTrip / 2)
+
+	return &TimeSkewReport{
+		ServerTime:        serverTime.UTC(),
+		TxTimestamp:       ledger.TxTimestamp,
+		PeerTime:          ledger.PeerTime,
+		TxTimestampSkewMs: ledger.TxTimestamp.Sub(serverTime).Milliseconds(),
+		PeerSkewMs:        ledger.PeerTime.Sub(serverTime).Milliseconds(),
+		RoundTripMs:       roundTrip.Milliseconds(),
+	}, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// steppingClock returns the given times in turn
+func steppingClock(times ...time.Time) func() time.Time {
+	return func() time.Time {
+		now := times[0]
+		times = times[1:]
+		return now
+	}
+}
+
+func TestMeasureTimeSkew(t *testing.T) {
+	start := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	now := steppingClock(start, start.Add(100*time.Millisecond))
+	evaluate := func() ([]byte, error) {
+		return []byte(`{"TxTimestamp":"2024-01-15T10:30:00.040Z","PeerTime":"2024-01-15T10:30:01.300Z"}`), nil
+	}
+
+	report, err := measureTimeSkew(evaluate, now)
+	if err != nil {
+		t.Fatalf("measureTimeSkew returned error: %v", err)
+	}
+
+	// The server time is taken halfway through the 100ms round trip
+	if want := start.Add(50 * time.Millisecond); !report.ServerTime.Equal(want) {
+		t.Errorf("ServerTime = %v, want %v", report.ServerTime, want)
+	}
+	if report.RoundTripMs != 100 {
+		t.Errorf("RoundTripMs = %d, want 100", report.RoundTripMs)
+	}
+	if report.TxTimestampSkewMs != -10 {
+		t.Errorf("TxTimestampSkewMs = %d, want -10 for a timestamp behind the server", report.TxTimestampSkewMs)
+	}
+	if report.PeerSkewMs != 1250 {
+		t.Errorf("PeerSkewMs = %d, want 1250", report.PeerSkewMs)
+	}
+}
+
+func TestMeasureTimeSkewErrors(t *testing.T) {
+	start := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	_, err := measureTimeSkew(func() ([]byte, error) {
+		return nil, errors.New("chaincode unavailable")
+	}, steppingClock(start, start))
+	if err == nil || err.Error() != "chaincode unavailable" {
+		t.Errorf("measureTimeSkew returned %v, want the evaluate error", err)
+	}
+
+	_, err = measureTimeSkew(func() ([]byte, error) {
+		return []byte("not json"), nil
+	}, steppingClock(start, start))
+	if err == nil {
+		t.Error("measureTimeSkew accepted an undecodable ledger time")
+	}
+}
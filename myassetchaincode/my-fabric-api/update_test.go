@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// updateRequest posts body to the update route of msisdn and returns the
+// response and the asset bound from it, if any
+func updateRequest(t *testing.T, msisdn, body string) (*httptest.ResponseRecorder, *Asset) {
+	t.Helper()
+	var bound *Asset
+	r := gin.New()
+	r.POST("/updateAsset/:msisdn", func(c *gin.Context) {
+		asset, ok := bindUpdateRequest(c)
+		if !ok {
+			return
+		}
+		bound = &asset
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/updateAsset/"+msisdn, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w, bound
+}
+
+func TestUpdateRequestRejectsMismatchedMSISDN(t *testing.T) {
+	w, bound := updateRequest(t, "9876543210", `{"MSISDN":"1234567890","Balance":2000,"Status":"Active"}`)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if bound != nil {
+		t.Errorf("mismatched request was bound to %+v", bound)
+	}
+	var body ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("error unmarshalling response: %v", err)
+	}
+	if !strings.Contains(body.Error, "1234567890") || !strings.Contains(body.Error, "9876543210") {
+		t.Errorf("error is %q, want it to name both MSISDNs", body.Error)
+	}
+}
+
+func TestUpdateRequestAcceptsMatchingMSISDN(t *testing.T) {
+	for _, body := range []string{
+		`{"Balance":2000,"Status":"Active"}`,
+		`{"MSISDN":"9876543210","Balance":2000,"Status":"Active"}`,
+		`{"MSISDN":"+91 98765-43210","Balance":2000,"Status":"Active"}`,
+	} {
+		w, bound := updateRequest(t, "9876543210", body)
+		if w.Code != http.StatusOK || bound == nil {
+			t.Errorf("body %s got status %d: %s", body, w.Code, w.Body)
+			continue
+		}
+		if bound.MSISDN != "9876543210" || bound.Balance != 2000 {
+			t.Errorf("body %s was bound to MSISDN %s and balance %d, want the path MSISDN and 2000", body, bound.MSISDN, bound.Balance)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Normalization rules shared with the chaincode's normalizeMSISDN
+const (
+	defaultCountryCode   = "91"
+	nationalNumberLength = 10
+)
+
+// MSISDN path parameter limits. The separators are the ones the chaincode
+// strips when normalizing; E.164 numbers have at most 15 digits.
+const (
+	maxMSISDNParamLength = 24
+	minMSISDNDigits      = 8
+	maxMSISDNDigits      = 15
+)
+
+// validateMSISDNParam rejects requests whose :msisdn path parameter is too
+// long or contains anything other than digits, a leading "+" and the
+// separators the chaincode normalizes away, before it reaches a ledger key
+func validateMSISDNParam() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		msisdn, ok := c.Params.Get("msisdn")
+		if !ok {
+			c.Next()
+			return
+		}
+
+		if err := checkMSISDN(msisdn); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// checkMSISDN reports why an MSISDN fails the format rules, or nil if it passes
+func checkMSISDN(msisdn string) error {
+	if len(msisdn) > maxMSISDNParamLength {
+		return fmt.Errorf("MSISDN must be at most %d characters", maxMSISDNParamLength)
+	}
+
+	digits := 0
+	for i, r := range msisdn {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case r == '+' && i == 0:
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return fmt.Errorf("MSISDN contains invalid character %q", r)
+		}
+	}
+
+	if digits < minMSISDNDigits || digits > maxMSISDNDigits {
+		return fmt.Errorf("MSISDN must have between %d and %d digits", minMSISDNDigits, maxMSISDNDigits)
+	}
+
+	return nil
+}
+
+// normalizeMSISDN mirrors the chaincode's normalizeMSISDN, so the API can tell
+// whether two spellings of an MSISDN name the same asset
+func normalizeMSISDN(msisdn string) string {
+	normalized := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')', '\t':
+			return -1
+		}
+		return r
+	}, msisdn)
+
+	if strings.HasPrefix(normalized, "+") {
+		normalized = normalized[1:]
+	} else if strings.HasPrefix(normalized, "00") {
+		normalized = normalized[2:]
+	}
+
+	if len(normalized) == len(defaultCountryCode)+nationalNumberLength && strings.HasPrefix(normalized, defaultCountryCode) {
+		normalized = normalized[len(defaultCountryCode):]
+	}
+
+	if len(normalized) == nationalNumberLength+1 && strings.HasPrefix(normalized, "0") {
+		normalized = normalized[1:]
+	}
+
+	return normalized
+}
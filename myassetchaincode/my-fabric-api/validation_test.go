@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestValidateMSISDNParam(t *testing.T) {
+	reached := false
+	r := gin.New()
+	r.Use(validateMSISDNParam())
+	r.GET("/readAsset/:msisdn", func(c *gin.Context) {
+		reached = true
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"national", "/readAsset/9876543210", http.StatusOK},
+		{"international with separators", "/readAsset/+91%2098765-43210", http.StatusOK},
+		{"over-length", "/readAsset/" + strings.Repeat("9", maxMSISDNParamLength+1), http.StatusBadRequest},
+		{"control character", "/readAsset/98765%0A43210", http.StatusBadRequest},
+		{"NUL", "/readAsset/9876543210%00", http.StatusBadRequest},
+		{"too few digits", "/readAsset/12345", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		reached = false
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		if w.Code != tt.status {
+			t.Errorf("%s MSISDN got status %d, want %d: %s", tt.name, w.Code, tt.status, w.Body)
+		}
+		if reached != (tt.status == http.StatusOK) {
+			t.Errorf("%s MSISDN reached the handler: %v", tt.name, reached)
+		}
+	}
+}
+
+func TestValidateMSISDNParamIgnoresOtherRoutes(t *testing.T) {
+	r := gin.New()
+	r.Use(validateMSISDNParam())
+	r.GET("/dealers/:dealerID/usage", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/dealers/D-001/usage", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("route without an MSISDN got status %d, want %d", w.Code, http.StatusOK)
+	}
+}
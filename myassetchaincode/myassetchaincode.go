@@ -1,31 +1,229 @@
 package main
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	"github.com/golang/protobuf/ptypes"
 )
 
-// Asset describes the structure of an asset
+// Asset describes the structure of an asset. Fields tagged encrypt:"true" are
+// stored encrypted when FIELD_ENCRYPTION_KEY is set (see fieldcrypt.go).
 type Asset struct {
-	DealerID     string    `json:"DealerID"`
-	MSISDN       string    `json:"MSISDN"`
-	MPIN         string    `json:"MPIN"`
-	Balance      int       `json:"Balance"`
-	Status       string    `json:"Status"`
-	TransAmount  int       `json:"TransAmount"`
-	TransType    string    `json:"TransType"`
-	Remarks      string    `json:"Remarks"`
-	Timestamp    time.Time `json:"Timestamp"`
+	DealerID    string            `json:"DealerID"`
+	MSISDN      string            `json:"MSISDN"`
+	MPIN        string            `json:"MPIN" encrypt:"true"`
+	Balance     int64             `json:"Balance"`
+	Status      string            `json:"Status"`
+	TransAmount int64             `json:"TransAmount"`
+	TransType   string            `json:"TransType"`
+	Remarks     string            `json:"Remarks" encrypt:"true"`
+	Timestamp   time.Time         `json:"Timestamp"`
+	LockedUntil time.Time         `json:"LockedUntil"`
+	Metadata    map[string]string `json:"Metadata"`
+	CreatedAt   time.Time         `json:"CreatedAt"`
+	Flagged     bool              `json:"Flagged"`
+	Label       string            `json:"Label"`
+
+	// PreviousMSISDN and ReassignedTo link the two keys of an asset moved by ReassignMSISDN
+	PreviousMSISDN string `json:"PreviousMSISDN"`
+	ReassignedTo   string `json:"ReassignedTo"`
+
+	// FailedAttempts counts consecutive wrong MPINs given to VerifyMPIN
+	FailedAttempts  int       `json:"FailedAttempts"`
+	MPINLockedUntil time.Time `json:"MPINLockedUntil"`
+
+	// MPINSetAt is when the MPIN was last set; MPINRotationRequired is derived
+	// from it on read and never stored
+	MPINSetAt            time.Time `json:"MPINSetAt"`
+	MPINRotationRequired bool      `json:"MPINRotationRequired"`
+
+	// ExternalRef is the payment processor reference of the last update, if any
+	ExternalRef string `json:"ExternalRef"`
+
+	// Category classifies the last update for finance reporting, see allowedCategories
+	Category string `json:"Category"`
+
+	// ParentMSISDN links a sub-wallet to the master asset it was created under
+	ParentMSISDN string `json:"ParentMSISDN,omitempty"`
+
+	// ReversedTxID is the transaction the last update reversed, see ReverseTransaction
+	ReversedTxID string `json:"ReversedTxID,omitempty"`
+
+	// Latitude and Longitude locate the asset on a map, see SetAssetLocation
+	Latitude  float64 `json:"Latitude,omitempty"`
+	Longitude float64 `json:"Longitude,omitempty"`
+
+	// SchemaVersion is the version of the record layout, see migrateAsset
+	SchemaVersion int `json:"SchemaVersion"`
 }
 
 // AssetHistoryEntry describes an entry in the asset transaction history
 type AssetHistoryEntry struct {
 	TxID      string    `json:"TxID"`
 	Timestamp time.Time `json:"Timestamp"`
+
+	// Value is the asset as written by the transaction. It is nil for a
+	// delete, and for a value that could not be decoded, which ParseError explains.
+	IsDelete   bool   `json:"IsDelete"`
+	Value      *Asset `json:"Value,omitempty"`
+	ParseError string `json:"ParseError,omitempty"`
+}
+
+// Limits on integrator-supplied asset metadata
+const (
+	maxMetadataEntries     = 32
+	maxMetadataKeyLength   = 64
+	maxMetadataValueLength = 256
+)
+
+// maxExternalRefLength is the longest ExternalRef an update may carry
+const maxExternalRefLength = 128
+
+// allowedCategories is the set of values accepted for Asset.Category. An
+// update may also leave the category empty.
+var allowedCategories = map[string]bool{
+	"fee":      true,
+	"topup":    true,
+	"purchase": true,
+	"refund":   true,
+}
+
+// mpinTransientKey is the transient map field carrying the MPIN on create
+const mpinTransientKey = "MPIN"
+
+// defaultStatus is assigned to assets created or updated without a status
+const defaultStatus = "Active"
+
+// allowedStatuses is the set of values accepted for Asset.Status
+var allowedStatuses = map[string]bool{
+	"Active":    true,
+	"Frozen":    true,
+	"Suspended": true,
+	"Deleted":   true,
+}
+
+// archiveKeyPrefix namespaces archived assets, stored under "archive_<msisdn>"
+const archiveKeyPrefix = "archive_"
+
+// dealerIndex is the composite key object type indexing MSISDNs by DealerID
+const dealerIndex = "dealer~msisdn"
+
+// approvalThreshold is the largest balance change UpdateAsset applies without a second approver
+const approvalThreshold = 10000
+
+// minBalance is the lowest balance AdjustBalance may leave an asset with
+const minBalance = 0
+
+// maxTopAssets is the most assets GetTopAssetsByBalance and GetMostActiveAssets return
+const maxTopAssets = 100
+
+// pendingUpdateObjectType is the composite key object type of pending updates awaiting approval
+const pendingUpdateObjectType = "pendingUpdate"
+
+// ErrApprovalRequired is returned when an update needs a second approver, or when the requester tries to approve it
+var ErrApprovalRequired = errors.New("update requires approval by a second party")
+
+// ErrBalanceBelowMinimum is returned when an adjustment would take a balance below minBalance
+var ErrBalanceBelowMinimum = errors.New("balance would fall below the minimum")
+
+// deleteAllConfirmation must be passed to DeleteAllAssets for it to proceed
+const deleteAllConfirmation = "CONFIRM_DELETE_ALL"
+
+// ErrDeleteAllNotConfirmed is returned when DeleteAllAssets is called without the confirmation token
+var ErrDeleteAllNotConfirmed = errors.New("deleting all assets requires confirmation")
+
+// ErrStatusMismatch is returned when a conditional update finds the asset in a
+// status other than the expected one
+var ErrStatusMismatch = errors.New("asset status does not match the expected status")
+
+// ErrUnreadableHistoryValue prefixes the ParseError of a history entry whose
+// value does not decode as an asset, for example after a schema change
+var ErrUnreadableHistoryValue = errors.New("history value could not be decoded")
+
+// ErrUpdateNonexistentAsset is returned when UpdateAsset targets an MSISDN that is not on the ledger
+var ErrUpdateNonexistentAsset = errors.New("cannot update nonexistent asset")
+
+// ErrParentNotFound is returned when an asset is created under a parent asset that is not on the ledger
+var ErrParentNotFound = errors.New("parent asset not found")
+
+// AssetPage is one page of assets returned by a paginated query
+type AssetPage struct {
+	Assets              []*Asset `json:"Assets"`
+	FetchedRecordsCount int32    `json:"FetchedRecordsCount"`
+	Bookmark            string   `json:"Bookmark"`
+}
+
+// DealerIndexReport lists the dealer index entries changed by RepairDealerIndex,
+// each formatted as "<DealerID>/<MSISDN>"
+type DealerIndexReport struct {
+	Added   []string `json:"Added"`
+	Removed []string `json:"Removed"`
+}
+
+// TimestampBackfillReport lists the MSISDNs of the assets BackfillTimestamps
+// gave a Timestamp, and of those left zero because they have no history
+type TimestampBackfillReport struct {
+	Backfilled []string `json:"Backfilled"`
+	Unknown    []string `json:"Unknown"`
+}
+
+// LedgerStats is a sanity report over all active assets
+type LedgerStats struct {
+	AssetCount    int    `json:"AssetCount"`
+	TotalBalance  int64  `json:"TotalBalance"`
+	LowestMSISDN  string `json:"LowestMSISDN"`
+	HighestMSISDN string `json:"HighestMSISDN"`
+	DealerCount   int    `json:"DealerCount"`
+	FrozenCount   int    `json:"FrozenCount"`
+}
+
+// AssetActivity is an asset with the number of history entries written for it
+type AssetActivity struct {
+	MSISDN       string `json:"MSISDN"`
+	DealerID     string `json:"DealerID"`
+	Transactions int    `json:"Transactions"`
+}
+
+// DealerSummary is a dealer with the number and total balance of its active assets
+type DealerSummary struct {
+	DealerID     string `json:"DealerID"`
+	AssetCount   int    `json:"AssetCount"`
+	TotalBalance int64  `json:"TotalBalance"`
+}
+
+// AuditResult compares the balance replayed from an asset's history with its stored balance
+type AuditResult struct {
+	MSISDN            string `json:"MSISDN"`
+	InitialBalance    int64  `json:"InitialBalance"`
+	ComputedBalance   int64  `json:"ComputedBalance"`
+	StoredBalance     int64  `json:"StoredBalance"`
+	Transactions      int    `json:"Transactions"`
+	Consistent        bool   `json:"Consistent"`
+	FirstMismatchTxID string `json:"FirstMismatchTxID,omitempty"`
+}
+
+// PendingUpdate is a high-value update waiting for a second approver
+type PendingUpdate struct {
+	RequestID     string    `json:"RequestID"`
+	MSISDN        string    `json:"MSISDN"`
+	NewBalanceStr string    `json:"NewBalanceStr"`
+	NewStatus     string    `json:"NewStatus"`
+	TransType     string    `json:"TransType"`
+	Remarks       string    `json:"Remarks"`
+	ExternalRef   string    `json:"ExternalRef"`
+	Category      string    `json:"Category"`
+	RequestedBy   string    `json:"RequestedBy"`
+	RequestedAt   time.Time `json:"RequestedAt"`
 }
 
 // SmartContract provides functions for managing an Asset
@@ -33,6 +231,14 @@ type SmartContract struct {
 	contractapi.Contract
 }
 
+// assetsChangedEvent is emitted by transactions that write more than one asset
+const assetsChangedEvent = "AssetsChanged"
+
+// AssetsChanged is the payload of an AssetsChanged event
+type AssetsChanged struct {
+	MSISDNs []string `json:"MSISDNs"`
+}
+
 // InitLedger adds a base set of assets to the ledger
 func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
 	assets := []Asset{
@@ -40,8 +246,9 @@ func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface)
 		{DealerID: "D002", MSISDN: "9876543210", MPIN: "5678", Balance: 1500, Status: "Active", TransAmount: 0, TransType: "", Remarks: ""},
 	}
 
+	var msisdns []string
 	for _, asset := range assets {
-		assetJSON, err := json.Marshal(asset)
+		assetJSON, err := marshalAsset(&asset)
 		if err != nil {
 			return err
 		}
@@ -50,13 +257,35 @@ func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface)
 		if err != nil {
 			return fmt.Errorf("failed to put to world state: %v", err)
 		}
+		msisdns = append(msisdns, asset.MSISDN)
 	}
 
-	return nil
+	return setAssetsChangedEvent(ctx, msisdns)
+}
+
+// CreateAsset creates a new asset and stores it on the ledger. The MPIN is
+// read from the "MPIN" transient field so it stays out of the proposal arguments.
+// When requireParent is set the asset is created as a sub-wallet of that
+// asset, which must exist and not be deleted.
+func (s *SmartContract) CreateAsset(ctx contractapi.TransactionContextInterface, dealerID, msisdn string, balance int64, status, transType, remarks, label, requireParent string) error {
+	mpin, err := getTransientMPIN(ctx, mpinTransientKey)
+	if err != nil {
+		return err
+	}
+
+	return s.createAsset(ctx, mpin, dealerID, msisdn, balance, status, label, requireParent)
 }
 
-// CreateAsset creates a new asset and stores it on the ledger
-func (s *SmartContract) CreateAsset(ctx contractapi.TransactionContextInterface, dealerID, msisdn, mpin string, balance int, status, transType, remarks string) error {
+// createAsset stores a new asset with the given MPIN on the ledger, linked to
+// parent when it is set
+func (s *SmartContract) createAsset(ctx contractapi.TransactionContextInterface, mpin, dealerID, msisdn string, balance int64, status, label, parent string) error {
+	msisdn = normalizeMSISDN(msisdn)
+
+	status, err := validateStatus(status)
+	if err != nil {
+		return err
+	}
+
 	exists, err := s.AssetExists(ctx, msisdn)
 	if err != nil {
 		return fmt.Errorf("error checking asset existence: %v", err)
@@ -65,6 +294,13 @@ func (s *SmartContract) CreateAsset(ctx contractapi.TransactionContextInterface,
 		return fmt.Errorf("asset with MSISDN %s already exists", msisdn)
 	}
 
+	if parent != "" {
+		parent = normalizeMSISDN(parent)
+		if err := s.checkParent(ctx, msisdn, parent); err != nil {
+			return err
+		}
+	}
+
 	asset := Asset{
 		DealerID:    dealerID,
 		MSISDN:      msisdn,
@@ -74,7 +310,9 @@ func (s *SmartContract) CreateAsset(ctx contractapi.TransactionContextInterface,
 		TransAmount:  0,
 		TransType:   "",
 		Remarks:     "",
+		Label:       label,
 	}
+	asset.ParentMSISDN = parent
 
 	// Get transaction timestamp
 	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
@@ -85,60 +323,341 @@ func (s *SmartContract) CreateAsset(ctx contractapi.TransactionContextInterface,
 	if err != nil {
 		return fmt.Errorf("error converting timestamp: %v", err)
 	}
+	asset.CreatedAt = asset.Timestamp
+	asset.MPINSetAt = asset.Timestamp
+
+	if err := checkBusinessRules(&asset); err != nil {
+		return err
+	}
 
-	assetJSON, err := json.Marshal(asset)
+	assetJSON, err := marshalAsset(&asset)
 	if err != nil {
 		return fmt.Errorf("error marshalling asset: %v", err)
 	}
 
-	return ctx.GetStub().PutState(msisdn, assetJSON)
+	err = ctx.GetStub().PutState(msisdn, assetJSON)
+	if err != nil {
+		return fmt.Errorf("failed to put to world state: %v", err)
+	}
+
+	if err := putDealerIndex(ctx, dealerID, msisdn); err != nil {
+		return err
+	}
+	if countsTowardsQuota(asset.Status) {
+		if err := reserveDealerSlot(ctx, dealerID); err != nil {
+			return err
+		}
+	}
+
+	return ctx.GetStub().SetEvent("AssetCreated", assetJSON)
+}
+
+// checkParent returns ErrParentNotFound unless parent is an existing asset,
+// other than msisdn itself, that has not been deleted
+func (s *SmartContract) checkParent(ctx contractapi.TransactionContextInterface, msisdn, parent string) error {
+	if parent == msisdn {
+		return fmt.Errorf("asset %s cannot be its own parent", msisdn)
+	}
+
+	parentJSON, err := ctx.GetStub().GetState(parent)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if parentJSON == nil {
+		return fmt.Errorf("%w: %s", ErrParentNotFound, parent)
+	}
+
+	var parentAsset Asset
+	if err := unmarshalAsset(parentJSON, &parentAsset); err != nil {
+		return fmt.Errorf("error unmarshalling parent asset: %v", err)
+	}
+	if parentAsset.Status == "Deleted" {
+		return fmt.Errorf("%w: %s is deleted", ErrParentNotFound, parent)
+	}
+	return nil
+}
+
+// UpdateAsset updates the values of an existing asset. When expectedStatus is
+// set the update only applies if the asset currently has that status.
+// externalRef links the update to a payment in an external system and
+// category classifies it for reporting.
+// Balance changes above approvalThreshold must go through RequestUpdate/ApproveUpdate.
+func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface, msisdn, newBalanceStr, newStatus, transType, remarks, expectedStatus, externalRef, category string) error {
+	return s.updateAsset(ctx, msisdn, newBalanceStr, newStatus, transType, remarks, expectedStatus, externalRef, category, false)
 }
 
-// UpdateAsset updates the values of an existing asset
-func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface, msisdn, newBalanceStr, newStatus, transType, remarks string) error {
-    fmt.Printf("Received arguments: msisdn=%s, newBalanceStr=%s, newStatus=%s, transType=%s, remarks=%s\n", msisdn, newBalanceStr, newStatus, transType, remarks)
+// updateAsset applies an update to an existing asset. approved is set when a
+// second party has signed off on the change, lifting the approval threshold.
+func (s *SmartContract) updateAsset(ctx contractapi.TransactionContextInterface, msisdn, newBalanceStr, newStatus, transType, remarks, expectedStatus, externalRef, category string, approved bool) error {
+	msisdn = normalizeMSISDN(msisdn)
+
+	exists, err := s.AssetExists(ctx, msisdn)
+	if err != nil {
+		return fmt.Errorf("error checking asset existence: %v", err)
+	}
+	if !exists {
+		return fmt.Errorf("%w with MSISDN %s", ErrUpdateNonexistentAsset, msisdn)
+	}
 
 	asset, err := s.ReadAsset(ctx, msisdn)
 	if err != nil {
 		return fmt.Errorf("error reading asset: %v", err)
 	}
+	if err := checkExpectedStatus(asset, expectedStatus); err != nil {
+		return err
+	}
+
+	now, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	if err := ensureUnlocked(asset, now); err != nil {
+		return err
+	}
 
 	// Convert newBalanceStr to integer
-	newBalance, err := strconv.Atoi(newBalanceStr)
+	newBalance, err := parseBalance(newBalanceStr)
 	if err != nil {
-        fmt.Printf("Error converting newBalanceStr to integer: %v\n", err)
 		return fmt.Errorf("error converting newBalanceStr to integer: %v", err)
 	}
 
+	newStatus, err = validateStatus(newStatus)
+	if err != nil {
+		return err
+	}
+	if err := validateExternalRef(externalRef); err != nil {
+		return err
+	}
+	if err := validateCategory(category); err != nil {
+		return err
+	}
+
+	change, err := subBalance(newBalance, asset.Balance)
+	if err != nil {
+		return err
+	}
+	if err := checkTransactionAmount(change); err != nil {
+		return err
+	}
+	if !approved && abs(change) > approvalThreshold {
+		return fmt.Errorf("%w: balance change of %d exceeds %d, submit it with RequestUpdate", ErrApprovalRequired, change, approvalThreshold)
+	}
+
+	// Flag assets whose balance moves too fast for review by fraud detection
+	exceeded, err := velocityExceeded(ctx, msisdn, now, change)
+	if err != nil {
+		return err
+	}
+	if exceeded {
+		asset.Flagged = true
+	}
+
+	if err := moveDealerSlot(ctx, asset.DealerID, asset.Status, newStatus); err != nil {
+		return err
+	}
+	if newStatus == "Deleted" {
+		if err := delStandingInstruction(ctx, msisdn); err != nil {
+			return err
+		}
+	}
+
 	asset.Balance = newBalance
 	asset.Status = newStatus
-	asset.TransAmount = newBalance - asset.Balance
+	asset.TransAmount = change
 	asset.TransType = transType
 	asset.Remarks = remarks
+	asset.ExternalRef = externalRef
+	asset.Category = category
+	asset.ReversedTxID = ""
+	if err := checkBusinessRules(asset); err != nil {
+		return err
+	}
 
 	// Get transaction timestamp
 	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
 	if err != nil {
-        fmt.Printf("Error getting transaction timestamp: %v\n", err)
 		return fmt.Errorf("error getting transaction timestamp: %v", err)
 	}
 	asset.Timestamp, err = ptypes.Timestamp(txTimestamp)
 	if err != nil {
-        fmt.Printf("Error converting timestamp: %v\n", err)
 		return fmt.Errorf("error converting timestamp: %v", err)
 	}
 
-	assetJSON, err := json.Marshal(asset)
+	assetJSON, err := marshalAsset(asset)
 	if err != nil {
-        fmt.Printf("Error marshalling asset: %v\n", err)
 		return fmt.Errorf("error marshalling asset: %v", err)
 	}
 
-	return ctx.GetStub().PutState(msisdn, assetJSON)
+	err = ctx.GetStub().PutState(msisdn, assetJSON)
+	if err != nil {
+		return fmt.Errorf("failed to put to world state: %v", err)
+	}
+
+	return ctx.GetStub().SetEvent("AssetUpdated", assetJSON)
+}
+
+// AdjustBalance adds delta (negative to deduct) to the current balance within a
+// single transaction, so concurrent adjustments cannot overwrite each other.
+// The same approval, velocity and expectedStatus checks as UpdateAsset apply.
+func (s *SmartContract) AdjustBalance(ctx contractapi.TransactionContextInterface, msisdn string, delta int64, transType, remarks, expectedStatus, externalRef, category string) error {
+	msisdn = normalizeMSISDN(msisdn)
+
+	exists, err := s.AssetExists(ctx, msisdn)
+	if err != nil {
+		return fmt.Errorf("error checking asset existence: %v", err)
+	}
+	if !exists {
+		return fmt.Errorf("%w with MSISDN %s", ErrUpdateNonexistentAsset, msisdn)
+	}
+
+	asset, err := s.ReadAsset(ctx, msisdn)
+	if err != nil {
+		return fmt.Errorf("error reading asset: %v", err)
+	}
+	if err := checkExpectedStatus(asset, expectedStatus); err != nil {
+		return err
+	}
+
+	if err := checkTransactionAmount(delta); err != nil {
+		return err
+	}
+
+	newBalance, err := addBalance(asset.Balance, delta)
+	if err != nil {
+		return err
+	}
+	if newBalance < minBalance {
+		return fmt.Errorf("%w: adjusting %d by %d gives %d, minimum is %d", ErrBalanceBelowMinimum, asset.Balance, delta, newBalance, minBalance)
+	}
+
+	return s.updateAsset(ctx, msisdn, strconv.FormatInt(newBalance, 10), asset.Status, transType, remarks, "", externalRef, category, false)
+}
+
+// RequestUpdate stores an update for later approval by a different identity and
+// returns its request ID, which is the ID of this transaction
+func (s *SmartContract) RequestUpdate(ctx contractapi.TransactionContextInterface, msisdn, newBalanceStr, newStatus, transType, remarks, externalRef, category string) (string, error) {
+	msisdn = normalizeMSISDN(msisdn)
+
+	exists, err := s.AssetExists(ctx, msisdn)
+	if err != nil {
+		return "", fmt.Errorf("error checking asset existence: %v", err)
+	}
+	if !exists {
+		return "", fmt.Errorf("%w with MSISDN %s", ErrUpdateNonexistentAsset, msisdn)
+	}
+
+	newBalance, err := parseBalance(newBalanceStr)
+	if err != nil {
+		return "", fmt.Errorf("error converting newBalanceStr to integer: %v", err)
+	}
+
+	// Reject an update that could never be applied before asking for approval
+	asset, err := s.ReadAsset(ctx, msisdn)
+	if err != nil {
+		return "", fmt.Errorf("error reading asset: %v", err)
+	}
+	change, err := subBalance(newBalance, asset.Balance)
+	if err != nil {
+		return "", err
+	}
+	if err := checkTransactionAmount(change); err != nil {
+		return "", err
+	}
+
+	newStatus, err = validateStatus(newStatus)
+	if err != nil {
+		return "", err
+	}
+	if err := validateExternalRef(externalRef); err != nil {
+		return "", err
+	}
+	if err := validateCategory(category); err != nil {
+		return "", err
+	}
+
+	requester, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return "", fmt.Errorf("error getting client identity: %v", err)
+	}
+
+	requestedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	pending := PendingUpdate{
+		RequestID:     ctx.GetStub().GetTxID(),
+		MSISDN:        msisdn,
+		NewBalanceStr: newBalanceStr,
+		NewStatus:     newStatus,
+		TransType:     transType,
+		Remarks:       remarks,
+		ExternalRef:   externalRef,
+		Category:      category,
+		RequestedBy:   requester,
+		RequestedAt:   requestedAt,
+	}
+
+	pendingKey, err := ctx.GetStub().CreateCompositeKey(pendingUpdateObjectType, []string{pending.RequestID})
+	if err != nil {
+		return "", fmt.Errorf("error creating pending update key: %v", err)
+	}
+
+	pendingJSON, err := json.Marshal(pending)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling pending update: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(pendingKey, pendingJSON); err != nil {
+		return "", fmt.Errorf("failed to put to world state: %v", err)
+	}
+
+	return pending.RequestID, nil
+}
+
+// ApproveUpdate applies a pending update. It must be called by an identity
+// other than the one that requested it.
+func (s *SmartContract) ApproveUpdate(ctx contractapi.TransactionContextInterface, requestID string) error {
+	pendingKey, err := ctx.GetStub().CreateCompositeKey(pendingUpdateObjectType, []string{requestID})
+	if err != nil {
+		return fmt.Errorf("error creating pending update key: %v", err)
+	}
+
+	pendingJSON, err := ctx.GetStub().GetState(pendingKey)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if pendingJSON == nil {
+		return fmt.Errorf("pending update %s does not exist", requestID)
+	}
+
+	var pending PendingUpdate
+	if err := json.Unmarshal(pendingJSON, &pending); err != nil {
+		return fmt.Errorf("error unmarshalling pending update: %v", err)
+	}
+
+	approver, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("error getting client identity: %v", err)
+	}
+	if approver == pending.RequestedBy {
+		return fmt.Errorf("%w: %s cannot approve their own request", ErrApprovalRequired, requestID)
+	}
+
+	if err := s.updateAsset(ctx, pending.MSISDN, pending.NewBalanceStr, pending.NewStatus, pending.TransType, pending.Remarks, "", pending.ExternalRef, pending.Category, true); err != nil {
+		return err
+	}
+
+	return ctx.GetStub().DelState(pendingKey)
 }
 
 // ReadAsset retrieves the current state of an asset
 func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, msisdn string) (*Asset, error) {
+	return s.readAssetKey(ctx, normalizeMSISDN(msisdn))
+}
+
+// readAssetKey is ReadAsset for an asset stored under exactly the key msisdn
+func (s *SmartContract) readAssetKey(ctx contractapi.TransactionContextInterface, msisdn string) (*Asset, error) {
 	assetJSON, err := ctx.GetStub().GetState(msisdn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read from world state: %v", err)
@@ -148,16 +667,28 @@ func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, m
 	}
 
 	var asset Asset
-	err = json.Unmarshal(assetJSON, &asset)
+	err = unmarshalAsset(assetJSON, &asset)
 	if err != nil {
 		return nil, fmt.Errorf("error unmarshalling asset: %v", err)
 	}
 
+	now, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	asset.MPINRotationRequired, err = mpinRotationRequired(&asset, now)
+	if err != nil {
+		return nil, err
+	}
+
 	return &asset, nil
 }
 
-// GetAssetHistory retrieves the transaction history of an asset
+// GetAssetHistory retrieves the transaction history of an asset along with the
+// value each transaction wrote
 func (s *SmartContract) GetAssetHistory(ctx contractapi.TransactionContextInterface, msisdn string) ([]*AssetHistoryEntry, error) {
+    msisdn = normalizeMSISDN(msisdn)
+
     resultsIterator, err := ctx.GetStub().GetHistoryForKey(msisdn)
     if err != nil {
         return nil, fmt.Errorf("error getting asset history: %v", err)
@@ -178,16 +709,1616 @@ func (s *SmartContract) GetAssetHistory(ctx contractapi.TransactionContextInterf
             return nil, fmt.Errorf("error converting timestamp: %v", err)
         }
 
+        // A value that no longer decodes is reported on its entry instead of failing the whole history
+        entry.IsDelete = queryResponse.IsDelete
+        if !entry.IsDelete {
+            var value Asset
+            if err := unmarshalAsset(queryResponse.Value, &value); err != nil {
+                entry.ParseError = fmt.Errorf("%w: %v", ErrUnreadableHistoryValue, err).Error()
+            } else {
+                // Past MPINs stay private even though the current one is readable
+                value.MPIN = ""
+                entry.Value = &value
+            }
+        }
+
         history = append(history, &entry)
     }
 
     return history, nil
 }
 
+// GetHistoryCount returns the number of history entries of an asset without
+// decoding them, so callers can check the size before fetching the history
+func (s *SmartContract) GetHistoryCount(ctx contractapi.TransactionContextInterface, msisdn string) (int, error) {
+	return historyCount(ctx, normalizeMSISDN(msisdn))
+}
 
+// historyCount counts the history entries of a normalized MSISDN
+func historyCount(ctx contractapi.TransactionContextInterface, msisdn string) (int, error) {
+	resultsIterator, err := ctx.GetStub().GetHistoryForKey(msisdn)
+	if err != nil {
+		return 0, fmt.Errorf("error getting asset history: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	count := 0
+	for resultsIterator.HasNext() {
+		if _, err := resultsIterator.Next(); err != nil {
+			return 0, fmt.Errorf("error iterating through history: %v", err)
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+
+
+// AuditAsset replays the TransAmount of every transaction since the asset was
+// created on top of its initial balance and compares the result with the
+// stored balance. Writes that repeat the previous version's transaction
+// fields, such as metadata changes, are not counted as transactions. A replayed
+// version that cannot be decoded fails the audit rather than skewing it.
+func (s *SmartContract) AuditAsset(ctx contractapi.TransactionContextInterface, msisdn string) (*AuditResult, error) {
+	msisdn = normalizeMSISDN(msisdn)
+
+	asset, err := s.ReadAsset(ctx, msisdn)
+	if err != nil {
+		return nil, fmt.Errorf("error reading asset: %v", err)
+	}
+
+	versions, err := assetVersions(ctx, msisdn)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the versions since the asset was last created are replayed
+	start := 0
+	for i, version := range versions {
+		if version.isDelete {
+			start = i + 1
+		}
+	}
+	versions = versions[start:]
+	for _, version := range versions {
+		if version.parseError != nil {
+			return nil, fmt.Errorf("error auditing transaction %s: %w", version.txID, version.parseError)
+		}
+	}
+
+	result := &AuditResult{MSISDN: msisdn, StoredBalance: asset.Balance}
+	if len(versions) > 0 {
+		result.InitialBalance = versions[0].asset.Balance
+	}
+	result.ComputedBalance = result.InitialBalance
+
+	for i := 1; i < len(versions); i++ {
+		previous, current := &versions[i-1].asset, &versions[i].asset
+		if repeatsTransaction(previous, current) {
+			continue
+		}
+
+		result.Transactions++
+		computed, err := addBalance(result.ComputedBalance, current.TransAmount)
+		if err != nil {
+			return nil, err
+		}
+		result.ComputedBalance = computed
+		if result.ComputedBalance != current.Balance && result.FirstMismatchTxID == "" {
+			result.FirstMismatchTxID = versions[i].txID
+		}
+	}
+
+	result.Consistent = result.FirstMismatchTxID == "" && result.ComputedBalance == result.StoredBalance
+	return result, nil
+}
+
+// MergeAssets moves the balance of a duplicate source asset into the target asset
+// and soft-deletes the source by marking it "Deleted" with a zero balance
+func (s *SmartContract) MergeAssets(ctx contractapi.TransactionContextInterface, sourceMSISDN, targetMSISDN string) error {
+	return s.mergeAssets(ctx, normalizeMSISDN(sourceMSISDN), normalizeMSISDN(targetMSISDN))
+}
+
+// MergeDuplicateAssets merges a cluster member reported by FindDuplicateAssets
+// into another. Unlike MergeAssets it takes the keys exactly as reported, so it
+// can reach legacy assets stored under MSISDNs that were never normalized.
+// Both keys must normalize to the same MSISDN.
+func (s *SmartContract) MergeDuplicateAssets(ctx contractapi.TransactionContextInterface, sourceKey, targetKey string) error {
+	if normalizeMSISDN(sourceKey) != normalizeMSISDN(targetKey) {
+		return fmt.Errorf("assets %s and %s are not duplicates: they normalize to %s and %s", sourceKey, targetKey, normalizeMSISDN(sourceKey), normalizeMSISDN(targetKey))
+	}
+	return s.mergeAssets(ctx, sourceKey, targetKey)
+}
+
+// mergeAssets merges the asset stored under sourceMSISDN into the one stored
+// under targetMSISDN, using the keys as given
+func (s *SmartContract) mergeAssets(ctx contractapi.TransactionContextInterface, sourceMSISDN, targetMSISDN string) error {
+	if sourceMSISDN == targetMSISDN {
+		return fmt.Errorf("cannot merge asset %s into itself", sourceMSISDN)
+	}
+
+	source, err := s.readAssetKey(ctx, sourceMSISDN)
+	if err != nil {
+		return fmt.Errorf("error reading source asset: %v", err)
+	}
+	target, err := s.readAssetKey(ctx, targetMSISDN)
+	if err != nil {
+		return fmt.Errorf("error reading target asset: %v", err)
+	}
+
+	if source.Status != "Active" || target.Status != "Active" {
+		return fmt.Errorf("cannot merge assets with statuses %s and %s, both must be Active", source.Status, target.Status)
+	}
+
+	timestamp, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	if err := ensureUnlocked(source, timestamp); err != nil {
+		return err
+	}
+	if err := ensureUnlocked(target, timestamp); err != nil {
+		return err
+	}
+
+	amount := source.Balance
+
+	target.Balance, err = addBalance(target.Balance, amount)
+	if err != nil {
+		return err
+	}
+	target.TransAmount = amount
+	target.TransType = "MERGE"
+	target.Remarks = fmt.Sprintf("merged from %s", sourceMSISDN)
+	target.ExternalRef = ""
+	target.Category = ""
+	target.ReversedTxID = ""
+	target.Timestamp = timestamp
+
+	source.Balance = 0
+	source.Status = "Deleted"
+	source.TransAmount = -amount
+	source.TransType = "MERGE"
+	source.Remarks = fmt.Sprintf("merged into %s", targetMSISDN)
+	source.ExternalRef = ""
+	source.Category = ""
+	source.ReversedTxID = ""
+	source.Timestamp = timestamp
+
+	if err := putAsset(ctx, target); err != nil {
+		return err
+	}
+	if err := putAsset(ctx, source); err != nil {
+		return err
+	}
+	if err := releaseDealerSlot(ctx, source.DealerID); err != nil {
+		return err
+	}
+	if err := delStandingInstruction(ctx, sourceMSISDN); err != nil {
+		return err
+	}
+
+	return setAssetsChangedEvent(ctx, []string{sourceMSISDN, targetMSISDN})
+}
+
+// ReassignMSISDN moves an asset to a new MSISDN when its owner changes number.
+// The asset is recreated under the new key with its balance, MPIN and metadata,
+// recording the move as a "REASSIGN" transaction that points back to the old
+// key, and the old asset is soft-deleted with a pointer to the new one.
+func (s *SmartContract) ReassignMSISDN(ctx contractapi.TransactionContextInterface, oldMSISDN, newMSISDN string) error {
+	oldMSISDN = normalizeMSISDN(oldMSISDN)
+	newMSISDN = normalizeMSISDN(newMSISDN)
+
+	if oldMSISDN == newMSISDN {
+		return fmt.Errorf("cannot reassign asset %s to its own MSISDN", oldMSISDN)
+	}
+
+	old, err := s.ReadAsset(ctx, oldMSISDN)
+	if err != nil {
+		return fmt.Errorf("error reading asset: %v", err)
+	}
+	if old.Status == "Deleted" {
+		return fmt.Errorf("cannot reassign deleted asset %s", oldMSISDN)
+	}
+
+	exists, err := s.AssetExists(ctx, newMSISDN)
+	if err != nil {
+		return fmt.Errorf("error checking asset existence: %v", err)
+	}
+	if exists {
+		return fmt.Errorf("asset with MSISDN %s already exists", newMSISDN)
+	}
+
+	timestamp, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	if err := ensureUnlocked(old, timestamp); err != nil {
+		return err
+	}
+
+	reassigned := *old
+	reassigned.MSISDN = newMSISDN
+	reassigned.TransAmount = old.Balance
+	reassigned.TransType = "REASSIGN"
+	reassigned.Remarks = fmt.Sprintf("reassigned from %s", oldMSISDN)
+	reassigned.ExternalRef = ""
+	reassigned.Category = ""
+	reassigned.ReversedTxID = ""
+	reassigned.Timestamp = timestamp
+	reassigned.PreviousMSISDN = oldMSISDN
+	reassigned.ReassignedTo = ""
+
+	old.Balance = 0
+	old.Status = "Deleted"
+	old.TransAmount = -reassigned.Balance
+	old.TransType = "REASSIGN"
+	old.Remarks = fmt.Sprintf("reassigned to %s", newMSISDN)
+	old.ExternalRef = ""
+	old.Category = ""
+	old.ReversedTxID = ""
+	old.Timestamp = timestamp
+	old.ReassignedTo = newMSISDN
+
+	if err := putAsset(ctx, &reassigned); err != nil {
+		return err
+	}
+	if err := putDealerIndex(ctx, reassigned.DealerID, newMSISDN); err != nil {
+		return err
+	}
+	// The new asset takes over the dealer slot of the old one, so the count is unchanged
+	if err := putAsset(ctx, old); err != nil {
+		return err
+	}
+	if err := delStandingInstruction(ctx, oldMSISDN); err != nil {
+		return err
+	}
+
+	assetJSON, err := marshalAsset(&reassigned)
+	if err != nil {
+		return fmt.Errorf("error marshalling asset: %v", err)
+	}
+
+	return ctx.GetStub().SetEvent("AssetReassigned", assetJSON)
+}
+
+// ApplyRateToAll adjusts the balance of every Active, unlocked asset by
+// ratePercent of its balance (negative for fees), recording the adjustment as
+// the asset's latest transaction. It returns the number of assets adjusted.
+func (s *SmartContract) ApplyRateToAll(ctx contractapi.TransactionContextInterface, ratePercent int, transType string) (int, error) {
+	timestamp, err := getTxTimestamp(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var assets []*Asset
+	err = forEachAsset(ctx, func(asset *Asset) error {
+		// Frozen, suspended and deleted assets do not accrue interest or fees
+		if asset.Status == "Active" && ensureUnlocked(asset, timestamp) == nil {
+			assets = append(assets, asset)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var msisdns []string
+	for _, asset := range assets {
+		product, err := mulBalance(asset.Balance, int64(ratePercent))
+		if err != nil {
+			return 0, err
+		}
+		amount := product / 100
+
+		asset.Balance, err = addBalance(asset.Balance, amount)
+		if err != nil {
+			return 0, err
+		}
+		asset.TransAmount = amount
+		asset.TransType = transType
+		asset.Remarks = fmt.Sprintf("applied rate of %d%%", ratePercent)
+		asset.ExternalRef = ""
+		asset.Category = ""
+		asset.ReversedTxID = ""
+		asset.Timestamp = timestamp
+
+		if err := putAsset(ctx, asset); err != nil {
+			return 0, err
+		}
+		msisdns = append(msisdns, asset.MSISDN)
+	}
+
+	return len(assets), setAssetsChangedEvent(ctx, msisdns)
+}
+
+// bulkFreezeTransType is recorded on the assets frozen by BulkFreezeByQuery
+const bulkFreezeTransType = "BULK_FREEZE"
+
+// BulkFreezeByQuery freezes every asset matched by a CouchDB rich query, such
+// as {"selector":{"Balance":{"$gt":100000}}}, and returns the number of assets
+// it froze. Assets that are already Frozen or Deleted are left unchanged. Rich
+// query results are not re-checked at commit, so assets that start matching
+// concurrently may be missed. It requires CouchDB.
+func (s *SmartContract) BulkFreezeByQuery(ctx contractapi.TransactionContextInterface, queryJSON string) (int, error) {
+	var query map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(queryJSON), &query); err != nil {
+		return 0, fmt.Errorf("invalid query: must be a JSON object: %v", err)
+	}
+	var selector map[string]interface{}
+	if err := json.Unmarshal(query["selector"], &selector); err != nil || len(selector) == 0 {
+		return 0, fmt.Errorf("invalid query: must have a non-empty selector object")
+	}
+
+	// Pending updates and other JSON records can match the caller's selector,
+	// but only assets have a DealerID
+	assetSelector, err := json.Marshal(map[string]interface{}{
+		"$and": []interface{}{selector, map[string]interface{}{"DealerID": map[string]bool{"$exists": true}}},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error building asset query: %v", err)
+	}
+	query["selector"] = assetSelector
+	assetQuery, err := json.Marshal(query)
+	if err != nil {
+		return 0, fmt.Errorf("error building asset query: %v", err)
+	}
+
+	timestamp, err := getTxTimestamp(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetQueryResult(string(assetQuery))
+	if err != nil {
+		return 0, fmt.Errorf("error querying assets: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	var assets []*Asset
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return 0, fmt.Errorf("error iterating through assets: %v", err)
+		}
+		if strings.HasPrefix(queryResponse.Key, "\x00") || !isAssetKey(queryResponse.Key) {
+			continue
+		}
+
+		var asset Asset
+		if err := unmarshalAsset(queryResponse.Value, &asset); err != nil {
+			return 0, fmt.Errorf("error unmarshalling asset %s: %v", queryResponse.Key, err)
+		}
+		if asset.Status != "Frozen" && asset.Status != "Deleted" {
+			assets = append(assets, &asset)
+		}
+	}
+
+	var msisdns []string
+	for _, asset := range assets {
+		asset.Status = "Frozen"
+		asset.TransAmount = 0
+		asset.TransType = bulkFreezeTransType
+		asset.Remarks = "frozen by bulk query"
+		asset.ExternalRef = ""
+		asset.Category = ""
+		asset.ReversedTxID = ""
+		asset.Timestamp = timestamp
+
+		if err := putAsset(ctx, asset); err != nil {
+			return 0, err
+		}
+		msisdns = append(msisdns, asset.MSISDN)
+	}
+
+	return len(assets), setAssetsChangedEvent(ctx, msisdns)
+}
+
+// LockAsset prevents changes to an asset until the given RFC3339 time.
+// The lock expires on its own once the transaction timestamp passes it.
+func (s *SmartContract) LockAsset(ctx contractapi.TransactionContextInterface, msisdn, untilRFC3339 string) error {
+	until, err := time.Parse(time.RFC3339, untilRFC3339)
+	if err != nil {
+		return fmt.Errorf("error parsing lock expiry: %v", err)
+	}
+
+	asset, err := s.ReadAsset(ctx, msisdn)
+	if err != nil {
+		return fmt.Errorf("error reading asset: %v", err)
+	}
+
+	now, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	if !until.After(now) {
+		return fmt.Errorf("lock expiry %s must be in the future", untilRFC3339)
+	}
+
+	asset.LockedUntil = until
+	asset.Timestamp = now
+
+	if err := putAsset(ctx, asset); err != nil {
+		return err
+	}
+
+	return setAssetEvent(ctx, "AssetUpdated", asset)
+}
+
+// SetAssetMetadata sets a metadata key on an asset, replacing any previous value.
+// An empty value removes the key.
+func (s *SmartContract) SetAssetMetadata(ctx contractapi.TransactionContextInterface, msisdn, key, value string) error {
+	if key == "" || len(key) > maxMetadataKeyLength {
+		return fmt.Errorf("metadata key must be between 1 and %d bytes", maxMetadataKeyLength)
+	}
+	if len(value) > maxMetadataValueLength {
+		return fmt.Errorf("metadata value for %s exceeds %d bytes", key, maxMetadataValueLength)
+	}
+
+	asset, err := s.ReadAsset(ctx, msisdn)
+	if err != nil {
+		return fmt.Errorf("error reading asset: %v", err)
+	}
+
+	now, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	if err := ensureUnlocked(asset, now); err != nil {
+		return err
+	}
+
+	if value == "" {
+		delete(asset.Metadata, key)
+	} else {
+		if asset.Metadata == nil {
+			asset.Metadata = make(map[string]string)
+		}
+		if _, exists := asset.Metadata[key]; !exists && len(asset.Metadata) >= maxMetadataEntries {
+			return fmt.Errorf("asset with MSISDN %s already has the maximum of %d metadata entries", asset.MSISDN, maxMetadataEntries)
+		}
+		asset.Metadata[key] = value
+	}
+	asset.Timestamp = now
+
+	if err := putAsset(ctx, asset); err != nil {
+		return err
+	}
+
+	return setAssetEvent(ctx, "AssetUpdated", asset)
+}
+
+// SetAssetLocation records where an asset is, in decimal degrees. An asset at
+// 0, 0 is treated as having no location, so passing 0, 0 clears it.
+func (s *SmartContract) SetAssetLocation(ctx contractapi.TransactionContextInterface, msisdn string, latitude, longitude float64) error {
+	if !(latitude >= -90 && latitude <= 90) {
+		return fmt.Errorf("latitude must be between -90 and 90, got %v", latitude)
+	}
+	if !(longitude >= -180 && longitude <= 180) {
+		return fmt.Errorf("longitude must be between -180 and 180, got %v", longitude)
+	}
+
+	asset, err := s.ReadAsset(ctx, msisdn)
+	if err != nil {
+		return fmt.Errorf("error reading asset: %v", err)
+	}
+
+	now, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	if err := ensureUnlocked(asset, now); err != nil {
+		return err
+	}
+
+	asset.Latitude = latitude
+	asset.Longitude = longitude
+	asset.Timestamp = now
+
+	if err := putAsset(ctx, asset); err != nil {
+		return err
+	}
+
+	return setAssetEvent(ctx, "AssetUpdated", asset)
+}
+
+// GetAssetMetadata returns the metadata attached to an asset
+func (s *SmartContract) GetAssetMetadata(ctx contractapi.TransactionContextInterface, msisdn string) (map[string]string, error) {
+	asset, err := s.ReadAsset(ctx, msisdn)
+	if err != nil {
+		return nil, fmt.Errorf("error reading asset: %v", err)
+	}
+
+	if asset.Metadata == nil {
+		return map[string]string{}, nil
+	}
+
+	return asset.Metadata, nil
+}
+
+// getTransientMPIN returns the MPIN passed in the given field of the
+// transaction's transient map
+func getTransientMPIN(ctx contractapi.TransactionContextInterface, field string) (string, error) {
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return "", fmt.Errorf("error getting transient data: %v", err)
+	}
+
+	mpin, ok := transient[field]
+	if !ok || len(mpin) == 0 {
+		return "", fmt.Errorf("MPIN must be provided in the %q transient field", field)
+	}
+
+	return string(mpin), nil
+}
+
+// parseBalance parses a whole-number balance, accepting thousands separators
+// ("1,000") and a zero fraction ("1000.00") as sent by some clients
+func parseBalance(value string) (int64, error) {
+	cleaned := strings.ReplaceAll(strings.TrimSpace(value), ",", "")
+
+	if whole, fraction, ok := strings.Cut(cleaned, "."); ok {
+		if strings.Trim(fraction, "0") != "" {
+			return 0, fmt.Errorf("balance %q must be a whole number", value)
+		}
+		cleaned = whole
+	}
+
+	balance, err := strconv.ParseInt(cleaned, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("balance %q is not a valid number", value)
+	}
+	return balance, nil
+}
+
+// validateStatus returns status, or defaultStatus when it is empty, and
+// rejects values outside allowedStatuses
+func validateStatus(status string) (string, error) {
+	if status == "" {
+		return defaultStatus, nil
+	}
+	if !allowedStatuses[status] {
+		return "", fmt.Errorf("invalid status %q: must be one of Active, Frozen, Suspended, Deleted", status)
+	}
+	return status, nil
+}
+
+// validateExternalRef rejects external references longer than maxExternalRefLength
+func validateExternalRef(externalRef string) error {
+	if len(externalRef) > maxExternalRefLength {
+		return fmt.Errorf("external reference must be at most %d bytes", maxExternalRefLength)
+	}
+	return nil
+}
+
+// validateCategory rejects categories outside allowedCategories
+func validateCategory(category string) error {
+	if category != "" && !allowedCategories[category] {
+		return fmt.Errorf("invalid category %q: must be one of fee, topup, purchase, refund", category)
+	}
+	return nil
+}
+
+// checkExpectedStatus returns ErrStatusMismatch if expectedStatus is set and
+// differs from the asset's current status
+func checkExpectedStatus(asset *Asset, expectedStatus string) error {
+	if expectedStatus == "" {
+		return nil
+	}
+	if !allowedStatuses[expectedStatus] {
+		return fmt.Errorf("invalid status %q: must be one of Active, Frozen, Suspended, Deleted", expectedStatus)
+	}
+	if asset.Status != expectedStatus {
+		return fmt.Errorf("%w: asset with MSISDN %s is %s, expected %s", ErrStatusMismatch, asset.MSISDN, asset.Status, expectedStatus)
+	}
+	return nil
+}
+
+// ensureUnlocked returns an error if the asset is locked at the given time
+func ensureUnlocked(asset *Asset, now time.Time) error {
+	if now.Before(asset.LockedUntil) {
+		return fmt.Errorf("asset with MSISDN %s is locked until %s", asset.MSISDN, asset.LockedUntil.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// GetAllAssets returns all assets found in the world state
+func (s *SmartContract) GetAllAssets(ctx contractapi.TransactionContextInterface) ([]*Asset, error) {
+	var assets []*Asset
+	err := forEachAsset(ctx, func(asset *Asset) error {
+		assets = append(assets, asset)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return assets, nil
+}
+
+// GetAssetsPage returns up to pageSize assets starting at bookmark.
+// Pass the returned Bookmark to fetch the next page; an empty bookmark starts at the beginning.
+func (s *SmartContract) GetAssetsPage(ctx contractapi.TransactionContextInterface, pageSize int, bookmark string) (*AssetPage, error) {
+	if pageSize <= 0 {
+		return nil, fmt.Errorf("page size must be positive, got %d", pageSize)
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetStateByRangeWithPagination("", "", int32(pageSize), bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	page := &AssetPage{Assets: []*Asset{}}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("error iterating through assets: %v", err)
+		}
+
+		if !isAssetKey(queryResponse.Key) {
+			continue
+		}
+
+		var asset Asset
+		if err := unmarshalAsset(queryResponse.Value, &asset); err != nil {
+			return nil, fmt.Errorf("error unmarshalling asset %s: %v", queryResponse.Key, err)
+		}
+		page.Assets = append(page.Assets, &asset)
+	}
+
+	page.FetchedRecordsCount = metadata.FetchedRecordsCount
+	page.Bookmark = metadata.Bookmark
+
+	return page, nil
+}
+
+// GetTotalBalance returns the sum of the balances of all assets
+func (s *SmartContract) GetTotalBalance(ctx contractapi.TransactionContextInterface) (int64, error) {
+	var total int64
+	err := forEachAsset(ctx, func(asset *Asset) error {
+		var err error
+		total, err = addBalance(total, asset.Balance)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// GetAverageBalance returns the mean balance of the assets with the given
+// status, or of all assets when status is empty. It returns 0 when no asset
+// matches.
+func (s *SmartContract) GetAverageBalance(ctx contractapi.TransactionContextInterface, status string) (float64, error) {
+	if status != "" && !allowedStatuses[status] {
+		return 0, fmt.Errorf("invalid status %q: must be one of Active, Frozen, Suspended, Deleted", status)
+	}
+
+	var total int64
+	var count int
+	err := forEachAsset(ctx, func(asset *Asset) error {
+		if status != "" && asset.Status != status {
+			return nil
+		}
+		var err error
+		if total, err = addBalance(total, asset.Balance); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if count == 0 {
+		return 0, nil
+	}
+	return float64(total) / float64(count), nil
+}
+
+// GetBalanceHistogram counts the assets in each balance range of bucketSize,
+// keyed by the range as "<low>-<high>" with both bounds included, for example
+// "0-99" and "100-199" for a bucketSize of 100. Empty ranges are left out.
+// The ranges at either end of int64 are cut short rather than overflowing.
+func (s *SmartContract) GetBalanceHistogram(ctx contractapi.TransactionContextInterface, bucketSize int) (map[string]int, error) {
+	if bucketSize <= 0 {
+		return nil, fmt.Errorf("bucket size must be positive, got %d", bucketSize)
+	}
+	size := int64(bucketSize)
+
+	histogram := make(map[string]int)
+	err := forEachAsset(ctx, func(asset *Asset) error {
+		// Division rounds toward zero, so a negative balance inside a range
+		// lands on the range's high end
+		bound := asset.Balance / size * size
+		low, high := bound, bound
+		var err error
+		if asset.Balance < 0 && asset.Balance%size != 0 {
+			high = bound - 1
+			if low, err = subBalance(bound, size); err != nil {
+				low = math.MinInt64
+			}
+		} else if high, err = addBalance(bound, size-1); err != nil {
+			high = math.MaxInt64
+		}
+		histogram[fmt.Sprintf("%d-%d", low, high)]++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return histogram, nil
+}
+
+// GetTopAssetsByBalance returns the n assets with the highest balances,
+// highest first. Equal balances are ordered by MSISDN.
+func (s *SmartContract) GetTopAssetsByBalance(ctx contractapi.TransactionContextInterface, n int) ([]*Asset, error) {
+	if n <= 0 || n > maxTopAssets {
+		return nil, fmt.Errorf("n must be between 1 and %d, got %d", maxTopAssets, n)
+	}
+
+	assets := []*Asset{}
+	err := forEachAsset(ctx, func(asset *Asset) error {
+		assets = append(assets, asset)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(assets, func(i, j int) bool {
+		if assets[i].Balance != assets[j].Balance {
+			return assets[i].Balance > assets[j].Balance
+		}
+		return assets[i].MSISDN < assets[j].MSISDN
+	})
+	if len(assets) > n {
+		assets = assets[:n]
+	}
+
+	return assets, nil
+}
+
+// GetMostActiveAssets returns the n assets with the most history entries,
+// most first. Equal counts are ordered by MSISDN. It reads the full history
+// of every asset, so its cost grows with the total number of transactions on
+// the ledger and it should not be called on every request.
+func (s *SmartContract) GetMostActiveAssets(ctx contractapi.TransactionContextInterface, n int) ([]AssetActivity, error) {
+	if n <= 0 || n > maxTopAssets {
+		return nil, fmt.Errorf("n must be between 1 and %d, got %d", maxTopAssets, n)
+	}
+
+	activity := []AssetActivity{}
+	err := forEachAsset(ctx, func(asset *Asset) error {
+		count, err := historyCount(ctx, asset.MSISDN)
+		if err != nil {
+			return err
+		}
+		activity = append(activity, AssetActivity{MSISDN: asset.MSISDN, DealerID: asset.DealerID, Transactions: count})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(activity, func(i, j int) bool {
+		if activity[i].Transactions != activity[j].Transactions {
+			return activity[i].Transactions > activity[j].Transactions
+		}
+		return activity[i].MSISDN < activity[j].MSISDN
+	})
+	if len(activity) > n {
+		activity = activity[:n]
+	}
+
+	return activity, nil
+}
+
+// SearchAssetsByLabel returns the assets whose Label contains the given
+// substring, ignoring case. It uses a rich query and requires CouchDB.
+func (s *SmartContract) SearchAssetsByLabel(ctx contractapi.TransactionContextInterface, substring string) ([]*Asset, error) {
+	selector := map[string]interface{}{
+		"selector": map[string]interface{}{
+			"Label": map[string]string{"$regex": "(?i)" + regexp.QuoteMeta(substring)},
+		},
+	}
+	queryString, err := json.Marshal(selector)
+	if err != nil {
+		return nil, fmt.Errorf("error building label query: %v", err)
+	}
+
+	resultsIterator, err := ctx.GetStub().GetQueryResult(string(queryString))
+	if err != nil {
+		return nil, fmt.Errorf("error querying assets by label: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	assets := []*Asset{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("error iterating through assets: %v", err)
+		}
+		if !isAssetKey(queryResponse.Key) {
+			continue
+		}
+
+		var asset Asset
+		if err := unmarshalAsset(queryResponse.Value, &asset); err != nil {
+			return nil, fmt.Errorf("error unmarshalling asset %s: %v", queryResponse.Key, err)
+		}
+		assets = append(assets, &asset)
+	}
+
+	return assets, nil
+}
+
+// SearchByMSISDNPrefix returns the assets whose MSISDN starts with prefix, in
+// MSISDN order. The prefix is matched against normalized MSISDNs, so it should
+// omit the country code and trunk prefix.
+func (s *SmartContract) SearchByMSISDNPrefix(ctx contractapi.TransactionContextInterface, prefix string) ([]*Asset, error) {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		return nil, fmt.Errorf("MSISDN prefix is required")
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByRange(prefix, prefix+"\uffff")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	assets := []*Asset{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("error iterating through assets: %v", err)
+		}
+		if !isAssetKey(queryResponse.Key) {
+			continue
+		}
+
+		var asset Asset
+		if err := unmarshalAsset(queryResponse.Value, &asset); err != nil {
+			return nil, fmt.Errorf("error unmarshalling asset %s: %v", queryResponse.Key, err)
+		}
+		assets = append(assets, &asset)
+	}
+
+	return assets, nil
+}
+
+// GetAssetsByExternalRef returns the assets whose last update carried the
+// given external reference. It uses a rich query and requires CouchDB.
+func (s *SmartContract) GetAssetsByExternalRef(ctx contractapi.TransactionContextInterface, externalRef string) ([]*Asset, error) {
+	if externalRef == "" {
+		return nil, fmt.Errorf("external reference is required")
+	}
+
+	// Pending updates also carry an ExternalRef, but only assets have a DealerID
+	selector := map[string]interface{}{
+		"selector": map[string]interface{}{
+			"DealerID":    map[string]bool{"$exists": true},
+			"ExternalRef": externalRef,
+		},
+	}
+	queryString, err := json.Marshal(selector)
+	if err != nil {
+		return nil, fmt.Errorf("error building external reference query: %v", err)
+	}
+
+	resultsIterator, err := ctx.GetStub().GetQueryResult(string(queryString))
+	if err != nil {
+		return nil, fmt.Errorf("error querying assets by external reference: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	assets := []*Asset{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("error iterating through assets: %v", err)
+		}
+		if strings.HasPrefix(queryResponse.Key, "\x00") || !isAssetKey(queryResponse.Key) {
+			continue
+		}
+
+		var asset Asset
+		if err := unmarshalAsset(queryResponse.Value, &asset); err != nil {
+			return nil, fmt.Errorf("error unmarshalling asset %s: %v", queryResponse.Key, err)
+		}
+		assets = append(assets, &asset)
+	}
+
+	return assets, nil
+}
+
+// GetLedgerStats returns the asset count, total balance, lowest and highest
+// MSISDN keys, distinct dealer count and Frozen count of the active assets
+func (s *SmartContract) GetLedgerStats(ctx contractapi.TransactionContextInterface) (*LedgerStats, error) {
+	stats := &LedgerStats{}
+	dealers := make(map[string]bool)
+
+	// Keys are visited in ascending order, so the first and last are the bounds
+	err := forEachAsset(ctx, func(asset *Asset) error {
+		if stats.AssetCount == 0 {
+			stats.LowestMSISDN = asset.MSISDN
+		}
+		stats.HighestMSISDN = asset.MSISDN
+		stats.AssetCount++
+		total, err := addBalance(stats.TotalBalance, asset.Balance)
+		if err != nil {
+			return err
+		}
+		stats.TotalBalance = total
+		dealers[asset.DealerID] = true
+		if asset.Status == "Frozen" {
+			stats.FrozenCount++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stats.DealerCount = len(dealers)
+	return stats, nil
+}
+
+// sortableFields are the asset fields QueryAssetsPage can sort by; each has a
+// CouchDB index under META-INF/statedb/couchdb/indexes
+var sortableFields = map[string]bool{
+	"Balance": true,
+	"MSISDN":  true,
+	"Status":  true,
+}
+
+// QueryAssetsPage returns one page of assets sorted by sortField in order
+// ("asc" or "desc"), optionally restricted to Labels containing labelQuery.
+// It uses a rich query and requires CouchDB.
+func (s *SmartContract) QueryAssetsPage(ctx contractapi.TransactionContextInterface, labelQuery, sortField, order string, pageSize int, bookmark string) (*AssetPage, error) {
+	if !sortableFields[sortField] {
+		return nil, fmt.Errorf("cannot sort by %q: must be one of Balance, MSISDN, Status", sortField)
+	}
+	if order != "asc" && order != "desc" {
+		return nil, fmt.Errorf("sort order must be asc or desc, got %q", order)
+	}
+	if pageSize <= 0 {
+		return nil, fmt.Errorf("page size must be positive, got %d", pageSize)
+	}
+
+	// Only assets have a DealerID; the sort field must appear in the selector
+	// for CouchDB to use its index
+	conditions := map[string]interface{}{
+		"DealerID": map[string]bool{"$exists": true},
+		sortField:  map[string]interface{}{"$gt": nil},
+	}
+	if labelQuery != "" {
+		conditions["Label"] = map[string]string{"$regex": "(?i)" + regexp.QuoteMeta(labelQuery)}
+	}
+	query := map[string]interface{}{
+		"selector": conditions,
+		"sort":     []map[string]string{{sortField: order}},
+	}
+	queryString, err := json.Marshal(query)
+	if err != nil {
+		return nil, fmt.Errorf("error building asset query: %v", err)
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetQueryResultWithPagination(string(queryString), int32(pageSize), bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("error querying assets: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	page := &AssetPage{Assets: []*Asset{}}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("error iterating through assets: %v", err)
+		}
+		if !isAssetKey(queryResponse.Key) {
+			continue
+		}
+
+		var asset Asset
+		if err := unmarshalAsset(queryResponse.Value, &asset); err != nil {
+			return nil, fmt.Errorf("error unmarshalling asset %s: %v", queryResponse.Key, err)
+		}
+		page.Assets = append(page.Assets, &asset)
+	}
+
+	page.FetchedRecordsCount = metadata.FetchedRecordsCount
+	page.Bookmark = metadata.Bookmark
+
+	return page, nil
+}
+
+// GetBalanceByDealer returns the sum of the balances of all assets grouped by DealerID
+func (s *SmartContract) GetBalanceByDealer(ctx contractapi.TransactionContextInterface) (map[string]int64, error) {
+	totals := make(map[string]int64)
+	err := forEachAsset(ctx, func(asset *Asset) error {
+		total, err := addBalance(totals[asset.DealerID], asset.Balance)
+		if err != nil {
+			return err
+		}
+		totals[asset.DealerID] = total
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return totals, nil
+}
+
+// GetDealers returns every dealer with active assets, sorted by DealerID
+func (s *SmartContract) GetDealers(ctx contractapi.TransactionContextInterface) ([]DealerSummary, error) {
+	byDealer := make(map[string]*DealerSummary)
+	err := forEachAsset(ctx, func(asset *Asset) error {
+		summary, ok := byDealer[asset.DealerID]
+		if !ok {
+			summary = &DealerSummary{DealerID: asset.DealerID}
+			byDealer[asset.DealerID] = summary
+		}
+		summary.AssetCount++
+		total, err := addBalance(summary.TotalBalance, asset.Balance)
+		if err != nil {
+			return err
+		}
+		summary.TotalBalance = total
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dealers := make([]DealerSummary, 0, len(byDealer))
+	for _, summary := range byDealer {
+		dealers = append(dealers, *summary)
+	}
+	sort.Slice(dealers, func(i, j int) bool {
+		return dealers[i].DealerID < dealers[j].DealerID
+	})
+
+	return dealers, nil
+}
+
+// FindDuplicateAssets returns the MSISDNs of assets stored under different
+// keys that normalize to the same MSISDN, such as records created before
+// normalization was introduced. Each cluster is sorted and the clusters are
+// ordered by their normalized MSISDN. Deleted assets, including merged
+// duplicates, are left out. The MSISDNs are the keys the assets are stored
+// under, to be passed to MergeDuplicateAssets.
+func (s *SmartContract) FindDuplicateAssets(ctx contractapi.TransactionContextInterface) ([][]string, error) {
+	byNormalized := make(map[string][]string)
+	err := forEachAsset(ctx, func(asset *Asset) error {
+		if asset.Status == "Deleted" {
+			return nil
+		}
+		normalized := normalizeMSISDN(asset.MSISDN)
+		byNormalized[normalized] = append(byNormalized[normalized], asset.MSISDN)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	normalizedKeys := make([]string, 0, len(byNormalized))
+	for normalized, msisdns := range byNormalized {
+		if len(msisdns) > 1 {
+			normalizedKeys = append(normalizedKeys, normalized)
+		}
+	}
+	sort.Strings(normalizedKeys)
+
+	clusters := make([][]string, 0, len(normalizedKeys))
+	for _, normalized := range normalizedKeys {
+		cluster := byNormalized[normalized]
+		sort.Strings(cluster)
+		clusters = append(clusters, cluster)
+	}
+
+	return clusters, nil
+}
+
+// GetAssetsNeedingAttention returns the assets operations should review: those
+// flagged by fraud detection, frozen while still holding a balance, or with a
+// balance below minBalance
+func (s *SmartContract) GetAssetsNeedingAttention(ctx contractapi.TransactionContextInterface) ([]*Asset, error) {
+	assets := []*Asset{}
+	err := forEachAsset(ctx, func(asset *Asset) error {
+		if asset.Flagged || (asset.Status == "Frozen" && asset.Balance != 0) || asset.Balance < minBalance {
+			assets = append(assets, asset)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return assets, nil
+}
+
+// isAssetKey reports whether a world state key holds an active asset rather
+// than an entry in another namespace such as the archive or snapshots
+func isAssetKey(key string) bool {
+	return !strings.HasPrefix(key, archiveKeyPrefix) && !strings.HasPrefix(key, snapshotKeyPrefix)
+}
+
+// forEachAsset calls fn for every active asset in the world state
+func forEachAsset(ctx contractapi.TransactionContextInterface, fn func(asset *Asset) error) error {
+	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return fmt.Errorf("error iterating through assets: %v", err)
+		}
+		if !isAssetKey(queryResponse.Key) {
+			continue
+		}
+
+		var asset Asset
+		if err := unmarshalAsset(queryResponse.Value, &asset); err != nil {
+			return fmt.Errorf("error unmarshalling asset %s: %v", queryResponse.Key, err)
+		}
+
+		if err := fn(&asset); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetAssetsCreatedBetween returns the assets whose CreatedAt lies in [from, to),
+// both given as RFC 3339 timestamps
+func (s *SmartContract) GetAssetsCreatedBetween(ctx contractapi.TransactionContextInterface, from, to string) ([]*Asset, error) {
+	fromTime, err := time.Parse(time.RFC3339, from)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing from timestamp: %v", err)
+	}
+	toTime, err := time.Parse(time.RFC3339, to)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing to timestamp: %v", err)
+	}
+
+	assets := []*Asset{}
+	err = forEachAsset(ctx, func(asset *Asset) error {
+		if !asset.CreatedAt.Before(fromTime) && asset.CreatedAt.Before(toTime) {
+			assets = append(assets, asset)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return assets, nil
+}
+
+// GetAssetsByDealer returns the assets belonging to a dealer using the dealer index
+func (s *SmartContract) GetAssetsByDealer(ctx contractapi.TransactionContextInterface, dealerID string) ([]*Asset, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(dealerIndex, []string{dealerID})
+	if err != nil {
+		return nil, fmt.Errorf("error reading dealer index: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	assets := []*Asset{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("error iterating through dealer index: %v", err)
+		}
+
+		_, keyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, fmt.Errorf("error splitting dealer index key: %v", err)
+		}
+
+		asset, err := s.ReadAsset(ctx, keyParts[1])
+		if err != nil {
+			return nil, fmt.Errorf("error reading indexed asset: %v", err)
+		}
+		assets = append(assets, asset)
+	}
+
+	return assets, nil
+}
+
+// RepairDealerIndex rebuilds the dealer index from the primary asset records,
+// adding missing entries and removing orphaned or stale ones
+func (s *SmartContract) RepairDealerIndex(ctx contractapi.TransactionContextInterface) (*DealerIndexReport, error) {
+	// expected maps each index key that should exist to its report label
+	expected := make(map[string]string)
+	err := forEachAsset(ctx, func(asset *Asset) error {
+		indexKey, err := ctx.GetStub().CreateCompositeKey(dealerIndex, []string{asset.DealerID, asset.MSISDN})
+		if err != nil {
+			return fmt.Errorf("error creating dealer index key: %v", err)
+		}
+		expected[indexKey] = asset.DealerID + "/" + asset.MSISDN
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(dealerIndex, []string{})
+	if err != nil {
+		return nil, fmt.Errorf("error reading dealer index: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	report := &DealerIndexReport{Added: []string{}, Removed: []string{}}
+	indexed := make(map[string]bool)
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("error iterating through dealer index: %v", err)
+		}
+
+		if _, ok := expected[queryResponse.Key]; ok {
+			indexed[queryResponse.Key] = true
+			continue
+		}
+
+		_, keyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, fmt.Errorf("error splitting dealer index key: %v", err)
+		}
+		if err := ctx.GetStub().DelState(queryResponse.Key); err != nil {
+			return nil, fmt.Errorf("error removing dealer index entry: %v", err)
+		}
+		report.Removed = append(report.Removed, strings.Join(keyParts, "/"))
+	}
+
+	missing := make([]string, 0, len(expected))
+	for indexKey := range expected {
+		if !indexed[indexKey] {
+			missing = append(missing, indexKey)
+		}
+	}
+	sort.Strings(missing)
+
+	for _, indexKey := range missing {
+		if err := ctx.GetStub().PutState(indexKey, []byte{0x00}); err != nil {
+			return nil, fmt.Errorf("error writing dealer index: %v", err)
+		}
+		report.Added = append(report.Added, expected[indexKey])
+	}
+
+	return report, nil
+}
+
+// BackfillTimestamps repairs legacy assets stored without a Timestamp by
+// setting it to the time of the asset's earliest history entry. Assets with no
+// history keep the zero Timestamp and are reported as unknown.
+func (s *SmartContract) BackfillTimestamps(ctx contractapi.TransactionContextInterface) (*TimestampBackfillReport, error) {
+	var missing []*Asset
+	err := forEachAsset(ctx, func(asset *Asset) error {
+		if asset.Timestamp.IsZero() {
+			missing = append(missing, asset)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &TimestampBackfillReport{Backfilled: []string{}, Unknown: []string{}}
+	for _, asset := range missing {
+		versions, err := assetVersions(ctx, asset.MSISDN)
+		if err != nil {
+			return nil, err
+		}
+		if len(versions) == 0 {
+			report.Unknown = append(report.Unknown, asset.MSISDN)
+			continue
+		}
+
+		asset.Timestamp = versions[0].at
+		if err := putAsset(ctx, asset); err != nil {
+			return nil, err
+		}
+		report.Backfilled = append(report.Backfilled, asset.MSISDN)
+	}
+
+	return report, setAssetsChangedEvent(ctx, report.Backfilled)
+}
+
+// DeleteAsset hard-deletes an asset from the world state. When collection is
+// set, the asset's private data in that collection is also purged, removing it
+// and its private history from peers. Public state history cannot be purged:
+// earlier versions of the asset remain readable through GetAssetHistory.
+func (s *SmartContract) DeleteAsset(ctx contractapi.TransactionContextInterface, msisdn, collection string) error {
+	msisdn = normalizeMSISDN(msisdn)
+
+	asset, err := s.ReadAsset(ctx, msisdn)
+	if err != nil {
+		return fmt.Errorf("error reading asset: %v", err)
+	}
+
+	if err := removeAsset(ctx, asset); err != nil {
+		return err
+	}
+
+	if collection != "" {
+		if err := ctx.GetStub().PurgePrivateData(collection, msisdn); err != nil {
+			return fmt.Errorf("error purging private data from collection %s: %v", collection, err)
+		}
+	}
+
+	assetJSON, err := marshalAsset(asset)
+	if err != nil {
+		return fmt.Errorf("error marshalling asset: %v", err)
+	}
+
+	return ctx.GetStub().SetEvent("AssetDeleted", assetJSON)
+}
+
+// DeleteAllAssets deletes every active asset and returns how many were
+// removed. It is meant for resetting test networks and refuses to run unless
+// confirm is deleteAllConfirmation.
+func (s *SmartContract) DeleteAllAssets(ctx contractapi.TransactionContextInterface, confirm string) (int, error) {
+	if confirm != deleteAllConfirmation {
+		return 0, fmt.Errorf("%w: pass %q to proceed", ErrDeleteAllNotConfirmed, deleteAllConfirmation)
+	}
+
+	var assets []*Asset
+	err := forEachAsset(ctx, func(asset *Asset) error {
+		assets = append(assets, asset)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Assets of the same dealer release the same quota counter, so later
+	// removals must see earlier ones
+	txCtx := newBatchContext(ctx)
+
+	var msisdns []string
+	for _, asset := range assets {
+		if err := removeAsset(txCtx, asset); err != nil {
+			return 0, err
+		}
+		msisdns = append(msisdns, asset.MSISDN)
+	}
+
+	return len(assets), setAssetsChangedEvent(ctx, msisdns)
+}
+
+// removeAsset deletes an asset from the world state along with its dealer
+// index entry, standing instruction and quota slot, if it still held one
+func removeAsset(ctx contractapi.TransactionContextInterface, asset *Asset) error {
+	if err := ctx.GetStub().DelState(asset.MSISDN); err != nil {
+		return fmt.Errorf("failed to delete from world state: %v", err)
+	}
+	if err := delDealerIndex(ctx, asset.DealerID, asset.MSISDN); err != nil {
+		return err
+	}
+	if err := delStandingInstruction(ctx, asset.MSISDN); err != nil {
+		return err
+	}
+	if !countsTowardsQuota(asset.Status) {
+		return nil
+	}
+	return releaseDealerSlot(ctx, asset.DealerID)
+}
+
+// ArchiveAsset moves an asset out of the active state into the archive namespace
+func (s *SmartContract) ArchiveAsset(ctx contractapi.TransactionContextInterface, msisdn string) error {
+	msisdn = normalizeMSISDN(msisdn)
+
+	asset, err := s.ReadAsset(ctx, msisdn)
+	if err != nil {
+		return fmt.Errorf("error reading asset: %v", err)
+	}
+
+	now, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	if err := ensureUnlocked(asset, now); err != nil {
+		return err
+	}
+
+	assetJSON, err := marshalAsset(asset)
+	if err != nil {
+		return fmt.Errorf("error marshalling asset: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(archiveKeyPrefix+msisdn, assetJSON); err != nil {
+		return fmt.Errorf("failed to put to world state: %v", err)
+	}
+	if err := ctx.GetStub().DelState(msisdn); err != nil {
+		return fmt.Errorf("failed to delete from world state: %v", err)
+	}
+	if err := delDealerIndex(ctx, asset.DealerID, msisdn); err != nil {
+		return err
+	}
+	if err := delStandingInstruction(ctx, msisdn); err != nil {
+		return err
+	}
+	if countsTowardsQuota(asset.Status) {
+		if err := releaseDealerSlot(ctx, asset.DealerID); err != nil {
+			return err
+		}
+	}
+
+	return ctx.GetStub().SetEvent("AssetArchived", assetJSON)
+}
+
+// RestoreArchivedAsset moves an archived asset back into the active state
+func (s *SmartContract) RestoreArchivedAsset(ctx contractapi.TransactionContextInterface, msisdn string) error {
+	msisdn = normalizeMSISDN(msisdn)
+
+	asset, err := s.GetArchivedAsset(ctx, msisdn)
+	if err != nil {
+		return err
+	}
+
+	exists, err := s.AssetExists(ctx, msisdn)
+	if err != nil {
+		return fmt.Errorf("error checking asset existence: %v", err)
+	}
+	if exists {
+		return fmt.Errorf("asset with MSISDN %s already exists", msisdn)
+	}
+
+	assetJSON, err := marshalAsset(asset)
+	if err != nil {
+		return fmt.Errorf("error marshalling asset: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(msisdn, assetJSON); err != nil {
+		return fmt.Errorf("failed to put to world state: %v", err)
+	}
+	if err := ctx.GetStub().DelState(archiveKeyPrefix + msisdn); err != nil {
+		return fmt.Errorf("failed to delete from world state: %v", err)
+	}
+	if err := putDealerIndex(ctx, asset.DealerID, msisdn); err != nil {
+		return err
+	}
+	if countsTowardsQuota(asset.Status) {
+		if err := reserveDealerSlot(ctx, asset.DealerID); err != nil {
+			return err
+		}
+	}
+
+	return ctx.GetStub().SetEvent("AssetRestored", assetJSON)
+}
+
+// GetArchivedAsset retrieves an asset from the archive namespace
+func (s *SmartContract) GetArchivedAsset(ctx contractapi.TransactionContextInterface, msisdn string) (*Asset, error) {
+	msisdn = normalizeMSISDN(msisdn)
+
+	assetJSON, err := ctx.GetStub().GetState(archiveKeyPrefix + msisdn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if assetJSON == nil {
+		return nil, fmt.Errorf("archived asset with MSISDN %s does not exist", msisdn)
+	}
+
+	var asset Asset
+	if err := unmarshalAsset(assetJSON, &asset); err != nil {
+		return nil, fmt.Errorf("error unmarshalling asset: %v", err)
+	}
+
+	return &asset, nil
+}
+
+// putDealerIndex writes the dealer index entry for an asset
+func putDealerIndex(ctx contractapi.TransactionContextInterface, dealerID, msisdn string) error {
+	indexKey, err := ctx.GetStub().CreateCompositeKey(dealerIndex, []string{dealerID, msisdn})
+	if err != nil {
+		return fmt.Errorf("error creating dealer index key: %v", err)
+	}
+
+	// Only the key matters; a non-nil value is required to store it
+	if err := ctx.GetStub().PutState(indexKey, []byte{0x00}); err != nil {
+		return fmt.Errorf("error writing dealer index: %v", err)
+	}
+
+	return nil
+}
+
+// delDealerIndex removes the dealer index entry for an asset
+func delDealerIndex(ctx contractapi.TransactionContextInterface, dealerID, msisdn string) error {
+	indexKey, err := ctx.GetStub().CreateCompositeKey(dealerIndex, []string{dealerID, msisdn})
+	if err != nil {
+		return fmt.Errorf("error creating dealer index key: %v", err)
+	}
+
+	if err := ctx.GetStub().DelState(indexKey); err != nil {
+		return fmt.Errorf("error deleting dealer index: %v", err)
+	}
+
+	return nil
+}
+
+// getTxTimestamp returns the transaction timestamp as a time.Time
+func getTxTimestamp(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error getting transaction timestamp: %v", err)
+	}
+
+	timestamp, err := ptypes.Timestamp(txTimestamp)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error converting timestamp: %v", err)
+	}
+
+	return timestamp, nil
+}
+
+// putAsset marshals an asset and writes it to the world state under its MSISDN
+func putAsset(ctx contractapi.TransactionContextInterface, asset *Asset) error {
+	assetJSON, err := marshalAsset(asset)
+	if err != nil {
+		return fmt.Errorf("error marshalling asset: %v", err)
+	}
+
+	return ctx.GetStub().PutState(asset.MSISDN, assetJSON)
+}
+
+// setAssetEvent emits the state of the single asset a transaction wrote
+func setAssetEvent(ctx contractapi.TransactionContextInterface, name string, asset *Asset) error {
+	assetJSON, err := marshalAsset(asset)
+	if err != nil {
+		return fmt.Errorf("error marshalling asset: %v", err)
+	}
+
+	return ctx.GetStub().SetEvent(name, assetJSON)
+}
+
+// setAssetsChangedEvent emits an AssetsChanged event naming the assets a
+// transaction wrote. A transaction keeps only the last event it sets, so one
+// that writes several assets names them all in this event instead of emitting
+// the state of each.
+func setAssetsChangedEvent(ctx contractapi.TransactionContextInterface, msisdns []string) error {
+	if len(msisdns) == 0 {
+		return nil
+	}
+
+	eventJSON, err := json.Marshal(AssetsChanged{MSISDNs: msisdns})
+	if err != nil {
+		return fmt.Errorf("error marshalling event: %v", err)
+	}
+
+	return ctx.GetStub().SetEvent(assetsChangedEvent, eventJSON)
+}
+
+// GetRawState returns the bytes stored under any world state key, base64
+// encoded and without decoding them as an asset, for inspecting corrupt records
+func (s *SmartContract) GetRawState(ctx contractapi.TransactionContextInterface, key string) (string, error) {
+	value, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if value == nil {
+		return "", fmt.Errorf("key %s does not exist", key)
+	}
+
+	return base64.StdEncoding.EncodeToString(value), nil
+}
+
+// AssetExists checks if an asset with the given MSISDN exists
+func (s *SmartContract) AssetExists(ctx contractapi.TransactionContextInterface, msisdn string) (bool, error) {
+	msisdn = normalizeMSISDN(msisdn)
 
-// AssetExists checks if an asset with the given MSISDN exists
-func (s *SmartContract) AssetExists(ctx contractapi.TransactionContextInterface, msisdn string) (bool, error) {
 	assetJSON, err := ctx.GetStub().GetState(msisdn)
 	if err != nil {
 		return false, fmt.Errorf("failed to read from world state: %v", err)
@@ -197,7 +2328,12 @@ func (s *SmartContract) AssetExists(ctx contractapi.TransactionContextInterface,
 }
 
 func main() {
-	assetChaincode, err := contractapi.NewChaincode(&SmartContract{})
+	assetChaincode, err := contractapi.NewChaincode(&SmartContract{
+		Contract: contractapi.Contract{
+			BeforeTransaction:         logCorrelationID,
+			TransactionContextHandler: new(auditContext),
+		},
+	})
 	if err != nil {
 		fmt.Printf("Error creating asset chaincode: %s", err.Error())
 		return
@@ -0,0 +1,2442 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// ledgerStub is a MockStub that behaves like a peer where the contract relies
+// on it: writes only become visible when the transaction commits, range
+// queries skip composite keys, key history is kept and only the last event
+// of a transaction is emitted. Private data purges are recorded as
+// "<collection>/<key>".
+type ledgerStub struct {
+	*shimtest.MockStub
+	txCount int
+	now     time.Time
+	writes  map[string][]byte
+	purges  []string
+	purged  []string
+	event   *peer.ChaincodeEvent
+	events  []*peer.ChaincodeEvent
+	history map[string][]*queryresult.KeyModification
+}
+
+func newLedgerStub() *ledgerStub {
+	stub := &ledgerStub{
+		MockStub: shimtest.NewMockStub("myassetchaincode", nil),
+		now:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		history:  make(map[string][]*queryresult.KeyModification),
+	}
+	stub.TransientMap = map[string][]byte{mpinTransientKey: []byte("1234")}
+	return stub
+}
+
+// transact runs fn as one transaction, committing its writes and event when
+// it succeeds and discarding them when it fails
+func (s *ledgerStub) transact(fn func(ctx *auditContext) error) error {
+	s.txCount++
+	s.now = s.now.Add(time.Minute)
+	s.MockTransactionStart(fmt.Sprintf("tx%d", s.txCount))
+	defer s.MockTransactionEnd(s.TxID)
+	s.writes = make(map[string][]byte)
+	s.purges = nil
+	s.event = nil
+
+	ctx := new(auditContext)
+	ctx.SetStub(s)
+	if err := fn(ctx); err != nil {
+		return err
+	}
+
+	ts, _ := ptypes.TimestampProto(s.now)
+	for key, value := range s.writes {
+		s.history[key] = append([]*queryresult.KeyModification{{TxId: s.TxID, Value: value, Timestamp: ts, IsDelete: value == nil}}, s.history[key]...)
+		if value == nil {
+			s.MockStub.DelState(key)
+		} else {
+			s.MockStub.PutState(key, value)
+		}
+	}
+	s.purged = append(s.purged, s.purges...)
+	if s.event != nil {
+		s.events = append(s.events, s.event)
+	}
+	return nil
+}
+
+// GetTxTimestamp returns the clock of the stub, which advances a minute per transaction
+func (s *ledgerStub) GetTxTimestamp() (*timestamp.Timestamp, error) {
+	return ptypes.TimestampProto(s.now)
+}
+
+// PutState buffers the write until the transaction commits
+func (s *ledgerStub) PutState(key string, value []byte) error {
+	s.writes[key] = value
+	return nil
+}
+
+// DelState buffers the deletion until the transaction commits
+func (s *ledgerStub) DelState(key string) error {
+	s.writes[key] = nil
+	return nil
+}
+
+// PurgePrivateData buffers the purge until the transaction commits
+func (s *ledgerStub) PurgePrivateData(collection, key string) error {
+	s.purges = append(s.purges, collection+"/"+key)
+	return nil
+}
+
+// SetEvent replaces the event of the transaction
+func (s *ledgerStub) SetEvent(name string, payload []byte) error {
+	s.event = &peer.ChaincodeEvent{EventName: name, Payload: payload}
+	return nil
+}
+
+// GetStateByRange lists the committed simple keys in [startKey, endKey)
+func (s *ledgerStub) GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	var results []*queryresult.KV
+	for _, key := range s.sortedKeys() {
+		if strings.HasPrefix(key, "\x00") || key < startKey || (endKey != "" && key >= endKey) {
+			continue
+		}
+		results = append(results, &queryresult.KV{Key: key, Value: s.State[key]})
+	}
+	return &kvIterator{results: results}, nil
+}
+
+// GetQueryResult runs a CouchDB selector over the committed JSON documents,
+// which like CouchDB include composite keys whose values are JSON
+func (s *ledgerStub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	results, err := s.queryDocuments(query)
+	if err != nil {
+		return nil, err
+	}
+	return &kvIterator{results: results}, nil
+}
+
+// GetQueryResultWithPagination is GetQueryResult returning pageSize documents
+// at a time, with the index of the next document as the bookmark
+func (s *ledgerStub) GetQueryResultWithPagination(query string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	results, err := s.queryDocuments(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	start := 0
+	if bookmark != "" {
+		if start, err = strconv.Atoi(bookmark); err != nil {
+			return nil, nil, fmt.Errorf("invalid bookmark %q", bookmark)
+		}
+	}
+	if start > len(results) {
+		start = len(results)
+	}
+	end := start + int(pageSize)
+	if end > len(results) {
+		end = len(results)
+	}
+
+	metadata := &peer.QueryResponseMetadata{FetchedRecordsCount: int32(end - start)}
+	if end < len(results) {
+		metadata.Bookmark = strconv.Itoa(end)
+	}
+	return &kvIterator{results: results[start:end]}, metadata, nil
+}
+
+// GetStateByPartialCompositeKeyWithPagination lists pageSize committed
+// composite keys under the partial key at a time, with the next key as the
+// bookmark
+func (s *ledgerStub) GetStateByPartialCompositeKeyWithPagination(objectType string, attributes []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	prefix, err := shim.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	metadata := &peer.QueryResponseMetadata{}
+	var results []*queryresult.KV
+	for _, key := range s.sortedKeys() {
+		if !strings.HasPrefix(key, prefix) || key < bookmark {
+			continue
+		}
+		if len(results) == int(pageSize) {
+			metadata.Bookmark = key
+			break
+		}
+		results = append(results, &queryresult.KV{Key: key, Value: s.State[key]})
+	}
+	metadata.FetchedRecordsCount = int32(len(results))
+	return &kvIterator{results: results}, metadata, nil
+}
+
+// queryDocuments returns the committed documents matching the selector of a
+// CouchDB query, ordered by its sort field if it has one
+func (s *ledgerStub) queryDocuments(query string) ([]*queryresult.KV, error) {
+	var parsed struct {
+		Selector map[string]interface{} `json:"selector"`
+		Sort     []map[string]string    `json:"sort"`
+	}
+	if err := json.Unmarshal([]byte(query), &parsed); err != nil {
+		return nil, fmt.Errorf("invalid query: %v", err)
+	}
+
+	var results []*queryresult.KV
+	docs := make(map[string]map[string]interface{})
+	for _, key := range s.sortedKeys() {
+		var doc map[string]interface{}
+		if json.Unmarshal(s.State[key], &doc) != nil {
+			continue
+		}
+		if matchesSelector(doc, parsed.Selector) {
+			docs[key] = doc
+			results = append(results, &queryresult.KV{Key: key, Value: s.State[key]})
+		}
+	}
+
+	for _, sortBy := range parsed.Sort {
+		for field, order := range sortBy {
+			sort.SliceStable(results, func(i, j int) bool {
+				a, b := docs[results[i].Key][field], docs[results[j].Key][field]
+				if order == "desc" {
+					return collate(b, a) < 0
+				}
+				return collate(a, b) < 0
+			})
+		}
+	}
+	return results, nil
+}
+
+// matchesSelector reports whether doc matches a CouchDB selector using
+// implicit equality and the $eq, $ne, $gt, $gte, $lt, $lte, $exists, $regex,
+// $in, $and and $or operators
+func matchesSelector(doc map[string]interface{}, selector map[string]interface{}) bool {
+	for field, condition := range selector {
+		switch field {
+		case "$and", "$or":
+			clauses, _ := condition.([]interface{})
+			matched := 0
+			for _, clause := range clauses {
+				if sub, ok := clause.(map[string]interface{}); ok && matchesSelector(doc, sub) {
+					matched++
+				}
+			}
+			if (field == "$and" && matched != len(clauses)) || (field == "$or" && matched == 0) {
+				return false
+			}
+			continue
+		}
+
+		value, present := doc[field]
+		operators, ok := condition.(map[string]interface{})
+		if !ok {
+			if !present || collate(value, condition) != 0 {
+				return false
+			}
+			continue
+		}
+		for operator, operand := range operators {
+			if !matchesOperator(value, present, operator, operand) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func matchesOperator(value interface{}, present bool, operator string, operand interface{}) bool {
+	if operator == "$exists" {
+		return present == operand
+	}
+	if !present {
+		return false
+	}
+	switch operator {
+	case "$eq":
+		return collate(value, operand) == 0
+	case "$ne":
+		return collate(value, operand) != 0
+	case "$gt":
+		return collate(value, operand) > 0
+	case "$gte":
+		return collate(value, operand) >= 0
+	case "$lt":
+		return collate(value, operand) < 0
+	case "$lte":
+		return collate(value, operand) <= 0
+	case "$regex":
+		str, ok := value.(string)
+		return ok && regexp.MustCompile(operand.(string)).MatchString(str)
+	case "$in":
+		candidates, _ := operand.([]interface{})
+		for _, candidate := range candidates {
+			if collate(value, candidate) == 0 {
+				return true
+			}
+		}
+		return false
+	}
+	panic("unsupported selector operator " + operator)
+}
+
+// collate compares two JSON values in CouchDB order: null, booleans,
+// numbers, strings, then anything else
+func collate(a, b interface{}) int {
+	rank := func(v interface{}) int {
+		switch v.(type) {
+		case nil:
+			return 0
+		case bool:
+			return 1
+		case float64:
+			return 2
+		case string:
+			return 3
+		}
+		return 4
+	}
+	if ra, rb := rank(a), rank(b); ra != rb {
+		return ra - rb
+	}
+	switch a := a.(type) {
+	case bool:
+		if a == b.(bool) {
+			return 0
+		} else if !a {
+			return -1
+		}
+		return 1
+	case float64:
+		if a < b.(float64) {
+			return -1
+		} else if a > b.(float64) {
+			return 1
+		}
+	case string:
+		return strings.Compare(a, b.(string))
+	}
+	return 0
+}
+
+// GetHistoryForKey returns the committed writes of key, newest first
+func (s *ledgerStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	return &historyIterator{results: s.history[key]}, nil
+}
+
+func (s *ledgerStub) sortedKeys() []string {
+	keys := make([]string, 0, len(s.State))
+	for key := range s.State {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// lastEvent returns the event of the last transaction that emitted one
+func (s *ledgerStub) lastEvent(t *testing.T) *peer.ChaincodeEvent {
+	t.Helper()
+	if len(s.events) == 0 {
+		t.Fatal("no event was emitted")
+	}
+	return s.events[len(s.events)-1]
+}
+
+type kvIterator struct {
+	results []*queryresult.KV
+}
+
+func (it *kvIterator) HasNext() bool { return len(it.results) > 0 }
+func (it *kvIterator) Close() error  { return nil }
+
+func (it *kvIterator) Next() (*queryresult.KV, error) {
+	next := it.results[0]
+	it.results = it.results[1:]
+	return next, nil
+}
+
+type historyIterator struct {
+	results []*queryresult.KeyModification
+}
+
+func (it *historyIterator) HasNext() bool { return len(it.results) > 0 }
+func (it *historyIterator) Close() error  { return nil }
+
+func (it *historyIterator) Next() (*queryresult.KeyModification, error) {
+	next := it.results[0]
+	it.results = it.results[1:]
+	return next, nil
+}
+
+// createTestAsset creates an active asset in a transaction of its own
+func createTestAsset(t *testing.T, stub *ledgerStub, dealerID, msisdn string, balance int64) {
+	t.Helper()
+	err := stub.transact(func(ctx *auditContext) error {
+		return new(SmartContract).CreateAsset(ctx, dealerID, msisdn, balance, "Active", "", "", "", "")
+	})
+	if err != nil {
+		t.Fatalf("CreateAsset(%s) returned error: %v", msisdn, err)
+	}
+}
+
+// readTestAsset reads an asset from the committed state
+func readTestAsset(t *testing.T, stub *ledgerStub, msisdn string) *Asset {
+	t.Helper()
+	var asset *Asset
+	err := stub.transact(func(ctx *auditContext) error {
+		var err error
+		asset, err = new(SmartContract).ReadAsset(ctx, msisdn)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("ReadAsset(%s) returned error: %v", msisdn, err)
+	}
+	return asset
+}
+
+func TestMergeAssets(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 300)
+	createTestAsset(t, stub, "D001", "9822222222", 200)
+
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.MergeAssets(ctx, "9811111111", "9822222222")
+	})
+	if err != nil {
+		t.Fatalf("MergeAssets returned error: %v", err)
+	}
+
+	target := readTestAsset(t, stub, "9822222222")
+	if target.Balance != 500 || target.TransAmount != 300 || target.TransType != "MERGE" {
+		t.Errorf("target has balance %d, amount %d, type %q, want 500, 300, MERGE", target.Balance, target.TransAmount, target.TransType)
+	}
+	source := readTestAsset(t, stub, "9811111111")
+	if source.Balance != 0 || source.Status != "Deleted" {
+		t.Errorf("source has balance %d, status %s, want 0, Deleted", source.Balance, source.Status)
+	}
+}
+
+func TestMergeAssetsMissingSource(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9822222222", 200)
+
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.MergeAssets(ctx, "9811111111", "9822222222")
+	})
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Fatalf("MergeAssets returned %v, want a missing source error", err)
+	}
+
+	if target := readTestAsset(t, stub, "9822222222"); target.Balance != 200 {
+		t.Errorf("target balance is %d, want 200", target.Balance)
+	}
+}
+
+// putLegacyAsset stores an asset under its MSISDN as given, as records
+// written before MSISDN normalization were
+func putLegacyAsset(t *testing.T, stub *ledgerStub, dealerID, msisdn string, balance int64, status string) {
+	t.Helper()
+	assetJSON, err := json.Marshal(Asset{DealerID: dealerID, MSISDN: msisdn, Balance: balance, Status: status})
+	if err != nil {
+		t.Fatalf("error marshalling asset: %v", err)
+	}
+	err = stub.transact(func(ctx *auditContext) error {
+		return stub.PutState(msisdn, assetJSON)
+	})
+	if err != nil {
+		t.Fatalf("storing legacy asset %q returned error: %v", msisdn, err)
+	}
+}
+
+// findTestDuplicates runs FindDuplicateAssets in a transaction of its own
+func findTestDuplicates(t *testing.T, stub *ledgerStub) [][]string {
+	t.Helper()
+	var clusters [][]string
+	err := stub.transact(func(ctx *auditContext) error {
+		var err error
+		clusters, err = new(SmartContract).FindDuplicateAssets(ctx)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("FindDuplicateAssets returned error: %v", err)
+	}
+	return clusters
+}
+
+func TestFindAndMergeDuplicateAssets(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 500)
+	createTestAsset(t, stub, "D001", "9822222222", 100)
+	putLegacyAsset(t, stub, "D001", "+91 98111 11111", 250, "Active")
+	putLegacyAsset(t, stub, "D002", "098222-22222", 0, "Deleted")
+
+	clusters := findTestDuplicates(t, stub)
+	if len(clusters) != 1 || strings.Join(clusters[0], ",") != "+91 98111 11111,9811111111" {
+		t.Fatalf("duplicate clusters are %q, want the legacy and normalized 9811111111", clusters)
+	}
+
+	// MergeAssets normalizes its arguments, so it cannot reach the legacy key
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.MergeAssets(ctx, clusters[0][0], clusters[0][1])
+	})
+	if err == nil {
+		t.Fatal("MergeAssets merged the legacy key into itself")
+	}
+	err = stub.transact(func(ctx *auditContext) error {
+		return s.MergeDuplicateAssets(ctx, clusters[0][0], clusters[0][1])
+	})
+	if err != nil {
+		t.Fatalf("MergeDuplicateAssets returned error: %v", err)
+	}
+
+	var legacy Asset
+	if err := json.Unmarshal(stub.State["+91 98111 11111"], &legacy); err != nil {
+		t.Fatalf("error unmarshalling legacy asset: %v", err)
+	}
+	if legacy.Status != "Deleted" || legacy.Balance != 0 {
+		t.Errorf("legacy asset has status %s and balance %d, want Deleted with 0", legacy.Status, legacy.Balance)
+	}
+	if asset := readTestAsset(t, stub, "9811111111"); asset.Balance != 750 || asset.TransType != "MERGE" {
+		t.Errorf("merged asset has balance %d and type %s, want 750 and MERGE", asset.Balance, asset.TransType)
+	}
+	if clusters := findTestDuplicates(t, stub); len(clusters) != 0 {
+		t.Errorf("duplicate clusters after the merge are %q, want none", clusters)
+	}
+
+	err = stub.transact(func(ctx *auditContext) error {
+		return s.MergeDuplicateAssets(ctx, "9822222222", "9811111111")
+	})
+	if err == nil || !strings.Contains(err.Error(), "not duplicates") {
+		t.Errorf("MergeDuplicateAssets of different MSISDNs returned %v, want a not duplicates error", err)
+	}
+}
+
+func TestReassignMSISDN(t *testing.T) {
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 700)
+
+	err := stub.transact(func(ctx *auditContext) error {
+		return new(SmartContract).ReassignMSISDN(ctx, "9811111111", "9822222222")
+	})
+	if err != nil {
+		t.Fatalf("ReassignMSISDN returned error: %v", err)
+	}
+
+	moved := readTestAsset(t, stub, "9822222222")
+	if moved.Balance != 700 || moved.DealerID != "D001" || moved.MPIN != "1234" || moved.Status != "Active" {
+		t.Errorf("reassigned asset is %+v, want balance 700 of D001 with the old MPIN", moved)
+	}
+	if moved.TransType != "REASSIGN" || moved.TransAmount != 700 || moved.PreviousMSISDN != "9811111111" {
+		t.Errorf("reassigned asset records %s of %d from %q, want REASSIGN of 700 from 9811111111", moved.TransType, moved.TransAmount, moved.PreviousMSISDN)
+	}
+
+	old := readTestAsset(t, stub, "9811111111")
+	if old.Status != "Deleted" || old.Balance != 0 || old.TransAmount != -700 || old.ReassignedTo != "9822222222" {
+		t.Errorf("old asset is %+v, want it soft-deleted with its balance moved to 9822222222", old)
+	}
+
+	event := stub.lastEvent(t)
+	var payload Asset
+	if err := unmarshalAsset(event.Payload, &payload); err != nil || event.EventName != "AssetReassigned" || payload.MSISDN != "9822222222" || payload.PreviousMSISDN != "9811111111" {
+		t.Errorf("event is %s with %s, want AssetReassigned carrying the new asset", event.EventName, event.Payload)
+	}
+}
+
+func TestReassignMSISDNRejectsExistingKey(t *testing.T) {
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 700)
+	createTestAsset(t, stub, "D002", "9822222222", 100)
+
+	err := stub.transact(func(ctx *auditContext) error {
+		return new(SmartContract).ReassignMSISDN(ctx, "9811111111", "9822222222")
+	})
+	if err == nil || !strings.Contains(err.Error(), "9822222222 already exists") {
+		t.Fatalf("ReassignMSISDN onto an existing asset returned %v, want an already exists error", err)
+	}
+
+	if asset := readTestAsset(t, stub, "9811111111"); asset.Status != "Active" || asset.Balance != 700 {
+		t.Errorf("old asset is %+v after the refused reassignment, want it unchanged", asset)
+	}
+	if asset := readTestAsset(t, stub, "9822222222"); asset.DealerID != "D002" || asset.Balance != 100 {
+		t.Errorf("existing asset is %+v after the refused reassignment, want it unchanged", asset)
+	}
+}
+
+func TestGetTotalBalance(t *testing.T) {
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 1000)
+	createTestAsset(t, stub, "D001", "9822222222", 2500)
+	createTestAsset(t, stub, "D002", "9833333333", 0)
+
+	var total int64
+	err := stub.transact(func(ctx *auditContext) error {
+		var err error
+		total, err = new(SmartContract).GetTotalBalance(ctx)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("GetTotalBalance returned error: %v", err)
+	}
+	if total != 3500 {
+		t.Errorf("GetTotalBalance = %d, want 3500", total)
+	}
+}
+
+// changedMSISDNs decodes the payload of an AssetsChanged event
+func changedMSISDNs(t *testing.T, event *peer.ChaincodeEvent) []string {
+	t.Helper()
+	if event.EventName != assetsChangedEvent {
+		t.Fatalf("event is %s, want %s", event.EventName, assetsChangedEvent)
+	}
+	var changed AssetsChanged
+	if err := json.Unmarshal(event.Payload, &changed); err != nil {
+		t.Fatalf("error unmarshalling event payload: %v", err)
+	}
+	sort.Strings(changed.MSISDNs)
+	return changed.MSISDNs
+}
+
+func TestMultiAssetWritesEmitAssetsChanged(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 300)
+	createTestAsset(t, stub, "D001", "9822222222", 200)
+	createTestAsset(t, stub, "D002", "9833333333", 100)
+
+	err := stub.transact(func(ctx *auditContext) error {
+		_, err := s.ApplyRateToAll(ctx, 10, "INTEREST")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("ApplyRateToAll returned error: %v", err)
+	}
+	if got := changedMSISDNs(t, stub.lastEvent(t)); strings.Join(got, ",") != "9811111111,9822222222,9833333333" {
+		t.Errorf("ApplyRateToAll event names %v, want all three assets", got)
+	}
+
+	err = stub.transact(func(ctx *auditContext) error {
+		return s.MergeAssets(ctx, "9811111111", "9822222222")
+	})
+	if err != nil {
+		t.Fatalf("MergeAssets returned error: %v", err)
+	}
+	if got := changedMSISDNs(t, stub.lastEvent(t)); strings.Join(got, ",") != "9811111111,9822222222" {
+		t.Errorf("MergeAssets event names %v, want source and target", got)
+	}
+}
+
+func TestSingleAssetWritesEmitAssetUpdated(t *testing.T) {
+	s := new(SmartContract)
+	writes := map[string]func(ctx *auditContext) error{
+		"LockAsset": func(ctx *auditContext) error {
+			return s.LockAsset(ctx, "9811111111", "2030-01-01T00:00:00Z")
+		},
+		"SetAssetMetadata": func(ctx *auditContext) error {
+			return s.SetAssetMetadata(ctx, "9811111111", "region", "north")
+		},
+		"SetAssetLocation": func(ctx *auditContext) error {
+			return s.SetAssetLocation(ctx, "9811111111", 28.6, 77.2)
+		},
+	}
+
+	for name, write := range writes {
+		stub := newLedgerStub()
+		createTestAsset(t, stub, "D001", "9811111111", 300)
+
+		if err := stub.transact(write); err != nil {
+			t.Fatalf("%s returned error: %v", name, err)
+		}
+
+		event := stub.lastEvent(t)
+		if event.EventName != "AssetUpdated" {
+			t.Errorf("%s emitted %s, want AssetUpdated", name, event.EventName)
+			continue
+		}
+		var asset Asset
+		if err := unmarshalAsset(event.Payload, &asset); err != nil || asset.MSISDN != "9811111111" {
+			t.Errorf("%s event payload is %s, want the asset", name, event.Payload)
+		}
+	}
+}
+
+func TestUpdateAssetNonexistent(t *testing.T) {
+	stub := newLedgerStub()
+
+	err := stub.transact(func(ctx *auditContext) error {
+		return new(SmartContract).UpdateAsset(ctx, "9800000000", "100", "Active", "CREDIT", "", "", "", "")
+	})
+	if !errors.Is(err, ErrUpdateNonexistentAsset) {
+		t.Fatalf("UpdateAsset returned %v, want ErrUpdateNonexistentAsset", err)
+	}
+	if !strings.Contains(err.Error(), "9800000000") {
+		t.Errorf("error %q does not name the MSISDN", err)
+	}
+}
+
+func TestParseBalance(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr string
+	}{
+		{value: "1000", want: 1000},
+		{value: "1,000", want: 1000},
+		{value: "1000.00", want: 1000},
+		{value: " 1,000.0 ", want: 1000},
+		{value: "1000.50", wantErr: "must be a whole number"},
+		{value: "abc", wantErr: "is not a valid number"},
+		{value: "", wantErr: "is not a valid number"},
+	}
+
+	for _, tt := range tests {
+		got, err := parseBalance(tt.value)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseBalance(%q) returned %d, %v, want an error containing %q", tt.value, got, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseBalance(%q) returned %d, %v, want %d", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestUpdateAssetAcceptsFormattedBalance(t *testing.T) {
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 100)
+
+	err := stub.transact(func(ctx *auditContext) error {
+		return new(SmartContract).UpdateAsset(ctx, "9811111111", "1,000.00", "Active", "CREDIT", "", "", "", "")
+	})
+	if err != nil {
+		t.Fatalf("UpdateAsset returned error: %v", err)
+	}
+	if asset := readTestAsset(t, stub, "9811111111"); asset.Balance != 1000 {
+		t.Errorf("balance is %d, want 1000", asset.Balance)
+	}
+
+	err = stub.transact(func(ctx *auditContext) error {
+		return new(SmartContract).UpdateAsset(ctx, "9811111111", "abc", "Active", "CREDIT", "", "", "", "")
+	})
+	if err == nil || !strings.Contains(err.Error(), `"abc"`) {
+		t.Errorf("UpdateAsset with balance abc returned %v, want an error naming the input", err)
+	}
+}
+
+func TestLockAssetRejectsUpdatesUntilExpiry(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 300)
+
+	until := stub.now.Add(time.Hour).Format(time.RFC3339)
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.LockAsset(ctx, "9811111111", until)
+	})
+	if err != nil {
+		t.Fatalf("LockAsset returned error: %v", err)
+	}
+
+	update := func(ctx *auditContext) error {
+		return s.UpdateAsset(ctx, "9811111111", "400", "Active", "CREDIT", "", "", "", "")
+	}
+	if err := stub.transact(update); err == nil || !strings.Contains(err.Error(), "locked") {
+		t.Fatalf("UpdateAsset of a locked asset returned %v, want a lock error", err)
+	}
+
+	stub.now = stub.now.Add(2 * time.Hour)
+	if err := stub.transact(update); err != nil {
+		t.Fatalf("UpdateAsset after the lock expired returned error: %v", err)
+	}
+	if asset := readTestAsset(t, stub, "9811111111"); asset.Balance != 400 {
+		t.Errorf("balance is %d, want 400", asset.Balance)
+	}
+}
+
+func TestAssetMetadata(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 300)
+
+	setMetadata := func(key, value string) error {
+		return stub.transact(func(ctx *auditContext) error {
+			return s.SetAssetMetadata(ctx, "9811111111", key, value)
+		})
+	}
+	getMetadata := func() map[string]string {
+		t.Helper()
+		var metadata map[string]string
+		err := stub.transact(func(ctx *auditContext) error {
+			var err error
+			metadata, err = s.GetAssetMetadata(ctx, "9811111111")
+			return err
+		})
+		if err != nil {
+			t.Fatalf("GetAssetMetadata returned error: %v", err)
+		}
+		return metadata
+	}
+
+	if metadata := getMetadata(); len(metadata) != 0 {
+		t.Errorf("new asset has metadata %v, want none", metadata)
+	}
+
+	for _, kv := range [][2]string{{"region", "north"}, {"plan", "prepaid"}, {"region", "south"}} {
+		if err := setMetadata(kv[0], kv[1]); err != nil {
+			t.Fatalf("SetAssetMetadata(%s, %s) returned error: %v", kv[0], kv[1], err)
+		}
+	}
+	if metadata := getMetadata(); len(metadata) != 2 || metadata["region"] != "south" || metadata["plan"] != "prepaid" {
+		t.Errorf("metadata is %v, want region south and plan prepaid", metadata)
+	}
+
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.UpdateAsset(ctx, "9811111111", "400", "Active", "CREDIT", "", "", "", "")
+	})
+	if err != nil {
+		t.Fatalf("UpdateAsset returned error: %v", err)
+	}
+	if metadata := getMetadata(); metadata["region"] != "south" || metadata["plan"] != "prepaid" {
+		t.Errorf("metadata after UpdateAsset is %v, want it preserved", metadata)
+	}
+
+	if err := setMetadata("plan", ""); err != nil {
+		t.Fatalf("SetAssetMetadata with an empty value returned error: %v", err)
+	}
+	if metadata := getMetadata(); len(metadata) != 1 {
+		t.Errorf("metadata after removing plan is %v, want only region", metadata)
+	}
+}
+
+func TestAssetMetadataLimits(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 300)
+
+	setMetadata := func(key, value string) error {
+		return stub.transact(func(ctx *auditContext) error {
+			return s.SetAssetMetadata(ctx, "9811111111", key, value)
+		})
+	}
+
+	if err := setMetadata("", "x"); err == nil {
+		t.Error("SetAssetMetadata accepted an empty key")
+	}
+	if err := setMetadata(strings.Repeat("k", maxMetadataKeyLength+1), "x"); err == nil {
+		t.Error("SetAssetMetadata accepted an oversized key")
+	}
+	if err := setMetadata("note", strings.Repeat("v", maxMetadataValueLength+1)); err == nil {
+		t.Error("SetAssetMetadata accepted an oversized value")
+	}
+
+	for i := 0; i < maxMetadataEntries; i++ {
+		if err := setMetadata(fmt.Sprintf("key%d", i), "x"); err != nil {
+			t.Fatalf("SetAssetMetadata of entry %d returned error: %v", i, err)
+		}
+	}
+	if err := setMetadata("onemore", "x"); err == nil {
+		t.Error("SetAssetMetadata accepted an entry beyond the maximum")
+	}
+	if err := setMetadata("key0", "y"); err != nil {
+		t.Errorf("SetAssetMetadata replacing an entry at the maximum returned error: %v", err)
+	}
+}
+
+func TestRepairDealerIndex(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 300)
+	createTestAsset(t, stub, "D002", "9822222222", 200)
+
+	orphanKey, _ := stub.CreateCompositeKey(dealerIndex, []string{"D009", "9899999999"})
+	droppedKey, _ := stub.CreateCompositeKey(dealerIndex, []string{"D002", "9822222222"})
+	err := stub.transact(func(ctx *auditContext) error {
+		if err := ctx.GetStub().PutState(orphanKey, []byte{0x00}); err != nil {
+			return err
+		}
+		return ctx.GetStub().DelState(droppedKey)
+	})
+	if err != nil {
+		t.Fatalf("error seeding the dealer index: %v", err)
+	}
+
+	var report *DealerIndexReport
+	err = stub.transact(func(ctx *auditContext) error {
+		var err error
+		report, err = s.RepairDealerIndex(ctx)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("RepairDealerIndex returned error: %v", err)
+	}
+
+	if strings.Join(report.Removed, ",") != "D009/9899999999" {
+		t.Errorf("report removed %v, want the orphaned entry", report.Removed)
+	}
+	if strings.Join(report.Added, ",") != "D002/9822222222" {
+		t.Errorf("report added %v, want the missing entry", report.Added)
+	}
+	if _, ok := stub.State[orphanKey]; ok {
+		t.Error("orphaned dealer index entry was not removed")
+	}
+	if _, ok := stub.State[droppedKey]; !ok {
+		t.Error("missing dealer index entry was not restored")
+	}
+
+	// A consistent index is left alone
+	err = stub.transact(func(ctx *auditContext) error {
+		var err error
+		report, err = s.RepairDealerIndex(ctx)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("RepairDealerIndex returned error: %v", err)
+	}
+	if len(report.Added) != 0 || len(report.Removed) != 0 {
+		t.Errorf("repair of a consistent index reported %+v, want no changes", report)
+	}
+}
+
+// testIdentity is a client identity known only by its ID
+type testIdentity string
+
+func (id testIdentity) GetID() (string, error)    { return string(id), nil }
+func (id testIdentity) GetMSPID() (string, error) { return "Org1MSP", nil }
+func (id testIdentity) GetAttributeValue(string) (string, bool, error) {
+	return "", false, nil
+}
+func (id testIdentity) AssertAttributeValue(string, string) error {
+	return errors.New("attribute not found")
+}
+func (id testIdentity) GetX509Certificate() (*x509.Certificate, error) { return nil, nil }
+
+func TestRequestAndApproveUpdate(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 300)
+
+	// An update above the threshold needs a second approver
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.UpdateAsset(ctx, "9811111111", "50000", "Active", "CREDIT", "", "", "", "")
+	})
+	if !errors.Is(err, ErrApprovalRequired) {
+		t.Fatalf("UpdateAsset above the threshold returned %v, want ErrApprovalRequired", err)
+	}
+
+	var requestID string
+	err = stub.transact(func(ctx *auditContext) error {
+		ctx.SetClientIdentity(testIdentity("alice"))
+		var err error
+		requestID, err = s.RequestUpdate(ctx, "9811111111", "50000", "Active", "CREDIT", "bonus", "", "")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("RequestUpdate returned error: %v", err)
+	}
+	pendingKey, _ := stub.CreateCompositeKey(pendingUpdateObjectType, []string{requestID})
+	var pending PendingUpdate
+	if err := json.Unmarshal(stub.State[pendingKey], &pending); err != nil {
+		t.Fatalf("pending update %s was not stored: %v", requestID, err)
+	}
+	if pending.RequestedBy != "alice" || pending.NewBalanceStr != "50000" {
+		t.Errorf("pending update is %+v, want 50000 requested by alice", pending)
+	}
+	if asset := readTestAsset(t, stub, "9811111111"); asset.Balance != 300 {
+		t.Errorf("balance is %d before approval, want 300", asset.Balance)
+	}
+
+	approveAs := func(approver string) error {
+		return stub.transact(func(ctx *auditContext) error {
+			ctx.SetClientIdentity(testIdentity(approver))
+			return s.ApproveUpdate(ctx, requestID)
+		})
+	}
+
+	if err := approveAs("alice"); !errors.Is(err, ErrApprovalRequired) {
+		t.Fatalf("self-approval returned %v, want ErrApprovalRequired", err)
+	}
+	if asset := readTestAsset(t, stub, "9811111111"); asset.Balance != 300 {
+		t.Errorf("balance is %d after self-approval, want 300", asset.Balance)
+	}
+
+	if err := approveAs("bob"); err != nil {
+		t.Fatalf("ApproveUpdate by a second party returned error: %v", err)
+	}
+	if asset := readTestAsset(t, stub, "9811111111"); asset.Balance != 50000 || asset.Remarks != "bonus" {
+		t.Errorf("asset has balance %d and remarks %q, want 50000 and bonus", asset.Balance, asset.Remarks)
+	}
+	if _, ok := stub.State[pendingKey]; ok {
+		t.Error("pending update was not removed after approval")
+	}
+	if err := approveAs("bob"); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("second approval returned %v, want a missing request error", err)
+	}
+}
+
+func TestCreateAssetStatus(t *testing.T) {
+	tests := []struct {
+		status  string
+		want    string
+		wantErr bool
+	}{
+		{"Active", "Active", false},
+		{"Frozen", "Frozen", false},
+		{"Suspended", "Suspended", false},
+		{"Deleted", "Deleted", false},
+		{"", "Active", false},
+		{"Activ", "", true},
+		{"active", "", true},
+	}
+
+	for _, tt := range tests {
+		stub := newLedgerStub()
+		err := stub.transact(func(ctx *auditContext) error {
+			return new(SmartContract).CreateAsset(ctx, "D001", "9811111111", 300, tt.status, "", "", "", "")
+		})
+		if tt.wantErr {
+			if err == nil || !strings.Contains(err.Error(), "invalid status") {
+				t.Errorf("CreateAsset with status %q returned %v, want an invalid status error", tt.status, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("CreateAsset with status %q returned error: %v", tt.status, err)
+			continue
+		}
+		if asset := readTestAsset(t, stub, "9811111111"); asset.Status != tt.want {
+			t.Errorf("CreateAsset with status %q stored %q, want %q", tt.status, asset.Status, tt.want)
+		}
+	}
+}
+
+func TestCreateAssetRequireParent(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 1000)
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.CreateAsset(ctx, "D001", "9899999999", 0, "Deleted", "", "", "", "")
+	})
+	if err != nil {
+		t.Fatalf("CreateAsset of a deleted asset returned error: %v", err)
+	}
+
+	// The parent MSISDN is normalized like the asset's own
+	err = stub.transact(func(ctx *auditContext) error {
+		return s.CreateAsset(ctx, "D001", "9822222222", 100, "Active", "", "", "", "+98 1111-1111")
+	})
+	if err != nil {
+		t.Fatalf("CreateAsset under an existing parent returned error: %v", err)
+	}
+	if asset := readTestAsset(t, stub, "9822222222"); asset.ParentMSISDN != "9811111111" {
+		t.Errorf("ParentMSISDN is %q, want 9811111111", asset.ParentMSISDN)
+	}
+
+	for _, parent := range []string{"9800000000", "9899999999"} {
+		err := stub.transact(func(ctx *auditContext) error {
+			return s.CreateAsset(ctx, "D001", "9833333333", 100, "Active", "", "", "", parent)
+		})
+		if !errors.Is(err, ErrParentNotFound) {
+			t.Errorf("CreateAsset under %s returned %v, want ErrParentNotFound", parent, err)
+		}
+	}
+	if _, ok := stub.State["9833333333"]; ok {
+		t.Error("asset was stored although its parent is missing")
+	}
+
+	err = stub.transact(func(ctx *auditContext) error {
+		return s.CreateAsset(ctx, "D001", "9833333333", 100, "Active", "", "", "", "9833333333")
+	})
+	if err == nil || !strings.Contains(err.Error(), "its own parent") {
+		t.Errorf("CreateAsset under itself returned %v, want an error", err)
+	}
+
+	createTestAsset(t, stub, "D001", "9844444444", 100)
+	if asset := readTestAsset(t, stub, "9844444444"); asset.ParentMSISDN != "" {
+		t.Errorf("ParentMSISDN of an asset created without a parent is %q, want none", asset.ParentMSISDN)
+	}
+}
+
+func TestUpdateAssetStatus(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 300)
+
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.UpdateAsset(ctx, "9811111111", "300", "Activ", "", "", "", "", "")
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid status") {
+		t.Fatalf("UpdateAsset with status Activ returned %v, want an invalid status error", err)
+	}
+
+	err = stub.transact(func(ctx *auditContext) error {
+		return s.UpdateAsset(ctx, "9811111111", "300", "Suspended", "", "", "", "", "")
+	})
+	if err != nil {
+		t.Fatalf("UpdateAsset with status Suspended returned error: %v", err)
+	}
+	if asset := readTestAsset(t, stub, "9811111111"); asset.Status != "Suspended" {
+		t.Errorf("status is %s, want Suspended", asset.Status)
+	}
+}
+
+func TestGetAssetsCreatedBetween(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	// Each transaction runs a minute after stub.now
+	createAt := func(msisdn string, at time.Time) {
+		stub.now = at.Add(-time.Minute)
+		createTestAsset(t, stub, "D001", msisdn, 100)
+	}
+	createAt("9811111111", time.Date(2024, 1, 31, 23, 59, 59, 0, time.UTC))
+	createAt("9822222222", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	createAt("9833333333", time.Date(2024, 2, 29, 23, 59, 59, 0, time.UTC))
+	createAt("9844444444", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+
+	// Modifying an asset inside the range does not move its creation time
+	stub.now = time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC)
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.UpdateAsset(ctx, "9811111111", "200", "Active", "CREDIT", "", "", "", "")
+	})
+	if err != nil {
+		t.Fatalf("UpdateAsset returned error: %v", err)
+	}
+
+	var assets []*Asset
+	err = stub.transact(func(ctx *auditContext) error {
+		var err error
+		assets, err = s.GetAssetsCreatedBetween(ctx, "2024-02-01T00:00:00Z", "2024-03-01T00:00:00Z")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("GetAssetsCreatedBetween returned error: %v", err)
+	}
+
+	var got []string
+	for _, asset := range assets {
+		got = append(got, asset.MSISDN)
+	}
+	if strings.Join(got, ",") != "9822222222,9833333333" {
+		t.Errorf("GetAssetsCreatedBetween returned %v, want the two assets created in February", got)
+	}
+}
+
+func TestGetAssetsCreatedBetweenInvalidRange(t *testing.T) {
+	stub := newLedgerStub()
+	err := stub.transact(func(ctx *auditContext) error {
+		_, err := new(SmartContract).GetAssetsCreatedBetween(ctx, "February", "2024-03-01T00:00:00Z")
+		return err
+	})
+	if err == nil || !strings.Contains(err.Error(), "from timestamp") {
+		t.Errorf("GetAssetsCreatedBetween returned %v, want a from timestamp error", err)
+	}
+}
+
+func TestArchiveAndRestoreAsset(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 300)
+	createTestAsset(t, stub, "D001", "9822222222", 200)
+
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.ArchiveAsset(ctx, "9811111111")
+	})
+	if err != nil {
+		t.Fatalf("ArchiveAsset returned error: %v", err)
+	}
+	if _, ok := stub.State["9811111111"]; ok {
+		t.Error("archived asset is still in the active state")
+	}
+	if event := stub.lastEvent(t); event.EventName != "AssetArchived" {
+		t.Errorf("ArchiveAsset emitted %s, want AssetArchived", event.EventName)
+	}
+
+	var archived *Asset
+	var all []*Asset
+	err = stub.transact(func(ctx *auditContext) error {
+		var err error
+		if archived, err = s.GetArchivedAsset(ctx, "9811111111"); err != nil {
+			return err
+		}
+		all, err = s.GetAllAssets(ctx)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("reading the archive returned error: %v", err)
+	}
+	if archived.Balance != 300 || archived.DealerID != "D001" {
+		t.Errorf("archived asset is %+v, want balance 300 of D001", archived)
+	}
+	if len(all) != 1 || all[0].MSISDN != "9822222222" {
+		t.Errorf("GetAllAssets returned %d assets, want only the active one", len(all))
+	}
+
+	err = stub.transact(func(ctx *auditContext) error {
+		return s.RestoreArchivedAsset(ctx, "9811111111")
+	})
+	if err != nil {
+		t.Fatalf("RestoreArchivedAsset returned error: %v", err)
+	}
+	if asset := readTestAsset(t, stub, "9811111111"); asset.Balance != 300 {
+		t.Errorf("restored balance is %d, want 300", asset.Balance)
+	}
+	if _, ok := stub.State[archiveKeyPrefix+"9811111111"]; ok {
+		t.Error("restored asset is still in the archive")
+	}
+
+	err = stub.transact(func(ctx *auditContext) error {
+		_, err := s.GetArchivedAsset(ctx, "9811111111")
+		return err
+	})
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("GetArchivedAsset after restore returned %v, want a missing archive error", err)
+	}
+}
+
+func TestGetBalanceByDealer(t *testing.T) {
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 1000)
+	createTestAsset(t, stub, "D002", "9822222222", 250)
+	createTestAsset(t, stub, "D001", "9833333333", 500)
+	createTestAsset(t, stub, "D003", "9844444444", 0)
+
+	var totals map[string]int64
+	err := stub.transact(func(ctx *auditContext) error {
+		var err error
+		totals, err = new(SmartContract).GetBalanceByDealer(ctx)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("GetBalanceByDealer returned error: %v", err)
+	}
+
+	want := map[string]int64{"D001": 1500, "D002": 250, "D003": 0}
+	if len(totals) != len(want) {
+		t.Errorf("GetBalanceByDealer returned %v, want %v", totals, want)
+	}
+	for dealerID, total := range want {
+		if got, ok := totals[dealerID]; !ok || got != total {
+			t.Errorf("total for %s is %d, want %d", dealerID, got, total)
+		}
+	}
+}
+
+func TestGetDealers(t *testing.T) {
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D002", "9811111111", 1000)
+	createTestAsset(t, stub, "D001", "9822222222", 250)
+	createTestAsset(t, stub, "D002", "9833333333", 500)
+	createTestAsset(t, stub, "D003", "9844444444", 0)
+
+	// Archived assets are no longer in the directory
+	err := stub.transact(func(ctx *auditContext) error {
+		return new(SmartContract).ArchiveAsset(ctx, "9822222222")
+	})
+	if err != nil {
+		t.Fatalf("ArchiveAsset returned error: %v", err)
+	}
+	createTestAsset(t, stub, "D001", "9855555555", 75)
+
+	var dealers []DealerSummary
+	err = stub.transact(func(ctx *auditContext) error {
+		var err error
+		dealers, err = new(SmartContract).GetDealers(ctx)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("GetDealers returned error: %v", err)
+	}
+
+	want := []DealerSummary{
+		{DealerID: "D001", AssetCount: 1, TotalBalance: 75},
+		{DealerID: "D002", AssetCount: 2, TotalBalance: 1500},
+		{DealerID: "D003", AssetCount: 1, TotalBalance: 0},
+	}
+	if len(dealers) != len(want) {
+		t.Fatalf("GetDealers returned %+v, want %+v", dealers, want)
+	}
+	for i := range want {
+		if dealers[i] != want[i] {
+			t.Errorf("dealer %d is %+v, want %+v", i, dealers[i], want[i])
+		}
+	}
+}
+
+func TestSearchAssetsByLabel(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	for msisdn, label := range map[string]string{
+		"9811111111": "Shop Counter North",
+		"9822222222": "warehouse north",
+		"9833333333": "Shop Counter South",
+		"9844444444": "",
+	} {
+		err := stub.transact(func(ctx *auditContext) error {
+			return s.CreateAsset(ctx, "D001", msisdn, 100, "Active", "", "", label, "")
+		})
+		if err != nil {
+			t.Fatalf("CreateAsset(%s) returned error: %v", msisdn, err)
+		}
+	}
+
+	search := func(substring string) string {
+		t.Helper()
+		var assets []*Asset
+		err := stub.transact(func(ctx *auditContext) error {
+			var err error
+			assets, err = s.SearchAssetsByLabel(ctx, substring)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("SearchAssetsByLabel(%q) returned error: %v", substring, err)
+		}
+		var msisdns []string
+		for _, asset := range assets {
+			msisdns = append(msisdns, asset.MSISDN)
+		}
+		sort.Strings(msisdns)
+		return strings.Join(msisdns, ",")
+	}
+
+	tests := []struct {
+		substring string
+		want      string
+	}{
+		{"North", "9811111111,9822222222"},
+		{"counter", "9811111111,9833333333"},
+		{"Counter S", "9833333333"},
+		{"n.rth", ""},
+		{"garage", ""},
+	}
+	for _, tt := range tests {
+		if got := search(tt.substring); got != tt.want {
+			t.Errorf("SearchAssetsByLabel(%q) returned %q, want %q", tt.substring, got, tt.want)
+		}
+	}
+}
+
+func TestSearchByMSISDNPrefix(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	for _, msisdn := range []string{"9812222222", "9811111111", "9811122222", "9711111111", "9813333333"} {
+		createTestAsset(t, stub, "D001", msisdn, 100)
+	}
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.ArchiveAsset(ctx, "9813333333")
+	})
+	if err != nil {
+		t.Fatalf("ArchiveAsset returned error: %v", err)
+	}
+
+	for prefix, want := range map[string]string{
+		"9811":       "9811111111,9811122222",
+		" 9811 ":     "9811111111,9811122222",
+		"98":         "9811111111,9811122222,9812222222",
+		"97":         "9711111111",
+		"9811111111": "9811111111",
+		"99":         "",
+		"archive_":   "",
+	} {
+		var assets []*Asset
+		err := stub.transact(func(ctx *auditContext) error {
+			var err error
+			assets, err = s.SearchByMSISDNPrefix(ctx, prefix)
+			return err
+		})
+		if err != nil {
+			t.Errorf("SearchByMSISDNPrefix(%q) returned error: %v", prefix, err)
+			continue
+		}
+		if assets == nil {
+			t.Errorf("SearchByMSISDNPrefix(%q) returned nil, want an empty list", prefix)
+		}
+		var got []string
+		for _, asset := range assets {
+			got = append(got, asset.MSISDN)
+		}
+		if strings.Join(got, ",") != want {
+			t.Errorf("SearchByMSISDNPrefix(%q) = %v, want %s", prefix, got, want)
+		}
+	}
+
+	err = stub.transact(func(ctx *auditContext) error {
+		_, err := s.SearchByMSISDNPrefix(ctx, " ")
+		return err
+	})
+	if err == nil {
+		t.Error("SearchByMSISDNPrefix without a prefix succeeded")
+	}
+}
+
+func TestGetAssetsNeedingAttention(t *testing.T) {
+	stub := newLedgerStub()
+	seeded := []*Asset{
+		{MSISDN: "9811111111", DealerID: "D001", Balance: 500, Status: "Active", Flagged: true},
+		{MSISDN: "9822222222", DealerID: "D001", Balance: 300, Status: "Frozen"},
+		{MSISDN: "9833333333", DealerID: "D001", Balance: -50, Status: "Active"},
+		{MSISDN: "9844444444", DealerID: "D002", Balance: 0, Status: "Frozen"},
+		{MSISDN: "9855555555", DealerID: "D002", Balance: 0, Status: "Active"},
+		{MSISDN: "9866666666", DealerID: "D002", Balance: 900, Status: "Suspended"},
+	}
+	err := stub.transact(func(ctx *auditContext) error {
+		for _, asset := range seeded {
+			if err := putAsset(ctx, asset); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error seeding assets: %v", err)
+	}
+
+	var assets []*Asset
+	err = stub.transact(func(ctx *auditContext) error {
+		var err error
+		assets, err = new(SmartContract).GetAssetsNeedingAttention(ctx)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("GetAssetsNeedingAttention returned error: %v", err)
+	}
+
+	var msisdns []string
+	for _, asset := range assets {
+		msisdns = append(msisdns, asset.MSISDN)
+	}
+	// Flagged, frozen with a balance and below the minimum balance, but not
+	// frozen and empty, healthy or merely suspended
+	if got := strings.Join(msisdns, ","); got != "9811111111,9822222222,9833333333" {
+		t.Errorf("assets needing attention are %s, want 9811111111,9822222222,9833333333", got)
+	}
+}
+
+func TestGetLedgerStats(t *testing.T) {
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D002", "9833333333", 700)
+	createTestAsset(t, stub, "D001", "9811111111", 1000)
+	createTestAsset(t, stub, "D002", "9899999999", 300)
+	createTestAsset(t, stub, "D003", "9822222222", 0)
+	err := stub.transact(func(ctx *auditContext) error {
+		return new(SmartContract).UpdateAsset(ctx, "9822222222", "0", "Frozen", "", "", "", "", "")
+	})
+	if err != nil {
+		t.Fatalf("UpdateAsset returned error: %v", err)
+	}
+
+	var stats *LedgerStats
+	err = stub.transact(func(ctx *auditContext) error {
+		var err error
+		stats, err = new(SmartContract).GetLedgerStats(ctx)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("GetLedgerStats returned error: %v", err)
+	}
+
+	if stats.AssetCount != 4 {
+		t.Errorf("AssetCount = %d, want 4", stats.AssetCount)
+	}
+	if stats.TotalBalance != 2000 {
+		t.Errorf("TotalBalance = %d, want 2000", stats.TotalBalance)
+	}
+	if stats.LowestMSISDN != "9811111111" || stats.HighestMSISDN != "9899999999" {
+		t.Errorf("MSISDN bounds are %s to %s, want 9811111111 to 9899999999", stats.LowestMSISDN, stats.HighestMSISDN)
+	}
+	if stats.DealerCount != 3 {
+		t.Errorf("DealerCount = %d, want 3", stats.DealerCount)
+	}
+	if stats.FrozenCount != 1 {
+		t.Errorf("FrozenCount = %d, want 1", stats.FrozenCount)
+	}
+}
+
+func TestGetLedgerStatsEmpty(t *testing.T) {
+	stub := newLedgerStub()
+
+	var stats *LedgerStats
+	err := stub.transact(func(ctx *auditContext) error {
+		var err error
+		stats, err = new(SmartContract).GetLedgerStats(ctx)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("GetLedgerStats returned error: %v", err)
+	}
+	if *stats != (LedgerStats{}) {
+		t.Errorf("stats of an empty ledger are %+v, want zero", stats)
+	}
+}
+
+func TestGetAverageBalance(t *testing.T) {
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 1000)
+	createTestAsset(t, stub, "D001", "9822222222", 500)
+	createTestAsset(t, stub, "D002", "9833333333", 0)
+	createTestAsset(t, stub, "D002", "9844444444", 300)
+	err := stub.transact(func(ctx *auditContext) error {
+		return new(SmartContract).UpdateAsset(ctx, "9844444444", "300", "Frozen", "", "", "", "", "")
+	})
+	if err != nil {
+		t.Fatalf("UpdateAsset returned error: %v", err)
+	}
+
+	for status, want := range map[string]float64{"": 450, "Active": 500, "Frozen": 300, "Suspended": 0} {
+		var average float64
+		err := stub.transact(func(ctx *auditContext) error {
+			var err error
+			average, err = new(SmartContract).GetAverageBalance(ctx, status)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("GetAverageBalance(%q) returned error: %v", status, err)
+		}
+		if average != want {
+			t.Errorf("GetAverageBalance(%q) = %v, want %v", status, average, want)
+		}
+	}
+
+	err = stub.transact(func(ctx *auditContext) error {
+		_, err := new(SmartContract).GetAverageBalance(ctx, "Closed")
+		return err
+	})
+	if err == nil {
+		t.Error("GetAverageBalance accepted the unknown status Closed")
+	}
+}
+
+func TestGetAverageBalanceEmpty(t *testing.T) {
+	stub := newLedgerStub()
+
+	var average float64
+	err := stub.transact(func(ctx *auditContext) error {
+		var err error
+		average, err = new(SmartContract).GetAverageBalance(ctx, "")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("GetAverageBalance returned error: %v", err)
+	}
+	if average != 0 {
+		t.Errorf("average balance of an empty ledger is %v, want 0", average)
+	}
+}
+
+func TestGetBalanceHistogram(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	for i, balance := range []int64{0, 50, 99, 100, 250, 299, 1000, -1, -100, -101, math.MaxInt64, math.MinInt64} {
+		createTestAsset(t, stub, "D001", fmt.Sprintf("98111111%02d", i), balance)
+	}
+
+	histogram := func(bucketSize int) (map[string]int, error) {
+		var histogram map[string]int
+		err := stub.transact(func(ctx *auditContext) error {
+			var err error
+			histogram, err = s.GetBalanceHistogram(ctx, bucketSize)
+			return err
+		})
+		return histogram, err
+	}
+
+	got, err := histogram(100)
+	if err != nil {
+		t.Fatalf("GetBalanceHistogram returned error: %v", err)
+	}
+	want := map[string]int{
+		"0-99":      3,
+		"100-199":   1,
+		"200-299":   2,
+		"1000-1099": 1,
+		"-100--1":   2,
+		"-200--101": 1,
+		// The ranges at the ends of int64 are cut short
+		"9223372036854775800-9223372036854775807":   1,
+		"-9223372036854775808--9223372036854775801": 1,
+	}
+	if len(got) != len(want) {
+		t.Errorf("histogram is %v, want %v", got, want)
+	}
+	for bucket, count := range want {
+		if got[bucket] != count {
+			t.Errorf("bucket %s counts %d assets, want %d", bucket, got[bucket], count)
+		}
+	}
+
+	if got, err := histogram(1000); err != nil || got["0-999"] != 6 || got["1000-1999"] != 1 || got["-1000--1"] != 3 {
+		t.Errorf("histogram with buckets of 1000 is %v, %v, want 6 in 0-999, 1 in 1000-1999 and 3 in -1000--1", got, err)
+	}
+
+	for _, bucketSize := range []int{0, -10} {
+		if _, err := histogram(bucketSize); err == nil {
+			t.Errorf("GetBalanceHistogram accepted a bucket size of %d", bucketSize)
+		}
+	}
+}
+
+func TestGetTopAssetsByBalance(t *testing.T) {
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 300)
+	createTestAsset(t, stub, "D002", "9822222222", 1200)
+	createTestAsset(t, stub, "D001", "9833333333", 50)
+	createTestAsset(t, stub, "D003", "9844444444", 700)
+	createTestAsset(t, stub, "D002", "9855555555", 700)
+
+	top := func(n int) ([]*Asset, error) {
+		var assets []*Asset
+		err := stub.transact(func(ctx *auditContext) error {
+			var err error
+			assets, err = new(SmartContract).GetTopAssetsByBalance(ctx, n)
+			return err
+		})
+		return assets, err
+	}
+
+	assets, err := top(3)
+	if err != nil {
+		t.Fatalf("GetTopAssetsByBalance returned error: %v", err)
+	}
+	var got []string
+	for _, asset := range assets {
+		got = append(got, asset.MSISDN)
+	}
+	if strings.Join(got, ",") != "9822222222,9844444444,9855555555" {
+		t.Errorf("top 3 are %v, want 9822222222, then the two 700 balances by MSISDN", got)
+	}
+
+	if assets, err := top(10); err != nil || len(assets) != 5 || assets[4].MSISDN != "9833333333" {
+		t.Errorf("top 10 of 5 assets returned %d assets, %v, want all 5 ending with the lowest", len(assets), err)
+	}
+	for _, n := range []int{0, -1, maxTopAssets + 1} {
+		if _, err := top(n); err == nil {
+			t.Errorf("GetTopAssetsByBalance(%d) succeeded, want an error", n)
+		}
+	}
+}
+
+func TestGetMostActiveAssets(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 300)
+	createTestAsset(t, stub, "D002", "9822222222", 1200)
+	createTestAsset(t, stub, "D001", "9833333333", 50)
+	createTestAsset(t, stub, "D003", "9844444444", 700)
+	// 9833333333 is updated three times, 9811111111 and 9844444444 once each
+	for _, msisdn := range []string{"9833333333", "9811111111", "9833333333", "9844444444", "9833333333"} {
+		err := stub.transact(func(ctx *auditContext) error {
+			return s.AdjustBalance(ctx, msisdn, 10, "", "", "", "", "")
+		})
+		if err != nil {
+			t.Fatalf("AdjustBalance(%s) returned error: %v", msisdn, err)
+		}
+	}
+
+	mostActive := func(n int) ([]AssetActivity, error) {
+		var activity []AssetActivity
+		err := stub.transact(func(ctx *auditContext) error {
+			var err error
+			activity, err = s.GetMostActiveAssets(ctx, n)
+			return err
+		})
+		return activity, err
+	}
+
+	activity, err := mostActive(3)
+	if err != nil {
+		t.Fatalf("GetMostActiveAssets returned error: %v", err)
+	}
+	want := []AssetActivity{
+		{MSISDN: "9833333333", DealerID: "D001", Transactions: 4},
+		{MSISDN: "9811111111", DealerID: "D001", Transactions: 2},
+		{MSISDN: "9844444444", DealerID: "D003", Transactions: 2},
+	}
+	if len(activity) != len(want) {
+		t.Fatalf("most active are %+v, want %+v", activity, want)
+	}
+	for i := range want {
+		if activity[i] != want[i] {
+			t.Errorf("most active #%d is %+v, want %+v", i+1, activity[i], want[i])
+		}
+	}
+
+	if activity, err := mostActive(10); err != nil || len(activity) != 4 || activity[3].MSISDN != "9822222222" {
+		t.Errorf("most active 10 of 4 assets returned %+v, %v, want all 4 ending with the untouched one", activity, err)
+	}
+	for _, n := range []int{0, maxTopAssets + 1} {
+		if _, err := mostActive(n); err == nil {
+			t.Errorf("GetMostActiveAssets(%d) succeeded, want an error", n)
+		}
+	}
+}
+
+func TestGetAssetsByExternalRef(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 500)
+	createTestAsset(t, stub, "D001", "9822222222", 100)
+	createTestAsset(t, stub, "D002", "9833333333", 300)
+
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.UpdateAsset(ctx, "9833333333", "400", "Active", "CREDIT", "", "", "PSP-0001", "topup")
+	})
+	if err != nil {
+		t.Fatalf("UpdateAsset returned error: %v", err)
+	}
+	err = stub.transact(func(ctx *auditContext) error {
+		return s.ExecuteBatch(ctx, `[{"Op": "transfer", "From": "9811111111", "To": "9822222222", "Amount": 200, "ExternalRef": "PSP-0002"}]`)
+	})
+	if err != nil {
+		t.Fatalf("ExecuteBatch returned error: %v", err)
+	}
+	// A pending update carrying the reference is not an asset
+	err = stub.transact(func(ctx *auditContext) error {
+		ctx.SetClientIdentity(testIdentity("alice"))
+		_, err := s.RequestUpdate(ctx, "9833333333", "50000", "Active", "CREDIT", "", "PSP-0002", "")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("RequestUpdate returned error: %v", err)
+	}
+
+	if asset := readTestAsset(t, stub, "9833333333"); asset.ExternalRef != "PSP-0001" {
+		t.Errorf("ExternalRef of the update is %q, want PSP-0001", asset.ExternalRef)
+	}
+
+	for ref, want := range map[string]string{"PSP-0001": "9833333333", "PSP-0002": "9811111111,9822222222", "PSP-9999": ""} {
+		var assets []*Asset
+		err := stub.transact(func(ctx *auditContext) error {
+			var err error
+			assets, err = s.GetAssetsByExternalRef(ctx, ref)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("GetAssetsByExternalRef(%s) returned error: %v", ref, err)
+		}
+		var got []string
+		for _, asset := range assets {
+			if asset.ExternalRef != ref {
+				t.Errorf("GetAssetsByExternalRef(%s) returned %s with reference %q", ref, asset.MSISDN, asset.ExternalRef)
+			}
+			got = append(got, asset.MSISDN)
+		}
+		sort.Strings(got)
+		if strings.Join(got, ",") != want {
+			t.Errorf("GetAssetsByExternalRef(%s) returned %v, want %s", ref, got, want)
+		}
+	}
+
+	err = stub.transact(func(ctx *auditContext) error {
+		return s.UpdateAsset(ctx, "9833333333", "400", "Active", "CREDIT", "", "", strings.Repeat("x", maxExternalRefLength+1), "")
+	})
+	if err == nil {
+		t.Error("UpdateAsset accepted an overlong ExternalRef")
+	}
+}
+
+func TestBulkFreezeByQuery(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 500)
+	createTestAsset(t, stub, "D001", "9822222222", 150000)
+	createTestAsset(t, stub, "D002", "9833333333", 200000)
+	createTestAsset(t, stub, "D002", "9844444444", 100000)
+	createTestAsset(t, stub, "D003", "9855555555", 300000)
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.UpdateAsset(ctx, "9855555555", "300000", "Frozen", "", "", "", "", "")
+	})
+	if err != nil {
+		t.Fatalf("UpdateAsset returned error: %v", err)
+	}
+	// A pending update names an asset over the threshold but is not an asset
+	err = stub.transact(func(ctx *auditContext) error {
+		ctx.SetClientIdentity(testIdentity("alice"))
+		_, err := s.RequestUpdate(ctx, "9811111111", "50000", "Active", "CREDIT", "", "", "")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("RequestUpdate returned error: %v", err)
+	}
+
+	bulkFreeze := func(query string) (int, error) {
+		var count int
+		err := stub.transact(func(ctx *auditContext) error {
+			var err error
+			count, err = s.BulkFreezeByQuery(ctx, query)
+			return err
+		})
+		return count, err
+	}
+
+	count, err := bulkFreeze(`{"selector":{"Balance":{"$gt":100000}}}`)
+	if err != nil {
+		t.Fatalf("BulkFreezeByQuery returned error: %v", err)
+	}
+	// The asset that was already Frozen is not counted again
+	if count != 2 {
+		t.Errorf("froze %d assets, want 2", count)
+	}
+	for msisdn, want := range map[string]string{
+		"9811111111": "Active",
+		"9822222222": "Frozen",
+		"9833333333": "Frozen",
+		"9844444444": "Active",
+		"9855555555": "Frozen",
+	} {
+		if asset := readTestAsset(t, stub, msisdn); asset.Status != want {
+			t.Errorf("status of %s is %s, want %s", msisdn, asset.Status, want)
+		}
+	}
+	if asset := readTestAsset(t, stub, "9822222222"); asset.TransType != bulkFreezeTransType || asset.Balance != 150000 {
+		t.Errorf("frozen asset has type %s and balance %d, want %s and its balance kept", asset.TransType, asset.Balance, bulkFreezeTransType)
+	}
+	if got := strings.Join(changedMSISDNs(t, stub.lastEvent(t)), ","); got != "9822222222,9833333333" {
+		t.Errorf("event names %s, want the frozen assets", got)
+	}
+
+	// The pending update matches on MSISDN too, but only the asset is frozen
+	if count, err := bulkFreeze(`{"selector":{"MSISDN":"9811111111"}}`); err != nil || count != 1 {
+		t.Errorf("freezing by MSISDN froze %d, %v, want only the asset", count, err)
+	}
+
+	for _, query := range []string{"", "not json", `["selector"]`, `{}`, `{"selector":{}}`, `{"selector":"Balance"}`} {
+		if _, err := bulkFreeze(query); err == nil || !strings.HasPrefix(err.Error(), "invalid query") {
+			t.Errorf("BulkFreezeByQuery(%q) returned %v, want an invalid query error", query, err)
+		}
+	}
+}
+
+func TestGetAssetHistoryToleratesCorruptEntry(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 500)
+	created := stub.State["9811111111"]
+
+	// A write in a schema the contract no longer decodes, then a repair
+	for _, value := range [][]byte{[]byte(`{"MSISDN":"9811111111","Balance":"five hundred"}`), created} {
+		err := stub.transact(func(ctx *auditContext) error {
+			return stub.PutState("9811111111", value)
+		})
+		if err != nil {
+			t.Fatalf("writing history entry returned error: %v", err)
+		}
+	}
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.UpdateAsset(ctx, "9811111111", "700", "Active", "CREDIT", "", "", "", "")
+	})
+	if err != nil {
+		t.Fatalf("UpdateAsset returned error: %v", err)
+	}
+
+	var history []*AssetHistoryEntry
+	err = stub.transact(func(ctx *auditContext) error {
+		var err error
+		history, err = s.GetAssetHistory(ctx, "9811111111")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("GetAssetHistory returned error: %v", err)
+	}
+	if len(history) != 4 {
+		t.Fatalf("history has %d entries, want all 4", len(history))
+	}
+
+	// Newest first: the update, the repair, the corrupt write, the create
+	for i, want := range []int64{700, 500, -1, 500} {
+		entry := history[i]
+		if want < 0 {
+			if entry.Value != nil || !strings.HasPrefix(entry.ParseError, ErrUnreadableHistoryValue.Error()) {
+				t.Errorf("corrupt entry has value %+v and parse error %q, want only a parse error", entry.Value, entry.ParseError)
+			}
+			continue
+		}
+		if entry.ParseError != "" || entry.Value == nil || entry.Value.Balance != want {
+			t.Errorf("entry %d is %+v, want a value with balance %d", i, entry, want)
+		} else if entry.Value.MPIN != "" {
+			t.Errorf("entry %d exposes MPIN %q", i, entry.Value.MPIN)
+		}
+	}
+}
+
+func TestGetHistoryCount(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 100)
+	for _, balance := range []string{"200", "300", "250"} {
+		err := stub.transact(func(ctx *auditContext) error {
+			return s.UpdateAsset(ctx, "9811111111", balance, "Active", "CREDIT", "", "", "", "")
+		})
+		if err != nil {
+			t.Fatalf("UpdateAsset to %s returned error: %v", balance, err)
+		}
+	}
+
+	var count int
+	var history []*AssetHistoryEntry
+	err := stub.transact(func(ctx *auditContext) error {
+		var err error
+		if count, err = s.GetHistoryCount(ctx, "+91 98111 11111"); err != nil {
+			return err
+		}
+		history, err = s.GetAssetHistory(ctx, "9811111111")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("reading history returned error: %v", err)
+	}
+	if count != 4 || count != len(history) {
+		t.Errorf("GetHistoryCount = %d and GetAssetHistory has %d entries, want both 4", count, len(history))
+	}
+
+	err = stub.transact(func(ctx *auditContext) error {
+		var err error
+		count, err = s.GetHistoryCount(ctx, "9800000000")
+		return err
+	})
+	if err != nil || count != 0 {
+		t.Errorf("GetHistoryCount of an unknown MSISDN = %d, %v, want 0", count, err)
+	}
+}
+
+func TestBackfillTimestamps(t *testing.T) {
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 500)
+	created := stub.now
+	createTestAsset(t, stub, "D001", "9822222222", 100)
+	current := readTestAsset(t, stub, "9822222222").Timestamp
+
+	// A later legacy write drops the Timestamp of 9811111111, and 9833333333
+	// predates history altogether
+	err := stub.transact(func(ctx *auditContext) error {
+		if err := stub.PutState("9811111111", []byte(`{"DealerID":"D001","MSISDN":"9811111111","Balance":500,"Status":"Active"}`)); err != nil {
+			return err
+		}
+		return stub.MockStub.PutState("9833333333", []byte(`{"DealerID":"D002","MSISDN":"9833333333","Balance":50,"Status":"Active"}`))
+	})
+	if err != nil {
+		t.Fatalf("writing legacy assets returned error: %v", err)
+	}
+
+	var report *TimestampBackfillReport
+	err = stub.transact(func(ctx *auditContext) error {
+		var err error
+		report, err = new(SmartContract).BackfillTimestamps(ctx)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("BackfillTimestamps returned error: %v", err)
+	}
+
+	if len(report.Backfilled) != 1 || report.Backfilled[0] != "9811111111" {
+		t.Errorf("backfilled %v, want 9811111111", report.Backfilled)
+	}
+	if len(report.Unknown) != 1 || report.Unknown[0] != "9833333333" {
+		t.Errorf("unknown %v, want 9833333333", report.Unknown)
+	}
+	if got := readTestAsset(t, stub, "9811111111").Timestamp; !got.Equal(created) {
+		t.Errorf("backfilled Timestamp is %v, want the earliest history entry %v", got, created)
+	}
+	if got := readTestAsset(t, stub, "9822222222").Timestamp; !got.Equal(current) {
+		t.Errorf("Timestamp of 9822222222 changed to %v, want %v kept", got, current)
+	}
+	if got := readTestAsset(t, stub, "9833333333").Timestamp; !got.IsZero() {
+		t.Errorf("Timestamp of 9833333333 is %v, want it left zero", got)
+	}
+}
+
+func TestCreateAssetReadsMPINFromTransient(t *testing.T) {
+	stub := newLedgerStub()
+	stub.TransientMap = map[string][]byte{mpinTransientKey: []byte("4321")}
+	createTestAsset(t, stub, "D001", "9811111111", 100)
+
+	var stored Asset
+	if err := unmarshalAsset(stub.State["9811111111"], &stored); err != nil {
+		t.Fatalf("error unmarshalling stored asset: %v", err)
+	}
+	if stored.MPIN != "4321" {
+		t.Errorf("stored MPIN is %q, want the transient value 4321", stored.MPIN)
+	}
+
+	stub.TransientMap = map[string][]byte{}
+	err := stub.transact(func(ctx *auditContext) error {
+		return new(SmartContract).CreateAsset(ctx, "D001", "9822222222", 100, "Active", "", "", "", "")
+	})
+	if err == nil || !strings.Contains(err.Error(), "transient") {
+		t.Errorf("CreateAsset without a transient MPIN returned %v, want a missing MPIN error", err)
+	}
+	if _, ok := stub.State["9822222222"]; ok {
+		t.Error("asset was created without an MPIN")
+	}
+}
+
+func TestApplyRateToAll(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 1000)
+	createTestAsset(t, stub, "D001", "9822222222", 555)
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.CreateAsset(ctx, "D001", "9833333333", 2000, "Frozen", "", "", "", "")
+	})
+	if err != nil {
+		t.Fatalf("CreateAsset of a frozen asset returned error: %v", err)
+	}
+
+	applyRate := func(ratePercent int, transType string) int {
+		t.Helper()
+		var applied int
+		err := stub.transact(func(ctx *auditContext) error {
+			var err error
+			applied, err = s.ApplyRateToAll(ctx, ratePercent, transType)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("ApplyRateToAll(%d) returned error: %v", ratePercent, err)
+		}
+		return applied
+	}
+
+	if applied := applyRate(10, "INTEREST"); applied != 2 {
+		t.Errorf("ApplyRateToAll applied to %d assets, want 2", applied)
+	}
+	tests := []struct {
+		msisdn  string
+		balance int64
+		amount  int64
+	}{
+		{"9811111111", 1100, 100},
+		// Fractions are truncated
+		{"9822222222", 610, 55},
+	}
+	for _, tt := range tests {
+		asset := readTestAsset(t, stub, tt.msisdn)
+		if asset.Balance != tt.balance || asset.TransAmount != tt.amount || asset.TransType != "INTEREST" {
+			t.Errorf("%s has balance %d, amount %d, type %s, want %d, %d, INTEREST", tt.msisdn, asset.Balance, asset.TransAmount, asset.TransType, tt.balance, tt.amount)
+		}
+	}
+	if frozen := readTestAsset(t, stub, "9833333333"); frozen.Balance != 2000 || frozen.TransType == "INTEREST" {
+		t.Errorf("frozen asset has balance %d and type %s, want it untouched", frozen.Balance, frozen.TransType)
+	}
+
+	// A negative rate charges a fee
+	applyRate(-5, "FEE")
+	if asset := readTestAsset(t, stub, "9811111111"); asset.Balance != 1045 || asset.TransAmount != -55 {
+		t.Errorf("after the fee balance is %d and amount %d, want 1045 and -55", asset.Balance, asset.TransAmount)
+	}
+}
+
+func TestGetRawStateReturnsBytesVerbatim(t *testing.T) {
+	stub := newLedgerStub()
+	corrupt := []byte("{\"MSISDN\":\"9811111111\",\"Balance\":\xff")
+	err := stub.transact(func(ctx *auditContext) error {
+		return ctx.GetStub().PutState("9811111111", corrupt)
+	})
+	if err != nil {
+		t.Fatalf("error storing the corrupt record: %v", err)
+	}
+
+	var encoded string
+	err = stub.transact(func(ctx *auditContext) error {
+		var err error
+		encoded, err = new(SmartContract).GetRawState(ctx, "9811111111")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("GetRawState returned error: %v", err)
+	}
+	value, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("GetRawState returned invalid base64 %q: %v", encoded, err)
+	}
+	if string(value) != string(corrupt) {
+		t.Errorf("GetRawState returned %q, want %q", value, corrupt)
+	}
+
+	err = stub.transact(func(ctx *auditContext) error {
+		_, err := new(SmartContract).GetRawState(ctx, "missing")
+		return err
+	})
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("GetRawState of a missing key returned %v, want a missing key error", err)
+	}
+}
+
+func TestDeleteAssetPurgesPrivateData(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 100)
+	createTestAsset(t, stub, "D001", "9822222222", 100)
+
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.DeleteAsset(ctx, "9811111111", "assetPrivateDetails")
+	})
+	if err != nil {
+		t.Fatalf("DeleteAsset returned error: %v", err)
+	}
+	if strings.Join(stub.purged, ",") != "assetPrivateDetails/9811111111" {
+		t.Errorf("purged %v, want the asset's key in assetPrivateDetails", stub.purged)
+	}
+	if _, ok := stub.State["9811111111"]; ok {
+		t.Error("deleted asset is still in the world state")
+	}
+
+	// Without a collection only the public state is deleted
+	err = stub.transact(func(ctx *auditContext) error {
+		return s.DeleteAsset(ctx, "9822222222", "")
+	})
+	if err != nil {
+		t.Fatalf("DeleteAsset returned error: %v", err)
+	}
+	if len(stub.purged) != 1 {
+		t.Errorf("purged %v, want no purge without a collection", stub.purged)
+	}
+}
+
+func TestQueryAssetsPageSortedByBalance(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	for msisdn, balance := range map[string]int64{
+		"9811111111": 300,
+		"9822222222": 100,
+		"9833333333": 500,
+		"9844444444": 200,
+		"9855555555": 400,
+	} {
+		createTestAsset(t, stub, "D001", msisdn, balance)
+	}
+
+	// pages follows bookmarks from the first page to the last and returns the
+	// balances of each page
+	pages := func(order string) []string {
+		t.Helper()
+		var balances []string
+		bookmark := ""
+		for {
+			var page *AssetPage
+			err := stub.transact(func(ctx *auditContext) error {
+				var err error
+				page, err = s.QueryAssetsPage(ctx, "", "Balance", order, 2, bookmark)
+				return err
+			})
+			if err != nil {
+				t.Fatalf("QueryAssetsPage(%s) returned error: %v", order, err)
+			}
+			var pageBalances []string
+			for _, asset := range page.Assets {
+				pageBalances = append(pageBalances, strconv.FormatInt(asset.Balance, 10))
+			}
+			balances = append(balances, strings.Join(pageBalances, ","))
+			if int(page.FetchedRecordsCount) != len(page.Assets) {
+				t.Errorf("page reports %d records but has %d", page.FetchedRecordsCount, len(page.Assets))
+			}
+			if page.Bookmark == "" {
+				return balances
+			}
+			bookmark = page.Bookmark
+		}
+	}
+
+	if got := strings.Join(pages("asc"), " | "); got != "100,200 | 300,400 | 500" {
+		t.Errorf("ascending pages are %s, want 100,200 | 300,400 | 500", got)
+	}
+	if got := strings.Join(pages("desc"), " | "); got != "500,400 | 300,200 | 100" {
+		t.Errorf("descending pages are %s, want 500,400 | 300,200 | 100", got)
+	}
+}
+
+func TestQueryAssetsPageValidation(t *testing.T) {
+	stub := newLedgerStub()
+	tests := []struct {
+		sortField, order string
+		pageSize         int
+	}{
+		{"MPIN", "asc", 10},
+		{"Balance", "up", 10},
+		{"Balance", "asc", 0},
+	}
+	for _, tt := range tests {
+		err := stub.transact(func(ctx *auditContext) error {
+			_, err := new(SmartContract).QueryAssetsPage(ctx, "", tt.sortField, tt.order, tt.pageSize, "")
+			return err
+		})
+		if err == nil {
+			t.Errorf("QueryAssetsPage(%s, %s, %d) succeeded, want an error", tt.sortField, tt.order, tt.pageSize)
+		}
+	}
+}
+
+func TestAdjustBalance(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 500)
+
+	adjust := func(delta int64) error {
+		return stub.transact(func(ctx *auditContext) error {
+			return s.AdjustBalance(ctx, "9811111111", delta, "ADJUST", "", "", "", "")
+		})
+	}
+
+	tests := []struct {
+		delta   int64
+		balance int64
+	}{
+		{100, 600},
+		{-250, 350},
+		{-350, 0},
+	}
+	for _, tt := range tests {
+		if err := adjust(tt.delta); err != nil {
+			t.Fatalf("AdjustBalance(%d) returned error: %v", tt.delta, err)
+		}
+		asset := readTestAsset(t, stub, "9811111111")
+		if asset.Balance != tt.balance || asset.TransAmount != tt.delta || asset.TransType != "ADJUST" {
+			t.Errorf("after AdjustBalance(%d) asset has balance %d, amount %d, type %s, want %d, %d, ADJUST", tt.delta, asset.Balance, asset.TransAmount, asset.TransType, tt.balance, tt.delta)
+		}
+	}
+
+	if err := adjust(-1); !errors.Is(err, ErrBalanceBelowMinimum) {
+		t.Fatalf("AdjustBalance below the minimum returned %v, want ErrBalanceBelowMinimum", err)
+	}
+	if asset := readTestAsset(t, stub, "9811111111"); asset.Balance != 0 {
+		t.Errorf("balance after the rejected adjustment is %d, want 0", asset.Balance)
+	}
+}
+
+func TestExpectedStatusPrecondition(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 500)
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.UpdateAsset(ctx, "9811111111", "500", "Frozen", "", "", "", "", "")
+	})
+	if err != nil {
+		t.Fatalf("freezing the asset returned error: %v", err)
+	}
+
+	// Debits that only apply to Active assets leave the frozen asset alone
+	err = stub.transact(func(ctx *auditContext) error {
+		return s.UpdateAsset(ctx, "9811111111", "400", "Frozen", "DEBIT", "", "Active", "", "")
+	})
+	if !errors.Is(err, ErrStatusMismatch) {
+		t.Errorf("UpdateAsset expecting Active returned %v, want ErrStatusMismatch", err)
+	}
+	err = stub.transact(func(ctx *auditContext) error {
+		return s.AdjustBalance(ctx, "9811111111", -100, "DEBIT", "", "Active", "", "")
+	})
+	if !errors.Is(err, ErrStatusMismatch) {
+		t.Errorf("AdjustBalance expecting Active returned %v, want ErrStatusMismatch", err)
+	}
+	if asset := readTestAsset(t, stub, "9811111111"); asset.Balance != 500 {
+		t.Fatalf("balance after the rejected writes is %d, want 500", asset.Balance)
+	}
+
+	err = stub.transact(func(ctx *auditContext) error {
+		return s.UpdateAsset(ctx, "9811111111", "400", "Frozen", "DEBIT", "", "Frozen", "", "")
+	})
+	if err != nil {
+		t.Fatalf("UpdateAsset expecting Frozen returned error: %v", err)
+	}
+	err = stub.transact(func(ctx *auditContext) error {
+		return s.AdjustBalance(ctx, "9811111111", -100, "DEBIT", "", "Frozen", "", "")
+	})
+	if err != nil {
+		t.Fatalf("AdjustBalance expecting Frozen returned error: %v", err)
+	}
+	if asset := readTestAsset(t, stub, "9811111111"); asset.Balance != 300 {
+		t.Errorf("balance after the matching writes is %d, want 300", asset.Balance)
+	}
+}
+
+// auditTestAsset runs AuditAsset in a transaction of its own
+func auditTestAsset(t *testing.T, stub *ledgerStub, msisdn string) *AuditResult {
+	t.Helper()
+	var result *AuditResult
+	err := stub.transact(func(ctx *auditContext) error {
+		var err error
+		result, err = new(SmartContract).AuditAsset(ctx, msisdn)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("AuditAsset(%s) returned error: %v", msisdn, err)
+	}
+	return result
+}
+
+func TestAuditAsset(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 500)
+	for _, delta := range []int64{200, -100} {
+		err := stub.transact(func(ctx *auditContext) error {
+			return s.AdjustBalance(ctx, "9811111111", delta, "ADJUST", "", "", "", "")
+		})
+		if err != nil {
+			t.Fatalf("AdjustBalance(%d) returned error: %v", delta, err)
+		}
+	}
+
+	result := auditTestAsset(t, stub, "9811111111")
+	if !result.Consistent || result.InitialBalance != 500 || result.ComputedBalance != 600 || result.Transactions != 2 {
+		t.Errorf("audit of a consistent asset is %+v, want consistent from 500 to 600 over 2 transactions", result)
+	}
+
+	// Overwrite the balance without recording a transaction for it
+	var tamperTxID string
+	err := stub.transact(func(ctx *auditContext) error {
+		tamperTxID = ctx.GetStub().GetTxID()
+		asset, err := s.ReadAsset(ctx, "9811111111")
+		if err != nil {
+			return err
+		}
+		asset.Balance = 9000
+		asset.Remarks = "tampered"
+		return putAsset(ctx, asset)
+	})
+	if err != nil {
+		t.Fatalf("error writing the inconsistent record: %v", err)
+	}
+
+	result = auditTestAsset(t, stub, "9811111111")
+	if result.Consistent {
+		t.Fatalf("audit of an inconsistent asset is %+v, want a mismatch", result)
+	}
+	if result.StoredBalance != 9000 || result.ComputedBalance != 500 {
+		t.Errorf("audit has stored balance %d and computed %d, want 9000 and 500", result.StoredBalance, result.ComputedBalance)
+	}
+	if result.FirstMismatchTxID != tamperTxID {
+		t.Errorf("first mismatch is %q, want the tampering transaction %q", result.FirstMismatchTxID, tamperTxID)
+	}
+}
+
+func TestAuditAssetDecodesVersionsLikeReadAsset(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 500)
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.AdjustBalance(ctx, "9811111111", 200, "CREDIT", "cash deposit", "", "", "")
+	})
+	if err != nil {
+		t.Fatalf("AdjustBalance returned error: %v", err)
+	}
+
+	// Encrypting the stored remarks changes the raw value but not the transaction
+	t.Setenv(encryptionKeyEnv, testEncryptionKey)
+	err = stub.transact(func(ctx *auditContext) error {
+		_, err := s.MigrateAllAssets(ctx)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("MigrateAllAssets returned error: %v", err)
+	}
+	if !strings.Contains(string(stub.State["9811111111"]), encryptedPrefix) {
+		t.Fatal("MigrateAllAssets did not encrypt the asset")
+	}
+
+	result := auditTestAsset(t, stub, "9811111111")
+	if !result.Consistent || result.ComputedBalance != 700 || result.Transactions != 1 {
+		t.Errorf("audit after encrypting is %+v, want consistent at 700 over 1 transaction", result)
+	}
+}
+
+func TestAuditAssetRejectsUnreadableVersion(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 500)
+	created := stub.State["9811111111"]
+
+	var corruptTxID string
+	for _, value := range [][]byte{[]byte(`{"MSISDN":"9811111111","Balance":"five hundred"}`), created} {
+		err := stub.transact(func(ctx *auditContext) error {
+			if corruptTxID == "" {
+				corruptTxID = ctx.GetStub().GetTxID()
+			}
+			return stub.PutState("9811111111", value)
+		})
+		if err != nil {
+			t.Fatalf("writing history entry returned error: %v", err)
+		}
+	}
+
+	err := stub.transact(func(ctx *auditContext) error {
+		_, err := s.AuditAsset(ctx, "9811111111")
+		return err
+	})
+	if !errors.Is(err, ErrUnreadableHistoryValue) || !strings.Contains(err.Error(), corruptTxID) {
+		t.Errorf("AuditAsset returned %v, want ErrUnreadableHistoryValue naming %s", err, corruptTxID)
+	}
+
+	// The balance series leaves out the point it cannot read
+	var series []BalancePoint
+	err = stub.transact(func(ctx *auditContext) error {
+		var err error
+		series, err = s.GetBalanceSeries(ctx, "9811111111")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("GetBalanceSeries returned error: %v", err)
+	}
+	if len(series) != 2 || series[0].Balance != 500 || series[1].Balance != 500 {
+		t.Errorf("series is %+v, want the two readable versions at 500", series)
+	}
+}
+
+func TestSetAssetLocation(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 300)
+
+	setLocation := func(latitude, longitude float64) error {
+		return stub.transact(func(ctx *auditContext) error {
+			return s.SetAssetLocation(ctx, "9811111111", latitude, longitude)
+		})
+	}
+
+	if err := setLocation(28.6139, 77.209); err != nil {
+		t.Fatalf("SetAssetLocation returned error: %v", err)
+	}
+	located := stub.now
+	if asset := readTestAsset(t, stub, "9811111111"); asset.Latitude != 28.6139 || asset.Longitude != 77.209 || !asset.Timestamp.Equal(located) {
+		t.Errorf("asset is at %v, %v updated %v, want 28.6139, 77.209 updated %v", asset.Latitude, asset.Longitude, asset.Timestamp, located)
+	}
+
+	for _, location := range [][2]float64{{91, 0}, {-90.5, 0}, {0, 180.1}, {0, -181}, {math.NaN(), 0}} {
+		if err := setLocation(location[0], location[1]); err == nil {
+			t.Errorf("SetAssetLocation accepted %v, %v", location[0], location[1])
+		}
+	}
+	if asset := readTestAsset(t, stub, "9811111111"); asset.Latitude != 28.6139 || asset.Longitude != 77.209 {
+		t.Errorf("asset moved to %v, %v after rejected locations", asset.Latitude, asset.Longitude)
+	}
+
+	// 0, 0 clears the location, which then drops out of the stored JSON
+	if err := setLocation(0, 0); err != nil {
+		t.Fatalf("SetAssetLocation(0, 0) returned error: %v", err)
+	}
+	if stored := string(stub.State["9811111111"]); strings.Contains(stored, "Latitude") || strings.Contains(stored, "Longitude") {
+		t.Errorf("cleared asset is stored as %s, want no coordinates", stored)
+	}
+
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.SetAssetLocation(ctx, "9800000000", 1, 1)
+	})
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("SetAssetLocation of a missing asset returned %v, want a does not exist error", err)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Composite key object types of the per-dealer quota and active asset count
+const (
+	dealerQuotaObjectType = "dealerQuota"
+	dealerUsageObjectType = "dealerUsage"
+)
+
+// ErrDealerQuotaExceeded is returned when a dealer already has as many assets as its quota allows
+var ErrDealerQuotaExceeded = errors.New("dealer asset quota exceeded")
+
+// DealerUsage reports how many active assets a dealer has against its quota.
+// A Quota of 0 means the dealer is unlimited.
+type DealerUsage struct {
+	DealerID string `json:"DealerID"`
+	Count    int    `json:"Count"`
+	Quota    int    `json:"Quota"`
+}
+
+// SetDealerQuota sets the maximum number of active assets a dealer may have;
+// 0 removes the limit. Lowering it below the current count only blocks new assets.
+func (s *SmartContract) SetDealerQuota(ctx contractapi.TransactionContextInterface, dealerID string, quota int) error {
+	if quota < 0 {
+		return fmt.Errorf("quota must not be negative, got %d", quota)
+	}
+
+	return putDealerCounter(ctx, dealerQuotaObjectType, dealerID, quota)
+}
+
+// GetDealerUsage returns a dealer's active asset count and quota
+func (s *SmartContract) GetDealerUsage(ctx contractapi.TransactionContextInterface, dealerID string) (*DealerUsage, error) {
+	return getDealerUsage(ctx, dealerID)
+}
+
+// getDealerUsage reads a dealer's active asset count and quota
+func getDealerUsage(ctx contractapi.TransactionContextInterface, dealerID string) (*DealerUsage, error) {
+	count, err := getDealerCounter(ctx, dealerUsageObjectType, dealerID)
+	if err != nil {
+		return nil, err
+	}
+	quota, err := getDealerCounter(ctx, dealerQuotaObjectType, dealerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DealerUsage{DealerID: dealerID, Count: count, Quota: quota}, nil
+}
+
+// reserveDealerSlot counts a new active asset against a dealer's quota,
+// failing when the quota is already used up
+func reserveDealerSlot(ctx contractapi.TransactionContextInterface, dealerID string) error {
+	usage, err := getDealerUsage(ctx, dealerID)
+	if err != nil {
+		return err
+	}
+	if usage.Quota > 0 && usage.Count >= usage.Quota {
+		return fmt.Errorf("%w: dealer %s has %d of %d assets", ErrDealerQuotaExceeded, dealerID, usage.Count, usage.Quota)
+	}
+
+	return putDealerCounter(ctx, dealerUsageObjectType, dealerID, usage.Count+1)
+}
+
+// releaseDealerSlot stops counting an asset that left the active state
+func releaseDealerSlot(ctx contractapi.TransactionContextInterface, dealerID string) error {
+	count, err := getDealerCounter(ctx, dealerUsageObjectType, dealerID)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		// Assets created before counting began were never counted
+		return nil
+	}
+
+	return putDealerCounter(ctx, dealerUsageObjectType, dealerID, count-1)
+}
+
+// countsTowardsQuota reports whether an asset with status takes up one of its
+// dealer's slots. Soft-deleted assets stay on the ledger but do not.
+func countsTowardsQuota(status string) bool {
+	return status != "Deleted"
+}
+
+// moveDealerSlot keeps a dealer's count in step with a status change of one of
+// its assets: moving into Deleted releases its slot and moving back out
+// reserves one again
+func moveDealerSlot(ctx contractapi.TransactionContextInterface, dealerID, oldStatus, newStatus string) error {
+	switch {
+	case countsTowardsQuota(oldStatus) && !countsTowardsQuota(newStatus):
+		return releaseDealerSlot(ctx, dealerID)
+	case !countsTowardsQuota(oldStatus) && countsTowardsQuota(newStatus):
+		return reserveDealerSlot(ctx, dealerID)
+	}
+	return nil
+}
+
+// getDealerCounter reads a per-dealer counter, defaulting to 0 when unset
+func getDealerCounter(ctx contractapi.TransactionContextInterface, objectType, dealerID string) (int, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(objectType, []string{dealerID})
+	if err != nil {
+		return 0, fmt.Errorf("error creating %s key: %v", objectType, err)
+	}
+
+	value, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if value == nil {
+		return 0, nil
+	}
+
+	count, err := strconv.Atoi(string(value))
+	if err != nil {
+		return 0, fmt.Errorf("error parsing %s of dealer %s: %v", objectType, dealerID, err)
+	}
+	return count, nil
+}
+
+// putDealerCounter writes a per-dealer counter
+func putDealerCounter(ctx contractapi.TransactionContextInterface, objectType, dealerID string, value int) error {
+	key, err := ctx.GetStub().CreateCompositeKey(objectType, []string{dealerID})
+	if err != nil {
+		return fmt.Errorf("error creating %s key: %v", objectType, err)
+	}
+
+	if err := ctx.GetStub().PutState(key, []byte(strconv.Itoa(value))); err != nil {
+		return fmt.Errorf("failed to put to world state: %v", err)
+	}
+	return nil
+}
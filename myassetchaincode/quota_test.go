@@ -0,0 +1,212 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// dealerTestUsage reads a dealer's usage in its own transaction
+func dealerTestUsage(t *testing.T, stub *ledgerStub, dealerID string) *DealerUsage {
+	t.Helper()
+	var usage *DealerUsage
+	err := stub.transact(func(ctx *auditContext) error {
+		var err error
+		usage, err = new(SmartContract).GetDealerUsage(ctx, dealerID)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("GetDealerUsage(%s) returned error: %v", dealerID, err)
+	}
+	return usage
+}
+
+func TestDealerQuotaRejectsCreatesBeyondQuota(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.SetDealerQuota(ctx, "D001", 2)
+	})
+	if err != nil {
+		t.Fatalf("SetDealerQuota returned error: %v", err)
+	}
+	createTestAsset(t, stub, "D001", "9811111111", 100)
+	createTestAsset(t, stub, "D001", "9822222222", 100)
+
+	for _, msisdn := range []string{"9833333333", "9844444444"} {
+		err = stub.transact(func(ctx *auditContext) error {
+			return s.CreateAsset(ctx, "D001", msisdn, 100, "Active", "", "", "", "")
+		})
+		if !errors.Is(err, ErrDealerQuotaExceeded) {
+			t.Errorf("CreateAsset(%s) beyond the quota returned %v, want ErrDealerQuotaExceeded", msisdn, err)
+		}
+		if _, ok := stub.State[msisdn]; ok {
+			t.Errorf("asset %s was created beyond the quota", msisdn)
+		}
+	}
+	if usage := dealerTestUsage(t, stub, "D001"); usage.Count != 2 || usage.Quota != 2 {
+		t.Errorf("usage is %+v, want 2 of 2", usage)
+	}
+
+	// Other dealers are not limited by D001's quota
+	createTestAsset(t, stub, "D002", "9833333333", 100)
+	if usage := dealerTestUsage(t, stub, "D002"); usage.Count != 1 || usage.Quota != 0 {
+		t.Errorf("usage of D002 is %+v, want 1 and unlimited", usage)
+	}
+}
+
+func TestDealerQuotaSlotFreedOnDelete(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.SetDealerQuota(ctx, "D001", 1)
+	})
+	if err != nil {
+		t.Fatalf("SetDealerQuota returned error: %v", err)
+	}
+	createTestAsset(t, stub, "D001", "9811111111", 100)
+
+	err = stub.transact(func(ctx *auditContext) error {
+		return s.DeleteAsset(ctx, "9811111111", "")
+	})
+	if err != nil {
+		t.Fatalf("DeleteAsset returned error: %v", err)
+	}
+	if usage := dealerTestUsage(t, stub, "D001"); usage.Count != 0 {
+		t.Errorf("usage after delete is %+v, want 0", usage)
+	}
+
+	createTestAsset(t, stub, "D001", "9822222222", 100)
+}
+
+func TestDealerQuotaSlotFreedOnSoftDelete(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.SetDealerQuota(ctx, "D001", 2)
+	})
+	if err != nil {
+		t.Fatalf("SetDealerQuota returned error: %v", err)
+	}
+	createTestAsset(t, stub, "D001", "9811111111", 100)
+	createTestAsset(t, stub, "D001", "9822222222", 100)
+
+	err = stub.transact(func(ctx *auditContext) error {
+		return s.MergeAssets(ctx, "9811111111", "9822222222")
+	})
+	if err != nil {
+		t.Fatalf("MergeAssets returned error: %v", err)
+	}
+	if usage := dealerTestUsage(t, stub, "D001"); usage.Count != 1 {
+		t.Errorf("usage after merging is %+v, want the merged source released", usage)
+	}
+	createTestAsset(t, stub, "D001", "9833333333", 100)
+
+	setStatus := func(msisdn, status string) error {
+		return stub.transact(func(ctx *auditContext) error {
+			return s.UpdateAsset(ctx, msisdn, "100", status, "", "", "", "", "")
+		})
+	}
+	if err := setStatus("9833333333", "Deleted"); err != nil {
+		t.Fatalf("UpdateAsset to Deleted returned error: %v", err)
+	}
+	if usage := dealerTestUsage(t, stub, "D001"); usage.Count != 1 {
+		t.Errorf("usage after a soft delete is %+v, want 1", usage)
+	}
+	if err := setStatus("9833333333", "Active"); err != nil {
+		t.Fatalf("UpdateAsset back to Active returned error: %v", err)
+	}
+	if usage := dealerTestUsage(t, stub, "D001"); usage.Count != 2 {
+		t.Errorf("usage after undeleting is %+v, want 2", usage)
+	}
+
+	// Bringing back the merged source needs a slot the dealer no longer has
+	if err := setStatus("9811111111", "Active"); !errors.Is(err, ErrDealerQuotaExceeded) {
+		t.Errorf("undeleting beyond the quota returned %v, want ErrDealerQuotaExceeded", err)
+	}
+
+	// A soft-deleted asset has no slot left to release when it is removed
+	err = stub.transact(func(ctx *auditContext) error {
+		return s.DeleteAsset(ctx, "9811111111", "")
+	})
+	if err != nil {
+		t.Fatalf("DeleteAsset returned error: %v", err)
+	}
+	if usage := dealerTestUsage(t, stub, "D001"); usage.Count != 2 {
+		t.Errorf("usage after removing the soft-deleted asset is %+v, want 2", usage)
+	}
+}
+
+func TestSetDealerQuotaRejectsNegative(t *testing.T) {
+	stub := newLedgerStub()
+
+	err := stub.transact(func(ctx *auditContext) error {
+		return new(SmartContract).SetDealerQuota(ctx, "D001", -1)
+	})
+	if err == nil {
+		t.Error("SetDealerQuota accepted a negative quota")
+	}
+}
+
+func TestDeleteAllAssetsRefusesWithoutConfirmation(t *testing.T) {
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 100)
+	createTestAsset(t, stub, "D002", "9822222222", 200)
+
+	for _, confirm := range []string{"", "yes", "confirm_delete_all"} {
+		err := stub.transact(func(ctx *auditContext) error {
+			_, err := new(SmartContract).DeleteAllAssets(ctx, confirm)
+			return err
+		})
+		if !errors.Is(err, ErrDeleteAllNotConfirmed) {
+			t.Errorf("DeleteAllAssets(%q) returned %v, want ErrDeleteAllNotConfirmed", confirm, err)
+		}
+	}
+	for _, msisdn := range []string{"9811111111", "9822222222"} {
+		if _, ok := stub.State[msisdn]; !ok {
+			t.Errorf("asset %s was deleted without confirmation", msisdn)
+		}
+	}
+}
+
+func TestDeleteAllAssets(t *testing.T) {
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 100)
+	createTestAsset(t, stub, "D001", "9822222222", 200)
+	createTestAsset(t, stub, "D001", "9833333333", 300)
+	createTestAsset(t, stub, "D002", "9844444444", 400)
+
+	var deleted int
+	err := stub.transact(func(ctx *auditContext) error {
+		var err error
+		deleted, err = new(SmartContract).DeleteAllAssets(ctx, deleteAllConfirmation)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("DeleteAllAssets returned error: %v", err)
+	}
+	if deleted != 4 {
+		t.Errorf("DeleteAllAssets removed %d assets, want 4", deleted)
+	}
+	for _, key := range stub.sortedKeys() {
+		if isAssetKey(key) && !strings.HasPrefix(key, "\x00") {
+			t.Errorf("asset key %s is still in the world state", key)
+		}
+		if strings.HasPrefix(key, "\x00"+dealerIndex) {
+			t.Errorf("dealer index entry %q is still in the world state", key)
+		}
+	}
+	if got := changedMSISDNs(t, stub.lastEvent(t)); strings.Join(got, ",") != "9811111111,9822222222,9833333333,9844444444" {
+		t.Errorf("DeleteAllAssets event names %v, want every deleted asset", got)
+	}
+
+	// Every asset of a dealer releases its slot, not just the last one
+	for _, dealerID := range []string{"D001", "D002"} {
+		if usage := dealerTestUsage(t, stub, dealerID); usage.Count != 0 {
+			t.Errorf("usage of %s after DeleteAllAssets is %+v, want 0", dealerID, usage)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// reversalObjectType is the composite key object type of reversal records,
+// keyed by MSISDN and the reversed transaction ID
+const reversalObjectType = "reversal"
+
+// transTypeReversal is recorded on the updates made by ReverseTransaction
+const transTypeReversal = "REVERSAL"
+
+// ErrAlreadyReversed is returned when a transaction that has already been reversed is reversed again
+var ErrAlreadyReversed = errors.New("transaction has already been reversed")
+
+// Reversal links a reversed transaction to the transaction that reversed it
+type Reversal struct {
+	OriginalTxID string `json:"OriginalTxID"`
+	ReversalTxID string `json:"ReversalTxID"`
+}
+
+// ReverseTransaction applies the inverse of the balance change an earlier
+// transaction made to an asset and records it with TransType REVERSAL and
+// ReversedTxID set to originalTxID. The same checks as AdjustBalance apply. A
+// transaction can only be reversed once.
+func (s *SmartContract) ReverseTransaction(ctx contractapi.TransactionContextInterface, msisdn, originalTxID, remarks string) error {
+	msisdn = normalizeMSISDN(msisdn)
+	if originalTxID == "" {
+		return fmt.Errorf("original transaction ID is required")
+	}
+
+	key, err := ctx.GetStub().CreateCompositeKey(reversalObjectType, []string{msisdn, originalTxID})
+	if err != nil {
+		return fmt.Errorf("error creating reversal key: %v", err)
+	}
+	reversalTxID, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if reversalTxID != nil {
+		return fmt.Errorf("%w: %s was reversed by %s", ErrAlreadyReversed, originalTxID, reversalTxID)
+	}
+
+	versions, err := assetVersions(ctx, msisdn)
+	if err != nil {
+		return err
+	}
+	var amount int64
+	found := false
+	for i, version := range versions {
+		if version.txID != originalTxID || version.isDelete {
+			continue
+		}
+		// Without both versions the balance change cannot be told apart from a repeat
+		if version.parseError != nil {
+			return fmt.Errorf("error reading transaction %s: %w", originalTxID, version.parseError)
+		}
+		if i > 0 && versions[i-1].parseError != nil {
+			return fmt.Errorf("error reading the transaction before %s: %w", originalTxID, versions[i-1].parseError)
+		}
+		if i > 0 && repeatsTransaction(&versions[i-1].asset, &version.asset) {
+			break
+		}
+		amount, found = version.asset.TransAmount, true
+		break
+	}
+	if !found || amount == 0 {
+		return fmt.Errorf("transaction %s did not change the balance of asset %s", originalTxID, msisdn)
+	}
+
+	// AdjustBalance writes the asset before ReversedTxID is added, so the second write must see the first
+	txCtx := newBatchContext(ctx)
+
+	inverse, err := subBalance(0, amount)
+	if err != nil {
+		return err
+	}
+	if err := s.AdjustBalance(txCtx, msisdn, inverse, transTypeReversal, remarks, "", "", ""); err != nil {
+		return err
+	}
+
+	asset, err := s.ReadAsset(txCtx, msisdn)
+	if err != nil {
+		return fmt.Errorf("error reading asset: %v", err)
+	}
+	asset.ReversedTxID = originalTxID
+	if err := putAsset(txCtx, asset); err != nil {
+		return err
+	}
+
+	if err := ctx.GetStub().PutState(key, []byte(ctx.GetStub().GetTxID())); err != nil {
+		return fmt.Errorf("failed to put to world state: %v", err)
+	}
+
+	// Replaces the AssetUpdated event of AdjustBalance, which lacks ReversedTxID
+	assetJSON, err := marshalAsset(asset)
+	if err != nil {
+		return fmt.Errorf("error marshalling asset: %v", err)
+	}
+	return ctx.GetStub().SetEvent("AssetUpdated", assetJSON)
+}
+
+// GetReversals returns the reversals recorded against an asset, ordered by
+// the ID of the reversed transaction
+func (s *SmartContract) GetReversals(ctx contractapi.TransactionContextInterface, msisdn string) ([]*Reversal, error) {
+	msisdn = normalizeMSISDN(msisdn)
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(reversalObjectType, []string{msisdn})
+	if err != nil {
+		return nil, fmt.Errorf("error reading reversals: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	reversals := []*Reversal{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("error iterating through reversals: %v", err)
+		}
+
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, fmt.Errorf("error splitting reversal key: %v", err)
+		}
+		reversals = append(reversals, &Reversal{OriginalTxID: attributes[1], ReversalTxID: string(queryResponse.Value)})
+	}
+
+	return reversals, nil
+}
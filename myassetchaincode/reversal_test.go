@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReverseTransaction(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 500)
+
+	var topupTxID string
+	err := stub.transact(func(ctx *auditContext) error {
+		topupTxID = ctx.GetStub().GetTxID()
+		return s.AdjustBalance(ctx, "9811111111", 200, "CREDIT", "topup", "", "", "topup")
+	})
+	if err != nil {
+		t.Fatalf("AdjustBalance returned error: %v", err)
+	}
+
+	var reversalTxID string
+	err = stub.transact(func(ctx *auditContext) error {
+		reversalTxID = ctx.GetStub().GetTxID()
+		return s.ReverseTransaction(ctx, "+91 98111 11111", topupTxID, "charged twice")
+	})
+	if err != nil {
+		t.Fatalf("ReverseTransaction returned error: %v", err)
+	}
+
+	asset := readTestAsset(t, stub, "9811111111")
+	if asset.Balance != 500 || asset.TransAmount != -200 || asset.TransType != transTypeReversal || asset.Remarks != "charged twice" {
+		t.Errorf("reversed asset is %+v, want balance 500 after a REVERSAL of -200", asset)
+	}
+	if asset.ReversedTxID != topupTxID {
+		t.Errorf("ReversedTxID is %q, want %q", asset.ReversedTxID, topupTxID)
+	}
+	if history := stub.history["9811111111"]; len(history) != 3 || history[0].TxId != reversalTxID {
+		t.Errorf("history has %d entries, want the reversal written once on top of 2", len(history))
+	}
+
+	event := stub.lastEvent(t)
+	var evented Asset
+	if err := json.Unmarshal(event.Payload, &evented); err != nil || event.EventName != "AssetUpdated" || evented.ReversedTxID != topupTxID {
+		t.Errorf("event is %s %s, want AssetUpdated with ReversedTxID %s", event.EventName, event.Payload, topupTxID)
+	}
+
+	var reversals []*Reversal
+	err = stub.transact(func(ctx *auditContext) error {
+		var err error
+		reversals, err = s.GetReversals(ctx, "9811111111")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("GetReversals returned error: %v", err)
+	}
+	if len(reversals) != 1 || reversals[0].OriginalTxID != topupTxID || reversals[0].ReversalTxID != reversalTxID {
+		t.Errorf("reversals are %+v, want %s reversed by %s", reversals, topupTxID, reversalTxID)
+	}
+
+	// A later transaction is not itself a reversal
+	err = stub.transact(func(ctx *auditContext) error {
+		return s.AdjustBalance(ctx, "9811111111", -50, "DEBIT", "", "", "", "")
+	})
+	if err != nil {
+		t.Fatalf("AdjustBalance returned error: %v", err)
+	}
+	if asset := readTestAsset(t, stub, "9811111111"); asset.ReversedTxID != "" {
+		t.Errorf("ReversedTxID after a later update is %q, want it cleared", asset.ReversedTxID)
+	}
+}
+
+func TestReverseTransactionTwice(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 500)
+
+	var debitTxID string
+	err := stub.transact(func(ctx *auditContext) error {
+		debitTxID = ctx.GetStub().GetTxID()
+		return s.UpdateAsset(ctx, "9811111111", "400", "Active", "DEBIT", "", "", "", "")
+	})
+	if err != nil {
+		t.Fatalf("UpdateAsset returned error: %v", err)
+	}
+
+	reverse := func(txID string) error {
+		return stub.transact(func(ctx *auditContext) error {
+			return s.ReverseTransaction(ctx, "9811111111", txID, "")
+		})
+	}
+	if err := reverse(debitTxID); err != nil {
+		t.Fatalf("ReverseTransaction returned error: %v", err)
+	}
+	if asset := readTestAsset(t, stub, "9811111111"); asset.Balance != 500 {
+		t.Fatalf("balance after reversing the debit is %d, want 500", asset.Balance)
+	}
+	events := len(stub.events)
+
+	if err := reverse(debitTxID); !errors.Is(err, ErrAlreadyReversed) {
+		t.Errorf("second reversal of %s returned %v, want ErrAlreadyReversed", debitTxID, err)
+	}
+	if asset := readTestAsset(t, stub, "9811111111"); asset.Balance != 500 {
+		t.Errorf("balance after the rejected reversal is %d, want 500", asset.Balance)
+	}
+	if len(stub.events) != events {
+		t.Error("rejected reversal emitted an event")
+	}
+
+	// Transactions that did not move the balance cannot be reversed
+	var metadataTxID string
+	err = stub.transact(func(ctx *auditContext) error {
+		metadataTxID = ctx.GetStub().GetTxID()
+		return s.SetAssetMetadata(ctx, "9811111111", "plan", "prepaid")
+	})
+	if err != nil {
+		t.Fatalf("SetAssetMetadata returned error: %v", err)
+	}
+	for _, txID := range []string{metadataTxID, "unknown", ""} {
+		if err := reverse(txID); err == nil || errors.Is(err, ErrAlreadyReversed) {
+			t.Errorf("ReverseTransaction(%q) returned %v, want a rejection", txID, err)
+		} else if txID != "" && !strings.Contains(err.Error(), "did not change the balance") {
+			t.Errorf("ReverseTransaction(%q) returned %v, want a did not change the balance error", txID, err)
+		}
+	}
+}
+
+func TestReverseTransactionIgnoresReencryption(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 500)
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.AdjustBalance(ctx, "9811111111", 200, "CREDIT", "topup", "", "", "")
+	})
+	if err != nil {
+		t.Fatalf("AdjustBalance returned error: %v", err)
+	}
+
+	// Rewriting the top-up with its remarks encrypted is not another top-up
+	t.Setenv(encryptionKeyEnv, testEncryptionKey)
+	var migrateTxID string
+	err = stub.transact(func(ctx *auditContext) error {
+		migrateTxID = ctx.GetStub().GetTxID()
+		_, err := s.MigrateAllAssets(ctx)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("MigrateAllAssets returned error: %v", err)
+	}
+
+	err = stub.transact(func(ctx *auditContext) error {
+		return s.ReverseTransaction(ctx, "9811111111", migrateTxID, "")
+	})
+	if err == nil || !strings.Contains(err.Error(), "did not change the balance") {
+		t.Errorf("reversing the migration returned %v, want a did not change the balance error", err)
+	}
+	if asset := readTestAsset(t, stub, "9811111111"); asset.Balance != 700 {
+		t.Errorf("balance is %d, want 700 untouched", asset.Balance)
+	}
+}
+
+func TestReverseTransactionRejectsUnreadableVersion(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 500)
+
+	// A corrupt write followed by a top-up written over it
+	var topupTxID string
+	for _, value := range []string{`{"MSISDN":"9811111111","Balance":"five hundred"}`, `{"MSISDN":"9811111111","DealerID":"D001","Balance":700,"Status":"Active","TransAmount":200}`} {
+		err := stub.transact(func(ctx *auditContext) error {
+			topupTxID = ctx.GetStub().GetTxID()
+			return stub.PutState("9811111111", []byte(value))
+		})
+		if err != nil {
+			t.Fatalf("writing history entry returned error: %v", err)
+		}
+	}
+
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.ReverseTransaction(ctx, "9811111111", topupTxID, "")
+	})
+	if !errors.Is(err, ErrUnreadableHistoryValue) {
+		t.Errorf("ReverseTransaction after an unreadable version returned %v, want ErrUnreadableHistoryValue", err)
+	}
+	if asset := readTestAsset(t, stub, "9811111111"); asset.Balance != 700 {
+		t.Errorf("balance is %d, want 700 untouched", asset.Balance)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// businessRulesEnv names the environment variable holding the JSON encoded
+// RuleSet that created and updated assets must satisfy. No deployment rules
+// apply when it is unset. Every endorsing peer must be configured with the
+// same value.
+const businessRulesEnv = "BUSINESS_RULES"
+
+// Names of the rules in a RuleSet, reported in RuleViolation.Rule
+const (
+	ruleMaxBalance      = "maxBalance"
+	ruleAllowedStatuses = "allowedStatuses"
+	ruleMSISDNPattern   = "msisdnPattern"
+)
+
+// ruleViolationPrefix starts the message of a RuleViolationError and is
+// followed by the JSON encoded list of RuleViolations
+const ruleViolationPrefix = "asset violates business rules: "
+
+// RuleSet holds the deployment specific rules an asset must satisfy on top of
+// the built-in validation. A zero value field leaves that rule off.
+type RuleSet struct {
+	// MaxBalance is the largest balance an asset may hold
+	MaxBalance int64 `json:"maxBalance,omitempty"`
+	// AllowedStatuses narrows the statuses an asset may have
+	AllowedStatuses []string `json:"allowedStatuses,omitempty"`
+	// MSISDNPattern is a regular expression the whole normalized MSISDN must match
+	MSISDNPattern string `json:"msisdnPattern,omitempty"`
+}
+
+// RuleViolation names a broken rule and explains how the asset broke it
+type RuleViolation struct {
+	Rule    string `json:"Rule"`
+	Message string `json:"Message"`
+}
+
+// RuleViolationError lists every rule an asset broke. Its message carries the
+// list as JSON so clients can recover it from the error text.
+type RuleViolationError struct {
+	Violations []RuleViolation
+}
+
+// Error returns ruleViolationPrefix followed by the violations as JSON
+func (e *RuleViolationError) Error() string {
+	violationsJSON, err := json.Marshal(e.Violations)
+	if err != nil {
+		return ruleViolationPrefix + err.Error()
+	}
+	return ruleViolationPrefix + string(violationsJSON)
+}
+
+// loadRuleSet reads the RuleSet from businessRulesEnv, returning nil when it is unset
+func loadRuleSet() (*RuleSet, error) {
+	value := os.Getenv(businessRulesEnv)
+	if value == "" {
+		return nil, nil
+	}
+
+	var rules RuleSet
+	if err := json.Unmarshal([]byte(value), &rules); err != nil {
+		return nil, fmt.Errorf("%s must be a JSON rule set: %v", businessRulesEnv, err)
+	}
+	return &rules, nil
+}
+
+// validateAgainstRules returns the rules asset breaks, in the order the rules
+// are declared in RuleSet, or nil when it satisfies them all
+func validateAgainstRules(asset *Asset, rules *RuleSet) ([]RuleViolation, error) {
+	if rules == nil {
+		return nil, nil
+	}
+
+	var violations []RuleViolation
+	if rules.MaxBalance > 0 && asset.Balance > rules.MaxBalance {
+		violations = append(violations, RuleViolation{
+			Rule:    ruleMaxBalance,
+			Message: fmt.Sprintf("balance %d exceeds %d", asset.Balance, rules.MaxBalance),
+		})
+	}
+
+	if len(rules.AllowedStatuses) > 0 {
+		allowed := false
+		for _, status := range rules.AllowedStatuses {
+			if asset.Status == status {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			violations = append(violations, RuleViolation{
+				Rule:    ruleAllowedStatuses,
+				Message: fmt.Sprintf("status %q is not one of %s", asset.Status, strings.Join(rules.AllowedStatuses, ", ")),
+			})
+		}
+	}
+
+	if rules.MSISDNPattern != "" {
+		pattern, err := regexp.Compile("^(?:" + rules.MSISDNPattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("%s has an invalid %s: %v", businessRulesEnv, ruleMSISDNPattern, err)
+		}
+		if !pattern.MatchString(asset.MSISDN) {
+			violations = append(violations, RuleViolation{
+				Rule:    ruleMSISDNPattern,
+				Message: fmt.Sprintf("MSISDN %s does not match %s", asset.MSISDN, rules.MSISDNPattern),
+			})
+		}
+	}
+
+	return violations, nil
+}
+
+// checkBusinessRules returns a *RuleViolationError when asset breaks any rule
+// of the configured RuleSet
+func checkBusinessRules(asset *Asset) error {
+	rules, err := loadRuleSet()
+	if err != nil {
+		return err
+	}
+
+	violations, err := validateAgainstRules(asset, rules)
+	if err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return &RuleViolationError{Violations: violations}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateAgainstRules(t *testing.T) {
+	rules := &RuleSet{MaxBalance: 1000, AllowedStatuses: []string{"Active", "Suspended"}, MSISDNPattern: `98\d{8}`}
+
+	tests := []struct {
+		name  string
+		asset Asset
+		want  []string
+	}{
+		{"satisfies all", Asset{MSISDN: "9811111111", Balance: 1000, Status: "Active"}, nil},
+		{"balance too high", Asset{MSISDN: "9811111111", Balance: 1001, Status: "Active"}, []string{ruleMaxBalance}},
+		{"status not allowed", Asset{MSISDN: "9811111111", Balance: 0, Status: "Frozen"}, []string{ruleAllowedStatuses}},
+		{"MSISDN not matching", Asset{MSISDN: "9711111111", Balance: 0, Status: "Active"}, []string{ruleMSISDNPattern}},
+		{"pattern must match whole MSISDN", Asset{MSISDN: "981111111199", Balance: 0, Status: "Active"}, []string{ruleMSISDNPattern}},
+		{"breaks every rule", Asset{MSISDN: "9711111111", Balance: 5000, Status: "Frozen"}, []string{ruleMaxBalance, ruleAllowedStatuses, ruleMSISDNPattern}},
+	}
+	for _, tt := range tests {
+		violations, err := validateAgainstRules(&tt.asset, rules)
+		if err != nil {
+			t.Fatalf("%s: validateAgainstRules returned error: %v", tt.name, err)
+		}
+		var got []string
+		for _, violation := range violations {
+			got = append(got, violation.Rule)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: violated rules are %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if violations, err := validateAgainstRules(&Asset{Balance: 1 << 40}, nil); err != nil || violations != nil {
+		t.Errorf("validateAgainstRules without rules returned %v, %v, want nothing", violations, err)
+	}
+	if _, err := validateAgainstRules(&Asset{}, &RuleSet{MSISDNPattern: "("}); err == nil {
+		t.Error("validateAgainstRules with an invalid pattern returned no error")
+	}
+}
+
+func TestBusinessRulesRejectWrites(t *testing.T) {
+	t.Setenv(businessRulesEnv, `{"maxBalance": 1000, "msisdnPattern": "98\\d{8}"}`)
+	s := new(SmartContract)
+	stub := newLedgerStub()
+
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.CreateAsset(ctx, "D001", "9711111111", 500, "Active", "", "", "", "")
+	})
+	var violation *RuleViolationError
+	if !errors.As(err, &violation) || len(violation.Violations) != 1 || violation.Violations[0].Rule != ruleMSISDNPattern {
+		t.Fatalf("creating an asset outside the MSISDN pattern returned %v, want a %s violation", err, ruleMSISDNPattern)
+	}
+	if !strings.HasPrefix(err.Error(), ruleViolationPrefix+`[{"Rule":"msisdnPattern"`) {
+		t.Errorf("error message is %q, want the violations as JSON", err.Error())
+	}
+
+	createTestAsset(t, stub, "D001", "9811111111", 500)
+	err = stub.transact(func(ctx *auditContext) error {
+		return s.UpdateAsset(ctx, "9811111111", "1500", "Active", "", "", "", "", "")
+	})
+	if !errors.As(err, &violation) || len(violation.Violations) != 1 || violation.Violations[0].Rule != ruleMaxBalance {
+		t.Fatalf("updating past the maximum balance returned %v, want a %s violation", err, ruleMaxBalance)
+	}
+	if asset := readTestAsset(t, stub, "9811111111"); asset.Balance != 500 {
+		t.Errorf("balance after the rejected update is %d, want 500", asset.Balance)
+	}
+
+	t.Setenv(businessRulesEnv, "not json")
+	err = stub.transact(func(ctx *auditContext) error {
+		return s.CreateAsset(ctx, "D001", "9822222222", 500, "Active", "", "", "", "")
+	})
+	if err == nil || !strings.Contains(err.Error(), businessRulesEnv) {
+		t.Errorf("creating an asset with a malformed rule set returned %v, want a configuration error", err)
+	}
+}
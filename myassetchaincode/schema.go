@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// currentSchemaVersion is the SchemaVersion assets are stored with. Assets
+// written before records were versioned read as version 0.
+const currentSchemaVersion = 1
+
+// migrateAsset upgrades an asset read from the world state to
+// currentSchemaVersion in place, one version at a time
+func migrateAsset(asset *Asset) {
+	if asset.SchemaVersion < 1 {
+		// Version 1 fills in the fields added to the original layout
+		if asset.Status == "" {
+			asset.Status = defaultStatus
+		}
+		if asset.CreatedAt.IsZero() {
+			asset.CreatedAt = asset.Timestamp
+		}
+		if asset.MPINSetAt.IsZero() {
+			asset.MPINSetAt = asset.CreatedAt
+		}
+	}
+
+	asset.SchemaVersion = currentSchemaVersion
+}
+
+// MigrateAllAssets rewrites every asset whose stored record differs from how
+// the current schema would store it: older schema versions, fields added
+// since it was written, or sensitive fields stored before encryption was
+// enabled. It returns the number of assets rewritten, so running it again
+// returns 0.
+func (s *SmartContract) MigrateAllAssets(ctx contractapi.TransactionContextInterface) (int, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return 0, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	rewrites := make(map[string][]byte)
+	var keys []string
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return 0, fmt.Errorf("error iterating through assets: %v", err)
+		}
+		if !isAssetKey(queryResponse.Key) {
+			continue
+		}
+
+		var asset Asset
+		if err := unmarshalAsset(queryResponse.Value, &asset); err != nil {
+			return 0, fmt.Errorf("error unmarshalling asset %s: %v", queryResponse.Key, err)
+		}
+		assetJSON, err := marshalAsset(&asset)
+		if err != nil {
+			return 0, fmt.Errorf("error marshalling asset %s: %v", queryResponse.Key, err)
+		}
+
+		if !bytes.Equal(assetJSON, queryResponse.Value) {
+			rewrites[queryResponse.Key] = assetJSON
+			keys = append(keys, queryResponse.Key)
+		}
+	}
+
+	for _, key := range keys {
+		if err := ctx.GetStub().PutState(key, rewrites[key]); err != nil {
+			return 0, fmt.Errorf("failed to put to world state: %v", err)
+		}
+	}
+
+	return len(keys), setAssetsChangedEvent(ctx, keys)
+}
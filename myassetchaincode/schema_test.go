@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// migrateTestAssets runs MigrateAllAssets in a transaction of its own
+func migrateTestAssets(t *testing.T, stub *ledgerStub) int {
+	t.Helper()
+	var migrated int
+	err := stub.transact(func(ctx *auditContext) error {
+		var err error
+		migrated, err = new(SmartContract).MigrateAllAssets(ctx)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("MigrateAllAssets returned error: %v", err)
+	}
+	return migrated
+}
+
+func TestMigrateAllAssets(t *testing.T) {
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 500)
+
+	// Version 0 records predate SchemaVersion, Status and CreatedAt
+	legacy := map[string]string{
+		"9822222222": `{"DealerID":"D001","MSISDN":"9822222222","Balance":100,"Timestamp":"2023-06-01T00:00:00Z"}`,
+		"9833333333": `{"DealerID":"D002","MSISDN":"9833333333","Balance":50,"Status":"Frozen","Timestamp":"2023-07-01T00:00:00Z"}`,
+	}
+	err := stub.transact(func(ctx *auditContext) error {
+		for key, value := range legacy {
+			if err := stub.PutState(key, []byte(value)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("storing legacy assets returned error: %v", err)
+	}
+	current := string(stub.State["9811111111"])
+
+	if migrated := migrateTestAssets(t, stub); migrated != 2 {
+		t.Errorf("MigrateAllAssets migrated %d assets, want the 2 legacy ones", migrated)
+	}
+	if got := changedMSISDNs(t, stub.lastEvent(t)); strings.Join(got, ",") != "9822222222,9833333333" {
+		t.Errorf("migration event names %v, want the migrated assets", got)
+	}
+	if string(stub.State["9811111111"]) != current {
+		t.Error("asset already at the current version was rewritten")
+	}
+
+	for _, msisdn := range []string{"9811111111", "9822222222", "9833333333"} {
+		var stored map[string]interface{}
+		if err := json.Unmarshal(stub.State[msisdn], &stored); err != nil {
+			t.Fatalf("error unmarshalling stored asset %s: %v", msisdn, err)
+		}
+		if stored["SchemaVersion"] != float64(currentSchemaVersion) {
+			t.Errorf("asset %s is stored at version %v, want %d", msisdn, stored["SchemaVersion"], currentSchemaVersion)
+		}
+	}
+	asset := readTestAsset(t, stub, "9822222222")
+	if asset.Status != defaultStatus || !asset.CreatedAt.Equal(asset.Timestamp) || asset.Balance != 100 {
+		t.Errorf("migrated asset has status %s, created %v and balance %d, want %s, its Timestamp and 100", asset.Status, asset.CreatedAt, asset.Balance, defaultStatus)
+	}
+	if asset := readTestAsset(t, stub, "9833333333"); asset.Status != "Frozen" {
+		t.Errorf("migrated asset has status %s, want its Frozen status kept", asset.Status)
+	}
+
+	if migrated := migrateTestAssets(t, stub); migrated != 0 {
+		t.Errorf("second MigrateAllAssets migrated %d assets, want 0", migrated)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// segmentIndex is the composite key object type indexing MSISDNs by segment
+const segmentIndex = "segment~msisdn"
+
+// AddAssetToSegment adds an existing asset to a named segment. Adding an asset
+// that is already a member has no effect.
+func (s *SmartContract) AddAssetToSegment(ctx contractapi.TransactionContextInterface, msisdn, segment string) error {
+	msisdn = normalizeMSISDN(msisdn)
+	if segment == "" {
+		return fmt.Errorf("segment name must not be empty")
+	}
+
+	exists, err := s.AssetExists(ctx, msisdn)
+	if err != nil {
+		return fmt.Errorf("error checking asset existence: %v", err)
+	}
+	if !exists {
+		return fmt.Errorf("asset with MSISDN %s does not exist", msisdn)
+	}
+
+	indexKey, err := ctx.GetStub().CreateCompositeKey(segmentIndex, []string{segment, msisdn})
+	if err != nil {
+		return fmt.Errorf("error creating segment index key: %v", err)
+	}
+
+	// Only the key matters; a non-nil value is required to store it
+	if err := ctx.GetStub().PutState(indexKey, []byte{0x00}); err != nil {
+		return fmt.Errorf("error writing segment index: %v", err)
+	}
+
+	return nil
+}
+
+// RemoveAssetFromSegment removes an asset from a named segment. Removing an
+// asset that is not a member has no effect.
+func (s *SmartContract) RemoveAssetFromSegment(ctx contractapi.TransactionContextInterface, msisdn, segment string) error {
+	msisdn = normalizeMSISDN(msisdn)
+
+	indexKey, err := ctx.GetStub().CreateCompositeKey(segmentIndex, []string{segment, msisdn})
+	if err != nil {
+		return fmt.Errorf("error creating segment index key: %v", err)
+	}
+
+	if err := ctx.GetStub().DelState(indexKey); err != nil {
+		return fmt.Errorf("error deleting segment index: %v", err)
+	}
+
+	return nil
+}
+
+// GetSegmentMembers returns the MSISDNs in a segment in ascending order
+func (s *SmartContract) GetSegmentMembers(ctx contractapi.TransactionContextInterface, segment string) ([]string, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(segmentIndex, []string{segment})
+	if err != nil {
+		return nil, fmt.Errorf("error reading segment index: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	members := []string{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("error iterating through segment index: %v", err)
+		}
+
+		_, keyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, fmt.Errorf("error splitting segment index key: %v", err)
+		}
+		members = append(members, keyParts[1])
+	}
+
+	return members, nil
+}
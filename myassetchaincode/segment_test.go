@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// segmentTestMembers lists a segment's members in their own transaction
+func segmentTestMembers(t *testing.T, stub *ledgerStub, segment string) string {
+	t.Helper()
+	var members []string
+	err := stub.transact(func(ctx *auditContext) error {
+		var err error
+		members, err = new(SmartContract).GetSegmentMembers(ctx, segment)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("GetSegmentMembers(%s) returned error: %v", segment, err)
+	}
+	return strings.Join(members, ",")
+}
+
+func TestSegmentMembership(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 100)
+	createTestAsset(t, stub, "D001", "9822222222", 100)
+	createTestAsset(t, stub, "D002", "9833333333", 100)
+
+	for _, member := range [][2]string{
+		{"9833333333", "premium"},
+		{"9811111111", "premium"},
+		{"9811111111", "premium"},
+		{"9822222222", "dormant"},
+	} {
+		err := stub.transact(func(ctx *auditContext) error {
+			return s.AddAssetToSegment(ctx, member[0], member[1])
+		})
+		if err != nil {
+			t.Fatalf("AddAssetToSegment(%s, %s) returned error: %v", member[0], member[1], err)
+		}
+	}
+
+	if got := segmentTestMembers(t, stub, "premium"); got != "9811111111,9833333333" {
+		t.Errorf("premium members are %s, want 9811111111,9833333333", got)
+	}
+	if got := segmentTestMembers(t, stub, "dormant"); got != "9822222222" {
+		t.Errorf("dormant members are %s, want 9822222222", got)
+	}
+
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.RemoveAssetFromSegment(ctx, "9811111111", "premium")
+	})
+	if err != nil {
+		t.Fatalf("RemoveAssetFromSegment returned error: %v", err)
+	}
+	if got := segmentTestMembers(t, stub, "premium"); got != "9833333333" {
+		t.Errorf("premium members after removal are %s, want 9833333333", got)
+	}
+	if got := segmentTestMembers(t, stub, "unknown"); got != "" {
+		t.Errorf("unknown segment has members %s, want none", got)
+	}
+}
+
+func TestAddAssetToSegmentValidation(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 100)
+
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.AddAssetToSegment(ctx, "9800000000", "premium")
+	})
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("adding a missing asset returned %v, want a does not exist error", err)
+	}
+
+	err = stub.transact(func(ctx *auditContext) error {
+		return s.AddAssetToSegment(ctx, "9811111111", "")
+	})
+	if err == nil {
+		t.Error("adding to an empty segment name succeeded")
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// snapshotKeyPrefix namespaces asset snapshots, stored under "snapshot_<id>"
+const snapshotKeyPrefix = "snapshot_"
+
+// Snapshot is every active asset as of CreatedAt
+type Snapshot struct {
+	SnapshotID string    `json:"SnapshotID"`
+	CreatedAt  time.Time `json:"CreatedAt"`
+	Assets     []*Asset  `json:"Assets"`
+}
+
+// storedSnapshot is a snapshot as written to the world state, with each asset
+// encoded like the asset itself so encrypted fields stay encrypted
+type storedSnapshot struct {
+	SnapshotID string            `json:"SnapshotID"`
+	CreatedAt  time.Time         `json:"CreatedAt"`
+	Assets     []json.RawMessage `json:"Assets"`
+}
+
+// CreateSnapshot stores every active asset under a single key so consumers can
+// read a consistent point-in-time copy with GetSnapshot. Snapshot IDs cannot
+// be reused.
+func (s *SmartContract) CreateSnapshot(ctx contractapi.TransactionContextInterface, snapshotID string) error {
+	if snapshotID == "" {
+		return fmt.Errorf("snapshot ID must not be empty")
+	}
+
+	key := snapshotKeyPrefix + snapshotID
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if existing != nil {
+		return fmt.Errorf("snapshot %s already exists", snapshotID)
+	}
+
+	createdAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	snapshot := storedSnapshot{SnapshotID: snapshotID, CreatedAt: createdAt, Assets: []json.RawMessage{}}
+	err = forEachAsset(ctx, func(asset *Asset) error {
+		assetJSON, err := marshalAsset(asset)
+		if err != nil {
+			return fmt.Errorf("error marshalling asset %s: %v", asset.MSISDN, err)
+		}
+		snapshot.Assets = append(snapshot.Assets, assetJSON)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	snapshotJSON, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("error marshalling snapshot: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(key, snapshotJSON); err != nil {
+		return fmt.Errorf("failed to put to world state: %v", err)
+	}
+
+	return nil
+}
+
+// GetSnapshot returns a snapshot stored by CreateSnapshot
+func (s *SmartContract) GetSnapshot(ctx contractapi.TransactionContextInterface, snapshotID string) (*Snapshot, error) {
+	snapshotJSON, err := ctx.GetStub().GetState(snapshotKeyPrefix + snapshotID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if snapshotJSON == nil {
+		return nil, fmt.Errorf("snapshot %s does not exist", snapshotID)
+	}
+
+	var stored storedSnapshot
+	if err := json.Unmarshal(snapshotJSON, &stored); err != nil {
+		return nil, fmt.Errorf("error unmarshalling snapshot: %v", err)
+	}
+
+	snapshot := &Snapshot{
+		SnapshotID: stored.SnapshotID,
+		CreatedAt:  stored.CreatedAt,
+		Assets:     make([]*Asset, 0, len(stored.Assets)),
+	}
+	for _, assetJSON := range stored.Assets {
+		var asset Asset
+		if err := unmarshalAsset(assetJSON, &asset); err != nil {
+			return nil, fmt.Errorf("error unmarshalling snapshot asset: %v", err)
+		}
+		snapshot.Assets = append(snapshot.Assets, &asset)
+	}
+
+	return snapshot, nil
+}
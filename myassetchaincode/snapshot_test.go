@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// createTestSnapshot creates a snapshot and reads it back, each in its own transaction
+func createTestSnapshot(t *testing.T, stub *ledgerStub, snapshotID string) *Snapshot {
+	t.Helper()
+	s := new(SmartContract)
+	if err := stub.transact(func(ctx *auditContext) error { return s.CreateSnapshot(ctx, snapshotID) }); err != nil {
+		t.Fatalf("CreateSnapshot(%s) returned error: %v", snapshotID, err)
+	}
+	createdAt := stub.now
+
+	var snapshot *Snapshot
+	err := stub.transact(func(ctx *auditContext) error {
+		var err error
+		snapshot, err = s.GetSnapshot(ctx, snapshotID)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("GetSnapshot(%s) returned error: %v", snapshotID, err)
+	}
+	if snapshot.SnapshotID != snapshotID || !snapshot.CreatedAt.Equal(createdAt) {
+		t.Errorf("snapshot is %s created at %s, want %s created at %s", snapshot.SnapshotID, snapshot.CreatedAt, snapshotID, createdAt)
+	}
+	return snapshot
+}
+
+func TestSnapshotMatchesStateAtCreation(t *testing.T) {
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 300)
+	createTestAsset(t, stub, "D002", "9822222222", 200)
+	want := []*Asset{readTestAsset(t, stub, "9811111111"), readTestAsset(t, stub, "9822222222")}
+
+	createTestSnapshot(t, stub, "eod-2024-01-01")
+
+	// Later writes do not change the snapshot
+	updateBalances(t, stub, "9811111111", 900)
+	createTestAsset(t, stub, "D001", "9833333333", 100)
+	var snapshot *Snapshot
+	err := stub.transact(func(ctx *auditContext) error {
+		var err error
+		snapshot, err = new(SmartContract).GetSnapshot(ctx, "eod-2024-01-01")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("GetSnapshot returned error: %v", err)
+	}
+
+	if len(snapshot.Assets) != len(want) {
+		t.Fatalf("snapshot has %d assets, want %d", len(snapshot.Assets), len(want))
+	}
+	for i, asset := range snapshot.Assets {
+		if asset.MSISDN != want[i].MSISDN || asset.DealerID != want[i].DealerID || asset.Balance != want[i].Balance || !asset.Timestamp.Equal(want[i].Timestamp) {
+			t.Errorf("snapshot asset %d is %+v, want %+v", i, asset, want[i])
+		}
+	}
+
+	// A later snapshot sees the new state but not the earlier snapshot
+	later := createTestSnapshot(t, stub, "eod-2024-01-02")
+	if len(later.Assets) != 3 || later.Assets[0].Balance != 900 {
+		t.Errorf("later snapshot has %d assets, first with balance %d, want 3 with 900", len(later.Assets), later.Assets[0].Balance)
+	}
+}
+
+func TestSnapshotErrors(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 300)
+	createTestSnapshot(t, stub, "eod")
+
+	err := stub.transact(func(ctx *auditContext) error { return s.CreateSnapshot(ctx, "eod") })
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("reusing a snapshot ID returned %v, want an already exists error", err)
+	}
+	err = stub.transact(func(ctx *auditContext) error { return s.CreateSnapshot(ctx, "") })
+	if err == nil {
+		t.Error("CreateSnapshot with an empty ID succeeded")
+	}
+	err = stub.transact(func(ctx *auditContext) error {
+		_, err := s.GetSnapshot(ctx, "missing")
+		return err
+	})
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("GetSnapshot of a missing snapshot returned %v, want a does not exist error", err)
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// standingInstructionObjectType is the composite key object type of standing
+// instructions, keyed by MSISDN
+const standingInstructionObjectType = "standingInstruction"
+
+// standingInstructionTransType is recorded on the adjustments made by standing instructions
+const standingInstructionTransType = "STANDING_INSTRUCTION"
+
+// Intervals accepted for StandingInstruction.Interval
+const (
+	intervalDaily   = "daily"
+	intervalWeekly  = "weekly"
+	intervalMonthly = "monthly"
+)
+
+// StandingInstruction is a recurring balance adjustment of an asset. Amount is
+// added to the balance (negative for a fee) every Interval, starting at NextRun.
+type StandingInstruction struct {
+	MSISDN   string    `json:"MSISDN"`
+	Amount   int64     `json:"Amount"`
+	Interval string    `json:"Interval"`
+	NextRun  time.Time `json:"NextRun"`
+}
+
+// StandingInstructionReport lists the MSISDNs whose due instructions were
+// applied, and the failed ones as "<msisdn>: <reason>"
+type StandingInstructionReport struct {
+	Applied []string `json:"Applied"`
+	Failed  []string `json:"Failed"`
+}
+
+// advance returns the run following at for the instruction's interval
+func (si *StandingInstruction) advance(at time.Time) time.Time {
+	switch si.Interval {
+	case intervalDaily:
+		return at.AddDate(0, 0, 1)
+	case intervalWeekly:
+		return at.AddDate(0, 0, 7)
+	default:
+		return at.AddDate(0, 1, 0)
+	}
+}
+
+// SetStandingInstruction sets the recurring adjustment of an asset, replacing
+// any previous one. interval is daily, weekly or monthly. The first run is at
+// firstRunRFC3339, or one interval from now when it is empty. Amounts above
+// approvalThreshold are refused because no second party can approve each run.
+func (s *SmartContract) SetStandingInstruction(ctx contractapi.TransactionContextInterface, msisdn string, amount int64, interval, firstRunRFC3339 string) error {
+	msisdn = normalizeMSISDN(msisdn)
+
+	exists, err := s.AssetExists(ctx, msisdn)
+	if err != nil {
+		return fmt.Errorf("error checking asset existence: %v", err)
+	}
+	if !exists {
+		return fmt.Errorf("asset with MSISDN %s does not exist", msisdn)
+	}
+
+	if amount == 0 {
+		return fmt.Errorf("standing instruction amount must not be zero")
+	}
+	if err := checkTransactionAmount(amount); err != nil {
+		return err
+	}
+	if abs(amount) > approvalThreshold {
+		return fmt.Errorf("%w: standing instruction amount of %d exceeds %d", ErrApprovalRequired, amount, approvalThreshold)
+	}
+
+	switch interval {
+	case intervalDaily, intervalWeekly, intervalMonthly:
+	default:
+		return fmt.Errorf("invalid interval %q: must be one of daily, weekly, monthly", interval)
+	}
+
+	instruction := StandingInstruction{MSISDN: msisdn, Amount: amount, Interval: interval}
+
+	if firstRunRFC3339 == "" {
+		now, err := getTxTimestamp(ctx)
+		if err != nil {
+			return err
+		}
+		instruction.NextRun = instruction.advance(now)
+	} else {
+		instruction.NextRun, err = time.Parse(time.RFC3339, firstRunRFC3339)
+		if err != nil {
+			return fmt.Errorf("error parsing first run: %v", err)
+		}
+	}
+
+	return putStandingInstruction(ctx, &instruction)
+}
+
+// GetStandingInstruction returns the standing instruction of an asset
+func (s *SmartContract) GetStandingInstruction(ctx contractapi.TransactionContextInterface, msisdn string) (*StandingInstruction, error) {
+	msisdn = normalizeMSISDN(msisdn)
+
+	key, err := ctx.GetStub().CreateCompositeKey(standingInstructionObjectType, []string{msisdn})
+	if err != nil {
+		return nil, fmt.Errorf("error creating standing instruction key: %v", err)
+	}
+
+	instructionJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if instructionJSON == nil {
+		return nil, fmt.Errorf("asset with MSISDN %s has no standing instruction", msisdn)
+	}
+
+	var instruction StandingInstruction
+	if err := json.Unmarshal(instructionJSON, &instruction); err != nil {
+		return nil, fmt.Errorf("error unmarshalling standing instruction: %v", err)
+	}
+	return &instruction, nil
+}
+
+// RemoveStandingInstruction cancels the standing instruction of an asset
+func (s *SmartContract) RemoveStandingInstruction(ctx contractapi.TransactionContextInterface, msisdn string) error {
+	if _, err := s.GetStandingInstruction(ctx, msisdn); err != nil {
+		return err
+	}
+
+	key, err := ctx.GetStub().CreateCompositeKey(standingInstructionObjectType, []string{normalizeMSISDN(msisdn)})
+	if err != nil {
+		return fmt.Errorf("error creating standing instruction key: %v", err)
+	}
+
+	if err := ctx.GetStub().DelState(key); err != nil {
+		return fmt.Errorf("failed to delete standing instruction: %v", err)
+	}
+	return nil
+}
+
+// ApplyDueStandingInstructions applies every standing instruction whose
+// NextRun has passed and advances its NextRun by one interval. An instruction
+// that missed several runs is applied once per call until it catches up. A
+// failed instruction keeps its NextRun so the next call retries it.
+func (s *SmartContract) ApplyDueStandingInstructions(ctx contractapi.TransactionContextInterface) (*StandingInstructionReport, error) {
+	now, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(standingInstructionObjectType, []string{})
+	if err != nil {
+		return nil, fmt.Errorf("error reading standing instructions: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	var due []*StandingInstruction
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("error iterating through standing instructions: %v", err)
+		}
+
+		var instruction StandingInstruction
+		if err := json.Unmarshal(queryResponse.Value, &instruction); err != nil {
+			return nil, fmt.Errorf("error unmarshalling standing instruction %s: %v", queryResponse.Key, err)
+		}
+		if !instruction.NextRun.After(now) {
+			due = append(due, &instruction)
+		}
+	}
+
+	report := &StandingInstructionReport{Applied: []string{}, Failed: []string{}}
+	for _, instruction := range due {
+		remarks := fmt.Sprintf("%s standing instruction due %s", instruction.Interval, instruction.NextRun.Format(time.RFC3339))
+		if err := s.AdjustBalance(ctx, instruction.MSISDN, instruction.Amount, standingInstructionTransType, remarks, "Active", "", ""); err != nil {
+			report.Failed = append(report.Failed, fmt.Sprintf("%s: %v", instruction.MSISDN, err))
+			continue
+		}
+
+		instruction.NextRun = instruction.advance(instruction.NextRun)
+		if err := putStandingInstruction(ctx, instruction); err != nil {
+			return nil, err
+		}
+		report.Applied = append(report.Applied, instruction.MSISDN)
+	}
+
+	// Replaces the AssetUpdated event of the last adjustment
+	return report, setAssetsChangedEvent(ctx, report.Applied)
+}
+
+// delStandingInstruction drops the standing instruction of an asset that is
+// deleted, archived or merged away, if it has one, so it neither fails on
+// every run nor moves the balance of a later asset under the same MSISDN
+func delStandingInstruction(ctx contractapi.TransactionContextInterface, msisdn string) error {
+	key, err := ctx.GetStub().CreateCompositeKey(standingInstructionObjectType, []string{msisdn})
+	if err != nil {
+		return fmt.Errorf("error creating standing instruction key: %v", err)
+	}
+
+	if err := ctx.GetStub().DelState(key); err != nil {
+		return fmt.Errorf("failed to delete standing instruction: %v", err)
+	}
+	return nil
+}
+
+// putStandingInstruction writes a standing instruction under its MSISDN
+func putStandingInstruction(ctx contractapi.TransactionContextInterface, instruction *StandingInstruction) error {
+	key, err := ctx.GetStub().CreateCompositeKey(standingInstructionObjectType, []string{instruction.MSISDN})
+	if err != nil {
+		return fmt.Errorf("error creating standing instruction key: %v", err)
+	}
+
+	instructionJSON, err := json.Marshal(instruction)
+	if err != nil {
+		return fmt.Errorf("error marshalling standing instruction: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(key, instructionJSON); err != nil {
+		return fmt.Errorf("failed to put to world state: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// standingTestInstruction reads the standing instruction of an asset
+func standingTestInstruction(t *testing.T, stub *ledgerStub, msisdn string) *StandingInstruction {
+	t.Helper()
+	var instruction *StandingInstruction
+	err := stub.transact(func(ctx *auditContext) error {
+		var err error
+		instruction, err = new(SmartContract).GetStandingInstruction(ctx, msisdn)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("GetStandingInstruction(%s) returned error: %v", msisdn, err)
+	}
+	return instruction
+}
+
+func TestApplyDueStandingInstructions(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 500)
+	createTestAsset(t, stub, "D001", "9822222222", 500)
+	createTestAsset(t, stub, "D001", "9833333333", 500)
+	err := stub.transact(func(ctx *auditContext) error {
+		// A monthly fee that is due, a credit that is not, and a due fee on a frozen asset
+		if err := s.SetStandingInstruction(ctx, "9811111111", -50, intervalMonthly, "2024-01-01T00:00:00Z"); err != nil {
+			return err
+		}
+		if err := s.SetStandingInstruction(ctx, "9822222222", 100, intervalWeekly, "2024-06-01T00:00:00Z"); err != nil {
+			return err
+		}
+		if err := s.SetStandingInstruction(ctx, "9833333333", -50, intervalDaily, "2024-01-01T00:00:00Z"); err != nil {
+			return err
+		}
+		return s.UpdateAsset(ctx, "9833333333", "500", "Frozen", "", "", "", "", "")
+	})
+	if err != nil {
+		t.Fatalf("setting standing instructions returned error: %v", err)
+	}
+
+	var report *StandingInstructionReport
+	err = stub.transact(func(ctx *auditContext) error {
+		var err error
+		report, err = s.ApplyDueStandingInstructions(ctx)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("ApplyDueStandingInstructions returned error: %v", err)
+	}
+
+	if len(report.Applied) != 1 || report.Applied[0] != "9811111111" {
+		t.Errorf("applied %v, want only the due fee", report.Applied)
+	}
+	if len(report.Failed) != 1 || !strings.HasPrefix(report.Failed[0], "9833333333: ") {
+		t.Errorf("failed %v, want the frozen asset", report.Failed)
+	}
+	if got := changedMSISDNs(t, stub.lastEvent(t)); strings.Join(got, ",") != "9811111111" {
+		t.Errorf("event names %v, want the applied asset", got)
+	}
+
+	asset := readTestAsset(t, stub, "9811111111")
+	if asset.Balance != 450 || asset.TransType != standingInstructionTransType {
+		t.Errorf("due asset has balance %d and type %s, want 450 and %s", asset.Balance, asset.TransType, standingInstructionTransType)
+	}
+	if next := standingTestInstruction(t, stub, "9811111111").NextRun; !next.Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("NextRun of the applied fee is %v, want a month later", next)
+	}
+	for msisdn, run := range map[string]time.Time{
+		"9822222222": time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		"9833333333": time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	} {
+		if asset := readTestAsset(t, stub, msisdn); asset.Balance != 500 {
+			t.Errorf("balance of %s is %d, want 500 untouched", msisdn, asset.Balance)
+		}
+		if next := standingTestInstruction(t, stub, msisdn).NextRun; !next.Equal(run) {
+			t.Errorf("NextRun of %s is %v, want %v kept", msisdn, next, run)
+		}
+	}
+
+	// Nothing is due again until next month
+	err = stub.transact(func(ctx *auditContext) error {
+		var err error
+		report, err = s.ApplyDueStandingInstructions(ctx)
+		return err
+	})
+	if err != nil || len(report.Applied) != 0 {
+		t.Errorf("second run applied %v, %v, want nothing", report.Applied, err)
+	}
+}
+
+func TestSetStandingInstructionValidation(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 500)
+
+	tests := []struct {
+		msisdn   string
+		amount   int64
+		interval string
+		firstRun string
+		want     string
+	}{
+		{"9800000000", -50, intervalMonthly, "", "does not exist"},
+		{"9811111111", 0, intervalMonthly, "", "must not be zero"},
+		{"9811111111", -50, "yearly", "", "invalid interval"},
+		{"9811111111", -50, intervalMonthly, "tomorrow", "error parsing first run"},
+		{"9811111111", approvalThreshold + 1, intervalMonthly, "", "requires approval"},
+	}
+	for _, tt := range tests {
+		err := stub.transact(func(ctx *auditContext) error {
+			return s.SetStandingInstruction(ctx, tt.msisdn, tt.amount, tt.interval, tt.firstRun)
+		})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("SetStandingInstruction(%s, %d, %s, %q) returned %v, want %q", tt.msisdn, tt.amount, tt.interval, tt.firstRun, err, tt.want)
+		}
+	}
+
+	// Without a first run the instruction starts one interval from now
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.SetStandingInstruction(ctx, "9811111111", -50, intervalWeekly, "")
+	})
+	if err != nil {
+		t.Fatalf("SetStandingInstruction returned error: %v", err)
+	}
+	if next := standingTestInstruction(t, stub, "9811111111").NextRun; !next.Equal(stub.now.Add(-time.Minute).AddDate(0, 0, 7)) {
+		t.Errorf("NextRun is %v, want a week after it was set", next)
+	}
+}
+
+func TestStandingInstructionRemovedWithAsset(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	msisdns := []string{"9811111111", "9822222222", "9833333333", "9844444444", "9855555555"}
+	for _, msisdn := range msisdns {
+		createTestAsset(t, stub, "D001", msisdn, 500)
+	}
+	createTestAsset(t, stub, "D001", "9899999999", 500)
+	err := stub.transact(func(ctx *auditContext) error {
+		for _, msisdn := range msisdns {
+			if err := s.SetStandingInstruction(ctx, msisdn, -50, intervalDaily, "2024-01-01T00:00:00Z"); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("setting standing instructions returned error: %v", err)
+	}
+
+	for name, remove := range map[string]func(ctx *auditContext) error{
+		"DeleteAsset": func(ctx *auditContext) error {
+			return s.DeleteAsset(ctx, "9811111111", "")
+		},
+		"ArchiveAsset": func(ctx *auditContext) error {
+			return s.ArchiveAsset(ctx, "9822222222")
+		},
+		"MergeAssets": func(ctx *auditContext) error {
+			return s.MergeAssets(ctx, "9833333333", "9899999999")
+		},
+		"ReassignMSISDN": func(ctx *auditContext) error {
+			return s.ReassignMSISDN(ctx, "9844444444", "9866666666")
+		},
+		"UpdateAsset to Deleted": func(ctx *auditContext) error {
+			return s.UpdateAsset(ctx, "9855555555", "500", "Deleted", "", "", "", "", "")
+		},
+	} {
+		if err := stub.transact(remove); err != nil {
+			t.Fatalf("%s returned error: %v", name, err)
+		}
+	}
+	for _, msisdn := range msisdns {
+		key, _ := shim.CreateCompositeKey(standingInstructionObjectType, []string{msisdn})
+		if _, ok := stub.State[key]; ok {
+			t.Errorf("standing instruction of %s outlived its asset", msisdn)
+		}
+	}
+
+	// A new owner of a deleted MSISDN does not inherit the old instruction
+	createTestAsset(t, stub, "D002", "9811111111", 500)
+	var report *StandingInstructionReport
+	err = stub.transact(func(ctx *auditContext) error {
+		var err error
+		report, err = s.ApplyDueStandingInstructions(ctx)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("ApplyDueStandingInstructions returned error: %v", err)
+	}
+	if len(report.Applied) != 0 || len(report.Failed) != 0 {
+		t.Errorf("report is %+v, want nothing applied or failed", report)
+	}
+	if asset := readTestAsset(t, stub, "9811111111"); asset.Balance != 500 {
+		t.Errorf("balance of the recreated asset is %d, want 500 untouched", asset.Balance)
+	}
+}
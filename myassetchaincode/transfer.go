@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// TransferCheck is whether a transfer would currently succeed and, if not, why
+type TransferCheck struct {
+	Possible bool   `json:"Possible"`
+	Reason   string `json:"Reason,omitempty"`
+}
+
+// CanTransfer reports whether moving amount from one asset to another would
+// succeed, without changing any state. Both assets must exist and be Active
+// and unlocked, the amount must be within the transaction and approval limits
+// and the source must keep at least minBalance. A failed check is reported as
+// the Reason; the error is only set when the ledger cannot be read.
+func (s *SmartContract) CanTransfer(ctx contractapi.TransactionContextInterface, fromMSISDN, toMSISDN string, amount int64) (*TransferCheck, error) {
+	fromMSISDN = normalizeMSISDN(fromMSISDN)
+	toMSISDN = normalizeMSISDN(toMSISDN)
+
+	if amount <= 0 {
+		return transferRefused("transfer amount must be positive, got %d", amount), nil
+	}
+	if fromMSISDN == toMSISDN {
+		return transferRefused("cannot transfer from asset %s to itself", fromMSISDN), nil
+	}
+	if err := checkTransactionAmount(amount); err != nil {
+		return transferRefused("%v", err), nil
+	}
+	if amount > approvalThreshold {
+		return transferRefused("%v: transfer of %d exceeds %d", ErrApprovalRequired, amount, approvalThreshold), nil
+	}
+
+	now, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var assets []*Asset
+	for _, msisdn := range []string{fromMSISDN, toMSISDN} {
+		exists, err := s.AssetExists(ctx, msisdn)
+		if err != nil {
+			return nil, fmt.Errorf("error checking asset existence: %v", err)
+		}
+		if !exists {
+			return transferRefused("asset with MSISDN %s does not exist", msisdn), nil
+		}
+
+		asset, err := s.ReadAsset(ctx, msisdn)
+		if err != nil {
+			return nil, fmt.Errorf("error reading asset: %v", err)
+		}
+		if asset.Status != "Active" {
+			return transferRefused("asset with MSISDN %s is %s", msisdn, asset.Status), nil
+		}
+		if err := ensureUnlocked(asset, now); err != nil {
+			return transferRefused("%v", err), nil
+		}
+		assets = append(assets, asset)
+	}
+
+	source, target := assets[0], assets[1]
+	remaining, err := subBalance(source.Balance, amount)
+	if err != nil {
+		return transferRefused("%v", err), nil
+	}
+	if remaining < minBalance {
+		return transferRefused("insufficient funds: asset with MSISDN %s has a balance of %d", fromMSISDN, source.Balance), nil
+	}
+	if _, err := addBalance(target.Balance, amount); err != nil {
+		return transferRefused("%v", err), nil
+	}
+
+	return &TransferCheck{Possible: true}, nil
+}
+
+// Transaction types recorded by TransferWithFee
+const (
+	transTypeTransferOut = "TRANSFER_OUT"
+	transTypeTransferIn  = "TRANSFER_IN"
+	transTypeTransferFee = "TRANSFER_FEE"
+)
+
+// TransferWithFee moves amount from one asset to another and fee from the
+// sender to the treasury asset in a single transaction, so either all three
+// balances change or none do. Like CanTransfer, every party must be Active and
+// unlocked. The sender must cover amount plus fee without falling below
+// minBalance. The treasury may be empty when fee is 0.
+func (s *SmartContract) TransferWithFee(ctx contractapi.TransactionContextInterface, fromMSISDN, toMSISDN, treasuryMSISDN string, amount, fee int64) error {
+	fromMSISDN = normalizeMSISDN(fromMSISDN)
+	toMSISDN = normalizeMSISDN(toMSISDN)
+
+	if amount <= 0 {
+		return fmt.Errorf("transfer amount must be positive, got %d", amount)
+	}
+	if fee < 0 {
+		return fmt.Errorf("transfer fee must not be negative, got %d", fee)
+	}
+	if fromMSISDN == toMSISDN {
+		return fmt.Errorf("cannot transfer from asset %s to itself", fromMSISDN)
+	}
+	if fee > 0 {
+		if treasuryMSISDN == "" {
+			return fmt.Errorf("a treasury asset is required to collect a fee")
+		}
+		treasuryMSISDN = normalizeMSISDN(treasuryMSISDN)
+		if treasuryMSISDN == fromMSISDN {
+			return fmt.Errorf("the sender %s cannot be the treasury", fromMSISDN)
+		}
+	}
+
+	debit, err := addBalance(amount, fee)
+	if err != nil {
+		return err
+	}
+
+	// The treasury may also be the recipient, so later adjustments must see earlier ones
+	txCtx := newBatchContext(ctx)
+
+	if err := s.AdjustBalance(txCtx, fromMSISDN, -debit, transTypeTransferOut, fmt.Sprintf("transfer of %d to %s with fee %d", amount, toMSISDN, fee), "Active", "", ""); err != nil {
+		return err
+	}
+	if err := s.AdjustBalance(txCtx, toMSISDN, amount, transTypeTransferIn, fmt.Sprintf("transfer from %s", fromMSISDN), "Active", "", ""); err != nil {
+		return err
+	}
+	changed := []string{fromMSISDN, toMSISDN}
+	if fee > 0 {
+		if err := s.AdjustBalance(txCtx, treasuryMSISDN, fee, transTypeTransferFee, fmt.Sprintf("fee on transfer from %s to %s", fromMSISDN, toMSISDN), "Active", "", "fee"); err != nil {
+			return err
+		}
+		if treasuryMSISDN != toMSISDN {
+			changed = append(changed, treasuryMSISDN)
+		}
+	}
+
+	// Replaces the AssetUpdated event of the last adjustment
+	return setAssetsChangedEvent(ctx, changed)
+}
+
+// transferRefused returns a failed TransferCheck with a formatted reason
+func transferRefused(format string, args ...interface{}) *TransferCheck {
+	return &TransferCheck{Reason: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCanTransfer(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 500)
+	createTestAsset(t, stub, "D001", "9822222222", 100)
+	createTestAsset(t, stub, "D002", "9833333333", 900)
+	createTestAsset(t, stub, "D002", "9844444444", 900)
+	err := stub.transact(func(ctx *auditContext) error {
+		if err := s.UpdateAsset(ctx, "9833333333", "900", "Frozen", "", "", "", "", ""); err != nil {
+			return err
+		}
+		return s.LockAsset(ctx, "9844444444", "2030-01-01T00:00:00Z")
+	})
+	if err != nil {
+		t.Fatalf("preparing assets returned error: %v", err)
+	}
+	writes := len(stub.history["9811111111"])
+
+	tests := []struct {
+		name     string
+		from, to string
+		amount   int64
+		reason   string
+	}{
+		{"possible", "9811111111", "9822222222", 500, ""},
+		{"insufficient funds", "9822222222", "9811111111", 101, "insufficient funds"},
+		{"frozen source", "9833333333", "9811111111", 10, "is Frozen"},
+		{"frozen target", "9811111111", "9833333333", 10, "is Frozen"},
+		{"locked", "9844444444", "9811111111", 10, "locked"},
+		{"nonexistent source", "9800000000", "9811111111", 10, "9800000000 does not exist"},
+		{"nonexistent target", "9811111111", "9800000000", 10, "9800000000 does not exist"},
+		{"non-positive amount", "9811111111", "9822222222", 0, "must be positive"},
+		{"same asset", "9811111111", "+91 98111 11111", 10, "to itself"},
+		{"needs approval", "9811111111", "9822222222", approvalThreshold + 1, "requires approval"},
+	}
+	for _, tt := range tests {
+		var check *TransferCheck
+		err := stub.transact(func(ctx *auditContext) error {
+			var err error
+			check, err = s.CanTransfer(ctx, tt.from, tt.to, tt.amount)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("%s: CanTransfer returned error: %v", tt.name, err)
+		}
+		if tt.reason == "" {
+			if !check.Possible || check.Reason != "" {
+				t.Errorf("%s: check is %+v, want possible without a reason", tt.name, check)
+			}
+			continue
+		}
+		if check.Possible || !strings.Contains(check.Reason, tt.reason) {
+			t.Errorf("%s: check is %+v, want refused with a reason containing %q", tt.name, check, tt.reason)
+		}
+	}
+
+	if len(stub.history["9811111111"]) != writes {
+		t.Error("CanTransfer wrote to the ledger")
+	}
+}
+
+func TestTransferWithFee(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 1000)
+	createTestAsset(t, stub, "D001", "9822222222", 100)
+	createTestAsset(t, stub, "D000", "9899999999", 0)
+
+	balances := func() [3]int64 {
+		return [3]int64{
+			readTestAsset(t, stub, "9811111111").Balance,
+			readTestAsset(t, stub, "9822222222").Balance,
+			readTestAsset(t, stub, "9899999999").Balance,
+		}
+	}
+
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.TransferWithFee(ctx, "9811111111", "9822222222", "9899999999", 300, 20)
+	})
+	if err != nil {
+		t.Fatalf("TransferWithFee returned error: %v", err)
+	}
+	if got := balances(); got != [3]int64{680, 400, 20} {
+		t.Errorf("sender, recipient and treasury balances are %v, want [680 400 20]", got)
+	}
+	if got := strings.Join(changedMSISDNs(t, stub.lastEvent(t)), ","); got != "9811111111,9822222222,9899999999" {
+		t.Errorf("event names %s, want the sender, recipient and treasury", got)
+	}
+	if fee := readTestAsset(t, stub, "9899999999"); fee.TransType != transTypeTransferFee || fee.Category != "fee" {
+		t.Errorf("treasury recorded %s in category %s, want %s in fee", fee.TransType, fee.Category, transTypeTransferFee)
+	}
+
+	// The sender covers the amount but not the fee on top of it
+	err = stub.transact(func(ctx *auditContext) error {
+		return s.TransferWithFee(ctx, "9811111111", "9822222222", "9899999999", 670, 20)
+	})
+	if !errors.Is(err, ErrBalanceBelowMinimum) {
+		t.Errorf("TransferWithFee beyond the sender's funds returned %v, want ErrBalanceBelowMinimum", err)
+	}
+	if got := balances(); got != [3]int64{680, 400, 20} {
+		t.Errorf("balances after the rejected transfer are %v, want [680 400 20] untouched", got)
+	}
+
+	// The treasury may be the recipient and then receives both
+	err = stub.transact(func(ctx *auditContext) error {
+		return s.TransferWithFee(ctx, "9811111111", "9899999999", "9899999999", 100, 10)
+	})
+	if err != nil {
+		t.Fatalf("TransferWithFee to the treasury returned error: %v", err)
+	}
+	if got := balances(); got != [3]int64{570, 400, 130} {
+		t.Errorf("balances after paying the treasury are %v, want [570 400 130]", got)
+	}
+
+	tests := []struct {
+		name               string
+		from, to, treasury string
+		amount, fee        int64
+		want               string
+	}{
+		{"negative fee", "9811111111", "9822222222", "9899999999", 10, -1, "must not be negative"},
+		{"fee without treasury", "9811111111", "9822222222", "", 10, 1, "treasury asset is required"},
+		{"sender as treasury", "9811111111", "9822222222", "9811111111", 10, 1, "cannot be the treasury"},
+		{"missing treasury", "9811111111", "9822222222", "9800000000", 10, 1, "9800000000"},
+	}
+	for _, tt := range tests {
+		err := stub.transact(func(ctx *auditContext) error {
+			return s.TransferWithFee(ctx, tt.from, tt.to, tt.treasury, tt.amount, tt.fee)
+		})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: TransferWithFee returned %v, want an error containing %q", tt.name, err, tt.want)
+		}
+	}
+	if got := balances(); got != [3]int64{570, 400, 130} {
+		t.Errorf("balances after the rejected transfers are %v, want [570 400 130] untouched", got)
+	}
+}
+
+func TestTransferWithFeeRequiresActiveParties(t *testing.T) {
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 1000)
+	createTestAsset(t, stub, "D001", "9822222222", 100)
+	createTestAsset(t, stub, "D001", "9833333333", 1000)
+	createTestAsset(t, stub, "D001", "9844444444", 100)
+	createTestAsset(t, stub, "D000", "9899999999", 0)
+	createTestAsset(t, stub, "D000", "9888888888", 0)
+	err := stub.transact(func(ctx *auditContext) error {
+		if err := s.UpdateAsset(ctx, "9833333333", "1000", "Frozen", "", "", "", "", ""); err != nil {
+			return err
+		}
+		if err := s.UpdateAsset(ctx, "9844444444", "100", "Deleted", "", "", "", "", ""); err != nil {
+			return err
+		}
+		return s.UpdateAsset(ctx, "9888888888", "0", "Suspended", "", "", "", "", "")
+	})
+	if err != nil {
+		t.Fatalf("preparing assets returned error: %v", err)
+	}
+	before := make(map[string]int64)
+	for _, msisdn := range []string{"9811111111", "9822222222", "9833333333", "9844444444", "9899999999", "9888888888"} {
+		before[msisdn] = readTestAsset(t, stub, msisdn).Balance
+	}
+
+	tests := []struct {
+		name               string
+		from, to, treasury string
+	}{
+		{"frozen sender", "9833333333", "9822222222", "9899999999"},
+		{"deleted recipient", "9811111111", "9844444444", "9899999999"},
+		{"suspended treasury", "9811111111", "9822222222", "9888888888"},
+	}
+	for _, tt := range tests {
+		err := stub.transact(func(ctx *auditContext) error {
+			return s.TransferWithFee(ctx, tt.from, tt.to, tt.treasury, 100, 10)
+		})
+		if !errors.Is(err, ErrStatusMismatch) {
+			t.Errorf("%s: TransferWithFee returned %v, want ErrStatusMismatch", tt.name, err)
+		}
+	}
+	for msisdn, balance := range before {
+		if got := readTestAsset(t, stub, msisdn).Balance; got != balance {
+			t.Errorf("balance of %s is %d after the rejected transfers, want %d", msisdn, got, balance)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+)
+
+// maxTransactionAmountEnv names the environment variable holding the largest
+// balance change a single update may make. There is no cap when it is unset.
+// Every endorsing peer must be configured with the same value.
+const maxTransactionAmountEnv = "MAX_TRANSACTION_AMOUNT"
+
+// ErrTransactionAmountExceeded is returned when an update would move more than the configured maximum
+var ErrTransactionAmountExceeded = errors.New("transaction amount exceeds the maximum")
+
+// maxTransactionAmount returns the configured cap on the balance change of a
+// single update, or 0 when there is none
+func maxTransactionAmount() (int64, error) {
+	value := os.Getenv(maxTransactionAmountEnv)
+	if value == "" {
+		return 0, nil
+	}
+
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || limit <= 0 {
+		return 0, fmt.Errorf("%s must be a positive integer, got %q", maxTransactionAmountEnv, value)
+	}
+
+	return limit, nil
+}
+
+// checkTransactionAmount rejects a balance change whose magnitude exceeds the
+// configured cap, or cannot be represented at all
+func checkTransactionAmount(amount int64) error {
+	if amount == math.MinInt64 {
+		return fmt.Errorf("%w: the magnitude of %d", ErrBalanceOverflow, amount)
+	}
+
+	limit, err := maxTransactionAmount()
+	if err != nil {
+		return err
+	}
+	if limit > 0 && abs(amount) > limit {
+		return fmt.Errorf("%w: %d exceeds %d", ErrTransactionAmountExceeded, abs(amount), limit)
+	}
+	return nil
+}
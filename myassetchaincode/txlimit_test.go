@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTransactionAmountCap(t *testing.T) {
+	t.Setenv(maxTransactionAmountEnv, "5000")
+	s := new(SmartContract)
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 10000)
+	createTestAsset(t, stub, "D001", "9822222222", 10000)
+
+	// Below and exactly at the cap, in either direction
+	updateBalances(t, stub, "9811111111", 14000, 9000)
+	err := stub.transact(func(ctx *auditContext) error {
+		return s.AdjustBalance(ctx, "9811111111", -5000, "DEBIT", "", "", "", "")
+	})
+	if err != nil {
+		t.Fatalf("AdjustBalance at the cap returned error: %v", err)
+	}
+	if asset := readTestAsset(t, stub, "9811111111"); asset.Balance != 4000 {
+		t.Errorf("balance is %d, want 4000", asset.Balance)
+	}
+
+	above := map[string]func(ctx *auditContext) error{
+		"UpdateAsset up": func(ctx *auditContext) error {
+			return s.UpdateAsset(ctx, "9822222222", "15001", "Active", "CREDIT", "", "", "", "")
+		},
+		"UpdateAsset down": func(ctx *auditContext) error {
+			return s.UpdateAsset(ctx, "9822222222", "4999", "Active", "DEBIT", "", "", "", "")
+		},
+		"AdjustBalance": func(ctx *auditContext) error {
+			return s.AdjustBalance(ctx, "9822222222", 5001, "CREDIT", "", "", "", "")
+		},
+		"TransferWithFee": func(ctx *auditContext) error {
+			return s.TransferWithFee(ctx, "9822222222", "9811111111", "", 5001, 0)
+		},
+	}
+	for name, write := range above {
+		if err := stub.transact(write); !errors.Is(err, ErrTransactionAmountExceeded) {
+			t.Errorf("%s above the cap returned %v, want ErrTransactionAmountExceeded", name, err)
+		}
+	}
+	if asset := readTestAsset(t, stub, "9822222222"); asset.Balance != 10000 {
+		t.Errorf("balance is %d after rejected updates, want 10000", asset.Balance)
+	}
+
+	var check *TransferCheck
+	err = stub.transact(func(ctx *auditContext) error {
+		var err error
+		check, err = s.CanTransfer(ctx, "9822222222", "9811111111", 5001)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("CanTransfer returned error: %v", err)
+	}
+	if check.Possible || !strings.Contains(check.Reason, ErrTransactionAmountExceeded.Error()) {
+		t.Errorf("CanTransfer above the cap returned %+v, want it refused for the cap", check)
+	}
+}
+
+func TestTransactionAmountCapSetting(t *testing.T) {
+	t.Setenv(maxTransactionAmountEnv, "")
+	if err := checkTransactionAmount(1 << 40); err != nil {
+		t.Errorf("checkTransactionAmount without a cap returned %v", err)
+	}
+
+	for _, value := range []string{"0", "-5", "lots"} {
+		t.Setenv(maxTransactionAmountEnv, value)
+		if err := checkTransactionAmount(1); err == nil || !strings.Contains(err.Error(), maxTransactionAmountEnv) {
+			t.Errorf("checkTransactionAmount with %s=%q returned %v, want a configuration error", maxTransactionAmountEnv, value, err)
+		}
+	}
+}
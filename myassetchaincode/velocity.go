@@ -0,0 +1,155 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Velocity limits: an asset is flagged when the balance moves by more than
+// the threshold in total over its last max transactions within the window.
+// Each environment variable falls back to its default when unset, and every
+// endorsing peer must be configured with the same values.
+const (
+	velocityThresholdEnv       = "VELOCITY_THRESHOLD"
+	velocityWindowEnv          = "VELOCITY_WINDOW"
+	velocityMaxTransactionsEnv = "VELOCITY_MAX_TRANSACTIONS"
+
+	defaultVelocityThreshold       = 50000
+	defaultVelocityWindow          = 60 * time.Minute
+	defaultVelocityMaxTransactions = 20
+)
+
+// velocityThreshold returns the configured largest total balance movement
+// allowed within the velocity window
+func velocityThreshold() (int64, error) {
+	value := os.Getenv(velocityThresholdEnv)
+	if value == "" {
+		return defaultVelocityThreshold, nil
+	}
+
+	threshold, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || threshold <= 0 {
+		return 0, fmt.Errorf("%s must be a positive integer, got %q", velocityThresholdEnv, value)
+	}
+
+	return threshold, nil
+}
+
+// velocityWindow returns the configured period over which balance movement is summed
+func velocityWindow() (time.Duration, error) {
+	value := os.Getenv(velocityWindowEnv)
+	if value == "" {
+		return defaultVelocityWindow, nil
+	}
+
+	window, err := time.ParseDuration(value)
+	if err != nil || window <= 0 {
+		return 0, fmt.Errorf("%s must be a positive duration such as 60m, got %q", velocityWindowEnv, value)
+	}
+
+	return window, nil
+}
+
+// velocityMaxTransactions returns the configured number of recent
+// transactions whose balance movement is summed
+func velocityMaxTransactions() (int, error) {
+	value := os.Getenv(velocityMaxTransactionsEnv)
+	if value == "" {
+		return defaultVelocityMaxTransactions, nil
+	}
+
+	count, err := strconv.Atoi(value)
+	if err != nil || count <= 0 {
+		return 0, fmt.Errorf("%s must be a positive integer, got %q", velocityMaxTransactionsEnv, value)
+	}
+
+	return count, nil
+}
+
+// velocityExceeded reports whether changing an asset's balance by change at
+// now would take its recent balance movement over the velocity threshold.
+// change must already have passed checkTransactionAmount. Movement too large
+// to represent counts as exceeding the threshold.
+func velocityExceeded(ctx contractapi.TransactionContextInterface, msisdn string, now time.Time, change int64) (bool, error) {
+	threshold, err := velocityThreshold()
+	if err != nil {
+		return false, err
+	}
+
+	movement, err := recentBalanceMovement(ctx, msisdn, now)
+	if errors.Is(err, ErrBalanceOverflow) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	total, err := addBalance(movement, abs(change))
+	if err != nil {
+		return true, nil
+	}
+
+	return total > threshold, nil
+}
+
+// recentBalanceMovement returns the sum of the absolute balance changes made to
+// an asset within the velocity window ending at now
+func recentBalanceMovement(ctx contractapi.TransactionContextInterface, msisdn string, now time.Time) (int64, error) {
+	window, err := velocityWindow()
+	if err != nil {
+		return 0, err
+	}
+	maxTransactions, err := velocityMaxTransactions()
+	if err != nil {
+		return 0, err
+	}
+
+	versions, err := assetVersions(ctx, msisdn)
+	if err != nil {
+		return 0, err
+	}
+
+	points := balancePoints(versions)
+
+	// Walk back over the transactions inside the window, then keep the one
+	// before them as the baseline the first change is measured from
+	since := now.Add(-window)
+	start := len(points)
+	for start > 0 && len(points)-start < maxTransactions && !points[start-1].Timestamp.Before(since) {
+		start--
+	}
+	if start > 0 {
+		start--
+	}
+
+	var movement int64
+	for i := start + 1; i < len(points); i++ {
+		change, err := subBalance(points[i].Balance, points[i-1].Balance)
+		if err != nil {
+			return 0, err
+		}
+		if change == math.MinInt64 {
+			return 0, fmt.Errorf("%w: the magnitude of %d", ErrBalanceOverflow, change)
+		}
+		movement, err = addBalance(movement, abs(change))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return movement, nil
+}
+
+// abs returns the absolute value of n
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
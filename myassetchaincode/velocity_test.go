@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+// updateBalances applies each balance to an asset in a transaction of its own
+func updateBalances(t *testing.T, stub *ledgerStub, msisdn string, balances ...int64) {
+	t.Helper()
+	for _, balance := range balances {
+		err := stub.transact(func(ctx *auditContext) error {
+			return new(SmartContract).UpdateAsset(ctx, msisdn, strconv.FormatInt(balance, 10), "Active", "CREDIT", "", "", "", "")
+		})
+		if err != nil {
+			t.Fatalf("UpdateAsset to %d returned error: %v", balance, err)
+		}
+	}
+}
+
+func TestVelocityNormalChange(t *testing.T) {
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 1000)
+
+	updateBalances(t, stub, "9811111111", 5000, 2000, 8000)
+
+	if asset := readTestAsset(t, stub, "9811111111"); asset.Flagged {
+		t.Error("asset was flagged after normal changes")
+	}
+}
+
+func TestVelocityBreach(t *testing.T) {
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 0)
+
+	// Six swings of 9000 each stay under the approval threshold but move 54000
+	updateBalances(t, stub, "9811111111", 9000, 0, 9000, 0, 9000)
+	if asset := readTestAsset(t, stub, "9811111111"); asset.Flagged {
+		t.Fatal("asset was flagged before the movement exceeded the threshold")
+	}
+
+	updateBalances(t, stub, "9811111111", 0)
+	if asset := readTestAsset(t, stub, "9811111111"); !asset.Flagged {
+		t.Error("asset was not flagged after moving more than the threshold")
+	}
+}
+
+func TestVelocityLimitsFromEnvironment(t *testing.T) {
+	t.Setenv(velocityThresholdEnv, "1000")
+	t.Setenv(velocityWindowEnv, "5m")
+
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 0)
+	updateBalances(t, stub, "9811111111", 600)
+
+	// Movement older than the window no longer counts
+	stub.now = stub.now.Add(10 * time.Minute)
+	updateBalances(t, stub, "9811111111", 0)
+	if asset := readTestAsset(t, stub, "9811111111"); asset.Flagged {
+		t.Fatal("movement outside the window was counted")
+	}
+
+	updateBalances(t, stub, "9811111111", 600)
+	if asset := readTestAsset(t, stub, "9811111111"); !asset.Flagged {
+		t.Error("asset was not flagged after moving more than the configured threshold")
+	}
+}
+
+func TestVelocityInvalidSetting(t *testing.T) {
+	t.Setenv(velocityMaxTransactionsEnv, "none")
+
+	stub := newLedgerStub()
+	createTestAsset(t, stub, "D001", "9811111111", 0)
+	err := stub.transact(func(ctx *auditContext) error {
+		return new(SmartContract).UpdateAsset(ctx, "9811111111", "100", "Active", "CREDIT", "", "", "", "")
+	})
+	if err == nil {
+		t.Error("UpdateAsset succeeded with an invalid velocity setting")
+	}
+}